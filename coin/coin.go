@@ -3,6 +3,7 @@ package coin
 import (
 	"errors"
 	"strings"
+	"sync"
 )
 
 // modeling structure and functions,
@@ -22,20 +23,32 @@ type Coin struct { // might also understand as public chain or token
 }
 
 var coinmap = make(map[string]*Coin)
+var coinLock sync.RWMutex // exchanges may be created concurrently
 
 func GetCoin(code string) *Coin {
+	coinLock.RLock()
+	defer coinLock.RUnlock()
 	return coinmap[strings.ToUpper(code)]
 }
 
+// return a copy, the registry keeps changing while exchanges are created
 func GetCoins() map[string]*Coin {
-	return coinmap
+	coinLock.RLock()
+	defer coinLock.RUnlock()
+	coins := make(map[string]*Coin, len(coinmap))
+	for code, c := range coinmap {
+		coins[code] = c
+	}
+	return coins
 }
 
 func AddCoin(coin *Coin) error {
 
 	if coin != nil && coin.Code != "" {
 		coin.Code = strings.ToUpper(coin.Code)
+		coinLock.Lock()
 		coinmap[coin.Code] = coin
+		coinLock.Unlock()
 	} else {
 		return errors.New("code is not assign yet")
 	}
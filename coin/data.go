@@ -19,7 +19,7 @@ func btc() {
 	c.Name = "Bitcoin"
 	c.Website = "https://www.bitcoin.org/"
 	c.Explorer = "https://explore.bitcoinh.org/"
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func bch() {
@@ -28,7 +28,7 @@ func bch() {
 	c.Name = "Bitcoin Cash"
 	c.Website = "https://www.bitcoincash.org/"
 	c.Explorer = "https://explore.bitcoincash.org/"
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func eth() {
@@ -38,7 +38,7 @@ func eth() {
 	c.Website = "https://www.ethereum.org/"
 	c.Explorer = "https://etherscan.io/"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func ltc() {
@@ -48,7 +48,7 @@ func ltc() {
 	c.Website = "https://litecoin.com/"
 	c.Explorer = "https://chainz.cryptoid.info/ltc/"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func usdt() {
@@ -58,7 +58,7 @@ func usdt() {
 	c.Website = "https://tether.to/"
 	c.Explorer = "https://omniexplorer.info/"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 /****************************************************/
@@ -70,7 +70,7 @@ func ada() {
 	c.Website = "https://www.cardano.org/en/home/"
 	c.Explorer = "https://www.cardano.org/en/home/"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func adx() {
@@ -80,7 +80,7 @@ func adx() {
 	c.Website = "https://www.adex.network/"
 	c.Explorer = "https://etherscan.io/token/0x4470bb87d77b963a013db939be332f927f2b992e"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func appc() {
@@ -90,7 +90,7 @@ func appc() {
 	c.Website = "https://appcoins.io/"
 	c.Explorer = "https://etherscan.io/token/0x1a7a8bd9106f2b8d977e08582dc7d24c723ab0db"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func ark() {
@@ -100,7 +100,7 @@ func ark() {
 	c.Website = "https://ark.io/"
 	c.Explorer = "https://explorer.ark.io/"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func ast() {
@@ -110,7 +110,7 @@ func ast() {
 	c.Website = "https://www.airswap.io/"
 	c.Explorer = "https://etherscan.io/token/0x27054b13b1b798b345b591a4d22e6562d47ea75a"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func bat() {
@@ -120,7 +120,7 @@ func bat() {
 	c.Website = "https://basicattentiontoken.org/"
 	c.Explorer = "https://etherscan.io/token/Bat"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func bcd() {
@@ -130,7 +130,7 @@ func bcd() {
 	c.Website = "http://btcd.io/"
 	c.Explorer = "http://explorer.btcd.io/"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func bcpt() {
@@ -140,7 +140,7 @@ func bcpt() {
 	c.Website = "https://blockmason.io/"
 	c.Explorer = "https://etherscan.io/token/0x1c4481750daa5ff521a2a7490d9981ed46465dbd"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func blz() {
@@ -150,7 +150,7 @@ func blz() {
 	c.Website = "https://bluzelle.com/"
 	c.Explorer = "https://etherscan.io/token/0x5732046a883704404f284ce41ffadd5b007fd668"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func bnt() {
@@ -160,7 +160,7 @@ func bnt() {
 	c.Website = "https://www.bancor.network/"
 	c.Explorer = "https://etherscan.io/token/Bancor"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func btg() {
@@ -170,7 +170,7 @@ func btg() {
 	c.Website = "https://bitcoingold.org/"
 	c.Explorer = "https://explorer.bitcoingold.org/insight/"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func bts() {
@@ -180,7 +180,7 @@ func bts() {
 	c.Website = "https://bitshares.org/"
 	c.Explorer = "http://cryptofresh.com/"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func chat() {
@@ -190,7 +190,7 @@ func chat() {
 	c.Website = "http://www.openchat.co/"
 	c.Explorer = "https://etherscan.io/token/0x442bc47357919446eabc18c7211e57a13d983469"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func cloak() {
@@ -200,7 +200,7 @@ func cloak() {
 	c.Website = "https://www.cloakcoin.com/"
 	c.Explorer = "https://chainz.cryptoid.info/cloak/"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func cmt() {
@@ -210,7 +210,7 @@ func cmt() {
 	c.Website = "https://www.cybermiles.io/"
 	c.Explorer = "https://etherscan.io/token/0xf85feea2fdd81d51177f6b8f35f0e6734ce45f5f"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
 
 func cvc() {
@@ -220,5 +220,5 @@ func cvc() {
 	c.Website = "https://www.civic.com/"
 	c.Explorer = "https://etherscan.io/token/civic"
 
-	coinmap[c.Code] = c
+	AddCoin(c)
 }
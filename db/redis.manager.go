@@ -14,22 +14,39 @@ const (
 )
 
 type RedisManager struct {
-	lock     sync.RWMutex
-	redisMap cmap.ConcurrentMap
+	lock      sync.RWMutex
+	redisMap  cmap.ConcurrentMap
+	stop      chan struct{} //closed by Close, stops the AutoGC of this manager
+	closeOnce sync.Once
 }
 
-var ShutdownSignal bool
+var sharedLock sync.Mutex
+var sharedMap = make(map[string]*RedisManager)
 
 func CreateRedisManager() *RedisManager {
 	rm := &RedisManager{}
 	rm.lock = sync.RWMutex{}
 	rm.redisMap = cmap.New()
+	rm.stop = make(chan struct{})
 
-	ShutdownSignal = false
 	go AutoGC(rm)
 	return rm
 }
 
+/*The Redis Manager Shared by the Instances of an Exchange
+Created once for the name, so there is one AutoGC per exchange however many instances; key the Redis by server and db*/
+func SharedRedisManager(name string) *RedisManager {
+	sharedLock.Lock()
+	defer sharedLock.Unlock()
+
+	rm, ok := sharedMap[name]
+	if !ok {
+		rm = CreateRedisManager()
+		sharedMap[name] = rm
+	}
+	return rm
+}
+
 func (rm *RedisManager) Add(key string, r *Redis) {
 	if r == nil {
 		log.Printf("r=nil error")
@@ -52,15 +69,20 @@ func (rm *RedisManager) Get(key string) *Redis {
 }
 
 func (rm *RedisManager) Close() {
-	ShutdownSignal = true
+	rm.closeOnce.Do(func() {
+		close(rm.stop)
+	})
 }
 
 func AutoGC(rm *RedisManager) {
+	ticker := time.NewTicker(time.Second * GC_PEROID)
+	defer ticker.Stop()
 	for {
-		if ShutdownSignal {
-			break
+		select {
+		case <-rm.stop:
+			return
+		case <-ticker.C:
 		}
-		time.Sleep(time.Second * GC_PEROID)
 
 		size := rm.redisMap.Count()
 		if size > MAX_CONN {
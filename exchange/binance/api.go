@@ -0,0 +1,1099 @@
+package binance
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"../../coin"
+	"../../exchange"
+	"../../market"
+	"../../pair"
+	"../../user"
+)
+
+/*The Base Endpoint URL*/
+const (
+	API_URL string = "https://api.binance.com"
+
+	tradePages = 10 // the pages of 1000 aggregate trades read by RecentTrades at most
+)
+
+/*API Base Knowledge
+Path: API function. Usually after the base endpoint URL
+Method:
+	Get - Call a URL, API return a response
+	Post - Call a URL & send a request, API return a response
+Public API:
+	It doesn't need authorization/signature , can be called by browser to get response.
+	using exchange.HttpGetRequest/exchange.HttpPostRequest
+Private API:
+	Authorization/Signature is requried. The signature request should look at Exchange API Document.
+	using ApiKeyRequest
+Response:
+	Response is a json structure.
+	Copy the json to https://transform.now.sh/json-to-go/ convert to go Struct.
+	Add the go Struct to model.go
+
+ex. Get /api/v3/depth
+Get - Method
+/api/v3/depth - Path*/
+
+/*************** Public API ***************/
+/*Get Pair Market Depth
+Step 1: Get Exchange Pair Code ex. symbol := e.GetPairCode(p)
+Step 2: Get the top 100 levels of each side
+Step 3: Convert the response to Standard Maker struct, lastUpdateId is the sequence of the depth stream*/
+func (e *Binance) OrderBook(p *pair.Pair) (*market.Maker, error) {
+	return e.orderBook(p, 100)
+}
+
+func (e *Binance) orderBook(p *pair.Pair, limit int) (*market.Maker, error) {
+	orderBook := OrderBook{}
+	symbol := e.GetPairCode(p)
+
+	strRequestUrl := "/api/v3/depth"
+	strUrl := e.API_URL + strRequestUrl
+	maker := &market.Maker{}
+	maker.WorkerIP = exchange.GetExternalIP()
+	maker.BeforeTimestamp = float64(time.Now().UnixNano() / 1e6)
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = symbol
+	mapParams["limit"] = strconv.Itoa(limit)
+
+	jsonOrderbook := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := e.responseErr("OrderBook", jsonOrderbook); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonOrderbook), &orderBook); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "orderBook", exchange.ErrNetwork, "OrderBook json Unmarshal error: %v %v", err, jsonOrderbook)
+	}
+	maker.AfterTimestamp = float64(time.Now().UnixNano() / 1e6)
+
+	maker.Bids = toLevels(orderBook.Bids)
+	maker.Asks = toLevels(orderBook.Asks)
+	maker.LastUpdateID = int(orderBook.LastUpdateID)
+	return maker, nil
+}
+
+/*Get the Ticker of the Pair*/
+func (e *Binance) Ticker(p *pair.Pair) (*market.Ticker, error) {
+	ticker := TickerData{}
+
+	strRequestUrl := "/api/v3/ticker/24hr"
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := e.responseErr("Ticker", jsonTickerReturn); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &ticker); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTickerReturn)
+	}
+	return toTicker(p, &ticker), nil
+}
+
+/*Get the Tickers of All the Pairs
+/api/v3/ticker/24hr returns all the symbols without the symbol param*/
+func (e *Binance) Tickers() ([]*market.Ticker, error) {
+	data := []*TickerData{}
+
+	strRequestUrl := "/api/v3/ticker/24hr"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := e.responseErr("Tickers", jsonTickerReturn); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &data); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Tickers", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTickerReturn)
+	}
+
+	tickers := []*market.Ticker{}
+	for _, t := range data {
+		if p := e.getPairByCode(t.Symbol); p != nil {
+			tickers = append(tickers, toTicker(p, t))
+		}
+	}
+	sort.Slice(tickers, func(i, j int) bool {
+		return tickers[i].Pair.Name < tickers[j].Pair.Name
+	})
+	return tickers, nil
+}
+
+func toTicker(pair *pair.Pair, data *TickerData) *market.Ticker {
+	ticker := &market.Ticker{}
+	ticker.Pair = pair
+	ticker.Bid, _ = strconv.ParseFloat(data.BidPrice, 64)
+	ticker.Ask, _ = strconv.ParseFloat(data.AskPrice, 64)
+	ticker.Last, _ = strconv.ParseFloat(data.LastPrice, 64)
+	ticker.High, _ = strconv.ParseFloat(data.HighPrice, 64)
+	ticker.Low, _ = strconv.ParseFloat(data.LowPrice, 64)
+	ticker.Volume, _ = strconv.ParseFloat(data.Volume, 64)
+	ticker.QuoteVolume, _ = strconv.ParseFloat(data.QuoteVolume, 64)
+	ticker.Timestamp = data.CloseTime
+	if ticker.Timestamp == 0 {
+		ticker.Timestamp = time.Now().UnixNano() / 1e6
+	}
+	return ticker
+}
+
+/*Get the Trades of the Market Since the Time
+/api/v3/aggTrades returns 1000 trades from startTime, the next page starts from the last id (fromId),
+up to tradePages pages
+m (buyer is maker): the seller is the aggressor*/
+func (e *Binance) RecentTrades(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	strRequestUrl := "/api/v3/aggTrades"
+	strUrl := e.API_URL + strRequestUrl
+
+	trades := []*market.Trade{}
+	var fromID int64
+	for page := 0; page < tradePages; page++ {
+		data := []*AggTrade{}
+
+		mapParams := make(map[string]string)
+		mapParams["symbol"] = e.GetPairCode(p)
+		mapParams["limit"] = "1000"
+		if page == 0 {
+			mapParams["startTime"] = fmt.Sprint(since.UnixNano() / 1e6)
+		} else {
+			mapParams["fromId"] = fmt.Sprint(fromID)
+		}
+
+		jsonTradesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+		if err := e.responseErr("RecentTrades", jsonTradesReturn); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(jsonTradesReturn), &data); err != nil {
+			return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTradesReturn)
+		}
+
+		for _, t := range data {
+			trade := &market.Trade{}
+			trade.TradeID = fmt.Sprint(t.ID)
+			trade.Pair = p
+			trade.Rate, _ = strconv.ParseFloat(t.Price, 64)
+			trade.Quantity, _ = strconv.ParseFloat(t.Qty, 64)
+			trade.Timestamp = t.Time
+			if t.IsBuyerMaker {
+				trade.Side = market.Sell
+			} else {
+				trade.Side = market.Buy
+			}
+			trade.Liquidity = market.LiquidityTaker
+			trades = append(trades, trade)
+		}
+		if len(data) < 1000 {
+			break
+		}
+		fromID = data[len(data)-1].ID + 1
+	}
+	return trades, nil
+}
+
+// the interval names of /api/v3/klines
+var klineIntervals = map[time.Duration]string{
+	time.Minute:        "1m",
+	3 * time.Minute:    "3m",
+	5 * time.Minute:    "5m",
+	15 * time.Minute:   "15m",
+	30 * time.Minute:   "30m",
+	time.Hour:          "1h",
+	2 * time.Hour:      "2h",
+	4 * time.Hour:      "4h",
+	6 * time.Hour:      "6h",
+	8 * time.Hour:      "8h",
+	12 * time.Hour:     "12h",
+	24 * time.Hour:     "1d",
+	3 * 24 * time.Hour: "3d",
+	7 * 24 * time.Hour: "1w",
+}
+
+/*Get the Candles of the Pair Since the Time
+/api/v3/klines returns up to 1000 candles from startTime, the intervals it doesn't support are built from the recent trades
+kline: [open time, open, high, low, close, volume, close time, quote volume, ...]*/
+func (e *Binance) Candles(p *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error) {
+	name, ok := klineIntervals[interval]
+	if !ok {
+		return exchange.CandlesFromTrades(e, p, interval, since)
+	}
+
+	klines := [][]interface{}{}
+
+	strRequestUrl := "/api/v3/klines"
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+	mapParams["interval"] = name
+	mapParams["startTime"] = fmt.Sprint(since.Truncate(interval).UnixNano() / 1e6)
+	mapParams["limit"] = "1000"
+
+	jsonCandlesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := e.responseErr("Candles", jsonCandlesReturn); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonCandlesReturn), &klines); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonCandlesReturn)
+	}
+
+	candles := []*market.Candle{}
+	for _, k := range klines {
+		if len(k) < 8 {
+			continue
+		}
+		timestamp, _ := strconv.ParseFloat(fmt.Sprint(k[0]), 64)
+		candle := &market.Candle{}
+		candle.Pair = p
+		candle.Timestamp = int64(timestamp)
+		candle.Open, _ = strconv.ParseFloat(fmt.Sprint(k[1]), 64)
+		candle.High, _ = strconv.ParseFloat(fmt.Sprint(k[2]), 64)
+		candle.Low, _ = strconv.ParseFloat(fmt.Sprint(k[3]), 64)
+		candle.Close, _ = strconv.ParseFloat(fmt.Sprint(k[4]), 64)
+		candle.Volume, _ = strconv.ParseFloat(fmt.Sprint(k[5]), 64)
+		candle.QuoteVolume, _ = strconv.ParseFloat(fmt.Sprint(k[7]), 64)
+		candles = append(candles, candle)
+	}
+	return candles, nil
+}
+
+/*Get Coins & Pairs Information
+The symbols of exchangeInfo with the base & quote asset, the status and the filters of each symbol*/
+func (e *Binance) getExchangeInfo() (*ExchangeInfo, error) {
+	exchangeInfo := &ExchangeInfo{}
+
+	strRequestUrl := "/api/v3/exchangeInfo"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonSymbolsReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := e.responseErr("ExchangeInfo", jsonSymbolsReturn); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonSymbolsReturn), exchangeInfo); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getExchangeInfo", exchange.ErrNetwork, "ExchangeInfo Unmarshal Err: %v %v", err, jsonSymbolsReturn)
+	}
+	return exchangeInfo, nil
+}
+
+/*Get the Server Time
+Used by the clock of the signed requests*/
+func (e *Binance) getServerTime() (time.Time, error) {
+	serverTime := ServerTime{}
+
+	strRequestUrl := "/api/v3/time"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTimeReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTimeReturn), &serverTime); err != nil {
+		return time.Time{}, exchange.Errorf(e.GetName(), "getServerTime", exchange.ErrNetwork, "Get Server Time Unmarshal Err: %v %v", err, jsonTimeReturn)
+	}
+	if serverTime.ServerTime == 0 {
+		return time.Time{}, exchange.Errorf(e.GetName(), "getServerTime", exchange.ErrRejected, "Get Server Time Err: %v", jsonTimeReturn)
+	}
+	return time.Unix(0, serverTime.ServerTime*1e6), nil
+}
+
+/*************** Private API ***************/
+func (e *Binance) UpdateAllBalances() error {
+	return e.UpdateAllBalancesByUser(nil)
+}
+
+/*Get Exchange Account All Coins Balance
+Step 1: Get the account (signed)
+Step 2: Get Coin Balance (market.Balance) and store in balanceMap, locked is held by the open orders*/
+func (e *Binance) UpdateAllBalancesByUser(u *user.User) error {
+	var uInstance *Binance
+	if u != nil {
+		uInstance = e.ForUser(u)
+	} else {
+		uInstance = e
+	}
+
+	if uInstance.API_KEY == "" || uInstance.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	accountInfo := AccountInfo{}
+	strRequest := "/api/v3/account"
+
+	jsonBalanceReturn := uInstance.ApiKeyRequest("GET", make(map[string]string), strRequest)
+	if err := uInstance.responseErr("UpdateAllBalances", jsonBalanceReturn); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(jsonBalanceReturn), &accountInfo); err != nil {
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrNetwork, "Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
+	}
+
+	now := time.Now().UnixNano() / 1e6
+	for _, data := range accountInfo.Balances {
+		c := coin.GetCoin(e.GetCode(data.Asset))
+		if c == nil {
+			continue
+		}
+		available, err := strconv.ParseFloat(data.Free, 64)
+		if err != nil {
+			continue
+		}
+		locked, _ := strconv.ParseFloat(data.Locked, 64)
+
+		balance := &market.Balance{}
+		balance.Coin = c
+		balance.Available = available
+		balance.Locked = locked
+		balance.Total = available + locked
+		balance.Timestamp = now
+		uInstance.balanceMap.Set(c.Code, balance)
+	}
+	return nil
+}
+
+/*Withdraw the coin to another address
+Withdraw by the default network of the coin, the fee is the withdrawFee of UpdateCoinConstrain*/
+func (e *Binance) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) (*exchange.WithdrawalResult, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	withdrawResponse := WithdrawResponse{}
+	strRequest := "/sapi/v1/capital/withdraw/apply"
+
+	mapParams := make(map[string]string)
+	mapParams["coin"] = e.GetSymbol(coin.Code)
+	mapParams["address"] = addr
+	if tag != "" {
+		mapParams["addressTag"] = tag
+	}
+	mapParams["amount"] = strconv.FormatFloat(quantity, 'f', -1, 64)
+
+	jsonSubmitWithdraw := e.ApiKeyRequest("POST", mapParams, strRequest)
+	if err := e.responseErr("Withdraw", jsonSubmitWithdraw); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonSubmitWithdraw), &withdrawResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonSubmitWithdraw)
+	}
+	if withdrawResponse.ID == "" {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrUnknown, "no id in response: %v", jsonSubmitWithdraw)
+	}
+
+	withdrawal := &exchange.WithdrawalResult{}
+	withdrawal.ID = withdrawResponse.ID
+	withdrawal.Coin = coin
+	withdrawal.Quantity = quantity
+	withdrawal.Fee = e.GetTxFee(coin)
+	withdrawal.Address = addr
+	withdrawal.Tag = tag
+	withdrawal.Status = exchange.TransferPending
+	withdrawal.Timestamp = time.Now().UnixNano() / 1e6
+	return withdrawal, nil
+}
+
+/*Update the Status of the Withdrawal
+Find the withdrawal by the id in the withdraw history of the coin*/
+func (e *Binance) WithdrawalStatus(withdrawal *exchange.WithdrawalResult) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if withdrawal == nil || withdrawal.Coin == nil {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrRejected, "withdrawal or its coin is nil")
+	}
+
+	history, err := e.getWithdrawHistory(withdrawal.Coin, time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, w := range history {
+		if w.ID == withdrawal.ID {
+			withdrawal.Fee, _ = strconv.ParseFloat(w.TransactionFee, 64)
+			withdrawal.TxHash = w.TxID
+			withdrawal.Status = withdrawStatusOf(w.Status)
+			withdrawal.Timestamp = applyTime(w.ApplyTime)
+			return nil
+		}
+	}
+	return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrNotFound, "withdrawal %s is not in the withdraw history", withdrawal.ID)
+}
+
+// the withdrawals of the coin since the time, nil coin: all coins, zero since: the recent withdrawals
+func (e *Binance) getWithdrawHistory(coin *coin.Coin, since time.Time) ([]*WithdrawHistory, error) {
+	history := []*WithdrawHistory{}
+	strRequest := "/sapi/v1/capital/withdraw/history"
+
+	mapParams := make(map[string]string)
+	if coin != nil {
+		mapParams["coin"] = e.GetSymbol(coin.Code)
+	}
+	if !since.IsZero() {
+		mapParams["startTime"] = fmt.Sprint(since.UnixNano() / 1e6)
+	}
+
+	jsonHistory := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := e.responseErr("WithdrawalStatus", jsonHistory); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonHistory), &history); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getWithdrawHistory", exchange.ErrNetwork, "WithdrawHistory Unmarshal Err: %v %v", err, jsonHistory)
+	}
+	return history, nil
+}
+
+// status: 0 email sent, 1 cancelled, 2 awaiting approval, 3 rejected, 4 processing, 5 failure, 6 completed
+func withdrawStatusOf(status int) exchange.TransferStatus {
+	switch status {
+	case 1:
+		return exchange.TransferCanceled
+	case 3, 5:
+		return exchange.TransferFailed
+	case 6:
+		return exchange.TransferCompleted
+	default:
+		return exchange.TransferPending
+	}
+}
+
+// status: 0 pending, 6 credited but cannot withdraw, 1 success
+func depositStatusOf(status int) exchange.TransferStatus {
+	switch status {
+	case 1, 6:
+		return exchange.TransferCompleted
+	default:
+		return exchange.TransferPending
+	}
+}
+
+// applyTime of the withdraw history in milliseconds, eg: 2019-10-12 11:12:02 in UTC
+func applyTime(value string) int64 {
+	t, err := time.Parse("2006-01-02 15:04:05", value)
+	if err != nil {
+		return 0
+	}
+	return t.UnixNano() / 1e6
+}
+
+/*Get the Deposit Address of the Coin
+The address of the default network*/
+func (e *Binance) GetDepositAddress(coin *coin.Coin) (*exchange.DepositAddress, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	depositAddress := DepositAddress{}
+	strRequest := "/sapi/v1/capital/deposit/address"
+
+	mapParams := make(map[string]string)
+	mapParams["coin"] = e.GetSymbol(coin.Code)
+
+	jsonAddress := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := e.responseErr("GetDepositAddress", jsonAddress); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonAddress), &depositAddress); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonAddress)
+	}
+	if depositAddress.Address == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrNotFound, "no deposit address for %s", coin.Code)
+	}
+
+	address := &exchange.DepositAddress{}
+	address.Coin = coin
+	address.Address = depositAddress.Address
+	address.Tag = depositAddress.Tag
+	return address, nil
+}
+
+/*Get the Deposits and Withdrawals of the Coin Since the Time
+Step 1: Get the deposit history and the withdraw history, nil coin: all coins
+Step 2: Sort by time*/
+func (e *Binance) GetTransfers(coin *coin.Coin, since time.Time) ([]*exchange.Transfer, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	deposits := []*DepositHistory{}
+	strRequest := "/sapi/v1/capital/deposit/hisrec"
+
+	mapParams := make(map[string]string)
+	if coin != nil {
+		mapParams["coin"] = e.GetSymbol(coin.Code)
+	}
+	mapParams["startTime"] = fmt.Sprint(since.UnixNano() / 1e6)
+
+	jsonDeposits := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := e.responseErr("GetTransfers", jsonDeposits); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonDeposits), &deposits); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonDeposits)
+	}
+
+	withdrawals, err := e.getWithdrawHistory(coin, since)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := []*exchange.Transfer{}
+	for _, d := range deposits {
+		transfer := &exchange.Transfer{}
+		transfer.ID = d.ID
+		transfer.Coin = e.transferCoin(coin, d.Coin)
+		transfer.Type = exchange.Deposit
+		transfer.Quantity, _ = strconv.ParseFloat(d.Amount, 64)
+		transfer.Address = d.Address
+		transfer.Tag = d.AddressTag
+		transfer.TxHash = d.TxID
+		transfer.Status = depositStatusOf(d.Status)
+		transfer.Timestamp = d.InsertTime
+		transfers = append(transfers, transfer)
+	}
+	for _, w := range withdrawals {
+		transfer := &exchange.Transfer{}
+		transfer.ID = w.ID
+		transfer.Coin = e.transferCoin(coin, w.Coin)
+		transfer.Type = exchange.Withdrawal
+		transfer.Quantity, _ = strconv.ParseFloat(w.Amount, 64)
+		transfer.Fee, _ = strconv.ParseFloat(w.TransactionFee, 64)
+		transfer.Address = w.Address
+		transfer.Tag = w.AddressTag
+		transfer.TxHash = w.TxID
+		transfer.Status = withdrawStatusOf(w.Status)
+		transfer.Timestamp = applyTime(w.ApplyTime)
+		transfers = append(transfers, transfer)
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].Timestamp < transfers[j].Timestamp
+	})
+	return transfers, nil
+}
+
+// the coin of the query, or the coin of the transfer if the query is for all coins
+func (e *Binance) transferCoin(query *coin.Coin, symbol string) *coin.Coin {
+	if query != nil {
+		return query
+	}
+	return coin.GetCoin(e.GetCode(symbol))
+}
+
+/*Get the Config of All Coins
+The networks of each coin with the withdraw fee, the withdraw & deposit status and the confirmations*/
+func (e *Binance) getCoinConfigs() ([]*CoinConfig, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "getCoinConfigs", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	coinConfigs := []*CoinConfig{}
+	strRequest := "/sapi/v1/capital/config/getall"
+
+	jsonConfigs := e.ApiKeyRequest("GET", make(map[string]string), strRequest)
+	if err := e.responseErr("UpdateCoinConstrain", jsonConfigs); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonConfigs), &coinConfigs); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getCoinConfigs", exchange.ErrNetwork, "UpdateCoinConstrain Unmarshal Err: %v %v", err, jsonConfigs)
+	}
+	return coinConfigs, nil
+}
+
+/*Update the Maker & Taker Fee of the Account
+Step 1: Get the trade fee of each symbol, ex. 0.001 is 0.1%
+Step 2: Store the fee of each pair in feeMap, GetTradeFee returns them*/
+func (e *Binance) UpdateFees() error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	tradeFees := []*TradeFee{}
+	strRequest := "/sapi/v1/asset/tradeFee"
+
+	jsonTradeFee := e.ApiKeyRequest("GET", make(map[string]string), strRequest)
+	if err := e.responseErr("UpdateFees", jsonTradeFee); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(jsonTradeFee), &tradeFees); err != nil {
+		return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonTradeFee)
+	}
+
+	now := time.Now().UnixNano() / 1e6
+	for _, data := range tradeFees {
+		p := e.getPairByCode(data.Symbol)
+		if p == nil {
+			continue
+		}
+		fee := &exchange.TradeFee{}
+		fee.Pair = p
+		fee.Maker, _ = strconv.ParseFloat(data.MakerCommission, 64)
+		fee.Taker, _ = strconv.ParseFloat(data.TakerCommission, 64)
+		fee.Source = exchange.SourceAPI
+		fee.Timestamp = now
+		e.feeMap.Set(p.Name, fee)
+	}
+	return nil
+}
+
+/*Get the Status of a Singal Order
+Step 1: Query the order with orderId
+Step 2: Change Order Status and Deal (Status reference ../market/market.go)*/
+func (e *Binance) OrderStatus(order *market.Order) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	orderStatus := Order{}
+	strRequest := "/api/v3/order"
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(order.Pair)
+	mapParams["orderId"] = order.OrderID
+
+	jsonOrderStatus := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := e.responseErr("OrderStatus", jsonOrderStatus); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(jsonOrderStatus), &orderStatus); err != nil {
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrderStatus)
+	}
+
+	updated := toOrder(order.Pair, &orderStatus)
+	order.Status = updated.Status
+	order.DealQuantity = updated.DealQuantity
+	order.DealRate = updated.DealRate
+	return nil
+}
+
+// the API queries one order at a time
+func (e *Binance) OrdersStatus(orders []*market.Order) error {
+	return exchange.OrdersStatusEach(e, orders)
+}
+
+/*Get an Order by the Client Order ID
+Step 1: Query the order with origClientOrderId
+Step 2: ErrNotFound if the exchange doesn't know the order (code -2013), it is safe to place it again*/
+func (e *Binance) OrderByClientID(p *pair.Pair, clientOrderID string) (*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	orderStatus := Order{}
+	strRequest := "/api/v3/order"
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+	mapParams["origClientOrderId"] = clientOrderID
+
+	jsonOrderStatus := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := e.responseErr("OrderByClientID", jsonOrderStatus); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonOrderStatus), &orderStatus); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrderStatus)
+	}
+	return toOrder(p, &orderStatus), nil
+}
+
+func toOrder(pair *pair.Pair, o *Order) *market.Order {
+	order := &market.Order{}
+	order.Pair = pair
+	order.OrderID = strconv.FormatInt(o.OrderID, 10)
+	order.ClientOrderID = o.ClientOrderID
+	order.Rate, _ = strconv.ParseFloat(o.Price, 64)
+	order.Quantity, _ = strconv.ParseFloat(o.OrigQty, 64)
+	order.DealQuantity, _ = strconv.ParseFloat(o.ExecutedQty, 64)
+	if quoteQty, err := strconv.ParseFloat(o.CummulativeQuoteQty, 64); err == nil && order.DealQuantity > 0 {
+		order.DealRate = quoteQty / order.DealQuantity
+	}
+	if o.Side == "SELL" {
+		order.Side = string(market.Sell)
+	} else {
+		order.Side = string(market.Buy)
+	}
+	order.Status = statusOf(o.Status)
+	return order
+}
+
+func statusOf(status string) market.OrderStatus {
+	switch status {
+	case "NEW":
+		return market.New
+	case "PARTIALLY_FILLED":
+		return market.Partial
+	case "REJECTED":
+		return market.Rejected
+	case "PENDING_CANCEL":
+		return market.Canceling
+	case "CANCELED":
+		return market.Canceled
+	case "FILLED":
+		return market.Filled
+	case "EXPIRED", "EXPIRED_IN_MATCH":
+		return market.Expired
+	}
+	return market.Other
+}
+
+func (e *Binance) ListOrders() (*[]market.Order, error) {
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.OrderList(orders), nil
+}
+
+/*Get the Open Orders
+Step 1: Get the open orders of the pair, or all pairs if the query has no pair
+Step 2: Sort by order time, so the pages are stable
+Step 3: Filter by pair and page (exchange.PageOrders)*/
+func (e *Binance) ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	openOrders := []*Order{}
+	strRequest := "/api/v3/openOrders"
+
+	mapParams := make(map[string]string)
+	if query != nil && query.Pair != nil {
+		mapParams["symbol"] = e.GetPairCode(query.Pair)
+	}
+
+	jsonOrders := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := e.responseErr("ListOpenOrders", jsonOrders); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonOrders), &openOrders); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrders)
+	}
+
+	sort.SliceStable(openOrders, func(i, j int) bool {
+		return openOrders[i].Time < openOrders[j].Time
+	})
+
+	orders := []*market.Order{}
+	for _, o := range openOrders {
+		p := e.getPairByCode(o.Symbol)
+		if p == nil {
+			log.Printf("Binance ListOpenOrders order %d pair %s is not in the pair list", o.OrderID, o.Symbol)
+			continue
+		}
+		orders = append(orders, toOrder(p, o))
+	}
+	return exchange.PageOrders(orders, query), nil
+}
+
+// the pair of the symbol in exchangeInfo, eg: ETHBTC
+func (e *Binance) getPairByCode(code string) *pair.Pair {
+	return e.pairCodeMap[strings.ToUpper(code)]
+}
+
+/*Cancel an Order
+Step 1: Cancel the order with orderId
+Step 2: Change Order Status (order.Status = market.Canceling)
+An order which is already closed or unknown: ErrNotFound (code -2011)*/
+func (e *Binance) CancelOrder(order *market.Order) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	cancelOrder := Order{}
+	strRequest := "/api/v3/order"
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(order.Pair)
+	mapParams["orderId"] = order.OrderID
+
+	jsonCancelOrder := e.ApiKeyRequest("DELETE", mapParams, strRequest)
+	if err := e.responseErr("CancelOrder", jsonCancelOrder); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(jsonCancelOrder), &cancelOrder); err != nil {
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonCancelOrder)
+	}
+
+	order.Status = market.Canceling
+
+	return nil
+}
+
+/*Get the Executed Trades Since the Time
+myTrades only returns the trades of one symbol, the pair is required*/
+func (e *Binance) GetFills(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if p == nil {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrUnsupported, "fills of all pairs are not supported, the pair is required")
+	}
+
+	myTrades := []*MyTrade{}
+	strRequest := "/api/v3/myTrades"
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+	mapParams["startTime"] = fmt.Sprint(since.UnixNano() / 1e6)
+	mapParams["limit"] = "1000"
+
+	jsonTrades := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := e.responseErr("GetFills", jsonTrades); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonTrades), &myTrades); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonTrades)
+	}
+
+	trades := []*market.Trade{}
+	for _, t := range myTrades {
+		trade := &market.Trade{}
+		trade.TradeID = fmt.Sprint(t.ID)
+		trade.OrderID = fmt.Sprint(t.OrderID)
+		trade.Pair = p
+		trade.Rate, _ = strconv.ParseFloat(t.Price, 64)
+		trade.Quantity, _ = strconv.ParseFloat(t.Qty, 64)
+		trade.Fee, _ = strconv.ParseFloat(t.Commission, 64)
+		trade.FeeCoin = coin.GetCoin(e.GetCode(t.CommissionAsset))
+		trade.Timestamp = t.Time
+		if t.IsBuyer {
+			trade.Side = market.Buy
+		} else {
+			trade.Side = market.Sell
+		}
+		if t.IsMaker {
+			trade.Liquidity = market.LiquidityMaker
+		} else {
+			trade.Liquidity = market.LiquidityTaker
+		}
+		trades = append(trades, trade)
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+/*Cancel All Order of All Pairs*/
+func (e *Binance) CancelAllOrder() error {
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+/*Cancel All Orders of the Pair, nil pair: all pairs
+DELETE openOrders cancels all the orders of one symbol
+Step 1: List the open orders, group them by pair
+Step 2: Cancel the open orders of each pair, a failed pair doesn't stop the others
+The orders closed in between are not in the report*/
+func (e *Binance) CancelAllOrders(p *pair.Pair) (*exchange.CancelReport, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	orders, err := e.ListOpenOrders(&market.OrderQuery{Pair: p})
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := []*pair.Pair{}
+	pairOrders := make(map[string][]*market.Order)
+	for _, order := range orders {
+		if _, ok := pairOrders[order.Pair.Name]; !ok {
+			pairs = append(pairs, order.Pair)
+		}
+		pairOrders[order.Pair.Name] = append(pairOrders[order.Pair.Name], order)
+	}
+
+	report := &exchange.CancelReport{Pair: p}
+	for _, cancelPair := range pairs {
+		canceledOrders := []*Order{}
+		strRequest := "/api/v3/openOrders"
+
+		mapParams := make(map[string]string)
+		mapParams["symbol"] = e.GetPairCode(cancelPair)
+
+		jsonCancelAll := e.ApiKeyRequest("DELETE", mapParams, strRequest)
+		err := e.responseErr("CancelAllOrders", jsonCancelAll)
+		if err == nil {
+			if unmarshalErr := json.Unmarshal([]byte(jsonCancelAll), &canceledOrders); unmarshalErr != nil {
+				err = exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrNetwork, "Unmarshal Err: %v %v", unmarshalErr, jsonCancelAll)
+			}
+		}
+		if err != nil {
+			for _, order := range pairOrders[cancelPair.Name] {
+				report.Failed = append(report.Failed, &exchange.CancelFailure{Order: order, Err: err})
+			}
+			continue
+		}
+
+		for _, o := range canceledOrders {
+			order := toOrder(cancelPair, o)
+			order.Status = market.Canceling
+			report.Canceled = append(report.Canceled, order)
+		}
+	}
+	return report, nil
+}
+
+/*Place an Order
+Step 1: Check the Request is supported by GetCapabilities (exchange.CheckOrderRequest)
+Step 2: Map Side, Order Type, Time in Force and Post Only to API Params
+	Limit: LIMIT, Post Only: LIMIT_MAKER, Market: MARKET, Stop Limit: STOP_LOSS_LIMIT
+Step 3: Call ApiKey Function & Create a new Order from the RESULT response*/
+func (e *Binance) PlaceOrder(request *market.OrderRequest) (*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if err := exchange.CheckOrderRequest(e.GetName(), e.GetCapabilities(), request); err != nil {
+		return nil, err
+	}
+	if request.PostOnly && request.Type == market.StopLimitOrder {
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrUnsupported, "post only stop limit order is not supported")
+	}
+
+	placeOrder := Order{}
+	strRequest := "/api/v3/order"
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(request.Pair)
+	mapParams["side"] = strings.ToUpper(string(request.Side))
+	mapParams["quantity"] = strconv.FormatFloat(request.Quantity, 'f', -1, 64)
+	switch request.Type {
+	case market.LimitOrder:
+		mapParams["price"] = strconv.FormatFloat(request.Rate, 'f', -1, 64)
+		if request.PostOnly {
+			mapParams["type"] = "LIMIT_MAKER"
+		} else {
+			mapParams["type"] = "LIMIT"
+			mapParams["timeInForce"] = string(request.TimeInForce)
+		}
+	case market.MarketOrder:
+		mapParams["type"] = "MARKET"
+	case market.StopLimitOrder:
+		mapParams["type"] = "STOP_LOSS_LIMIT"
+		mapParams["price"] = strconv.FormatFloat(request.Rate, 'f', -1, 64)
+		mapParams["stopPrice"] = strconv.FormatFloat(request.StopRate, 'f', -1, 64)
+		mapParams["timeInForce"] = string(request.TimeInForce)
+	}
+	mapParams["newClientOrderId"] = request.ClientOrderID
+	mapParams["newOrderRespType"] = "RESULT"
+
+	jsonPlaceReturn := e.ApiKeyRequest("POST", mapParams, strRequest)
+	if err := e.responseErr("PlaceOrder", jsonPlaceReturn); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(jsonPlaceReturn), &placeOrder); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonPlaceReturn)
+	}
+	if placeOrder.OrderID == 0 {
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrUnknown, "no orderId in response: %v", jsonPlaceReturn)
+	}
+
+	order := toOrder(request.Pair, &placeOrder)
+	order.ClientOrderID = request.ClientOrderID
+	order.JsonResponse = jsonPlaceReturn
+	return order, nil
+}
+
+/*Place a limit Sell Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Binance) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Sell, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
+
+/*Place a limit Buy Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Binance) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Buy, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
+
+/*The Error of the Response, nil if it is not an error
+Binance answers the errors with {"code": -2013, "msg": "Order does not exist."}
+-1021 means the timestamp is out of the recvWindow, the clock is synced for the next request*/
+func (e *Binance) responseErr(op, jsonResponse string) error {
+	errResponse := ErrorResponse{}
+	if err := json.Unmarshal([]byte(jsonResponse), &errResponse); err != nil || errResponse.Code == 0 {
+		return nil
+	}
+
+	kind := exchange.ErrRejected
+	switch errResponse.Code {
+	case -2011, -2013: //unknown order sent, order does not exist
+		kind = exchange.ErrNotFound
+	case -1002, -1022, -2014, -2015: //unauthorized, invalid signature, bad API key format, invalid API key, IP or permissions
+		kind = exchange.ErrAuth
+	case -1021: //timestamp for this request is outside of the recvWindow
+		e.clock.Sync()
+		kind = exchange.ErrNetwork
+	case -1001, -1003, -1007: //disconnected, too many requests, timeout waiting for the backend
+		kind = exchange.ErrNetwork
+	}
+	return exchange.Errorf(e.GetName(), op, kind, "%d %s", errResponse.Code, errResponse.Msg)
+}
+
+/*************** Signature Http Request ***************/
+/*Method: GET, POST or DELETE and Signature is required
+Step 1: Add the timestamp of the server clock to mapParams
+Step 2: Sign the query string by HMAC-SHA256 of the API Secret, append it as signature
+Step 3: Send the API Key in the X-MBX-APIKEY header*/
+func (e *Binance) ApiKeyRequest(strMethod string, mapParams map[string]string, strRequestPath string) string {
+	mapParams["timestamp"] = strconv.FormatInt(e.clock.Now().UnixNano()/1e6, 10)
+
+	strUrl := e.API_URL + strRequestPath
+
+	strParams := Map2UrlQuery(mapParams)
+	signature := ComputeHmac256(strParams, e.API_SECRET)
+	signMessage := strUrl + "?" + strParams + "&signature=" + signature
+
+	httpClient := &http.Client{}
+	request, err := http.NewRequest(strMethod, signMessage, nil)
+	if nil != err {
+		return err.Error()
+	}
+	request.Header.Add("X-MBX-APIKEY", e.API_KEY)
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := httpClient.Do(request)
+	if nil != err {
+		return err.Error()
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if nil != err {
+		return err.Error()
+	}
+
+	return string(body)
+}
+
+//Signature加密 HMAC-SHA256 of the query string, hex encoded
+func ComputeHmac256(strMessage string, strSecret string) string {
+	key := []byte(strSecret)
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(strMessage))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// 将map格式的请求参数转换为字符串格式的
+// mapParams: map格式的参数键值对
+// return: 查询字符串, url encoded & sorted by key, the same query is signed and sent
+func Map2UrlQuery(mapParams map[string]string) string {
+	values := url.Values{}
+	for key, value := range mapParams {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
@@ -0,0 +1,442 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map"
+
+	"../../coin"
+	"../../db"
+	"../../exchange"
+	"../../market"
+	"../../pair"
+	"../../user"
+)
+
+type Binance struct {
+	Name         string `bson:"name"`
+	Website      string `bson:"website"`
+	RedisManager *db.RedisManager
+	RedisServer  string
+	RedisDB      int
+	API_KEY      string
+	API_SECRET   string
+	API_URL      string //the REST endpoint, API_URL by default
+	WalletStatus []exchange.Wallet_Stat
+
+	pairList         []*pair.Pair //the pairs on this exchange
+	coinList         []*coin.Coin
+	balanceMap       cmap.ConcurrentMap
+	userMap          cmap.ConcurrentMap //credentials: *Binance, the instances of other users
+	clock            *exchange.Clock    //server time of the signed requests, shared with the user instances
+	feeMap           cmap.ConcurrentMap //pair name: *exchange.TradeFee, the fees of this account from UpdateFees
+	pairConstrainMap cmap.ConcurrentMap //pair name: *exchange.PairConstrain, LOT_SIZE and PRICE_FILTER of exchangeInfo
+	coinConstrainMap cmap.ConcurrentMap //coin code: *exchange.CoinConstrain, the default network of capital config
+
+	pairCodeMap map[string]*pair.Pair //symbol of exchangeInfo eg. ETHBTC: *pair.Pair, read only after InitPairs
+}
+
+// the name of Binance in the registry
+const NAME exchange.ExchangeName = "BINANCE"
+
+func init() {
+	exchange.Register(NAME, func(config *exchange.Config) (exchange.Exchange, error) {
+		return CreateBinance(config), nil
+	})
+}
+
+/***************************************************/
+/*Create New Exchange
+Name the Exchange (Capital Letter) in NAME
+Register the Create function in init(), the exchange is then available in ExchangeManager
+Name: Exchange Name
+Website: Exchange Website URL
+MakerDB: Exchange Redis Server & Number(Import from Config)
+Execute Coins & Pairs Initial
+API_KEY: Import from Config
+API_SECRET: Import from Config
+API_URL: Import from Config, empty: API_URL
+WalletStatus: If API doesn't provide Wallet Status, import data from Postgres*/
+func CreateBinance(config *exchange.Config) *Binance {
+	instance := &Binance{}
+	instance.Name = "Binance"
+	instance.Website = "https://www.binance.com/"
+
+	instance.RedisManager = db.SharedRedisManager(string(NAME))
+	instance.RedisServer = config.RedisServer
+	instance.RedisDB = config.RedisDB
+
+	instance.API_KEY = config.API_KEY
+	instance.API_SECRET = config.API_SECRET
+	instance.API_URL = API_URL
+	if config.API_URL != "" {
+		instance.API_URL = strings.TrimSuffix(config.API_URL, "/")
+	}
+
+	instance.WalletStatus = config.WalletStatus
+
+	instance.pairList = make([]*pair.Pair, 0)
+	instance.coinList = make([]*coin.Coin, 0)
+	instance.balanceMap = cmap.New()
+	instance.userMap = cmap.New()
+	instance.clock = exchange.NewClock(NAME, instance.getServerTime)
+	instance.feeMap = cmap.New()
+	instance.pairConstrainMap = cmap.New()
+	instance.coinConstrainMap = cmap.New()
+	instance.pairCodeMap = make(map[string]*pair.Pair)
+
+	instance.FixSymbol()
+	instance.InitCoins()
+	instance.InitPairs()
+	return instance
+}
+
+func (e *Binance) GetMakerDB() *db.Redis {
+	key := fmt.Sprintf("%s-%s-%d", NAME, e.RedisServer, e.RedisDB) // the instances share the manager
+	d := e.RedisManager.Get(key)
+	if d == nil {
+		d = db.CreateRedis()
+		d.Init(e.RedisServer, e.RedisDB)
+		e.RedisManager.Add(key, d)
+	}
+	return d
+}
+
+/*Get the Instance of Another User
+The user instance shares the pairs, coins, constrains and Redis of this instance, but has its own API Key and balances*/
+func (e *Binance) ForUser(u *user.User) *Binance {
+	key := u.CredentialKey()
+	if tmp, ok := e.userMap.Get(key); ok {
+		return tmp.(*Binance)
+	}
+
+	uInstance := &Binance{}
+	uInstance.Name = e.Name
+	uInstance.Website = e.Website
+	uInstance.RedisManager = e.RedisManager
+	uInstance.RedisServer = e.RedisServer
+	uInstance.RedisDB = e.RedisDB
+	uInstance.API_KEY = u.API_KEY
+	uInstance.API_SECRET = u.API_SECRET
+	uInstance.API_URL = e.API_URL
+	uInstance.WalletStatus = e.WalletStatus
+
+	uInstance.pairList = e.pairList
+	uInstance.coinList = e.coinList
+	uInstance.balanceMap = cmap.New()
+	uInstance.userMap = e.userMap
+	uInstance.clock = e.clock
+	uInstance.feeMap = cmap.New()
+	uInstance.pairConstrainMap = e.pairConstrainMap
+	uInstance.coinConstrainMap = e.coinConstrainMap
+	uInstance.pairCodeMap = e.pairCodeMap
+
+	if !e.userMap.SetIfAbsent(key, uInstance) {
+		tmp, _ := e.userMap.Get(key)
+		return tmp.(*Binance)
+	}
+	return uInstance
+}
+
+/*Initial the Pairs of Exchange
+Step 1: Get exchangeInfo
+Step 2: Skip the symbols which are not TRADING
+Step 3: Identify Base (quoteAsset) & Target (baseAsset) and Get Pair
+Step 4: Add Pair to Exchange Pairs Arrary, keep the LOT_SIZE and PRICE_FILTER of the pair*/
+func (e *Binance) InitPairs() {
+	exchangeInfo, err := e.getExchangeInfo()
+	if err != nil {
+		log.Printf("Binance InitPairs Err: %v", err)
+		return
+	}
+
+	for _, symbol := range exchangeInfo.Symbols {
+		if symbol.Status != "TRADING" {
+			continue
+		}
+		base := coin.GetCoin(e.GetCode(symbol.QuoteAsset))
+		target := coin.GetCoin(e.GetCode(symbol.BaseAsset))
+		if base != nil && target != nil {
+			p := pair.GetPair(base, target)
+			e.pairList = append(e.pairList, p)
+			e.pairCodeMap[symbol.Symbol] = p
+			e.setPairConstrain(p, symbol)
+		}
+	}
+}
+
+/*Initial the Coins of Exchange
+Step 1: Get exchangeInfo
+Step 2: Get the baseAsset and quoteAsset of each symbol
+Step 3: Check the coin (Use Standard Code ex. e.GetCode(coin)) exists or not
+Step 4: if the coin doesn't exist in coinmap, Add the coin in coinmap
+	- Code: General Short Code*/
+func (e *Binance) InitCoins() {
+	exchangeInfo, err := e.getExchangeInfo()
+	if err != nil {
+		log.Printf("Binance InitCoins Err: %v", err)
+		return
+	}
+
+	added := make(map[string]bool)
+	for _, symbol := range exchangeInfo.Symbols {
+		for _, asset := range []string{symbol.BaseAsset, symbol.QuoteAsset} {
+			code := e.GetCode(asset)
+			if added[code] {
+				continue
+			}
+			added[code] = true
+
+			c := coin.GetCoin(code)
+			if c == nil {
+				c = &coin.Coin{}
+				c.Code = code
+				coin.AddCoin(c)
+			}
+			e.coinList = append(e.coinList, c)
+		}
+	}
+}
+
+/***************************************************/
+/*Upload updated Maker to Redis
+Step 1: Change Instance Name (e *<exchange Instance Name>)
+Step 2: Change Exchange Name exchange.<Capital Letter Exchange Name>*/
+func (e *Binance) UpdateMaker(pair *pair.Pair, maker *market.Maker) error {
+	m, err := json.Marshal(maker)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
+	return e.GetMakerDB().Set(key, string(m))
+}
+
+/*Get Maker from Redis
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>
+Step 3: Change Error Exchange Name    <exchange Name> does not have the pair*/
+func (e *Binance) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
+	val, err := e.GetMakerDB().Get(key)
+	if err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrNotFound, "does not have the pair : %v", pair.Name)
+	}
+	if str, ok := val.(string); ok {
+		if err := json.Unmarshal([]byte(str), &maker); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrUnknown, "Key: %v can't convert to string: %v", key, val)
+	}
+	return maker, err
+}
+
+/***************************************************/
+func (e *Binance) SetCoins() error {
+	return nil
+}
+
+func (e *Binance) GetCoins() []*coin.Coin {
+	return e.coinList
+}
+
+func (e *Binance) SetPairs() error {
+	return nil
+}
+
+/*Get Exchange All Pairs
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Binance) GetPairs() []*pair.Pair {
+	return e.pairList
+}
+
+/*Get Exchange A Pair
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Binance) GetPair(key string) *pair.Pair {
+	for _, p := range e.pairList {
+		if p.Name == key {
+			return p
+		}
+	}
+	return nil
+}
+
+/*Get Pair Code base on Exchange
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Change Format of Code   ex. ADABTC in Binance, eos_btc in TradeSatoshi*/
+func (e *Binance) GetPairCode(pair *pair.Pair) string {
+	code := fmt.Sprintf("%s%s", strings.ToUpper(e.GetSymbol(pair.Target.Code)), strings.ToUpper(e.GetSymbol(pair.Base.Code)))
+	return code
+}
+
+/*Check the exchange has the pair
+The pairs of exchangeInfo which are TRADING*/
+func (e *Binance) HasPair(pair *pair.Pair) bool {
+	return e.GetPair(pair.Name) != nil
+}
+
+/*************** pairs on the exchanges ***************/
+/*Get Exchange Name
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>*/
+func (e *Binance) GetName() exchange.ExchangeName {
+	return NAME
+}
+
+// the offset of the server time measured by the signed requests
+func (e *Binance) GetClockSkew() time.Duration {
+	return e.clock.Skew()
+}
+
+/*Get Exchange Taker Fee
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Change Return base on the taker fee that exchange provides*/
+func (e *Binance) GetFee(pair *pair.Pair) float64 { // Taker fee for each coin
+	return e.GetTradeFee(pair).Taker
+}
+
+/*Get Exchange Maker & Taker Fee
+The commission of the account after UpdateFees, 0.1% for both before*/
+func (e *Binance) GetTradeFee(pair *pair.Pair) *exchange.TradeFee {
+	if tmp, ok := e.feeMap.Get(pair.Name); ok {
+		return tmp.(*exchange.TradeFee)
+	}
+	return &exchange.TradeFee{
+		Pair:   pair,
+		Maker:  0.001,
+		Taker:  0.001,
+		Source: exchange.SourceStatic,
+	}
+}
+
+/*Get Pair LotSize(Quantity)
+The stepSize of LOT_SIZE in exchangeInfo, kept by InitPairs and UpdatePairConstrain*/
+func (e *Binance) GetLotSize(pair *pair.Pair) float64 {
+	if tmp, ok := e.pairConstrainMap.Get(pair.Name); ok {
+		return tmp.(*exchange.PairConstrain).LotSize
+	}
+	return 0.00000001
+}
+
+/*Get Pair PriceFilter(Price)
+The tickSize of PRICE_FILTER in exchangeInfo, kept by InitPairs and UpdatePairConstrain*/
+func (e *Binance) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for price
+	if tmp, ok := e.pairConstrainMap.Get(pair.Name); ok {
+		return tmp.(*exchange.PairConstrain).TickSize
+	}
+	return 0.00000001
+}
+
+func (e *Binance) GetCapabilities() *exchange.Capabilities {
+	constrainFetchMethod := &exchange.ConstrainFetchMethod{}
+	constrainFetchMethod.Fee = true
+	constrainFetchMethod.LotSize = true
+	constrainFetchMethod.TickSize = true
+	constrainFetchMethod.TxFee = true
+	constrainFetchMethod.Withdraw = true
+	constrainFetchMethod.Deposit = true
+	constrainFetchMethod.Confirmation = true
+
+	capabilities := &exchange.Capabilities{}
+	capabilities.OrderTypes = []market.OrderType{market.LimitOrder, market.MarketOrder, market.StopLimitOrder}
+	capabilities.TimeInForce = []market.TimeInForce{market.GTC, market.IOC, market.FOK}
+	capabilities.PostOnly = true
+	capabilities.ClientOrderID = true
+	capabilities.CancelAll = true
+	capabilities.ListOrders = true
+	capabilities.Withdraw = true
+	capabilities.DepositAddress = true
+	capabilities.TransferHistory = true
+	capabilities.WebSocketMarketData = true
+	capabilities.WebSocketUserData = true
+	capabilities.BatchOrderBooks = false
+	capabilities.FeeSource = exchange.SourceAPI
+	capabilities.ConstrainSource = constrainFetchMethod
+	return capabilities
+}
+
+/*************** coins on the exchanges ***************/
+/*Get Coin Balance
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Binance) GetBalance(coin *coin.Coin) float64 {
+	if tmp, ok := e.balanceMap.Get(coin.Code); ok {
+		return tmp.(*market.Balance).Available
+	} else {
+		return 0.0
+	}
+}
+
+/*Get the Balances of All Coins
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Binance) GetBalances() []*market.Balance {
+	balances := []*market.Balance{}
+	for _, tmp := range e.balanceMap.Items() {
+		balance := *tmp.(*market.Balance)
+		balances = append(balances, &balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Coin.Code < balances[j].Coin.Code
+	})
+	return balances
+}
+
+/*Get Coin Withdraw Fee
+The withdrawFee of the default network in capital config, kept by UpdateCoinConstrain*/
+func (e *Binance) GetTxFee(coin *coin.Coin) float64 { // Withdraw Fee
+	if constrain := e.getCoinConstrain(coin); constrain != nil {
+		return constrain.TxFee
+	}
+	return 0
+}
+
+/*Get Coin Confirmation
+The minConfirm of the default network in capital config, kept by UpdateCoinConstrain*/
+func (e *Binance) GetConfirmation(coin *coin.Coin) int { // deposit confirmations
+	if constrain := e.getCoinConstrain(coin); constrain != nil {
+		return constrain.Confirmation
+	}
+	return 0
+}
+
+/*Check Coin Withdraw Enable
+The withdrawEnable of the default network in capital config, kept by UpdateCoinConstrain
+false before UpdateCoinConstrain*/
+func (e *Binance) CanWithdraw(coin *coin.Coin) bool { // does withdraw enable
+	if constrain := e.getCoinConstrain(coin); constrain != nil {
+		return constrain.Withdraw
+	}
+	return false
+}
+
+/*Check Coin Deposit Enable
+The depositEnable of the default network in capital config, kept by UpdateCoinConstrain
+false before UpdateCoinConstrain*/
+func (e *Binance) CanDeposit(coin *coin.Coin) bool { // does deposit enable
+	if constrain := e.getCoinConstrain(coin); constrain != nil {
+		return constrain.Deposit
+	}
+	return false
+}
+
+func (e *Binance) getCoinConstrain(coin *coin.Coin) *exchange.CoinConstrain {
+	if coin == nil {
+		return nil
+	}
+	if tmp, ok := e.coinConstrainMap.Get(coin.Code); ok {
+		return tmp.(*exchange.CoinConstrain)
+	}
+	return nil
+}
+
+/*Get trading website URL
+Step 1: Find the website's Exchange page, copy it's URL
+Step 2: Change the pair's syntax to match the URL syntax
+*/
+func (e *Binance) GetTradingWebURL(pair *pair.Pair) string {
+	return fmt.Sprintf("https://www.binance.com/en/trade/%s_%s", e.GetSymbol(pair.Target.Code), e.GetSymbol(pair.Base.Code))
+}
@@ -0,0 +1,128 @@
+package binance
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"../../coin"
+	"../../exchange"
+	"../../pair"
+)
+
+/*Update Pairs Constrain
+Step 1: Get exchangeInfo
+Step 2: Get Each Symbol, Identify Base & Target and Get Pair
+Step 3: Add LotSize - the stepSize of LOT_SIZE
+Step 4: Add TickSize - the tickSize of PRICE_FILTER*/
+func (e *Binance) UpdatePairConstrain() error {
+	exchangeInfo, err := e.getExchangeInfo()
+	if err != nil {
+		return err
+	}
+
+	for _, symbol := range exchangeInfo.Symbols {
+		if p := e.getPairByCode(symbol.Symbol); p != nil {
+			e.setPairConstrain(p, symbol)
+		}
+	}
+	return nil
+}
+
+func (e *Binance) setPairConstrain(p *pair.Pair, symbol *SymbolInfo) {
+	pairConstrain := &exchange.PairConstrain{}
+	pairConstrain.Pair = p
+	for _, filter := range symbol.Filters {
+		switch filter.FilterType {
+		case "LOT_SIZE":
+			lotSize, err := strconv.ParseFloat(filter.StepSize, 64)
+			if err != nil {
+				log.Printf("Binance %s Lot_Size Err: %s", symbol.Symbol, err)
+			}
+			pairConstrain.LotSize = lotSize
+		case "PRICE_FILTER":
+			tickSize, err := strconv.ParseFloat(filter.TickSize, 64)
+			if err != nil {
+				log.Printf("Binance %s Tick_Size Err: %s", symbol.Symbol, err)
+			}
+			pairConstrain.TickSize = tickSize
+		}
+	}
+	if symbol.Status != "TRADING" {
+		pairConstrain.Issue = symbol.Status
+	}
+	e.pairConstrainMap.Set(p.Name, pairConstrain)
+}
+
+/*Update Coins Constrain
+Step 1: Get the capital config of all coins (signed)
+Step 2: Get the coin (Use Standard Code ex. e.GetCode(coin))
+Step 3: Use the default network of the coin
+Step 4: Add TxFee, Withdraw & Deposit Status and Confirmation*/
+func (e *Binance) UpdateCoinConstrain() error {
+	coinConfigs, err := e.getCoinConfigs()
+	if err != nil {
+		return err
+	}
+
+	for _, data := range coinConfigs {
+		c := coin.GetCoin(e.GetCode(data.Coin))
+		if c == nil {
+			continue
+		}
+
+		var network *Network
+		for _, n := range data.NetworkList {
+			if n.IsDefault {
+				network = n
+				break
+			}
+		}
+		if network == nil {
+			continue
+		}
+
+		coinConstrain := &exchange.CoinConstrain{}
+		coinConstrain.Coin = c
+		coinConstrain.TxFee, _ = strconv.ParseFloat(network.WithdrawFee, 64)
+		coinConstrain.Withdraw = data.WithdrawAllEnable && network.WithdrawEnable
+		coinConstrain.Deposit = data.DepositAllEnable && network.DepositEnable
+		coinConstrain.Confirmation = network.MinConfirm
+		e.coinConstrainMap.Set(c.Code, coinConstrain)
+	}
+	return nil
+}
+
+/***************************************************/
+var symbolMap = make(map[string]string) //read only after FixSymbol
+var symbolOnce sync.Once
+
+/*Standard Coin Code
+Coin has same code but it is different currency
+Fix the coin code to bitontop standard*/
+func (e *Binance) FixSymbol() { //key: exchange specific    val： bitontop standard
+	symbolOnce.Do(func() {
+		symbolMap["YOYO"] = "YOYOW"
+	})
+}
+
+/*Get Exchange Standard Code*/
+func (e *Binance) GetSymbol(code string) string {
+	code = strings.ToUpper(code)
+	for k, v := range symbolMap {
+		if code == v {
+			return k
+		}
+	}
+	return code
+}
+
+/*Get Bitontop Standard Code*/
+func (e *Binance) GetCode(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if val, ok := symbolMap[symbol]; ok {
+		return val
+	}
+	return symbol
+}
@@ -0,0 +1,242 @@
+package binance
+
+import "encoding/json"
+
+type ErrorResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+type ExchangeInfo struct {
+	Timezone   string        `json:"timezone"`
+	ServerTime int64         `json:"serverTime"`
+	Symbols    []*SymbolInfo `json:"symbols"`
+}
+
+type SymbolInfo struct {
+	Symbol              string    `json:"symbol"`
+	Status              string    `json:"status"`
+	BaseAsset           string    `json:"baseAsset"`
+	BaseAssetPrecision  int       `json:"baseAssetPrecision"`
+	QuoteAsset          string    `json:"quoteAsset"`
+	QuoteAssetPrecision int       `json:"quoteAssetPrecision"`
+	OrderTypes          []string  `json:"orderTypes"`
+	Filters             []*Filter `json:"filters"`
+}
+
+type Filter struct {
+	FilterType  string `json:"filterType"`
+	MinPrice    string `json:"minPrice"`
+	MaxPrice    string `json:"maxPrice"`
+	TickSize    string `json:"tickSize"`
+	MinQty      string `json:"minQty"`
+	MaxQty      string `json:"maxQty"`
+	StepSize    string `json:"stepSize"`
+	MinNotional string `json:"minNotional"`
+}
+
+type OrderBook struct {
+	LastUpdateID int64      `json:"lastUpdateId"`
+	Bids         [][]string `json:"bids"`
+	Asks         [][]string `json:"asks"`
+}
+
+type ServerTime struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
+type TickerData struct {
+	Symbol      string `json:"symbol"`
+	BidPrice    string `json:"bidPrice"`
+	AskPrice    string `json:"askPrice"`
+	LastPrice   string `json:"lastPrice"`
+	HighPrice   string `json:"highPrice"`
+	LowPrice    string `json:"lowPrice"`
+	Volume      string `json:"volume"`
+	QuoteVolume string `json:"quoteVolume"`
+	CloseTime   int64  `json:"closeTime"`
+}
+
+/*The Aggregate Trade
+encoding/json matches the keys case-insensitively if there is no exact match,
+so M (best match) has its own field beside m (buyer is maker)*/
+type AggTrade struct {
+	ID           int64           `json:"a"`
+	Price        string          `json:"p"`
+	Qty          string          `json:"q"`
+	FirstID      int64           `json:"f"`
+	LastID       int64           `json:"l"`
+	Time         int64           `json:"T"`
+	IsBuyerMaker bool            `json:"m"`
+	BestMatch    json.RawMessage `json:"M"`
+}
+
+type AccountInfo struct {
+	MakerCommission int        `json:"makerCommission"`
+	TakerCommission int        `json:"takerCommission"`
+	CanTrade        bool       `json:"canTrade"`
+	CanWithdraw     bool       `json:"canWithdraw"`
+	CanDeposit      bool       `json:"canDeposit"`
+	UpdateTime      int64      `json:"updateTime"`
+	Balances        []*Balance `json:"balances"`
+}
+
+type Balance struct {
+	Asset  string `json:"asset"`
+	Free   string `json:"free"`
+	Locked string `json:"locked"`
+}
+
+type Order struct {
+	Symbol              string `json:"symbol"`
+	OrderID             int64  `json:"orderId"`
+	ClientOrderID       string `json:"clientOrderId"`
+	Price               string `json:"price"`
+	OrigQty             string `json:"origQty"`
+	ExecutedQty         string `json:"executedQty"`
+	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
+	Status              string `json:"status"`
+	TimeInForce         string `json:"timeInForce"`
+	Type                string `json:"type"`
+	Side                string `json:"side"`
+	StopPrice           string `json:"stopPrice"`
+	Time                int64  `json:"time"`
+	TransactTime        int64  `json:"transactTime"`
+	UpdateTime          int64  `json:"updateTime"`
+}
+
+type MyTrade struct {
+	Symbol          string `json:"symbol"`
+	ID              int64  `json:"id"`
+	OrderID         int64  `json:"orderId"`
+	Price           string `json:"price"`
+	Qty             string `json:"qty"`
+	QuoteQty        string `json:"quoteQty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+	Time            int64  `json:"time"`
+	IsBuyer         bool   `json:"isBuyer"`
+	IsMaker         bool   `json:"isMaker"`
+}
+
+type TradeFee struct {
+	Symbol          string `json:"symbol"`
+	MakerCommission string `json:"makerCommission"`
+	TakerCommission string `json:"takerCommission"`
+}
+
+type CoinConfig struct {
+	Coin              string     `json:"coin"`
+	Name              string     `json:"name"`
+	DepositAllEnable  bool       `json:"depositAllEnable"`
+	WithdrawAllEnable bool       `json:"withdrawAllEnable"`
+	NetworkList       []*Network `json:"networkList"`
+}
+
+type Network struct {
+	Network        string `json:"network"`
+	Coin           string `json:"coin"`
+	IsDefault      bool   `json:"isDefault"`
+	DepositEnable  bool   `json:"depositEnable"`
+	WithdrawEnable bool   `json:"withdrawEnable"`
+	WithdrawFee    string `json:"withdrawFee"`
+	WithdrawMin    string `json:"withdrawMin"`
+	MinConfirm     int    `json:"minConfirm"`
+}
+
+type WithdrawResponse struct {
+	ID string `json:"id"`
+}
+
+type WithdrawHistory struct {
+	ID             string `json:"id"`
+	Amount         string `json:"amount"`
+	TransactionFee string `json:"transactionFee"`
+	Coin           string `json:"coin"`
+	Status         int    `json:"status"`
+	Address        string `json:"address"`
+	AddressTag     string `json:"addressTag"`
+	TxID           string `json:"txId"`
+	ApplyTime      string `json:"applyTime"` // UTC, eg: 2019-10-12 11:12:02
+}
+
+type DepositHistory struct {
+	ID         string `json:"id"`
+	Amount     string `json:"amount"`
+	Coin       string `json:"coin"`
+	Status     int    `json:"status"`
+	Address    string `json:"address"`
+	AddressTag string `json:"addressTag"`
+	TxID       string `json:"txId"`
+	InsertTime int64  `json:"insertTime"`
+}
+
+type DepositAddress struct {
+	Address string `json:"address"`
+	Coin    string `json:"coin"`
+	Tag     string `json:"tag"`
+}
+
+type ListenKey struct {
+	ListenKey string `json:"listenKey"`
+}
+
+type WsDepth struct {
+	Event     string     `json:"e"`
+	EventTime int64      `json:"E"`
+	Symbol    string     `json:"s"`
+	FirstID   int64      `json:"U"`
+	LastID    int64      `json:"u"`
+	Bids      [][]string `json:"b"`
+	Asks      [][]string `json:"a"`
+}
+
+/*The Message of the User Data Stream: executionReport or outboundAccountPosition
+encoding/json matches the keys case-insensitively if there is no exact match,
+so every key of executionReport has its own field, even the ones which are not used*/
+type WsUserData struct {
+	Event     string `json:"e"`
+	EventTime int64  `json:"E"`
+
+	Symbol             string          `json:"s"`
+	ClientOrderID      string          `json:"c"`
+	Side               string          `json:"S"`
+	OrderType          string          `json:"o"`
+	TimeInForce        string          `json:"f"`
+	Quantity           string          `json:"q"`
+	Price              string          `json:"p"`
+	StopPrice          json.RawMessage `json:"P"`
+	IcebergQuantity    json.RawMessage `json:"F"`
+	OrderListID        json.RawMessage `json:"g"`
+	OrigClientOrderID  string          `json:"C"` // the canceled order of a cancel
+	ExecutionType      string          `json:"x"` // NEW, CANCELED, REJECTED, TRADE, EXPIRED
+	Status             string          `json:"X"`
+	RejectReason       string          `json:"r"`
+	OrderID            int64           `json:"i"`
+	LastQuantity       string          `json:"l"`
+	Executed           string          `json:"z"`
+	LastPrice          string          `json:"L"`
+	Fee                string          `json:"n"`
+	FeeAsset           string          `json:"N"`
+	TradeTime          int64           `json:"T"`
+	TradeID            int64           `json:"t"`
+	Ignore             json.RawMessage `json:"I"`
+	IsOnBook           json.RawMessage `json:"w"`
+	WorkingTime        json.RawMessage `json:"W"`
+	IsMaker            bool            `json:"m"`
+	IgnoreM            json.RawMessage `json:"M"`
+	CreationTime       json.RawMessage `json:"O"`
+	CumulativeQuote    string          `json:"Z"`
+	LastQuote          json.RawMessage `json:"Y"`
+	QuoteOrderQuantity json.RawMessage `json:"Q"`
+	SelfTradePrevent   json.RawMessage `json:"V"`
+
+	UpdateTime json.RawMessage `json:"u"`
+	Balances   []*WsBalance    `json:"B"`
+}
+
+type WsBalance struct {
+	Asset  string `json:"a"`
+	Free   string `json:"f"`
+	Locked string `json:"l"`
+}
@@ -0,0 +1,117 @@
+package binance
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"../../exchange"
+	"../../market"
+	"../../pair"
+)
+
+/*The WebSocket Endpoint URL*/
+const (
+	WS_URL string = "wss://stream.binance.com:9443/ws"
+
+	wsDepth     = 1000             // the levels of the REST snapshot, the levels out of it are not kept
+	wsStreams   = 100              // the streams in one SUBSCRIBE message
+	wsHeartbeat = 30 * time.Second // the client pings, the server answers pong
+)
+
+/*Stream the Order Books by WebSocket
+Step 1: Subscribe <symbol>@depth@100ms of the pairs
+Step 2: The first update resyncs the book from the REST snapshot (1000 levels), the updates older than
+	the snapshot (u <= lastUpdateId) are dropped
+Step 3: Each update follows the last one: U <= the last u + 1 <= u, a gap resyncs the book
+Step 4: Offer the whole book to the channel after each update*/
+func (e *Binance) StreamOrderBook(pairs []*pair.Pair, stop <-chan struct{}) (<-chan *market.Maker, error) {
+	if len(pairs) == 0 {
+		return nil, exchange.Errorf(e.GetName(), "StreamOrderBook", exchange.ErrRejected, "no pair to stream")
+	}
+
+	streams := []string{}
+	books := make(map[string]*exchange.OrderBookEngine) //symbol: book, read only
+	for _, p := range pairs {
+		if !e.HasPair(p) {
+			return nil, exchange.Errorf(e.GetName(), "StreamOrderBook", exchange.ErrNotFound, "Binance does not have the pair : %v", p.Name)
+		}
+		symbol := e.GetPairCode(p)
+		streams = append(streams, strings.ToLower(symbol)+"@depth@100ms")
+		books[symbol] = exchange.NewOrderBookEngine(e.GetName(), p, wsDepth, func(p *pair.Pair) (*market.Maker, error) {
+			return e.orderBook(p, wsDepth)
+		})
+	}
+
+	out := make(chan *market.Maker, exchange.StreamBuffer)
+	client := exchange.NewWsClient(e.GetName(), WS_URL, func(c *exchange.WsClient, message []byte) {
+		depth := WsDepth{}
+		if err := json.Unmarshal(message, &depth); err != nil {
+			log.Printf("Binance StreamOrderBook Unmarshal Err: %v %s", err, message)
+			return
+		}
+		if depth.Event != "depthUpdate" {
+			return // the result of SUBSCRIBE
+		}
+
+		book, ok := books[depth.Symbol]
+		if !ok {
+			return
+		}
+		if err := book.Apply(&exchange.BookUpdate{
+			FirstID:   int(depth.FirstID),
+			LastID:    int(depth.LastID),
+			Bids:      toLevels(depth.Bids),
+			Asks:      toLevels(depth.Asks),
+			Timestamp: float64(depth.EventTime),
+		}); err != nil {
+			log.Printf("Binance StreamOrderBook %v", err)
+			return
+		}
+		exchange.OfferMaker(out, book.Maker())
+	})
+	client.Heartbeat = wsHeartbeat
+
+	for start, id := 0, 1; start < len(streams); start, id = start+wsStreams, id+1 {
+		end := start + wsStreams
+		if end > len(streams) {
+			end = len(streams)
+		}
+		client.Subscribe(map[string]interface{}{
+			"method": "SUBSCRIBE",
+			"params": streams[start:end],
+			"id":     id,
+		})
+	}
+	client.Start()
+
+	go func() {
+		<-stop
+		client.Close()
+		close(out)
+	}()
+	return out, nil
+}
+
+// the levels of the depth: [[price, quantity], ...]
+func toLevels(data [][]string) []market.Order {
+	levels := []market.Order{}
+	for _, d := range data {
+		if len(d) < 2 {
+			continue
+		}
+		rate, err := strconv.ParseFloat(d[0], 64)
+		if err != nil {
+			continue
+		}
+		quantity, err := strconv.ParseFloat(d[1], 64)
+		if err != nil {
+			continue
+		}
+		levels = append(levels, market.Order{Rate: rate, Quantity: quantity})
+	}
+	return levels
+}
+
@@ -0,0 +1,195 @@
+package binance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"../../coin"
+	"../../exchange"
+	"../../market"
+)
+
+const listenKeyKeepAlive = 30 * time.Minute // the listen key expires in 60 minutes without keepalive
+
+/*Stream the Orders, Fills and Balances of the Account
+Step 1: Create a listen key on each connect and connect to WS_URL/<listen key>,
+	keep the key alive every 30 minutes and delete it after stop
+Step 2: An executionReport is sent as the order, and as a fill if it is a TRADE
+Step 3: An outboundAccountPosition updates balanceMap and is sent for each coin*/
+func (e *Binance) StreamUserData(stop <-chan struct{}) (<-chan *exchange.UserEvent, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "StreamUserData", exchange.ErrAuth, "Binance API Key or Secret Key are nil.")
+	}
+
+	var lock sync.Mutex
+	var listenKey string
+
+	out := make(chan *exchange.UserEvent, exchange.StreamBuffer)
+	client := exchange.NewWsClient(e.GetName(), WS_URL, func(c *exchange.WsClient, message []byte) {
+		data := &WsUserData{}
+		if err := json.Unmarshal(message, data); err != nil {
+			log.Printf("Binance StreamUserData Unmarshal Err: %v %s", err, message)
+			return
+		}
+		for _, event := range e.toUserEvents(data) {
+			if !exchange.SendUserEvent(out, event, stop) {
+				return
+			}
+		}
+	})
+	client.Heartbeat = wsHeartbeat
+	client.Endpoint = func() (string, error) {
+		key, err := e.listenKey("POST", "")
+		if err != nil {
+			return "", err
+		}
+		lock.Lock()
+		listenKey = key
+		lock.Unlock()
+		return WS_URL + "/" + key, nil
+	}
+	client.Start()
+
+	go func() {
+		ticker := time.NewTicker(listenKeyKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				client.Close()
+				lock.Lock()
+				key := listenKey
+				lock.Unlock()
+				if key != "" {
+					e.listenKey("DELETE", key)
+				}
+				close(out)
+				return
+			case <-ticker.C:
+				lock.Lock()
+				key := listenKey
+				lock.Unlock()
+				if _, err := e.listenKey("PUT", key); err != nil {
+					log.Printf("Binance StreamUserData keepalive %v", err) // a new key is created on reconnect
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+/*Create (POST), Keep Alive (PUT) or Delete (DELETE) the Listen Key
+The listen key only needs the API Key header, it is not signed*/
+func (e *Binance) listenKey(method, key string) (string, error) {
+	strUrl := e.API_URL + "/api/v3/userDataStream"
+	if key != "" {
+		strUrl += "?listenKey=" + url.QueryEscape(key)
+	}
+
+	request, err := http.NewRequest(method, strUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Add("X-MBX-APIKEY", e.API_KEY)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", exchange.NewError(e.GetName(), "StreamUserData", exchange.ErrNetwork, err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", exchange.NewError(e.GetName(), "StreamUserData", exchange.ErrNetwork, err)
+	}
+	if err := e.responseErr("StreamUserData", string(body)); err != nil {
+		return "", err
+	}
+
+	listenKey := ListenKey{}
+	if err := json.Unmarshal(body, &listenKey); err != nil {
+		return "", exchange.Errorf(e.GetName(), "listenKey", exchange.ErrNetwork, "ListenKey Unmarshal Err: %v %s", err, body)
+	}
+	return listenKey.ListenKey, nil
+}
+
+func (e *Binance) toUserEvents(data *WsUserData) []*exchange.UserEvent {
+	events := []*exchange.UserEvent{}
+	switch data.Event {
+	case "executionReport":
+		p := e.getPairByCode(data.Symbol)
+		if p == nil {
+			log.Printf("Binance StreamUserData order %d pair %s is not in the pair list", data.OrderID, data.Symbol)
+			return events
+		}
+
+		order := &market.Order{}
+		order.Pair = p
+		order.OrderID = strconv.FormatInt(data.OrderID, 10)
+		order.ClientOrderID = data.ClientOrderID
+		if data.ExecutionType == "CANCELED" && data.OrigClientOrderID != "" {
+			order.ClientOrderID = data.OrigClientOrderID //c is the id of the cancel request
+		}
+		order.Rate, _ = strconv.ParseFloat(data.Price, 64)
+		order.Quantity, _ = strconv.ParseFloat(data.Quantity, 64)
+		order.DealQuantity, _ = strconv.ParseFloat(data.Executed, 64)
+		if quote, _ := strconv.ParseFloat(data.CumulativeQuote, 64); order.DealQuantity > 0 {
+			order.DealRate = quote / order.DealQuantity
+		}
+		order.Status = statusOf(data.Status)
+		if data.RejectReason != "" && data.RejectReason != "NONE" {
+			order.StatusMessage = data.RejectReason
+		}
+		side := market.Buy
+		if data.Side == "SELL" {
+			side = market.Sell
+		}
+		order.Side = string(side)
+		events = append(events, exchange.NewOrderEvent(order))
+
+		if data.ExecutionType == "TRADE" {
+			trade := &market.Trade{}
+			trade.TradeID = strconv.FormatInt(data.TradeID, 10)
+			trade.OrderID = order.OrderID
+			trade.Pair = p
+			trade.Side = side
+			trade.Rate, _ = strconv.ParseFloat(data.LastPrice, 64)
+			trade.Quantity, _ = strconv.ParseFloat(data.LastQuantity, 64)
+			trade.Fee, _ = strconv.ParseFloat(data.Fee, 64)
+			trade.FeeCoin = coin.GetCoin(e.GetCode(data.FeeAsset))
+			trade.Timestamp = data.TradeTime
+			if data.IsMaker {
+				trade.Liquidity = market.LiquidityMaker
+			} else {
+				trade.Liquidity = market.LiquidityTaker
+			}
+			events = append(events, exchange.NewFillEvent(trade))
+		}
+
+	case "outboundAccountPosition":
+		now := time.Now().UnixNano() / 1e6
+		for _, b := range data.Balances {
+			c := coin.GetCoin(e.GetCode(b.Asset))
+			if c == nil {
+				continue
+			}
+			balance := &market.Balance{}
+			balance.Coin = c
+			balance.Available, _ = strconv.ParseFloat(b.Free, 64)
+			balance.Locked, _ = strconv.ParseFloat(b.Locked, 64)
+			balance.Total = balance.Available + balance.Locked
+			balance.Timestamp = now
+			e.balanceMap.Set(c.Code, balance)
+			events = append(events, exchange.NewBalanceEvent(balance))
+		}
+
+	case "listenKeyExpired":
+		log.Printf("Binance StreamUserData listen key expired, keepalive failed")
+	}
+	return events
+}
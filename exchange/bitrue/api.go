@@ -59,7 +59,7 @@ func (e *Bitrue) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	symbol := e.GetPairCode(p)
 
 	strRequestUrl := "/api/v1/depth"
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 	maker := &market.Maker{}
 	maker.WorkerIP = exchange.GetExternalIP()
 	maker.BeforeTimestamp = float64(time.Now().UnixNano() / 1e6)
@@ -69,7 +69,7 @@ func (e *Bitrue) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	jsonBitrueOrderbook := exchange.HttpGetRequest(strUrl, mapParams)
 	err := json.Unmarshal([]byte(jsonBitrueOrderbook), &orderBook)
 	if err != nil {
-		return nil, fmt.Errorf("Bitrue OrderBook json Unmarshal error:%v", err)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrNetwork, "json Unmarshal error:%v", err)
 	}
 
 	//Convert Exchange Struct to Maker
@@ -103,18 +103,188 @@ func (e *Bitrue) OrderBook(p *pair.Pair) (*market.Maker, error) {
 
 		maker.Asks = append(maker.Asks, selldata)
 	}
+	maker.LastUpdateID = int(orderBook.LastUpdateID)
 	return maker, nil
 }
 
+/*Get the Ticker of the Pair*/
+func (e *Bitrue) Ticker(p *pair.Pair) (*market.Ticker, error) {
+	ticker := TickerData{}
+
+	strRequestUrl := "/api/v1/ticker/24hr"
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &ticker); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTickerReturn)
+	}
+	if ticker.Code != 0 {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrRejected, "failed: %v Message: %v", ticker.Code, ticker.Msg)
+	}
+	return toTicker(p, &ticker), nil
+}
+
+/*Get the Tickers of All the Pairs
+/api/v1/ticker/24hr returns all the symbols without the symbol param*/
+func (e *Bitrue) Tickers() ([]*market.Ticker, error) {
+	data := []*TickerData{}
+
+	strRequestUrl := "/api/v1/ticker/24hr"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &data); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Tickers", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTickerReturn)
+	}
+
+	tickers := []*market.Ticker{}
+	for _, t := range data {
+		if p := e.getPairByCode(t.Symbol); p != nil {
+			tickers = append(tickers, toTicker(p, t))
+		}
+	}
+	sort.Slice(tickers, func(i, j int) bool {
+		return tickers[i].Pair.Name < tickers[j].Pair.Name
+	})
+	return tickers, nil
+}
+
+func toTicker(pair *pair.Pair, data *TickerData) *market.Ticker {
+	ticker := &market.Ticker{}
+	ticker.Pair = pair
+	ticker.Bid, _ = strconv.ParseFloat(data.BidPrice, 64)
+	ticker.Ask, _ = strconv.ParseFloat(data.AskPrice, 64)
+	ticker.Last, _ = strconv.ParseFloat(data.LastPrice, 64)
+	ticker.High, _ = strconv.ParseFloat(data.HighPrice, 64)
+	ticker.Low, _ = strconv.ParseFloat(data.LowPrice, 64)
+	ticker.Volume, _ = strconv.ParseFloat(data.Volume, 64)
+	ticker.QuoteVolume, _ = strconv.ParseFloat(data.QuoteVolume, 64)
+	ticker.Timestamp = data.CloseTime
+	if ticker.Timestamp == 0 {
+		ticker.Timestamp = time.Now().UnixNano() / 1e6
+	}
+	return ticker
+}
+
+/*Get the Trades of the Market Since the Time
+/api/v1/trades returns the latest 1000 trades, the older trades are not available
+isBuyerMaker: the seller is the aggressor*/
+func (e *Bitrue) RecentTrades(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	data := []*MarketTrade{}
+
+	strRequestUrl := "/api/v1/trades"
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+	mapParams["limit"] = "1000"
+
+	jsonTradesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonTradesReturn), &data); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTradesReturn)
+	}
+
+	trades := []*market.Trade{}
+	for _, t := range data {
+		if t.Time < since.UnixNano()/1e6 {
+			continue
+		}
+		trade := &market.Trade{}
+		trade.TradeID = fmt.Sprintf("%d", t.ID)
+		trade.Pair = p
+		trade.Rate, _ = strconv.ParseFloat(t.Price, 64)
+		trade.Quantity, _ = strconv.ParseFloat(t.Qty, 64)
+		trade.Timestamp = t.Time
+		if t.IsBuyerMaker {
+			trade.Side = market.Sell
+		} else {
+			trade.Side = market.Buy
+		}
+		trade.Liquidity = market.LiquidityTaker
+		trades = append(trades, trade)
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+// the interval names of /api/v1/klines
+var klineIntervals = map[time.Duration]string{
+	time.Minute:        "1m",
+	3 * time.Minute:    "3m",
+	5 * time.Minute:    "5m",
+	15 * time.Minute:   "15m",
+	30 * time.Minute:   "30m",
+	time.Hour:          "1h",
+	2 * time.Hour:      "2h",
+	4 * time.Hour:      "4h",
+	6 * time.Hour:      "6h",
+	8 * time.Hour:      "8h",
+	12 * time.Hour:     "12h",
+	24 * time.Hour:     "1d",
+	3 * 24 * time.Hour: "3d",
+	7 * 24 * time.Hour: "1w",
+}
+
+/*Get the Candles of the Pair Since the Time
+/api/v1/klines returns up to 1000 candles from startTime, the intervals it doesn't support are built from the recent trades
+kline: [open time, open, high, low, close, volume, close time, quote volume, ...]*/
+func (e *Bitrue) Candles(p *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error) {
+	name, ok := klineIntervals[interval]
+	if !ok {
+		return exchange.CandlesFromTrades(e, p, interval, since)
+	}
+
+	klines := [][]interface{}{}
+
+	strRequestUrl := "/api/v1/klines"
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+	mapParams["interval"] = name
+	mapParams["startTime"] = fmt.Sprint(since.Truncate(interval).UnixNano() / 1e6)
+	mapParams["limit"] = "1000"
+
+	jsonCandlesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonCandlesReturn), &klines); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonCandlesReturn)
+	}
+
+	candles := []*market.Candle{}
+	for _, k := range klines {
+		if len(k) < 8 {
+			continue
+		}
+		timestamp, _ := strconv.ParseFloat(fmt.Sprint(k[0]), 64)
+		candle := &market.Candle{}
+		candle.Pair = p
+		candle.Timestamp = int64(timestamp)
+		candle.Open, _ = strconv.ParseFloat(fmt.Sprint(k[1]), 64)
+		candle.High, _ = strconv.ParseFloat(fmt.Sprint(k[2]), 64)
+		candle.Low, _ = strconv.ParseFloat(fmt.Sprint(k[3]), 64)
+		candle.Close, _ = strconv.ParseFloat(fmt.Sprint(k[4]), 64)
+		candle.Volume, _ = strconv.ParseFloat(fmt.Sprint(k[5]), 64)
+		candle.QuoteVolume, _ = strconv.ParseFloat(fmt.Sprint(k[7]), 64)
+		candles = append(candles, candle)
+	}
+	return candles, nil
+}
+
 /*Get Coins Information (If API provide)
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)*/
-func GetBitrueCoin() BitruePair {
+func (e *Bitrue) GetBitrueCoin() BitruePair {
 	coinsInfo := BitruePair{}
 
 	strRequestUrl := "/api/v1/exchangeInfo"
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	jsonCurrencyReturn := exchange.HttpGetRequest(strUrl, nil)
 	json.Unmarshal([]byte(jsonCurrencyReturn), &coinsInfo)
@@ -139,8 +309,26 @@ Step 3: Modify API Path(strRequestUrl)*/
 // }
 
 /*************** Private API ***************/
-func (e *Bitrue) UpdateAllBalances() {
-	e.UpdateAllBalancesByUser(nil)
+func (e *Bitrue) UpdateAllBalances() error {
+	return e.UpdateAllBalancesByUser(nil)
+}
+
+/*Get the Server Time
+Used by the clock of the signed requests*/
+func (e *Bitrue) GetBitrueTime() (time.Time, error) {
+	serverTime := ServerTime{}
+
+	strRequestUrl := "/api/v1/time"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTimeReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTimeReturn), &serverTime); err != nil {
+		return time.Time{}, exchange.Errorf(e.GetName(), "GetBitrueTime", exchange.ErrNetwork, "Get Server Time Unmarshal Err: %v %v", err, jsonTimeReturn)
+	}
+	if serverTime.ServerTime == 0 {
+		return time.Time{}, exchange.Errorf(e.GetName(), "GetBitrueTime", exchange.ErrRejected, "Get Server Time Err: %v", jsonTimeReturn)
+	}
+	return time.Unix(0, serverTime.ServerTime*1e6), nil
 }
 
 /*Get Exchange Account All Coins Balance  --reference Binance
@@ -148,20 +336,17 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)
 Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Get Coin Availamount and store in balanceMap*/
-func (e *Bitrue) UpdateAllBalancesByUser(u *user.User) {
+Step 5: Get Coin Balance (market.Balance) and store in balanceMap*/
+func (e *Bitrue) UpdateAllBalancesByUser(u *user.User) error {
 	var uInstance *Bitrue
 	if u != nil {
-		uInstance = &Bitrue{}
-		uInstance.API_KEY = u.API_KEY
-		uInstance.API_SECRET = u.API_SECRET
+		uInstance = e.ForUser(u)
 	} else {
 		uInstance = e
 	}
 
 	if uInstance.API_KEY == "" || uInstance.API_SECRET == "" {
-		log.Printf("Bitrue API Key or Secret Key are nil.")
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	accountBalance := AccountBalances{}
@@ -170,31 +355,188 @@ func (e *Bitrue) UpdateAllBalancesByUser(u *user.User) {
 	jsonBalanceReturn := uInstance.ApiKeyRequest("GET", make(map[string]string), strRequest)
 
 	if err := json.Unmarshal([]byte(jsonBalanceReturn), &accountBalance); err != nil {
-		log.Printf("Bitrue Get Balance Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrNetwork, "Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
 	} else {
+		now := time.Now().UnixNano() / 1e6
 		for _, data := range accountBalance.Balances {
 			c := coin.GetCoin(e.GetCode(data.Asset))
 			if c != nil {
 				available, err := strconv.ParseFloat(data.Free, 64)
-				if err == nil {
-					balanceMap.Set(c.Code, available)
+				if err != nil {
+					continue
 				}
+				locked, _ := strconv.ParseFloat(data.Locked, 64)
+
+				balance := &market.Balance{}
+				balance.Coin = c
+				balance.Available = available
+				balance.Locked = locked
+				balance.Total = available + locked
+				balance.Timestamp = now
+				uInstance.balanceMap.Set(c.Code, balance)
 			}
 		}
 	}
 
 	//TODO: GetBalance
+	return nil
 }
 
-/*Withdraw the coin to another address
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Check the success of withdraw*/
-func (e *Bitrue) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) bool {
-	return false
+/*Withdraw the Coin to the Address
+Step 1: Get the chain of the coin from WalletStatus, ErrRejected if it is not configured:
+	a coin may be on many chains (eg. USDT on ERC20 and TRC20), the chain is not guessed
+Step 2: Commit the withdrawal on the chain, the tag is sent if it is not empty
+Step 3: The withdrawal is pending, update it by WithdrawalStatus*/
+func (e *Bitrue) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) (*exchange.WithdrawalResult, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	chain := e.withdrawChain(coin)
+	if chain == "" {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrRejected, "the withdraw chain of %s is not configured in WalletStatus", coin.Code)
+	}
+
+	jsonResponse := JsonResponse{}
+	commit := WithdrawCommit{}
+	strRequest := "/api/v1/withdraw/commit"
+
+	mapParams := make(map[string]string)
+	mapParams["coin"] = strings.ToLower(e.GetSymbol(coin.Code))
+	mapParams["chainName"] = chain
+	mapParams["amount"] = strconv.FormatFloat(quantity, 'f', -1, 64)
+	mapParams["addressTo"] = addr
+	if tag != "" {
+		mapParams["tag"] = tag
+	}
+
+	jsonSubmitWithdraw := e.ApiKeyRequest("POST", mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonSubmitWithdraw), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonSubmitWithdraw)
+	} else if jsonResponse.Status != 200 {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrRejected, "%v %v", jsonResponse.Status, jsonResponse.Msg)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &commit); err != nil || commit.WithdrawID == 0 {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrUnknown, "no id in response: %v", jsonSubmitWithdraw)
+	}
+
+	withdrawal := &exchange.WithdrawalResult{}
+	withdrawal.ID = strconv.FormatInt(commit.WithdrawID, 10)
+	withdrawal.Coin = coin
+	withdrawal.Quantity = quantity
+	withdrawal.Fee = commit.Fee
+	withdrawal.Address = addr
+	withdrawal.Tag = tag
+	withdrawal.Status = exchange.TransferPending
+	withdrawal.Timestamp = time.Now().UnixNano() / 1e6
+	return withdrawal, nil
+}
+
+// the withdraw chain of the coin in WalletStatus, empty if it is not configured
+func (e *Bitrue) withdrawChain(coin *coin.Coin) string {
+	for _, status := range e.WalletStatus {
+		if strings.EqualFold(status.Currency, coin.Code) {
+			return status.Chain
+		}
+	}
+	return ""
+}
+
+/*Update the Withdrawal from the Withdraw History
+Step 1: Get the withdraw history of the coin since the withdrawal
+Step 2: Find the withdrawal by id, update the status, fee and tx hash*/
+func (e *Bitrue) WithdrawalStatus(withdrawal *exchange.WithdrawalResult) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := JsonResponse{}
+	history := []*WithdrawHistory{}
+	strRequest := "/api/v1/withdraw/history"
+
+	mapParams := make(map[string]string)
+	mapParams["coin"] = strings.ToLower(e.GetSymbol(withdrawal.Coin.Code))
+	if withdrawal.Timestamp > 0 {
+		mapParams["startTime"] = strconv.FormatInt(withdrawal.Timestamp-60000, 10) // the local time may be ahead of the exchange
+	}
+
+	jsonWithdrawHistory := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonWithdrawHistory), &jsonResponse); err != nil {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonWithdrawHistory)
+	} else if jsonResponse.Status != 200 {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrRejected, "%v %v", jsonResponse.Status, jsonResponse.Msg)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &history); err != nil {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	for _, h := range history {
+		if strconv.FormatInt(h.ID, 10) != withdrawal.ID {
+			continue
+		}
+		if fee, err := strconv.ParseFloat(h.Fee, 64); err == nil {
+			withdrawal.Fee = fee
+		}
+		withdrawal.TxHash = h.TxID
+		withdrawal.Status = transferStatusOf(h.Status)
+		return nil
+	}
+	return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrNotFound, "withdrawal %s is not in the history", withdrawal.ID)
+}
+
+// the status of the withdraw history: 0 email sent, 1 canceled, 2 awaiting approval, 3 rejected, 4 processing, 5 failure, 6 completed
+func transferStatusOf(status int) exchange.TransferStatus {
+	switch status {
+	case 1:
+		return exchange.TransferCanceled
+	case 3, 5:
+		return exchange.TransferFailed
+	case 6:
+		return exchange.TransferCompleted
+	}
+	return exchange.TransferPending
+}
+
+/*Get the Deposit Address of the Coin
+Bitrue API doesn't provide the deposit address and the deposit history*/
+func (e *Bitrue) GetDepositAddress(coin *coin.Coin) (*exchange.DepositAddress, error) {
+	return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrUnsupported, "deposit address is not supported")
+}
+
+func (e *Bitrue) GetTransfers(coin *coin.Coin, since time.Time) ([]*exchange.Transfer, error) {
+	return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrUnsupported, "transfer history is not supported")
+}
+
+/*Update the Maker & Taker Fee of the Account
+Step 1: Get the commission of the account, in basis points ex. 10 is 0.1%
+Step 2: Store the fee of each pair in feeMap, GetTradeFee returns them*/
+func (e *Bitrue) UpdateFees() error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	accountBalance := AccountBalances{}
+	strRequest := "/api/v1/account"
+
+	jsonAccount := e.ApiKeyRequest("GET", make(map[string]string), strRequest)
+	if err := json.Unmarshal([]byte(jsonAccount), &accountBalance); err != nil {
+		return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonAccount)
+	}
+	if accountBalance.Code != 0 {
+		return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrRejected, "%v %v", accountBalance.Code, accountBalance.Msg)
+	}
+
+	now := time.Now().UnixNano() / 1e6
+	for _, p := range e.pairList {
+		fee := &exchange.TradeFee{}
+		fee.Pair = p
+		fee.Maker = float64(accountBalance.MakerCommission) / 10000
+		fee.Taker = float64(accountBalance.TakerCommission) / 10000
+		fee.Source = exchange.SourceAPI
+		fee.Timestamp = now
+		e.feeMap.Set(p.Name, fee)
+	}
+	return nil
 }
 
 /*Get the Status of a Singal Order  --reference Binance
@@ -202,16 +544,17 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)
 Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Change Order Status (Status reference ../market/market.go)*/
+Step 5: Change Order Status (Status reference ../market/market.go) and the Deal from executedQty & cummulativeQuoteQty
+Step 6: ErrNotFound if the exchange doesn't know the order (code -2013)*/
 func (e *Bitrue) OrderStatus(order *market.Order) error {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return fmt.Errorf("Bitrue API Key or Secret Key are nil.")
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	orderStatus := TradeHistory{}
 	strRequest := "/api/v1/order"
 
-	timestamp := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+	timestamp := strconv.FormatInt(e.clock.Now().UnixNano()/1e6, 10)
 
 	mapParams := make(map[string]string)
 	mapParams["method"] = "GET"
@@ -221,35 +564,154 @@ func (e *Bitrue) OrderStatus(order *market.Order) error {
 
 	jsonOrderStatus := e.ApiKeyRequest("GET", mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonOrderStatus), &orderStatus); err != nil {
-		return fmt.Errorf("Bitrue OrderStatus Unmarshal Err: %v %v", err, jsonOrderStatus)
-	} else {
-		if strconv.Itoa(orderStatus.OrderID) == order.OrderID {
-			switch orderStatus.Status {
-			case "NEW":
-				order.Status = market.New
-			case "PARTIALLY_FILLED":
-				order.Status = market.Partial
-			case "REJECTED":
-				order.Status = market.Rejected
-			case "PENDING_CANCEL":
-				order.Status = market.Canceling
-			case "CANCELED":
-				order.Status = market.Canceled
-			case "FILLED":
-				order.Status = market.Filled
-			case "EXPIRED":
-				order.Status = market.Expired
-			default:
-				order.Status = market.Other
-			}
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrderStatus)
+	}
+	if orderStatus.OrderID == 0 {
+		if orderStatus.Code == -2013 { //Order does not exist
+			return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrNotFound, "order %s is not found", order.OrderID)
 		}
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrRejected, "%v %v", orderStatus.Code, orderStatus.Msg)
+	}
+
+	if strconv.Itoa(orderStatus.OrderID) == order.OrderID {
+		deal := toOrder(order.Pair, &orderStatus)
+		order.Status = deal.Status
+		order.DealQuantity = deal.DealQuantity
+		order.DealRate = deal.DealRate
 	}
 
 	return nil
 }
 
+// the API queries one order at a time
+func (e *Bitrue) OrdersStatus(orders []*market.Order) error {
+	return exchange.OrdersStatusEach(e, orders)
+}
+
+/*Get an Order by the Client Order ID  --reference Binance
+Step 1: Query the order with origClientOrderId
+Step 2: ErrNotFound if the exchange doesn't know the order (code -2013), it is safe to place it again*/
+func (e *Bitrue) OrderByClientID(pair *pair.Pair, clientOrderID string) (*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	orderStatus := TradeHistory{}
+	strRequest := "/api/v1/order"
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = strings.ToUpper(e.GetPairCode(pair))
+	mapParams["origClientOrderId"] = clientOrderID
+
+	jsonOrderStatus := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonOrderStatus), &orderStatus); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrderStatus)
+	}
+	if orderStatus.OrderID == 0 { //-2013 Order does not exist: ErrNotFound
+		return nil, e.codeErr("OrderByClientID", orderStatus.Code, orderStatus.Msg)
+	}
+
+	return toOrder(pair, &orderStatus), nil
+}
+
+func toOrder(pair *pair.Pair, history *TradeHistory) *market.Order {
+	order := &market.Order{}
+	order.Pair = pair
+	order.OrderID = strconv.Itoa(history.OrderID)
+	order.ClientOrderID = history.ClientOrderID
+	order.Rate, _ = strconv.ParseFloat(history.Price, 64)
+	order.Quantity, _ = strconv.ParseFloat(history.OrigQty, 64)
+	order.DealQuantity, _ = strconv.ParseFloat(history.ExecutedQty, 64)
+	if quoteQty, err := strconv.ParseFloat(history.CummulativeQuoteQty, 64); err == nil && order.DealQuantity > 0 {
+		order.DealRate = quoteQty / order.DealQuantity
+	}
+	if history.Side == "SELL" {
+		order.Side = string(market.Sell)
+	} else {
+		order.Side = string(market.Buy)
+	}
+	order.Status = statusOf(history.Status)
+	return order
+}
+
+func statusOf(status string) market.OrderStatus {
+	switch status {
+	case "NEW":
+		return market.New
+	case "PARTIALLY_FILLED":
+		return market.Partial
+	case "REJECTED":
+		return market.Rejected
+	case "PENDING_CANCEL":
+		return market.Canceling
+	case "CANCELED":
+		return market.Canceled
+	case "FILLED":
+		return market.Filled
+	case "EXPIRED":
+		return market.Expired
+	}
+	return market.Other
+}
+
 func (e *Bitrue) ListOrders() (*[]market.Order, error) {
-	return nil, nil
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.OrderList(orders), nil
+}
+
+/*Get the Open Orders  --reference Binance
+Step 1: Get the open orders of the pair, or all pairs if the query has no pair
+Step 2: Sort by order time, so the pages are stable
+Step 3: Filter by pair and page (exchange.PageOrders)*/
+func (e *Bitrue) ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	openOrders := []*TradeHistory{}
+	strRequest := "/api/v1/openOrders"
+
+	mapParams := make(map[string]string)
+	if query != nil && query.Pair != nil {
+		mapParams["symbol"] = strings.ToUpper(e.GetPairCode(query.Pair))
+	}
+
+	jsonOrders := e.ApiKeyRequest("GET", mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonOrders), &openOrders); err != nil {
+		errResponse := TradeHistory{}
+		if json.Unmarshal([]byte(jsonOrders), &errResponse) == nil && errResponse.Code != 0 {
+			return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrRejected, "%v %v", errResponse.Code, errResponse.Msg)
+		}
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrders)
+	}
+
+	sort.Slice(openOrders, func(i, j int) bool {
+		return openOrders[i].Time < openOrders[j].Time
+	})
+
+	orders := []*market.Order{}
+	for _, o := range openOrders {
+		p := e.getPairByCode(o.Symbol)
+		if p == nil {
+			log.Printf("Bitrue ListOpenOrders order %d pair %s is not in the pair list", o.OrderID, o.Symbol)
+			continue
+		}
+		orders = append(orders, toOrder(p, o))
+	}
+	return exchange.PageOrders(orders, query), nil
+}
+
+// the pair of the symbol in the order, eg: ETHBTC
+func (e *Bitrue) getPairByCode(code string) *pair.Pair {
+	for _, p := range e.pairList {
+		if strings.EqualFold(e.GetPairCode(p), code) {
+			return p
+		}
+	}
+	return nil
 }
 
 /*Cancel an Order  --reference Binance
@@ -260,7 +722,7 @@ Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
 Step 5: Change Order Status (order.Status = market.Canceling)*/
 func (e *Bitrue) CancelOrder(order *market.Order) error {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return fmt.Errorf("Bitrue API Key or Secret Key are nil.")
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	strRequest := "/api/v1/order"
@@ -268,12 +730,13 @@ func (e *Bitrue) CancelOrder(order *market.Order) error {
 
 	mapParams := make(map[string]string)
 	mapParams["symbol"] = strings.ToUpper(e.GetPairCode(order.Pair))
+	mapParams["orderId"] = order.OrderID
 
 	jsonCancelOrder := e.ApiKeyRequest("DELETE", mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonCancelOrder), &cancelOrder); err != nil {
-		return fmt.Errorf("Bitrue CancelOrder Unmarshal Err: %v %v", err, jsonCancelOrder)
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonCancelOrder)
 	} else if strconv.Itoa(cancelOrder.OrderID) != order.OrderID {
-		return fmt.Errorf("Bitrue CancelOrder failed:%+v Message:%v", cancelOrder)
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrRejected, "%v %v", cancelOrder.Code, cancelOrder.Msg)
 	}
 
 	order.Status = market.Canceling
@@ -281,96 +744,167 @@ func (e *Bitrue) CancelOrder(order *market.Order) error {
 	return nil
 }
 
-/*Cancel All Order
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Change Order Status (order.Status = market.Canceling)*/
-func (e *Bitrue) CancelAllOrder() error {
-	return nil
-}
-
-/*Place a limit Sell Order  --reference Binance
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
-func (e *Bitrue) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+/*Get the Executed Trades Since the Time, nil pair: all pairs  --reference Binance
+Step 1: myTrades only returns the trades of one symbol, all the pairs are requested if pair is nil
+Step 2: Sort by time*/
+func (e *Bitrue) GetFills(pair *pair.Pair, since time.Time) ([]*market.Trade, error) {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return nil, fmt.Errorf("Bitrue API Key or Secret Key are nil.")
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
-	placeOrder := PlaceOrder{}
-	strRequest := "/api/v1/order"
+	pairs := e.pairList
+	if pair != nil {
+		pairs = append(pairs[:0:0], pair)
+	}
 
-	mapParams := make(map[string]string)
-	mapParams["symbol"] = strings.ToUpper(e.GetPairCode(pair))
-	mapParams["side"] = "SELL"
-	mapParams["type"] = "LIMIT"
-	mapParams["price"] = fmt.Sprint(rate)
-	mapParams["quantity"] = fmt.Sprint(quantity)
+	trades := []*market.Trade{}
+	for _, p := range pairs {
+		myTrades := []*MyTrade{}
+		strRequest := "/api/v1/myTrades"
+
+		mapParams := make(map[string]string)
+		mapParams["symbol"] = strings.ToUpper(e.GetPairCode(p))
+		mapParams["startTime"] = fmt.Sprint(since.UnixNano() / 1e6)
+		mapParams["limit"] = "1000"
+
+		jsonTrades := e.ApiKeyRequest("GET", mapParams, strRequest)
+		if err := json.Unmarshal([]byte(jsonTrades), &myTrades); err != nil {
+			errResponse := TradeHistory{}
+			if json.Unmarshal([]byte(jsonTrades), &errResponse) == nil && errResponse.Code != 0 {
+				return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrRejected, "%v %v", errResponse.Code, errResponse.Msg)
+			}
+			return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonTrades)
+		}
 
-	jsonPlaceReturn := e.ApiKeyRequest("POST", mapParams, strRequest)
-	if err := json.Unmarshal([]byte(jsonPlaceReturn), &placeOrder); err != nil {
-		return nil, fmt.Errorf("Bitrue LimitSell Unmarshal Err: %v %v", err, jsonPlaceReturn)
-	} else {
-		order := &market.Order{
-			OrderID:      strconv.Itoa(placeOrder.OrderID),
-			Pair:         pair,
-			Rate:         rate,
-			Quantity:     quantity,
-			Side:         "Sell",
-			Status:       market.New,
-			JsonResponse: jsonPlaceReturn,
+		for _, t := range myTrades {
+			if t.Time < since.UnixNano()/1e6 {
+				continue
+			}
+
+			trade := &market.Trade{}
+			trade.TradeID = fmt.Sprint(t.ID)
+			trade.OrderID = fmt.Sprint(t.OrderID)
+			trade.Pair = p
+			trade.Rate, _ = strconv.ParseFloat(t.Price, 64)
+			trade.Quantity, _ = strconv.ParseFloat(t.Qty, 64)
+			trade.Fee, _ = strconv.ParseFloat(t.Commission, 64)
+			trade.FeeCoin = coin.GetCoin(e.GetCode(t.CommissionAsset))
+			trade.Timestamp = t.Time
+			if t.IsBuyer {
+				trade.Side = market.Buy
+			} else {
+				trade.Side = market.Sell
+			}
+			if t.IsMaker {
+				trade.Liquidity = market.LiquidityMaker
+			} else {
+				trade.Liquidity = market.LiquidityTaker
+			}
+			trades = append(trades, trade)
 		}
-		return order, nil
 	}
 
-	return nil, nil
+	sort.Slice(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
 }
 
-/*Place a limit Buy Order  --reference Binance
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
-func (e *Bitrue) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+/*Cancel All Order of All Pairs*/
+func (e *Bitrue) CancelAllOrder() error {
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+/*Cancel All Orders of the Pair, nil pair: all pairs
+The API doesn't have cancel all, list the open orders then cancel them (exchange.CancelOpenOrders)*/
+func (e *Bitrue) CancelAllOrders(pair *pair.Pair) (*exchange.CancelReport, error) {
+	return exchange.CancelOpenOrders(e, pair)
+}
 
+/*Place an Order  --reference Binance
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Check the Request is supported by GetCapabilities (exchange.CheckOrderRequest)
+Step 3: Map Side, Order Type, Time in Force and Post Only to API Params
+Step 4: Call ApiKey Function & Create a new Order*/
+func (e *Bitrue) PlaceOrder(request *market.OrderRequest) (*market.Order, error) {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return nil, fmt.Errorf("Bitrue API Key or Secret Key are nil.")
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if err := exchange.CheckOrderRequest(e.GetName(), e.GetCapabilities(), request); err != nil {
+		return nil, err
 	}
 
 	placeOrder := PlaceOrder{}
 	strRequest := "/api/v1/order"
 
 	mapParams := make(map[string]string)
-	mapParams["method"] = "POST"
-	mapParams["symbol"] = strings.ToUpper(e.GetPairCode(pair))
-	mapParams["side"] = "BUY"
-	mapParams["type"] = "LIMIT"
-	mapParams["price"] = fmt.Sprint(rate)
-	mapParams["quantity"] = fmt.Sprint(quantity)
+	mapParams["symbol"] = strings.ToUpper(e.GetPairCode(request.Pair))
+	mapParams["side"] = strings.ToUpper(string(request.Side))
+	mapParams["quantity"] = fmt.Sprint(request.Quantity)
+	switch request.Type {
+	case market.LimitOrder:
+		mapParams["type"] = "LIMIT"
+		mapParams["price"] = fmt.Sprint(request.Rate)
+		mapParams["timeInForce"] = string(request.TimeInForce)
+	case market.MarketOrder:
+		mapParams["type"] = "MARKET"
+	}
+	mapParams["newClientOrderId"] = request.ClientOrderID
 
 	jsonPlaceReturn := e.ApiKeyRequest("POST", mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonPlaceReturn), &placeOrder); err != nil {
-		return nil, fmt.Errorf("Bitrue LimitBuy Unmarshal Err: %v %v", err, jsonPlaceReturn)
-	} else {
-		order := &market.Order{
-			OrderID:      strconv.Itoa(placeOrder.OrderID),
-			Pair:         pair,
-			Rate:         rate,
-			Quantity:     quantity,
-			Side:         "Buy",
-			Status:       market.New,
-			JsonResponse: jsonPlaceReturn,
-		}
-		return order, nil
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonPlaceReturn)
+	}
+	if placeOrder.OrderID == 0 {
+		return nil, e.codeErr("PlaceOrder", placeOrder.Code, placeOrder.Msg)
 	}
 
-	return nil, nil
+	order := &market.Order{
+		OrderID:       strconv.Itoa(placeOrder.OrderID),
+		ClientOrderID: request.ClientOrderID,
+		Pair:          request.Pair,
+		Rate:          request.Rate,
+		Quantity:      request.Quantity,
+		Side:          string(request.Side),
+		Status:        market.New,
+		JsonResponse:  jsonPlaceReturn,
+	}
+	return order, nil
+}
+
+/*Place a limit Sell Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Bitrue) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Sell, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
+
+/*Place a limit Buy Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Bitrue) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Buy, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
+
+/*The Error of the Code in the Response  --reference Binance responseErr
+Bitrue answers the errors with {"code": -2013, "msg": "Order does not exist."}
+-1021 means the timestamp is out of the recvWindow, the clock is synced for the next request*/
+func (e *Bitrue) codeErr(op string, code int, msg string) error {
+	kind := exchange.ErrRejected
+	switch code {
+	case -2011, -2013: //unknown order sent, order does not exist
+		kind = exchange.ErrNotFound
+	case -1002, -1022, -2014, -2015: //unauthorized, invalid signature, bad API key format, invalid API key, IP or permissions
+		kind = exchange.ErrAuth
+	case -1021: //timestamp for this request is outside of the recvWindow
+		e.clock.Sync()
+		kind = exchange.ErrNetwork
+	case -1001, -1003, -1007: //disconnected, too many requests, timeout waiting for the backend
+		kind = exchange.ErrNetwork
+	}
+	return exchange.Errorf(e.GetName(), op, kind, "%d %s", code, msg)
 }
 
 /*************** Signature Http Request ***************/
@@ -382,7 +916,7 @@ func (e *Bitrue) ApiKeyGet(mapParams map[string]string, strRequestPath string) s
 	strMethod := mapParams["method"]
 	delete(mapParams, "method")
 
-	strUrl := API_URL + strRequestPath
+	strUrl := e.API_URL + strRequestPath
 
 	var strParams string
 	if nil != mapParams {
@@ -419,9 +953,9 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Create mapParams Depend on API Signature request
 Step 3: Add HttpGetRequest below strUrl if API has different requests*/
 func (e *Bitrue) ApiKeyRequest(strMethod string, mapParams map[string]string, strRequestPath string) string {
-	mapParams["timestamp"] = fmt.Sprintf("%.0d", time.Now().UnixNano()/1e6)
+	mapParams["timestamp"] = strconv.FormatInt(e.clock.Now().UnixNano()/1e6, 10)
 
-	strUrl := API_URL + strRequestPath
+	strUrl := e.API_URL + strRequestPath
 
 	var strParams string
 	if nil != mapParams {
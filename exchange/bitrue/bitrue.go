@@ -2,10 +2,10 @@ package bitrue
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	cmap "github.com/orcaman/concurrent-map"
 
@@ -14,6 +14,7 @@ import (
 	"../../exchange"
 	"../../market"
 	"../../pair"
+	"../../user"
 )
 
 type Bitrue struct {
@@ -24,65 +25,113 @@ type Bitrue struct {
 	RedisDB      int
 	API_KEY      string
 	API_SECRET   string
+	API_URL      string //the REST endpoint, API_URL by default
 	WalletStatus []exchange.Wallet_Stat
-}
 
-var pairList = make([]*pair.Pair, 0) //the last num is the number of pairs on this exchange
-var coinList = make([]*coin.Coin, 0)
-var balanceMap cmap.ConcurrentMap
+	pairList   []*pair.Pair //the pairs on this exchange
+	coinList   []*coin.Coin
+	balanceMap cmap.ConcurrentMap
+	userMap    cmap.ConcurrentMap //credentials: *Bitrue, the instances of other users
+	clock      *exchange.Clock    //server time of the signed requests, shared with the user instances
+	feeMap     cmap.ConcurrentMap //pair name: *exchange.TradeFee, the fees of this account from UpdateFees
+}
 
-// var balanceMap = make(map[*coin.Coin]float64)
+// the name of Bitrue in the registry
+const NAME exchange.ExchangeName = "BITRUE"
 
-var instance *Bitrue
-var once sync.Once
+func init() {
+	exchange.Register(NAME, func(config *exchange.Config) (exchange.Exchange, error) {
+		return CreateBitrue(config), nil
+	})
+}
 
 /***************************************************/
 /*Create New Exchange
-Add Exchange Name(Capital Letter) to meta.go
+Name the Exchange (Capital Letter) in NAME
+Register the Create function in init(), the exchange is then available in ExchangeManager
 Name: Exchange Name
 Website: Exchange Website URL
 MakerDB: Exchange Redis Server & Number(Import from Config)
 Execute Coins & Pairs Initial
 API_KEY: Import from Config
 API_SECRET: Import from Config
+API_URL: Import from Config, empty: API_URL
 WalletStatus: If API doesn't provide Wallet Status, import data from Postgres*/
 func CreateBitrue(config *exchange.Config) *Bitrue {
-	once.Do(func() {
-		instance = &Bitrue{}
-		instance.Name = "Bitrue"
-		instance.Website = "https://www.bitrue.com/"
-
-		instance.RedisManager = db.CreateRedisManager()
-		instance.RedisServer = config.RedisServer
-		instance.RedisDB = config.RedisDB
-
-		instance.API_KEY = config.API_KEY
-		instance.API_SECRET = config.API_SECRET
+	instance := &Bitrue{}
+	instance.Name = "Bitrue"
+	instance.Website = "https://www.bitrue.com/"
+
+	instance.RedisManager = db.SharedRedisManager(string(NAME))
+	instance.RedisServer = config.RedisServer
+	instance.RedisDB = config.RedisDB
+
+	instance.API_KEY = config.API_KEY
+	instance.API_SECRET = config.API_SECRET
+	instance.API_URL = API_URL
+	if config.API_URL != "" {
+		instance.API_URL = strings.TrimSuffix(config.API_URL, "/")
+	}
 
-		instance.WalletStatus = config.WalletStatus
+	instance.WalletStatus = config.WalletStatus
 
-		if balanceMap == nil {
-			balanceMap = cmap.New()
-		}
+	instance.pairList = make([]*pair.Pair, 0)
+	instance.coinList = make([]*coin.Coin, 0)
+	instance.balanceMap = cmap.New()
+	instance.userMap = cmap.New()
+	instance.clock = exchange.NewClock(NAME, instance.GetBitrueTime)
+	instance.feeMap = cmap.New()
 
-		instance.FixSymbol()
-		instance.InitCoins()
-		//instance.InitPairs()
-	})
+	instance.FixSymbol()
+	instance.InitCoins()
+	//instance.InitPairs()
 	return instance
 }
 
 func (e *Bitrue) GetMakerDB() *db.Redis {
-	key := string(exchange.BITRUE)
+	key := fmt.Sprintf("%s-%s-%d", NAME, e.RedisServer, e.RedisDB) // the instances share the manager
 	d := e.RedisManager.Get(key)
 	if d == nil {
 		d = db.CreateRedis()
-		d.Init(instance.RedisServer, instance.RedisDB)
+		d.Init(e.RedisServer, e.RedisDB)
 		e.RedisManager.Add(key, d)
 	}
 	return d
 }
 
+/*Get the Instance of Another User
+The user instance shares the pairs, coins and Redis of this instance, but has its own API Key and balances*/
+func (e *Bitrue) ForUser(u *user.User) *Bitrue {
+	key := u.CredentialKey()
+	if tmp, ok := e.userMap.Get(key); ok {
+		return tmp.(*Bitrue)
+	}
+
+	uInstance := &Bitrue{}
+	uInstance.Name = e.Name
+	uInstance.Website = e.Website
+	uInstance.RedisManager = e.RedisManager
+	uInstance.RedisServer = e.RedisServer
+	uInstance.RedisDB = e.RedisDB
+	uInstance.API_KEY = u.API_KEY
+	uInstance.API_SECRET = u.API_SECRET
+	uInstance.API_URL = e.API_URL
+	uInstance.WalletStatus = e.WalletStatus
+
+	uInstance.pairList = e.pairList
+	uInstance.coinList = e.coinList
+	uInstance.balanceMap = cmap.New()
+	uInstance.userMap = e.userMap
+	uInstance.clock = e.clock
+	uInstance.feeMap = cmap.New()
+
+	if !e.userMap.SetIfAbsent(key, uInstance) {
+		tmp, _ := e.userMap.Get(key)
+		return tmp.(*Bitrue)
+	}
+	return uInstance
+}
+
 /*Initial the Pairs of Exchange
 Step 1: Change Instance Name (e *<exchange Instance Name>)
 Step 2: Get API Data
@@ -100,7 +149,7 @@ func (e *Bitrue) InitPairs() {
 	// 		target := coin.GetCoin(e.GetCode(symbol.BaseCurrency))
 	// 		if base != nil && target != nil {
 	// 			pair := pair.GetPair(base, target)
-	// 			pairList = append(pairList, pair)
+	// 			e.pairList = append(e.pairList, pair)
 	// 		}
 	// 	}
 }
@@ -121,7 +170,7 @@ Step 5: if the coin doesn't exist in coinmap, Add the coin in coinmap
 	- Blocktime: the time of the block created
 	- Blocklast: the last block of the chain*/
 func (e *Bitrue) InitCoins() {
-	coinInfo := GetBitrueCoin()
+	coinInfo := e.GetBitrueCoin()
 
 	for _, data := range coinInfo.Symbols {
 		//Modify according to type and structure
@@ -131,16 +180,16 @@ func (e *Bitrue) InitCoins() {
 			c.Code = e.GetCode(data.BaseAsset)
 			coin.AddCoin(c)
 		}
-		coinList = append(coinList, c)
+		e.coinList = append(e.coinList, c)
 		c1 := coin.GetCoin(e.GetCode(data.QuoteAsset))
 		if c1 == nil {
 			c1 = &coin.Coin{}
 			c1.Code = e.GetCode(data.QuoteAsset)
 			coin.AddCoin(c1)
 		}
-		coinList = append(coinList, c)
+		e.coinList = append(e.coinList, c)
 		pair := pair.GetPair(c1, c)
-		pairList = append(pairList, pair)
+		e.pairList = append(e.pairList, pair)
 		//log.Printf("pairList one shot check :%v %v", c1, c)
 	}
 }
@@ -154,7 +203,7 @@ func (e *Bitrue) UpdateMaker(pair *pair.Pair, maker *market.Maker) error {
 	if err != nil {
 		return err
 	}
-	key := fmt.Sprintf("%s-%s", exchange.BITRUE, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	return e.GetMakerDB().Set(key, string(m))
 }
 
@@ -163,10 +212,10 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>
 Step 3: Change Error Exchange Name    <exchange Name> does not have the pair*/
 func (e *Bitrue) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
-	key := fmt.Sprintf("%s-%s", exchange.BITRUE, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Bitrue does not have the pair : %v", pair.Name))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrNotFound, "does not have the pair : %v", pair.Name)
 	}
 	if str, ok := val.(string); ok {
 
@@ -174,7 +223,7 @@ func (e *Bitrue) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
 			return nil, err
 		}
 	} else {
-		return nil, errors.New(fmt.Sprintf("Bitrue GetMaker Key: %v can't convert to string: %v", key, val))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrUnknown, "Key: %v can't convert to string: %v", key, val)
 	}
 	return maker, err
 }
@@ -185,7 +234,7 @@ func (e *Bitrue) SetCoins() error {
 }
 
 func (e *Bitrue) GetCoins() []*coin.Coin {
-	return coinList
+	return e.coinList
 }
 
 func (e *Bitrue) SetPairs() error {
@@ -195,7 +244,18 @@ func (e *Bitrue) SetPairs() error {
 /*Get Exchange All Pairs
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Bitrue) GetPairs() []*pair.Pair {
-	return pairList
+	return e.pairList
+}
+
+/*Get Exchange A Pair
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Bitrue) GetPair(key string) *pair.Pair {
+	for _, p := range e.pairList {
+		if p.Name == key {
+			return p
+		}
+	}
+	return nil
 }
 
 /*Get Pair Code base on Exchange
@@ -222,14 +282,33 @@ func (e *Bitrue) HasPair(pair *pair.Pair) bool {
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>*/
 func (e *Bitrue) GetName() exchange.ExchangeName {
-	return exchange.BITRUE
+	return NAME
+}
+
+// the offset of the server time measured by the signed requests
+func (e *Bitrue) GetClockSkew() time.Duration {
+	return e.clock.Skew()
 }
 
 /*Get Exchange Taker Fee
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Return base on the taker fee that exchange provides*/
 func (e *Bitrue) GetFee(pair *pair.Pair) float64 { // Taker fee for each coin
-	return 0.00098 //Taker Fee: 0.2%
+	return e.GetTradeFee(pair).Taker
+}
+
+/*Get Exchange Maker & Taker Fee
+The commission of the account after UpdateFees, 0.098% for both before*/
+func (e *Bitrue) GetTradeFee(pair *pair.Pair) *exchange.TradeFee {
+	if tmp, ok := e.feeMap.Get(pair.Name); ok {
+		return tmp.(*exchange.TradeFee)
+	}
+	return &exchange.TradeFee{
+		Pair:   pair,
+		Maker:  0.00098,
+		Taker:  0.00098,
+		Source: exchange.SourceStatic,
+	}
 }
 
 /*Get Pair LotSize(Quantity)
@@ -260,7 +339,7 @@ func (e *Bitrue) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for price
 	return 0.00000001
 }
 
-func (e *Bitrue) GetConstrainFetchMethod(pair *pair.Pair) *exchange.ConstrainFetchMethod {
+func (e *Bitrue) GetCapabilities() *exchange.Capabilities {
 	constrainFetchMethod := &exchange.ConstrainFetchMethod{}
 	constrainFetchMethod.Fee = false
 	constrainFetchMethod.LotSize = false
@@ -269,20 +348,50 @@ func (e *Bitrue) GetConstrainFetchMethod(pair *pair.Pair) *exchange.ConstrainFet
 	constrainFetchMethod.Withdraw = false
 	constrainFetchMethod.Deposit = false
 	constrainFetchMethod.Confirmation = false
-	return constrainFetchMethod
+
+	capabilities := &exchange.Capabilities{}
+	capabilities.OrderTypes = []market.OrderType{market.LimitOrder, market.MarketOrder}
+	capabilities.TimeInForce = []market.TimeInForce{market.GTC, market.IOC, market.FOK}
+	capabilities.PostOnly = false
+	capabilities.ClientOrderID = true
+	capabilities.CancelAll = true
+	capabilities.ListOrders = true
+	capabilities.Withdraw = true
+	capabilities.DepositAddress = false
+	capabilities.TransferHistory = false
+	capabilities.WebSocketMarketData = true
+	capabilities.WebSocketUserData = true
+	capabilities.BatchOrderBooks = false
+	capabilities.FeeSource = exchange.SourceAPI
+	capabilities.ConstrainSource = constrainFetchMethod
+	return capabilities
 }
 
 /*************** coins on the exchanges ***************/
 /*Get Coin Balance
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Bitrue) GetBalance(coin *coin.Coin) float64 {
-	if tmp, ok := balanceMap.Get(coin.Code); ok {
-		return tmp.(float64)
+	if tmp, ok := e.balanceMap.Get(coin.Code); ok {
+		return tmp.(*market.Balance).Available
 	} else {
 		return 0.0
 	}
 }
 
+/*Get the Balances of All Coins
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Bitrue) GetBalances() []*market.Balance {
+	balances := []*market.Balance{}
+	for _, tmp := range e.balanceMap.Items() {
+		balance := *tmp.(*market.Balance)
+		balances = append(balances, &balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Coin.Code < balances[j].Coin.Code
+	})
+	return balances
+}
+
 /*Get Coin Withdraw Fee
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2:
@@ -2,6 +2,9 @@ package bitrue
 
 import (
 	"strings"
+	"sync"
+
+	"../../exchange"
 )
 
 /*Update Pairs Constrain  --If API provide those information
@@ -12,7 +15,7 @@ Step 4: Get Each Symbol
 Step 5: Identify Base & Target and Get Pair
 Step 6: Add LotSize  - float64
 Step 7: Add TickSize  - float64*/
-func (e *Bitrue) UpdatePairConstrain() {
+func (e *Bitrue) UpdatePairConstrain() error {
 	// pairData := GetBitrueCoin()
 
 	// //If Exchange doesn't provide constrain info, Leave bitrue
@@ -42,14 +45,14 @@ func (e *Bitrue) UpdatePairConstrain() {
 	// 		log.Printf("Bitrue UpdatePairConstrain Marshal err: %s\n", err)
 	// 	}
 	// 	if pairConstrain.Pair.Name != "" {
-	// 		key := fmt.Sprintf("%s-Constrain-%s", exchange.BITRUE, pairConstrain.Pair.Name)
+	// 		key := fmt.Sprintf("%s-Constrain-%s", NAME, pairConstrain.Pair.Name)
 	// 		err = e.GetMakerDB().Set(key, string(l))
 	// 		if err != nil {
 	// 			log.Printf("Bitrue UpdatePairConstrain Set DB err: %s\n", err)
 	// 		}
 	// 	}
 	// }
-	return
+	return exchange.Errorf(e.GetName(), "UpdatePairConstrain", exchange.ErrUnsupported, "pair constrain is not provided by the API")
 }
 
 /*Update Coins Constrain  --If API provide those information
@@ -62,7 +65,7 @@ Step 6: Add TxFee - float64
 Step 7: Add Withdraw Status - Bool
 Step 7: Add Deposite Status - Bool
 Step 7: Add Confirmation - Int*/
-func (e *Bitrue) UpdateCoinConstrain() {
+func (e *Bitrue) UpdateCoinConstrain() error {
 	// coinInfo := GetBitrueCoin()
 
 	// //If Exchange doesn't provide constrain info, Leave bitrue
@@ -79,24 +82,27 @@ func (e *Bitrue) UpdateCoinConstrain() {
 	// 		log.Printf("Bitrue UpdateCoinConstrain Marshal err: %s\n", err)
 	// 	}
 	// 	if coinConstrain.Coin != nil {
-	// 		key := fmt.Sprintf("%s-Constrain-%s", exchange.BITRUE, coinConstrain.Coin.Code)
+	// 		key := fmt.Sprintf("%s-Constrain-%s", NAME, coinConstrain.Coin.Code)
 	// 		err = e.GetMakerDB().Set(key, string(l))
 	// 		if err != nil {
 	// 			log.Printf("Bitrue UpdateCoinConstrain Set DB err: %s\n", err)
 	// 		}
 	// 	}
 	// }
-	return
+	return exchange.Errorf(e.GetName(), "UpdateCoinConstrain", exchange.ErrUnsupported, "coin constrain is not provided by the API")
 }
 
 /***************************************************/
-var symbolMap = make(map[string]string)
+var symbolMap = make(map[string]string) //read only after FixSymbol
+var symbolOnce sync.Once
 
 /*Standard Coin Code
 Coin has same code but it is different currency
 Fix the coin code to bitontop standard*/
 func (e *Bitrue) FixSymbol() { //key: exchange specific    val： bitontop standard
-	symbolMap["BCHSV"] = "BSV"
+	symbolOnce.Do(func() {
+		symbolMap["BCHSV"] = "BSV"
+	})
 }
 
 /*Get Exchange Standard Code*/
@@ -48,6 +48,28 @@ type PlaceOrder struct {
 	OrderID       int    `json:"orderId"`
 	ClientOrderID string `json:"clientOrderId"`
 	TransactTime  int64  `json:"transactTime"`
+	Code          int    `json:"code"` //error code
+	Msg           string `json:"msg"`
+}
+
+type WithdrawCommit struct {
+	WithdrawID int64   `json:"withdrawId"`
+	Amount     float64 `json:"amount"`
+	Fee        float64 `json:"fee"`
+	Coin       string  `json:"coin"`
+	AddressTo  string  `json:"addressTo"`
+}
+
+type WithdrawHistory struct {
+	ID        int64  `json:"id"`
+	Symbol    string `json:"symbol"`
+	Amount    string `json:"amount"`
+	Fee       string `json:"fee"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+	AddressTo string `json:"addressTo"`
+	TxID      string `json:"txid"`
+	Status    int    `json:"status"`
 }
 
 type TradeHistory struct {
@@ -67,6 +89,8 @@ type TradeHistory struct {
 	Time                int64  `json:"time"`
 	UpdateTime          int64  `json:"updateTime"`
 	IsWorking           bool   `json:"isWorking"`
+	Code                int    `json:"code"` //error code
+	Msg                 string `json:"msg"`
 }
 
 type AccountBalances struct {
@@ -83,6 +107,8 @@ type AccountBalances struct {
 		Free   string `json:"free"`
 		Locked string `json:"locked"`
 	} `json:"balances"`
+	Code int    `json:"code"` //error code
+	Msg  string `json:"msg"`
 }
 
 type CancelOrder struct {
@@ -90,4 +116,103 @@ type CancelOrder struct {
 	OrigClientOrderID string `json:"origClientOrderId"`
 	OrderID           int    `json:"orderId"`
 	ClientOrderID     string `json:"clientOrderId"`
+	Code              int    `json:"code"` //error code
+	Msg               string `json:"msg"`
+}
+
+type MyTrade struct {
+	Symbol          string `json:"symbol"`
+	ID              int64  `json:"id"`
+	OrderID         int64  `json:"orderId"`
+	Price           string `json:"price"`
+	Qty             string `json:"qty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+	Time            int64  `json:"time"`
+	IsBuyer         bool   `json:"isBuyer"`
+	IsMaker         bool   `json:"isMaker"`
+}
+
+type TickerData struct {
+	Code        int    `json:"code"`
+	Msg         string `json:"msg"`
+	Symbol      string `json:"symbol"`
+	LastPrice   string `json:"lastPrice"`
+	BidPrice    string `json:"bidPrice"`
+	AskPrice    string `json:"askPrice"`
+	HighPrice   string `json:"highPrice"`
+	LowPrice    string `json:"lowPrice"`
+	Volume      string `json:"volume"`
+	QuoteVolume string `json:"quoteVolume"`
+	CloseTime   int64  `json:"closeTime"`
+}
+
+type MarketTrade struct {
+	ID           int64  `json:"id"`
+	Price        string `json:"price"`
+	Qty          string `json:"qty"`
+	Time         int64  `json:"time"`
+	IsBuyerMaker bool   `json:"isBuyerMaker"`
+}
+
+type ServerTime struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
+// the message of the WebSocket, the ping of the server has Ping only
+type WsDepth struct {
+	Channel string `json:"channel"`
+	Ts      int64  `json:"ts"`
+	Ping    int64  `json:"ping"`
+	Tick    struct {
+		Buys [][]json.Number `json:"buys"`
+		Asks [][]json.Number `json:"asks"`
+	} `json:"tick"`
+}
+
+type ListenKey struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data struct {
+		ListenKey string `json:"listenKey"`
+	} `json:"data"`
+}
+
+/*The Message of the User Data Stream
+encoding/json matches the keys case-insensitively if there is no exact match,
+so the keys which differ only in case (I, x, Z, P, Q, f, l) have their own fields*/
+type WsUserData struct {
+	Event     string          `json:"e"`
+	EventID   json.RawMessage `json:"I"`
+	EventTime int64           `json:"E"`
+	Ping      int64           `json:"ping"`
+
+	Symbol          string          `json:"s"`
+	OrderID         json.Number     `json:"i"`
+	ClientOrderID   string          `json:"c"`
+	Side            json.RawMessage `json:"S"` // 1 or BUY, 2 or SELL
+	Price           json.Number     `json:"p"`
+	StopPrice       json.RawMessage `json:"P"`
+	Quantity        json.Number     `json:"q"`
+	QuoteQuantity   json.RawMessage `json:"Q"`
+	Status          json.RawMessage `json:"X"` // the code or the name of the status
+	ExecutionType   json.RawMessage `json:"x"`
+	Executed        json.Number     `json:"z"` // the filled quantity of the order
+	CumulativeQuote json.Number     `json:"Z"` // the filled amount of the order
+	TradeID         json.Number     `json:"t"`
+	LastPrice       json.Number     `json:"L"`
+	LastQuantity    json.Number     `json:"l"`
+	Fee             json.Number     `json:"n"`
+	FeeAsset        string          `json:"N"`
+	TradeTime       int64           `json:"T"`
+
+	Balances []*WsBalance `json:"B"`
+}
+
+type WsBalance struct {
+	Asset       string          `json:"a"`
+	Free        json.Number     `json:"F"`
+	FreeDelta   json.RawMessage `json:"f"`
+	Locked      json.Number     `json:"L"`
+	LockedDelta json.RawMessage `json:"l"`
 }
@@ -0,0 +1,127 @@
+package bitrue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"../../exchange"
+	"../../market"
+	"../../pair"
+)
+
+/*The WebSocket Endpoint URL*/
+const (
+	WS_URL string = "wss://ws.bitrue.com/kline-api/ws"
+
+	wsHeartbeat = 30 * time.Second // the server pings, the client answers pong
+)
+
+/*Stream the Order Books by WebSocket
+Step 1: Subscribe the depth channel of the pairs
+Step 2: The messages are gzip compressed, answer the ping of the server with pong
+Step 3: Each depth message is the whole book without sequence, it replaces the book*/
+func (e *Bitrue) StreamOrderBook(pairs []*pair.Pair, stop <-chan struct{}) (<-chan *market.Maker, error) {
+	if len(pairs) == 0 {
+		return nil, exchange.Errorf(e.GetName(), "StreamOrderBook", exchange.ErrRejected, "no pair to stream")
+	}
+
+	books := make(map[string]*exchange.OrderBookEngine) //channel: book, read only
+	for _, p := range pairs {
+		channel := fmt.Sprintf("market_%s_depth_step0", strings.ToLower(e.GetPairCode(p)))
+		books[channel] = exchange.NewOrderBookEngine(e.GetName(), p, 0, e.OrderBook)
+	}
+
+	out := make(chan *market.Maker, exchange.StreamBuffer)
+	client := exchange.NewWsClient(e.GetName(), WS_URL, func(c *exchange.WsClient, message []byte) {
+		depth, err := readDepth(message)
+		if err != nil {
+			log.Printf("Bitrue StreamOrderBook %v", err)
+			return
+		}
+		if depth.Ping != 0 {
+			c.Send(map[string]int64{"pong": depth.Ping})
+			return
+		}
+		if book, ok := books[depth.Channel]; ok {
+			book.Apply(&exchange.BookUpdate{
+				Snapshot:  true,
+				Bids:      toLevels(depth.Tick.Buys),
+				Asks:      toLevels(depth.Tick.Asks),
+				Timestamp: float64(depth.Ts),
+			})
+			exchange.OfferMaker(out, book.Maker())
+		}
+	})
+	client.Heartbeat = wsHeartbeat
+	for channel := range books {
+		client.Subscribe(map[string]interface{}{
+			"event": "sub",
+			"params": map[string]string{
+				"cb_id":   channel,
+				"channel": channel,
+			},
+		})
+	}
+	client.Start()
+
+	go func() {
+		<-stop
+		client.Close()
+		close(out)
+	}()
+	return out, nil
+}
+
+func readDepth(message []byte) (*WsDepth, error) {
+	message, err := gunzip(message)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := &WsDepth{}
+	if err := json.Unmarshal(message, depth); err != nil {
+		return nil, exchange.Errorf(NAME, "readDepth", exchange.ErrNetwork, "Unmarshal Err: %v %s", err, message)
+	}
+	return depth, nil
+}
+
+// the message is gzip compressed, the plain json is returned as it is
+func gunzip(message []byte) ([]byte, error) {
+	if len(message) < 2 || message[0] != 0x1f || message[1] != 0x8b {
+		return message, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(message))
+	if err != nil {
+		return nil, exchange.Errorf(NAME, "gunzip", exchange.ErrUnknown, "gzip Err: %v", err)
+	}
+	defer reader.Close()
+	if message, err = ioutil.ReadAll(reader); err != nil {
+		return nil, exchange.Errorf(NAME, "gunzip", exchange.ErrUnknown, "gzip Err: %v", err)
+	}
+	return message, nil
+}
+
+func toLevels(data [][]json.Number) []market.Order {
+	levels := []market.Order{}
+	for _, d := range data {
+		if len(d) < 2 {
+			continue
+		}
+		rate, err := d[0].Float64()
+		if err != nil {
+			continue
+		}
+		quantity, err := d[1].Float64()
+		if err != nil {
+			continue
+		}
+		levels = append(levels, market.Order{Rate: rate, Quantity: quantity})
+	}
+	return levels
+}
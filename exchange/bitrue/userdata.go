@@ -0,0 +1,237 @@
+package bitrue
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"../../coin"
+	"../../exchange"
+	"../../market"
+)
+
+/*The User Data Stream Endpoint URL*/
+const (
+	WS_USER_URL    string = "wss://wsapi.bitrue.com/stream"
+	LISTEN_KEY_URL string = "https://open.bitrue.com/poseidon/api/v1/listenKey"
+
+	listenKeyKeepAlive = 30 * time.Minute // the listen key expires in 60 minutes without keepalive
+)
+
+/*Stream the Orders, Fills and Balances of the Account
+Step 1: Create a listen key on each connect, keep it alive every 30 minutes and delete it after stop
+Step 2: Subscribe user_order_update and user_balance_update, answer the ping of the server with pong
+Step 3: An ORDER message is sent as the order, and as a fill if it has the last trade
+Step 4: A BALANCE message updates balanceMap and is sent for each coin*/
+func (e *Bitrue) StreamUserData(stop <-chan struct{}) (<-chan *exchange.UserEvent, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "StreamUserData", exchange.ErrAuth, "Bitrue API Key or Secret Key are nil.")
+	}
+
+	var lock sync.Mutex
+	var listenKey string
+
+	out := make(chan *exchange.UserEvent, exchange.StreamBuffer)
+	client := exchange.NewWsClient(e.GetName(), WS_USER_URL, func(c *exchange.WsClient, message []byte) {
+		data, err := readUserData(message)
+		if err != nil {
+			log.Printf("Bitrue StreamUserData %v", err)
+			return
+		}
+		if data.Ping != 0 {
+			c.Send(map[string]int64{"pong": data.Ping})
+			return
+		}
+		for _, event := range e.toUserEvents(data) {
+			if !exchange.SendUserEvent(out, event, stop) {
+				return
+			}
+		}
+	})
+	client.Heartbeat = wsHeartbeat
+	client.Endpoint = func() (string, error) {
+		key, err := e.listenKey("POST", "")
+		if err != nil {
+			return "", err
+		}
+		lock.Lock()
+		listenKey = key
+		lock.Unlock()
+		return WS_USER_URL + "?listenKey=" + key, nil
+	}
+	for _, channel := range []string{"user_order_update", "user_balance_update"} {
+		client.Subscribe(map[string]interface{}{
+			"event": "sub",
+			"params": map[string]string{
+				"channel": channel,
+			},
+		})
+	}
+	client.Start()
+
+	go func() {
+		ticker := time.NewTicker(listenKeyKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				client.Close()
+				lock.Lock()
+				key := listenKey
+				lock.Unlock()
+				if key != "" {
+					e.listenKey("DELETE", key)
+				}
+				close(out)
+				return
+			case <-ticker.C:
+				lock.Lock()
+				key := listenKey
+				lock.Unlock()
+				if _, err := e.listenKey("PUT", key); err != nil {
+					log.Printf("Bitrue StreamUserData keepalive %v", err) // a new key is created on reconnect
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+/*Create (POST), Keep Alive (PUT) or Delete (DELETE) the Listen Key
+The listen key only needs the API Key header, it is not signed*/
+func (e *Bitrue) listenKey(method, key string) (string, error) {
+	strUrl := LISTEN_KEY_URL
+	if key != "" {
+		strUrl += "/" + key
+	}
+
+	request, err := http.NewRequest(method, strUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Add("X-MBX-APIKEY", e.API_KEY)
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", exchange.NewError(e.GetName(), "StreamUserData", exchange.ErrNetwork, err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", exchange.NewError(e.GetName(), "StreamUserData", exchange.ErrNetwork, err)
+	}
+
+	listenKey := ListenKey{}
+	if err := json.Unmarshal(body, &listenKey); err != nil {
+		return "", exchange.Errorf(e.GetName(), "listenKey", exchange.ErrNetwork, "ListenKey Unmarshal Err: %v %s", err, body)
+	}
+	if listenKey.Code != 200 {
+		return "", exchange.Errorf(e.GetName(), "StreamUserData", exchange.ErrAuth, "listen key %s: %d %s", method, listenKey.Code, listenKey.Msg)
+	}
+	return listenKey.Data.ListenKey, nil
+}
+
+func readUserData(message []byte) (*WsUserData, error) {
+	message, err := gunzip(message)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &WsUserData{}
+	if err := json.Unmarshal(message, data); err != nil {
+		return nil, exchange.Errorf(NAME, "readUserData", exchange.ErrNetwork, "Unmarshal Err: %v %s", err, message)
+	}
+	return data, nil
+}
+
+func (e *Bitrue) toUserEvents(data *WsUserData) []*exchange.UserEvent {
+	events := []*exchange.UserEvent{}
+	switch data.Event {
+	case "ORDER":
+		p := e.getPairByCode(data.Symbol)
+		if p == nil {
+			log.Printf("Bitrue StreamUserData order %s pair %s is not in the pair list", data.OrderID, data.Symbol)
+			return events
+		}
+
+		order := &market.Order{}
+		order.Pair = p
+		order.OrderID = data.OrderID.String()
+		order.ClientOrderID = data.ClientOrderID
+		order.Rate, _ = data.Price.Float64()
+		order.Quantity, _ = data.Quantity.Float64()
+		order.DealQuantity, _ = data.Executed.Float64()
+		if quote, _ := data.CumulativeQuote.Float64(); order.DealQuantity > 0 {
+			order.DealRate = quote / order.DealQuantity
+		}
+		order.Status = wsStatusOf(wsCode(data.Status))
+		side := market.Buy
+		if code := wsCode(data.Side); code == "2" || code == "SELL" {
+			side = market.Sell
+		}
+		order.Side = string(side)
+		events = append(events, exchange.NewOrderEvent(order))
+
+		if quantity, _ := data.LastQuantity.Float64(); quantity > 0 {
+			trade := &market.Trade{}
+			trade.TradeID = data.TradeID.String()
+			trade.OrderID = order.OrderID
+			trade.Pair = p
+			trade.Side = side
+			trade.Rate, _ = data.LastPrice.Float64()
+			trade.Quantity = quantity
+			trade.Fee, _ = data.Fee.Float64()
+			trade.FeeCoin = coin.GetCoin(e.GetCode(strings.ToUpper(data.FeeAsset)))
+			trade.Timestamp = data.TradeTime
+			events = append(events, exchange.NewFillEvent(trade))
+		}
+
+	case "BALANCE":
+		now := time.Now().UnixNano() / 1e6
+		for _, b := range data.Balances {
+			c := coin.GetCoin(e.GetCode(strings.ToUpper(b.Asset)))
+			if c == nil {
+				continue
+			}
+			balance := &market.Balance{}
+			balance.Coin = c
+			balance.Available, _ = b.Free.Float64()
+			balance.Locked, _ = b.Locked.Float64()
+			balance.Total = balance.Available + balance.Locked
+			balance.Timestamp = now
+			e.balanceMap.Set(c.Code, balance)
+			events = append(events, exchange.NewBalanceEvent(balance))
+		}
+	}
+	return events
+}
+
+// the number or the string of the field, eg: 1 or "BUY"
+func wsCode(raw json.RawMessage) string {
+	var code string
+	if err := json.Unmarshal(raw, &code); err == nil {
+		return code
+	}
+	return string(raw)
+}
+
+// the status of the user data stream: the code 0 NEW, 1 PARTIALLY_FILLED, 2 FILLED, 3 CANCELED, 4 PENDING_CANCEL, or the name
+func wsStatusOf(code string) market.OrderStatus {
+	switch code {
+	case "0":
+		return market.New
+	case "1":
+		return market.Partial
+	case "2":
+		return market.Filled
+	case "3":
+		return market.Canceled
+	case "4":
+		return market.Canceling
+	}
+	return statusOf(code)
+}
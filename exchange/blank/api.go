@@ -6,7 +6,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strconv"
 	"time"
 
@@ -64,7 +63,7 @@ func (e *Blank) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	jsonBlankOrderbook := exchange.HttpGetRequest(strUrl, nil)
 	err := json.Unmarshal([]byte(jsonBlankOrderbook), &orderBook)
 	if err != nil {
-		return nil, fmt.Errorf("Blank OrderBook json Unmarshal error:%v", err)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrNetwork, "json Unmarshal error:%v", err)
 	}
 
 	//Convert Exchange Struct to Maker
@@ -102,6 +101,55 @@ func (e *Blank) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	return maker, nil
 }
 
+/*Get the Ticker of the Pair  --reference Bitrue
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Add Model of API Response
+Step 3: Get Exchange Pair Code ex. symbol := e.GetPairCode(p)
+Step 4: Modify API Path(strRequestUrl)
+Step 5: Convert the response to market.Ticker, Volume is in pair.Target*/
+func (e *Blank) Ticker(p *pair.Pair) (*market.Ticker, error) {
+	return nil, nil
+}
+
+/*Get the Tickers of All the Pairs  --reference Kraken
+Use the batch API if the exchange provides one, otherwise call Ticker for each pair in e.pairList*/
+func (e *Blank) Tickers() ([]*market.Ticker, error) {
+	return []*market.Ticker{}, nil
+}
+
+/*Get the Trades of the Market Since the Time  --reference Bitrue
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Add Model of API Response
+Step 3: Modify API Path(strRequestUrl), page with the cursor of the API if it has one (reference Kraken)
+Step 4: Convert to market.Trade, Side is the aggressor side
+Step 5: Sort the trades by Timestamp, oldest first*/
+func (e *Blank) RecentTrades(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	return []*market.Trade{}, nil
+}
+
+/*Get the Candles of the Pair Since the Time  --reference Bitrue
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Add Model of API Response
+Step 3: Map the interval to the interval name of the API, build the others from the trades (exchange.CandlesFromTrades)
+Step 4: Convert to market.Candle, Timestamp is the open time in milliseconds
+Step 5: Sort the candles by Timestamp, oldest first
+Keep exchange.CandlesFromTrades if the exchange doesn't have a candle API (reference Cryptopia)*/
+func (e *Blank) Candles(p *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error) {
+	return exchange.CandlesFromTrades(e, p, interval, since)
+}
+
+/*Stream the Order Books
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2:
+	Condition 1: API provides WebSocket depth  --Refer Kraken Code (stream.go)
+		Subscribe the pairs with exchange.NewWsClient & offer each book by exchange.OfferMaker
+		capabilities.WebSocketMarketData = true
+	Condition 2: API doesn't provide WebSocket
+		return exchange.PollOrderBook(e, pairs, interval, stop), nil*/
+func (e *Blank) StreamOrderBook(pairs []*pair.Pair, stop <-chan struct{}) (<-chan *market.Maker, error) {
+	return exchange.PollOrderBook(e, pairs, 5*time.Second, stop), nil
+}
+
 /*Get Coins Information (If API provide)
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
@@ -135,8 +183,8 @@ func GetBlankPair() *PairsData {
 }
 
 /*************** Private API ***************/
-func (e *Blank) UpdateAllBalances() {
-	e.UpdateAllBalancesByUser(nil)
+func (e *Blank) UpdateAllBalances() error {
+	return e.UpdateAllBalancesByUser(nil)
 }
 
 /*Get Exchange Account All Coins Balance  --reference Cryptopia
@@ -144,34 +192,73 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)
 Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Get Coin Availamount and store in balanceMap*/
-func (e *Blank) UpdateAllBalancesByUser(u *user.User) {
+Step 5: Get Coin Balance (market.Balance) and store in balanceMap*/
+func (e *Blank) UpdateAllBalancesByUser(u *user.User) error {
 	var uInstance *Blank
 	if u != nil {
-		uInstance = &Blank{}
-		uInstance.API_KEY = u.API_KEY
-		uInstance.API_SECRET = u.API_SECRET
-		// uInstance.MakerDB = e.MakerDB
+		uInstance = e.ForUser(u)
 	} else {
 		uInstance = e
 	}
 
 	if uInstance.API_KEY == "" || uInstance.API_SECRET == "" {
-		log.Printf("Blank API Key or Secret Key are nil.")
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	//TODO: GetBalance
+	return nil
 }
 
-/*Withdraw the coin to another address
+/*Withdraw the coin to another address  --reference Cryptopia
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)
 Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Check the success of withdraw*/
-func (e *Blank) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) bool {
-	return false
+Step 5: Return the withdrawal id of the exchange in WithdrawalResult, the Status is TransferPending*/
+func (e *Blank) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) (*exchange.WithdrawalResult, error) {
+	return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrUnsupported, "withdraw is not supported")
+}
+
+/*Update the Status of the Withdrawal  --reference Kraken
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Add Model of API Response
+Step 3: Modify API Path(strRequestUrl)
+Step 4: Find the withdrawal by withdrawal.ID, update Fee, TxHash and Status (reference ../exchange/model.go)
+Step 5: Return ErrNotFound if the withdrawal is not found*/
+func (e *Blank) WithdrawalStatus(withdrawal *exchange.WithdrawalResult) error {
+	return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrUnsupported, "withdraw is not supported")
+}
+
+/*Get the Deposit Address of the Coin  --reference Cryptopia
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Add Model of API Response
+Step 3: Modify API Path(strRequestUrl)
+Step 4: Set the Tag if the chain uses a tag, memo or payment id
+Keep ErrUnsupported and capabilities.DepositAddress = false if the API doesn't provide it*/
+func (e *Blank) GetDepositAddress(coin *coin.Coin) (*exchange.DepositAddress, error) {
+	return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrUnsupported, "deposit address is not supported")
+}
+
+/*Get the Deposits and Withdrawals Since the Time  --reference Cryptopia
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Add Model of API Response
+Step 3: Modify API Path(strRequestUrl), return ErrUnsupported for nil coin if the API requires the coin
+Step 4: Convert to exchange.Transfer with Status (reference ../exchange/model.go)
+Step 5: Sort the transfers by Timestamp*/
+func (e *Blank) GetTransfers(coin *coin.Coin, since time.Time) ([]*exchange.Transfer, error) {
+	return []*exchange.Transfer{}, nil
+}
+
+/*Stream the Orders, Fills and Balances of the Account
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2:
+	Condition 1: API provides a user data WebSocket  --Refer Kraken or Bitrue Code (userdata.go)
+		Subscribe with the token or listen key of exchange.WsClient OnConnect / Endpoint & send the events by exchange.SendUserEvent
+		capabilities.WebSocketUserData = true
+	Condition 2: API doesn't provide WebSocket
+		return exchange.PollUserData(e, interval, stop), nil*/
+func (e *Blank) StreamUserData(stop <-chan struct{}) (<-chan *exchange.UserEvent, error) {
+	return exchange.PollUserData(e, 5*time.Second, stop), nil
 }
 
 /*Get the Status of a Singal Order  --reference Cryptopia
@@ -184,8 +271,56 @@ func (e *Blank) OrderStatus(order *market.Order) error {
 	return nil
 }
 
+/*Get the Status of Many Orders
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2:
+	Condition 1: API queries many orders in one request  --Refer Kraken Code
+		Update each order like OrderStatus
+	Condition 2: API doesn't provide it
+		return exchange.OrdersStatusEach(e, orders)*/
+func (e *Blank) OrdersStatus(orders []*market.Order) error {
+	return exchange.OrdersStatusEach(e, orders)
+}
+
+/*Get an Order by the Client Order ID
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2:
+	Condition 1: API supports client order id  --reference Bitrue
+		Send the client order id in PlaceOrder, return ErrNotFound only when the exchange doesn't know the order
+	Condition 2: API doesn't support client order id  --reference Fcoin
+		Keep the placed orders in the instance, return ErrUnsupported when the order is not found*/
+func (e *Blank) OrderByClientID(pair *pair.Pair, clientOrderID string) (*market.Order, error) {
+	return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrUnsupported, "client order id is not supported")
+}
+
 func (e *Blank) ListOrders() (*[]market.Order, error) {
-	return nil, nil
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.OrderList(orders), nil
+}
+
+/*Get the Open Orders  --reference Bitrue
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Add Model of API Response
+Step 3: Modify API Path(strRequestUrl), add the pair param if the API can filter by pair
+Step 4: Sort the orders (eg. by create time), so the pages are stable
+Step 5: Convert to market.Order with Status, DealQuantity and DealRate
+Step 6: Filter by pair and page (exchange.PageOrders)*/
+func (e *Blank) ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error) {
+	orders := []*market.Order{}
+	return exchange.PageOrders(orders, query), nil
+}
+
+/*Get the Executed Trades Since the Time  --reference Bitrue
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Add Model of API Response
+Step 3: Modify API Path(strRequestUrl), loop e.pairList if the API needs the pair and pair is nil
+Step 4: Convert to market.Trade with Fee, FeeCoin and Liquidity (LiquidityUnknown if the API doesn't provide it)
+Step 5: Sort the trades by Timestamp*/
+func (e *Blank) GetFills(pair *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	return []*market.Trade{}, nil
 }
 
 /*Cancel an Order  --reference Cryptopia
@@ -198,36 +333,51 @@ func (e *Blank) CancelOrder(order *market.Order) error {
 	return nil
 }
 
-/*Cancel All Order
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Change Order Status (order.Status = market.Canceling)*/
+/*Cancel All Order of All Pairs
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Blank) CancelAllOrder() error {
-	return nil
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		return err
+	}
+	return report.Err()
 }
 
-/*Place a limit Sell Order  --reference Cryptopia
+/*Cancel All Orders of the Pair, nil pair: all pairs
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
-func (e *Blank) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
-	return nil, nil
+Step 2:
+	Condition 1: API has cancel all  --reference Cryptopia
+		Call the API, add the canceled orders to the report
+	Condition 2: API doesn't have cancel all  --reference Bitrue
+		exchange.CancelOpenOrders lists the open orders then cancels them*/
+func (e *Blank) CancelAllOrders(pair *pair.Pair) (*exchange.CancelReport, error) {
+	return exchange.CancelOpenOrders(e, pair)
 }
 
-/*Place a limit Buy Order  --reference Cryptopia
+/*Place an Order  --reference Cryptopia
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
-func (e *Blank) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+Step 2: Check the Request is supported by GetCapabilities (exchange.CheckOrderRequest)
+Step 3: Map Side, Order Type, Time in Force and Post Only to API Params
+Step 4: Call ApiKey Function & Create a new Order*/
+func (e *Blank) PlaceOrder(request *market.OrderRequest) (*market.Order, error) {
+	if err := exchange.CheckOrderRequest(e.GetName(), e.GetCapabilities(), request); err != nil {
+		return nil, err
+	}
 	return nil, nil
 }
 
+/*Place a limit Sell Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Blank) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Sell, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
+
+/*Place a limit Buy Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Blank) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Buy, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
+
 /*************** Signature Http Request ***************/
 /*Method: GET and Signature is required  --reference Cryptopia
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
@@ -235,7 +385,7 @@ Step 2: Create mapParams Depend on API Signature request
 Step 3: Add HttpGetRequest below strUrl if API has different requests*/
 func (e *Blank) ApiKeyGet(mapParams map[string]string, strRequestPath string) string {
 	strMethod := "GET"
-	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05")
+	timestamp := e.clock.Now().UTC().Format("2006-01-02T15:04:05")
 
 	mapParams["AccessKeyId"] = e.API_KEY
 	mapParams["Timestamp"] = timestamp
@@ -252,7 +402,7 @@ Step 2: Create mapParams Depend on API Signature request
 Step 3: Add HttpGetRequest below strUrl if API has different requests*/
 func (e *Blank) ApiKeyPost(mapParams map[string]string, strRequestPath string) string {
 	strMethod := "POST"
-	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05")
+	timestamp := e.clock.Now().UTC().Format("2006-01-02T15:04:05")
 
 	//Signature Request Params
 	mapParams2Sign := make(map[string]string)
@@ -2,10 +2,10 @@ package blank
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	cmap "github.com/orcaman/concurrent-map"
 
@@ -14,6 +14,7 @@ import (
 	"../../exchange"
 	"../../market"
 	"../../pair"
+	"../../user"
 )
 
 type Blank struct {
@@ -25,20 +26,27 @@ type Blank struct {
 	API_KEY      string
 	API_SECRET   string
 	WalletStatus []exchange.Wallet_Stat
-}
 
-var pairList = make([]*pair.Pair, 0) //the last num is the number of pairs on this exchange
-var coinList = make([]*coin.Coin, 0)
-var balanceMap cmap.ConcurrentMap
+	pairList   []*pair.Pair //the pairs on this exchange
+	coinList   []*coin.Coin
+	balanceMap cmap.ConcurrentMap
+	userMap    cmap.ConcurrentMap //credentials: *Blank, the instances of other users
+	clock      *exchange.Clock    //server time of the signed requests, shared with the user instances
+}
 
-// var balanceMap = make(map[*coin.Coin]float64)
+// the name of Blank in the registry
+const NAME exchange.ExchangeName = "BLANK"
 
-var instance *Blank
-var once sync.Once
+func init() {
+	exchange.Register(NAME, func(config *exchange.Config) (exchange.Exchange, error) {
+		return CreateBlank(config), nil
+	})
+}
 
 /***************************************************/
 /*Create New Exchange
-Add Exchange Name(Capital Letter) to meta.go
+Name the Exchange (Capital Letter) in NAME
+Register the Create function in init(), the exchange is then available in ExchangeManager
 Name: Exchange Name
 Website: Exchange Website URL
 MakerDB: Exchange Redis Server & Number(Import from Config)
@@ -47,42 +55,73 @@ API_KEY: Import from Config
 API_SECRET: Import from Config
 WalletStatus: If API doesn't provide Wallet Status, import data from Postgres*/
 func CreateBlank(config *exchange.Config) *Blank {
-	once.Do(func() {
-		instance = &Blank{}
-		instance.Name = "Blank"
-		instance.Website = "https://www.blank.com/"
+	instance := &Blank{}
+	instance.Name = "Blank"
+	instance.Website = "https://www.blank.com/"
 
-		instance.RedisManager = db.CreateRedisManager()
-		instance.RedisServer = config.RedisServer
-		instance.RedisDB = config.RedisDB
+	instance.RedisManager = db.SharedRedisManager(string(NAME))
+	instance.RedisServer = config.RedisServer
+	instance.RedisDB = config.RedisDB
 
-		instance.API_KEY = config.API_KEY
-		instance.API_SECRET = config.API_SECRET
+	instance.API_KEY = config.API_KEY
+	instance.API_SECRET = config.API_SECRET
 
-		instance.WalletStatus = config.WalletStatus
+	instance.WalletStatus = config.WalletStatus
 
-		if balanceMap == nil {
-			balanceMap = cmap.New()
-		}
+	instance.pairList = make([]*pair.Pair, 0)
+	instance.coinList = make([]*coin.Coin, 0)
+	instance.balanceMap = cmap.New()
+	instance.userMap = cmap.New()
+	instance.clock = exchange.NewClock(NAME, nil) //nil: no server time API, reference GetKrakenTime
 
-		instance.FixSymbol()
-		instance.InitCoins()
-		instance.InitPairs()
-	})
+	instance.FixSymbol()
+	instance.InitCoins()
+	instance.InitPairs()
 	return instance
 }
 
 func (e *Blank) GetMakerDB() *db.Redis {
-	key := string(exchange.BLANK)
+	key := fmt.Sprintf("%s-%s-%d", NAME, e.RedisServer, e.RedisDB) // the instances share the manager
 	d := e.RedisManager.Get(key)
 	if d == nil {
 		d = db.CreateRedis()
-		d.Init(instance.RedisServer, instance.RedisDB)
+		d.Init(e.RedisServer, e.RedisDB)
 		e.RedisManager.Add(key, d)
 	}
 	return d
 }
 
+/*Get the Instance of Another User
+The user instance shares the pairs, coins and Redis of this instance, but has its own API Key and balances*/
+func (e *Blank) ForUser(u *user.User) *Blank {
+	key := u.CredentialKey()
+	if tmp, ok := e.userMap.Get(key); ok {
+		return tmp.(*Blank)
+	}
+
+	uInstance := &Blank{}
+	uInstance.Name = e.Name
+	uInstance.Website = e.Website
+	uInstance.RedisManager = e.RedisManager
+	uInstance.RedisServer = e.RedisServer
+	uInstance.RedisDB = e.RedisDB
+	uInstance.API_KEY = u.API_KEY
+	uInstance.API_SECRET = u.API_SECRET
+	uInstance.WalletStatus = e.WalletStatus
+
+	uInstance.pairList = e.pairList
+	uInstance.coinList = e.coinList
+	uInstance.balanceMap = cmap.New()
+	uInstance.userMap = e.userMap
+	uInstance.clock = e.clock
+
+	if !e.userMap.SetIfAbsent(key, uInstance) {
+		tmp, _ := e.userMap.Get(key)
+		return tmp.(*Blank)
+	}
+	return uInstance
+}
+
 /*Initial the Pairs of Exchange
 Step 1: Change Instance Name (e *<exchange Instance Name>)
 Step 2: Get API Data
@@ -100,7 +139,7 @@ func (e *Blank) InitPairs() {
 			target := coin.GetCoin(e.GetCode(symbol.BaseCurrency))
 			if base != nil && target != nil {
 				pair := pair.GetPair(base, target)
-				pairList = append(pairList, pair)
+				e.pairList = append(e.pairList, pair)
 			}
 		}
 	}
@@ -135,7 +174,7 @@ func (e *Blank) InitCoins() {
 				c.Name = data.FullName
 				coin.AddCoin(c)
 			}
-			coinList = append(coinList, c)
+			e.coinList = append(e.coinList, c)
 		}
 	}
 }
@@ -149,7 +188,7 @@ func (e *Blank) UpdateMaker(pair *pair.Pair, maker *market.Maker) error {
 	if err != nil {
 		return err
 	}
-	key := fmt.Sprintf("%s-%s", exchange.BLANK, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	return e.GetMakerDB().Set(key, string(m))
 }
 
@@ -158,10 +197,10 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>
 Step 3: Change Error Exchange Name    <exchange Name> does not have the pair*/
 func (e *Blank) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
-	key := fmt.Sprintf("%s-%s", exchange.BLANK, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Blank does not have the pair : %v", pair.Name))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrNotFound, "does not have the pair : %v", pair.Name)
 	}
 	if str, ok := val.(string); ok {
 
@@ -169,7 +208,7 @@ func (e *Blank) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
 			return nil, err
 		}
 	} else {
-		return nil, errors.New(fmt.Sprintf("Blank GetMaker Key: %v can't convert to string: %v", key, val))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrUnknown, "Key: %v can't convert to string: %v", key, val)
 	}
 	return maker, err
 }
@@ -180,7 +219,7 @@ func (e *Blank) SetCoins() error {
 }
 
 func (e *Blank) GetCoins() []*coin.Coin {
-	return coinList
+	return e.coinList
 }
 
 func (e *Blank) SetPairs() error {
@@ -190,12 +229,17 @@ func (e *Blank) SetPairs() error {
 /*Get Exchange All Pairs
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Blank) GetPairs() []*pair.Pair {
-	return pairList
+	return e.pairList
 }
 
 /*Get Exchange A Pair
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Blank) GetPair(key string) *pair.Pair {
+	for _, p := range e.pairList {
+		if p.Name == key {
+			return p
+		}
+	}
 	return nil
 }
 
@@ -223,14 +267,46 @@ func (e *Blank) HasPair(pair *pair.Pair) bool {
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>*/
 func (e *Blank) GetName() exchange.ExchangeName {
-	return exchange.BLANK
+	return NAME
+}
+
+// the offset of the server time measured by the signed requests
+func (e *Blank) GetClockSkew() time.Duration {
+	return e.clock.Skew()
 }
 
 /*Get Exchange Taker Fee
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Return base on the taker fee that exchange provides*/
 func (e *Blank) GetFee(pair *pair.Pair) float64 { // Taker fee for each coin
-	return 0.002 //Taker Fee: 0.2%
+	return e.GetTradeFee(pair).Taker
+}
+
+/*Get Exchange Maker & Taker Fee
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2:
+	Condition 1: API provides the fee of the account  --Refer Kraken Code
+		return the fee stored in feeMap by UpdateFees, the fee schedule before
+	Condition 2: API doesn't provide the fee
+		return the maker & taker fee that exchange provides, Source: exchange.SourceStatic*/
+func (e *Blank) GetTradeFee(pair *pair.Pair) *exchange.TradeFee {
+	return &exchange.TradeFee{
+		Pair:   pair,
+		Maker:  0.002,
+		Taker:  0.002,
+		Source: exchange.SourceStatic,
+	}
+}
+
+/*Update the Maker & Taker Fee of the Account
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2:
+	Condition 1: API provides the fee of the account  --Refer Kraken Code
+		Get the fee of each pair & store in feeMap
+	Condition 2: API doesn't provide the fee
+		return nil*/
+func (e *Blank) UpdateFees() error {
+	return nil
 }
 
 /*Get Pair LotSize(Quantity)
@@ -261,7 +337,10 @@ func (e *Blank) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for price
 	return 0.00000001
 }
 
-func (e *Blank) GetConstrainFetchMethod(pair *pair.Pair) *exchange.ConstrainFetchMethod {
+/*Get the Functions Supported by the Exchange
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Only set true when the function calls the API, placeholders returning nil are false*/
+func (e *Blank) GetCapabilities() *exchange.Capabilities {
 	constrainFetchMethod := &exchange.ConstrainFetchMethod{}
 	constrainFetchMethod.Fee = false
 	constrainFetchMethod.LotSize = false
@@ -270,20 +349,50 @@ func (e *Blank) GetConstrainFetchMethod(pair *pair.Pair) *exchange.ConstrainFetc
 	constrainFetchMethod.Withdraw = false
 	constrainFetchMethod.Deposit = false
 	constrainFetchMethod.Confirmation = false
-	return constrainFetchMethod
+
+	capabilities := &exchange.Capabilities{}
+	capabilities.OrderTypes = []market.OrderType{market.LimitOrder}
+	capabilities.TimeInForce = []market.TimeInForce{market.GTC}
+	capabilities.PostOnly = false
+	capabilities.ClientOrderID = false
+	capabilities.CancelAll = false
+	capabilities.ListOrders = false
+	capabilities.Withdraw = false
+	capabilities.DepositAddress = false
+	capabilities.TransferHistory = false
+	capabilities.WebSocketMarketData = false
+	capabilities.WebSocketUserData = false
+	capabilities.BatchOrderBooks = false
+	capabilities.FeeSource = exchange.SourceStatic
+	capabilities.ConstrainSource = constrainFetchMethod
+	return capabilities
 }
 
 /*************** coins on the exchanges ***************/
 /*Get Coin Balance
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Blank) GetBalance(coin *coin.Coin) float64 {
-	if tmp, ok := balanceMap.Get(coin.Code); ok {
-		return tmp.(float64)
+	if tmp, ok := e.balanceMap.Get(coin.Code); ok {
+		return tmp.(*market.Balance).Available
 	} else {
 		return 0.0
 	}
 }
 
+/*Get the Balances of All Coins
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Blank) GetBalances() []*market.Balance {
+	balances := []*market.Balance{}
+	for _, tmp := range e.balanceMap.Items() {
+		balance := *tmp.(*market.Balance)
+		balances = append(balances, &balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Coin.Code < balances[j].Coin.Code
+	})
+	return balances
+}
+
 /*Get Coin Withdraw Fee
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2:
@@ -6,6 +6,7 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 
 	"../../coin"
 	"../../exchange"
@@ -20,7 +21,7 @@ Step 4: Get Each Symbol
 Step 5: Identify Base & Target and Get Pair
 Step 6: Add LotSize  - float64
 Step 7: Add TickSize  - float64*/
-func (e *Blank) UpdatePairConstrain() {
+func (e *Blank) UpdatePairConstrain() error {
 	pairData := GetBlankPair()
 
 	//If Exchange doesn't provide constrain info, Leave blank
@@ -50,13 +51,14 @@ func (e *Blank) UpdatePairConstrain() {
 			log.Printf("Blank UpdatePairConstrain Marshal err: %s\n", err)
 		}
 		if pairConstrain.Pair.Name != "" {
-			key := fmt.Sprintf("%s-Constrain-%s", exchange.BLANK, pairConstrain.Pair.Name)
+			key := fmt.Sprintf("%s-Constrain-%s", NAME, pairConstrain.Pair.Name)
 			err = e.GetMakerDB().Set(key, string(l))
 			if err != nil {
 				log.Printf("Blank UpdatePairConstrain Set DB err: %s\n", err)
 			}
 		}
 	}
+	return nil
 }
 
 /*Update Coins Constrain  --If API provide those information
@@ -69,7 +71,7 @@ Step 6: Add TxFee - float64
 Step 7: Add Withdraw Status - Bool
 Step 7: Add Deposite Status - Bool
 Step 7: Add Confirmation - Int*/
-func (e *Blank) UpdateCoinConstrain() {
+func (e *Blank) UpdateCoinConstrain() error {
 	coinInfo := GetBlankCoin()
 
 	//If Exchange doesn't provide constrain info, Leave blank
@@ -86,23 +88,27 @@ func (e *Blank) UpdateCoinConstrain() {
 			log.Printf("Blank UpdateCoinConstrain Marshal err: %s\n", err)
 		}
 		if coinConstrain.Coin != nil {
-			key := fmt.Sprintf("%s-Constrain-%s", exchange.BLANK, coinConstrain.Coin.Code)
+			key := fmt.Sprintf("%s-Constrain-%s", NAME, coinConstrain.Coin.Code)
 			err = e.GetMakerDB().Set(key, string(l))
 			if err != nil {
 				log.Printf("Blank UpdateCoinConstrain Set DB err: %s\n", err)
 			}
 		}
 	}
+	return nil
 }
 
 /***************************************************/
-var symbolMap = make(map[string]string)
+var symbolMap = make(map[string]string) //read only after FixSymbol
+var symbolOnce sync.Once
 
 /*Standard Coin Code
 Coin has same code but it is different currency
 Fix the coin code to bitontop standard*/
 func (e *Blank) FixSymbol() { //key: exchange specific    val： bitontop standard
-	symbolMap["-"] = ""
+	symbolOnce.Do(func() {
+		symbolMap["-"] = ""
+	})
 }
 
 /*Get Exchange Standard Code*/
@@ -0,0 +1,216 @@
+package exchange
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"../market"
+	"../pair"
+)
+
+/*An Update of the Order Book from the Stream
+The levels with Quantity 0 are removed. FirstID and LastID are the sequence of the update,
+it follows the book when FirstID <= the last id + 1 <= LastID (the ids may overlap).
+LastID 0 means the exchange has no sequence, the update is applied as it is*/
+type BookUpdate struct {
+	Snapshot  bool // replace the whole book
+	FirstID   int  // 0: the same as LastID
+	LastID    int
+	Bids      []market.Order
+	Asks      []market.Order
+	Timestamp float64 // in milliseconds, 0: the time the update is applied
+}
+
+/*The Local Order Book of a Pair
+Kept from the snapshot and delta updates of a stream. A sequence gap resyncs the book from
+the REST snapshot (OrderBook of the exchange with LastUpdateID), so no update is lost silently.
+Apply is called by one goroutine, Maker returns a consistent copy at any time*/
+type OrderBookEngine struct {
+	name     ExchangeName
+	pair     *pair.Pair
+	depth    int                                          // the levels kept on each side, 0: all
+	snapshot func(pair *pair.Pair) (*market.Maker, error) // nil: the stream sends the snapshots
+
+	applyLock sync.Mutex   // one Apply at a time, the snapshot is fetched without holding lock
+	lock      sync.RWMutex // the book below
+	bids      map[float64]float64
+	asks      map[float64]float64
+	lastID    int
+	synced    bool
+	timestamp float64
+	resyncs   int
+}
+
+func NewOrderBookEngine(name ExchangeName, pair *pair.Pair, depth int, snapshot func(pair *pair.Pair) (*market.Maker, error)) *OrderBookEngine {
+	return &OrderBookEngine{
+		name:     name,
+		pair:     pair,
+		depth:    depth,
+		snapshot: snapshot,
+		bids:     make(map[float64]float64),
+		asks:     make(map[float64]float64),
+	}
+}
+
+/*Apply an Update to the Book
+Step 1: A snapshot replaces the book, unless it is older than the book
+Step 2: Resync from the REST snapshot if the book is not synced or the update leaves a gap
+Step 3: Drop the update if the book has it already, ErrNetwork if the gap is still there after the resync
+Step 4: Apply the levels and move the last id*/
+func (b *OrderBookEngine) Apply(update *BookUpdate) error {
+	b.applyLock.Lock()
+	defer b.applyLock.Unlock()
+
+	timestamp := update.Timestamp
+	if timestamp == 0 {
+		timestamp = float64(time.Now().UnixNano() / 1e6)
+	}
+
+	if update.Snapshot {
+		if b.synced && update.LastID != 0 && update.LastID < b.lastID {
+			return nil // out of order
+		}
+		b.reset(update.Bids, update.Asks, update.LastID, timestamp)
+		return nil
+	}
+
+	first := update.FirstID
+	if first == 0 {
+		first = update.LastID
+	}
+	if !b.synced || (update.LastID != 0 && first > b.lastID+1) {
+		if err := b.resync(); err != nil {
+			return err
+		}
+	}
+
+	if update.LastID != 0 {
+		if update.LastID <= b.lastID {
+			return nil
+		}
+		if first > b.lastID+1 {
+			b.lock.Lock()
+			b.synced = false
+			b.lock.Unlock()
+			return Errorf(b.name, "OrderBook", ErrNetwork, "%s sequence gap: the book is at %d, the update is %d-%d", b.pair.Name, b.lastID, first, update.LastID)
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	applyLevels(b.bids, update.Bids)
+	applyLevels(b.asks, update.Asks)
+	b.trim()
+	if update.LastID != 0 {
+		b.lastID = update.LastID
+	}
+	b.timestamp = timestamp
+	return nil
+}
+
+/*Replace the Book by the REST Snapshot
+The last id of the book is the LastUpdateID of the snapshot*/
+func (b *OrderBookEngine) Resync() error {
+	b.applyLock.Lock()
+	defer b.applyLock.Unlock()
+	return b.resync()
+}
+
+func (b *OrderBookEngine) resync() error {
+	if b.snapshot == nil {
+		return Errorf(b.name, "OrderBook", ErrNetwork, "%s is not synced, wait for the snapshot of the stream", b.pair.Name)
+	}
+
+	maker, err := b.snapshot(b.pair)
+	if err != nil {
+		return err
+	}
+	timestamp := maker.Timestamp
+	if timestamp == 0 {
+		timestamp = float64(time.Now().UnixNano() / 1e6)
+	}
+	b.reset(maker.Bids, maker.Asks, maker.LastUpdateID, timestamp)
+
+	b.lock.Lock()
+	b.resyncs++
+	b.lock.Unlock()
+	return nil
+}
+
+func (b *OrderBookEngine) reset(bids, asks []market.Order, lastID int, timestamp float64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.bids = make(map[float64]float64)
+	b.asks = make(map[float64]float64)
+	applyLevels(b.bids, bids)
+	applyLevels(b.asks, asks)
+	b.trim()
+	b.lastID = lastID
+	b.synced = true
+	b.timestamp = timestamp
+}
+
+// the times the book is resynced from the REST snapshot
+func (b *OrderBookEngine) Resyncs() int {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.resyncs
+}
+
+/*Get a Copy of the Book
+Bids are sorted from the highest rate, Asks from the lowest, LastUpdateID is the last id applied*/
+func (b *OrderBookEngine) Maker() *market.Maker {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	maker := &market.Maker{}
+	maker.Pair = b.pair
+	maker.LastUpdateID = b.lastID
+	maker.Timestamp = b.timestamp
+	maker.Bids = sortedLevels(b.bids, true)
+	maker.Asks = sortedLevels(b.asks, false)
+	return maker
+}
+
+// keep the best depth levels, the exchange stops updating the levels out of the depth
+func (b *OrderBookEngine) trim() {
+	if b.depth <= 0 {
+		return
+	}
+	for _, side := range []struct {
+		levels     map[float64]float64
+		descending bool
+	}{{b.bids, true}, {b.asks, false}} {
+		if len(side.levels) <= b.depth {
+			continue
+		}
+		for _, level := range sortedLevels(side.levels, side.descending)[b.depth:] {
+			delete(side.levels, level.Rate)
+		}
+	}
+}
+
+func applyLevels(side map[float64]float64, levels []market.Order) {
+	for _, level := range levels {
+		if level.Quantity == 0 {
+			delete(side, level.Rate)
+		} else {
+			side[level.Rate] = level.Quantity
+		}
+	}
+}
+
+func sortedLevels(side map[float64]float64, descending bool) []market.Order {
+	levels := make([]market.Order, 0, len(side))
+	for rate, quantity := range side {
+		levels = append(levels, market.Order{Rate: rate, Quantity: quantity})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if descending {
+			return levels[i].Rate > levels[j].Rate
+		}
+		return levels[i].Rate < levels[j].Rate
+	})
+	return levels
+}
@@ -0,0 +1,86 @@
+package exchange
+
+import (
+	"fmt"
+	"sync"
+
+	"../market"
+	"../pair"
+)
+
+// the number of orders canceled at the same time by CancelOpenOrders
+const cancelWorkers = 4
+
+// the result of CancelAllOrders
+type CancelReport struct {
+	Pair     *pair.Pair // nil: all pairs
+	Canceled []*market.Order
+	Failed   []*CancelFailure
+}
+
+type CancelFailure struct {
+	Order *market.Order
+	Err   error
+}
+
+// nil if all the orders are canceled
+func (r *CancelReport) Err() error {
+	if r == nil || len(r.Failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d orders are not canceled, order %s: %v",
+		len(r.Failed), len(r.Failed)+len(r.Canceled), r.Failed[0].Order.OrderID, r.Failed[0].Err)
+}
+
+func (r *CancelReport) add(order *market.Order, err error) {
+	if err != nil {
+		r.Failed = append(r.Failed, &CancelFailure{Order: order, Err: err})
+	} else {
+		r.Canceled = append(r.Canceled, order)
+	}
+}
+
+// one cancel all at a time for each exchange instance
+var cancelLocks sync.Map
+
+/*Cancel All the Open Orders One by One
+For the exchanges without a cancel all API
+Step 1: List the open orders of the pair (nil pair: all pairs)
+Step 2: Cancel the orders, a failed order doesn't stop the others
+Calls on the same instance run one after another, so a kill switch calling it concurrently
+doesn't cancel the same order twice, the later call finds the orders already canceled*/
+func CancelOpenOrders(ex Exchange, pair *pair.Pair) (*CancelReport, error) {
+	tmp, _ := cancelLocks.LoadOrStore(ex, &sync.Mutex{})
+	lock := tmp.(*sync.Mutex)
+	lock.Lock()
+	defer lock.Unlock()
+
+	orders, err := ex.ListOpenOrders(&market.OrderQuery{Pair: pair})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CancelReport{Pair: pair}
+	reportLock := sync.Mutex{}
+	queue := make(chan *market.Order)
+	wg := sync.WaitGroup{}
+	for i := 0; i < cancelWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for order := range queue {
+				err := ex.CancelOrder(order)
+				reportLock.Lock()
+				report.add(order, err)
+				reportLock.Unlock()
+			}
+		}()
+	}
+	for _, order := range orders {
+		queue <- order
+	}
+	close(queue)
+	wg.Wait()
+
+	return report, nil
+}
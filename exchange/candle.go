@@ -0,0 +1,62 @@
+package exchange
+
+import (
+	"sort"
+	"time"
+
+	"../market"
+	"../pair"
+)
+
+/*Aggregate the Trades to Candles of the Interval
+The candles start at the multiples of the interval since the Unix epoch (UTC),
+the intervals without any trade have no candle*/
+func BuildCandles(pair *pair.Pair, trades []*market.Trade, interval time.Duration) []*market.Candle {
+	candles := []*market.Candle{}
+	step := int64(interval / time.Millisecond)
+	if step <= 0 {
+		return candles
+	}
+
+	sorted := make([]*market.Trade, len(trades))
+	copy(sorted, trades)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp < sorted[j].Timestamp
+	})
+
+	var candle *market.Candle
+	for _, trade := range sorted {
+		start := trade.Timestamp - trade.Timestamp%step
+		if candle == nil || candle.Timestamp != start {
+			candle = &market.Candle{Pair: pair, Timestamp: start, Open: trade.Rate, High: trade.Rate, Low: trade.Rate}
+			candles = append(candles, candle)
+		}
+		if trade.Rate > candle.High {
+			candle.High = trade.Rate
+		}
+		if trade.Rate < candle.Low {
+			candle.Low = trade.Rate
+		}
+		candle.Close = trade.Rate
+		candle.Volume += trade.Quantity
+		candle.QuoteVolume += trade.Quantity * trade.Rate
+	}
+	return candles
+}
+
+/*Build the Candles from the Recent Trades of the Exchange
+For the exchanges without a candle API or the intervals the API doesn't support.
+The trades older than the exchange keeps (see RecentTrades) are not in the candles*/
+func CandlesFromTrades(ex Exchange, pair *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error) {
+	if interval <= 0 {
+		return nil, Errorf(ex.GetName(), "Candles", ErrRejected, "invalid interval %v", interval)
+	}
+
+	// the first candle starts before since, so it has all its trades
+	start := since.Truncate(interval)
+	trades, err := ex.RecentTrades(pair, start)
+	if err != nil {
+		return nil, err
+	}
+	return BuildCandles(pair, trades, interval), nil
+}
@@ -0,0 +1,146 @@
+package exchange
+
+import (
+	"strings"
+
+	"../market"
+)
+
+// where the value comes from
+type Source string
+
+const (
+	SourceAPI      Source = "API"      // fetched from the exchange API
+	SourceStatic   Source = "Static"   // hard coded in the exchange package
+	SourceDatabase Source = "Database" // imported from Postgres, see Config.WalletStatus
+)
+
+/*Capabilities of an Exchange
+false means the function is not implemented for the exchange (eg. it returns nil without calling the API),
+so the strategy should not rely on it*/
+type Capabilities struct {
+	OrderTypes          []market.OrderType
+	TimeInForce         []market.TimeInForce // GTC is assumed when empty
+	PostOnly            bool
+	ClientOrderID       bool // orders can be found by client order id on the exchange, not only in this process
+	CancelAll           bool
+	ListOrders          bool
+	Withdraw            bool
+	DepositAddress      bool
+	TransferHistory     bool
+	WebSocketMarketData bool
+	WebSocketUserData   bool // orders, fills and balances are pushed, otherwise polled
+	BatchOrderBooks     bool
+	FeeSource           Source
+	ConstrainSource     *ConstrainFetchMethod // true: the constrain is fetched from API
+}
+
+type Feature string
+
+const (
+	FeatureLimitOrder          Feature = "LimitOrder"
+	FeatureMarketOrder         Feature = "MarketOrder"
+	FeatureStopLimitOrder      Feature = "StopLimitOrder"
+	FeatureIOC                 Feature = "IOC"
+	FeatureFOK                 Feature = "FOK"
+	FeaturePostOnly            Feature = "PostOnly"
+	FeatureClientOrderID       Feature = "ClientOrderID"
+	FeatureCancelAll           Feature = "CancelAll"
+	FeatureListOrders          Feature = "ListOrders"
+	FeatureWithdraw            Feature = "Withdraw"
+	FeatureDepositAddress      Feature = "DepositAddress"
+	FeatureTransferHistory     Feature = "TransferHistory"
+	FeatureWebSocketMarketData Feature = "WebSocketMarketData"
+	FeatureWebSocketUserData   Feature = "WebSocketUserData"
+	FeatureBatchOrderBooks     Feature = "BatchOrderBooks"
+	FeatureFeeFromAPI          Feature = "FeeFromAPI"
+)
+
+func (c *Capabilities) SupportOrderType(orderType market.OrderType) bool {
+	for _, t := range c.OrderTypes {
+		if t == orderType {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Capabilities) SupportTimeInForce(timeInForce market.TimeInForce) bool {
+	if len(c.TimeInForce) == 0 {
+		return timeInForce == market.GTC
+	}
+	for _, t := range c.TimeInForce {
+		if t == timeInForce {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Capabilities) Has(feature Feature) bool {
+	if c == nil {
+		return false
+	}
+
+	switch feature {
+	case FeatureLimitOrder:
+		return c.SupportOrderType(market.LimitOrder)
+	case FeatureMarketOrder:
+		return c.SupportOrderType(market.MarketOrder)
+	case FeatureStopLimitOrder:
+		return c.SupportOrderType(market.StopLimitOrder)
+	case FeatureIOC:
+		return c.SupportTimeInForce(market.IOC)
+	case FeatureFOK:
+		return c.SupportTimeInForce(market.FOK)
+	case FeaturePostOnly:
+		return c.PostOnly
+	case FeatureClientOrderID:
+		return c.ClientOrderID
+	case FeatureCancelAll:
+		return c.CancelAll
+	case FeatureListOrders:
+		return c.ListOrders
+	case FeatureWithdraw:
+		return c.Withdraw
+	case FeatureDepositAddress:
+		return c.DepositAddress
+	case FeatureTransferHistory:
+		return c.TransferHistory
+	case FeatureWebSocketMarketData:
+		return c.WebSocketMarketData
+	case FeatureWebSocketUserData:
+		return c.WebSocketUserData
+	case FeatureBatchOrderBooks:
+		return c.BatchOrderBooks
+	case FeatureFeeFromAPI:
+		return c.FeeSource == SourceAPI
+	}
+	return false
+}
+
+// the features which are not supported
+func (c *Capabilities) Missing(features ...Feature) []Feature {
+	missing := []Feature{}
+	for _, f := range features {
+		if !c.Has(f) {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
+
+/*Check the Exchange Before Starting a Strategy
+Return an ErrUnsupported *Error listing the features the exchange lacks*/
+func Require(ex Exchange, features ...Feature) error {
+	missing := ex.GetCapabilities().Missing(features...)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(missing))
+	for i, f := range missing {
+		names[i] = string(f)
+	}
+	return Errorf(ex.GetName(), "Require", ErrUnsupported, "%s is not supported", strings.Join(names, ", "))
+}
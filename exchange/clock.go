@@ -0,0 +1,119 @@
+package exchange
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	clockSyncInterval = 10 * time.Minute // the clock is synced again after the interval
+	clockSyncTimeout  = 5 * time.Second  // the server time API is not waited longer
+)
+
+/*Server Time of an Exchange for the Signed Requests
+The offset to the local clock is measured by the server time API of the exchange,
+so a drifting host is not rejected with nonce or timestamp errors.
+Without the server time API (nil fetch) it is the local clock.*/
+type Clock struct {
+	name  ExchangeName
+	fetch func() (time.Time, error) // server time of the exchange
+
+	lock      sync.Mutex
+	offset    time.Duration // server time - local time
+	synced    time.Time     // local time of the last sync, zero: never
+	lastNonce int64
+}
+
+func NewClock(name ExchangeName, fetch func() (time.Time, error)) *Clock {
+	return &Clock{name: name, fetch: fetch}
+}
+
+/*Measure the Offset to the Server Time
+The server time is taken at the middle of the round trip,
+ErrNetwork if the server time is not returned in clockSyncTimeout*/
+func (c *Clock) Sync() error {
+	if c.fetch == nil {
+		return nil
+	}
+
+	type fetched struct {
+		server time.Time
+		err    error
+	}
+	done := make(chan fetched, 1) // the fetch after the timeout doesn't block
+	before := time.Now()
+	go func() {
+		server, err := c.fetch()
+		done <- fetched{server, err}
+	}()
+
+	var server time.Time
+	select {
+	case f := <-done:
+		if f.err != nil {
+			return NewError(c.name, "Sync", ErrNetwork, f.err)
+		}
+		server = f.server
+	case <-time.After(clockSyncTimeout):
+		return Errorf(c.name, "Sync", ErrNetwork, "server time is not returned in %v", clockSyncTimeout)
+	}
+	after := time.Now()
+	local := before.Add(after.Sub(before) / 2)
+
+	c.lock.Lock()
+	c.offset = server.Sub(local)
+	c.synced = after
+	c.lock.Unlock()
+	return nil
+}
+
+/*The Current Server Time
+Sync first if the clock is never synced, the sync after clockSyncInterval runs in the background
+and the last offset is used until it is done, the old offset is kept if the sync fails*/
+func (c *Clock) Now() time.Time {
+	c.lock.Lock()
+	stale := c.fetch != nil && time.Since(c.synced) > clockSyncInterval
+	first := c.synced.IsZero()
+	if stale {
+		// the other calls keep the old offset during the sync, a failed sync is retried after a minute
+		c.synced = time.Now().Add(-clockSyncInterval + time.Minute)
+	}
+	c.lock.Unlock()
+
+	if stale && first {
+		if err := c.Sync(); err != nil {
+			log.Printf("%s Clock Sync Err: %v", c.name, err)
+		}
+	} else if stale {
+		go func() {
+			if err := c.Sync(); err != nil {
+				log.Printf("%s Clock Sync Err: %v", c.name, err)
+			}
+		}()
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return time.Now().Add(c.offset)
+}
+
+// the server time in nanoseconds, always larger than the last nonce
+func (c *Clock) Nonce() int64 {
+	nonce := c.Now().UnixNano()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if nonce <= c.lastNonce {
+		nonce = c.lastNonce + 1
+	}
+	c.lastNonce = nonce
+	return nonce
+}
+
+// server time - local time, 0 if not synced
+func (c *Clock) Skew() time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.offset
+}
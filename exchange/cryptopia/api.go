@@ -11,6 +11,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -59,7 +60,7 @@ func (e *Cryptopia) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	symbol := e.GetPairCode(p)
 
 	strRequestUrl := fmt.Sprintf("/api/GetMarketOrders/%s", symbol)
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	maker := &market.Maker{}
 	maker.WorkerIP = exchange.GetExternalIP()
@@ -67,13 +68,13 @@ func (e *Cryptopia) OrderBook(p *pair.Pair) (*market.Maker, error) {
 
 	jsonMarketDepthReturn := exchange.HttpGetRequest(strUrl, nil)
 	if err := json.Unmarshal([]byte(jsonMarketDepthReturn), &jsonResponse); err != nil {
-		return nil, fmt.Errorf("Cryptopia OrderBook json Unmarshal error: %v %v", err, jsonMarketDepthReturn)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonMarketDepthReturn)
 	} else if !jsonResponse.Success {
-		return nil, fmt.Errorf("Cryptopia OrderBook failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
 	}
 
 	if err := json.Unmarshal(jsonResponse.Data, &orderbook); err != nil {
-		return nil, fmt.Errorf("Cryptopia OrderBook Data Unmarshal error: %v %s", err, jsonResponse.Data)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrUnknown, "Data Unmarshal error: %v %s", err, jsonResponse.Data)
 	} else {
 		//Convert Exchange Struct to Maker
 		for _, bid := range orderbook.Buy {
@@ -96,16 +97,137 @@ func (e *Cryptopia) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	}
 }
 
+/*Get the Ticker of the Pair*/
+func (e *Cryptopia) Ticker(p *pair.Pair) (*market.Ticker, error) {
+	jsonResponse := JsonResponse{}
+	marketData := MarketData{}
+
+	strRequestUrl := fmt.Sprintf("/api/GetMarket/%s", e.GetPairCode(p))
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTickerReturn)
+	} else if !jsonResponse.Success {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &marketData); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrUnknown, "Data Unmarshal error: %v %s", err, jsonResponse.Data)
+	}
+	return toTicker(p, &marketData), nil
+}
+
+/*Get the Tickers of All the Pairs
+GetMarkets returns all the markets in one call*/
+func (e *Cryptopia) Tickers() ([]*market.Ticker, error) {
+	jsonResponse := JsonResponse{}
+	markets := []*MarketData{}
+
+	strRequestUrl := "/api/GetMarkets"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Tickers", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTickerReturn)
+	} else if !jsonResponse.Success {
+		return nil, exchange.Errorf(e.GetName(), "Tickers", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &markets); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Tickers", exchange.ErrUnknown, "Data Unmarshal error: %v %s", err, jsonResponse.Data)
+	}
+
+	tickers := []*market.Ticker{}
+	for _, m := range markets {
+		if p := e.getPairByMarket(m.Label); p != nil {
+			tickers = append(tickers, toTicker(p, m))
+		}
+	}
+	sort.Slice(tickers, func(i, j int) bool {
+		return tickers[i].Pair.Name < tickers[j].Pair.Name
+	})
+	return tickers, nil
+}
+
+func toTicker(pair *pair.Pair, data *MarketData) *market.Ticker {
+	ticker := &market.Ticker{}
+	ticker.Pair = pair
+	ticker.Bid = data.BidPrice
+	ticker.Ask = data.AskPrice
+	ticker.Last = data.LastPrice
+	ticker.High = data.High
+	ticker.Low = data.Low
+	ticker.Volume = data.Volume
+	ticker.QuoteVolume = data.BaseVolume
+	ticker.Timestamp = time.Now().UnixNano() / 1e6
+	return ticker
+}
+
+/*Get the Trades of the Market Since the Time
+GetMarketHistory returns the trades of the last hours, the hours cover the since time*/
+func (e *Cryptopia) RecentTrades(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	jsonResponse := JsonResponse{}
+	data := []*MarketHistory{}
+
+	hours := int(time.Since(since).Hours()) + 1
+	strRequestUrl := fmt.Sprintf("/api/GetMarketHistory/%s/%d", e.GetPairCode(p), hours)
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTradesReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTradesReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTradesReturn)
+	} else if !jsonResponse.Success {
+		return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &data); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrUnknown, "Data Unmarshal error: %v %s", err, jsonResponse.Data)
+	}
+
+	trades := []*market.Trade{}
+	for _, t := range data {
+		if t.Timestamp < since.Unix() {
+			continue
+		}
+		trade := &market.Trade{}
+		trade.Pair = p
+		trade.Rate = t.Price
+		trade.Quantity = t.Amount
+		trade.Timestamp = t.Timestamp * 1000
+		if t.Type == "Sell" {
+			trade.Side = market.Sell
+		} else {
+			trade.Side = market.Buy
+		}
+		trade.Liquidity = market.LiquidityTaker
+		trades = append(trades, trade)
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+/*Get the Candles of the Pair Since the Time
+Cryptopia doesn't have a candle API, the candles are built from the trades of GetMarketHistory*/
+func (e *Cryptopia) Candles(p *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error) {
+	return exchange.CandlesFromTrades(e, p, interval, since)
+}
+
+// the API has no WebSocket, the order books are polled by REST
+func (e *Cryptopia) StreamOrderBook(pairs []*pair.Pair, stop <-chan struct{}) (<-chan *market.Maker, error) {
+	return exchange.PollOrderBook(e, pairs, 5*time.Second, stop), nil
+}
+
 /*Get Coins Information (If API provide)
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)*/
-func GetCryptopiaCoin() *CoinsData {
+func (e *Cryptopia) GetCryptopiaCoin() *CoinsData {
 	jsonResponse := JsonResponse{}
 	coinsData := &CoinsData{}
 
 	strRequestUrl := "/api/GetCurrencies"
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	jsonCurrencyReturn := exchange.HttpGetRequest(strUrl, nil)
 	if err := json.Unmarshal([]byte(jsonCurrencyReturn), &jsonResponse); err != nil {
@@ -127,12 +249,12 @@ func GetCryptopiaCoin() *CoinsData {
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)*/
-func GetCryptopiaPair() *PairsData {
+func (e *Cryptopia) GetCryptopiaPair() *PairsData {
 	jsonResponse := JsonResponse{}
 	pairsData := &PairsData{}
 
 	strRequestUrl := "/api/GetTradePairs"
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	jsonCurrencyReturn := exchange.HttpGetRequest(strUrl, nil)
 	if err := json.Unmarshal([]byte(jsonCurrencyReturn), &jsonResponse); err != nil {
@@ -151,8 +273,8 @@ func GetCryptopiaPair() *PairsData {
 }
 
 /*************** Private API ***************/
-func (e *Cryptopia) UpdateAllBalances() { // Get Exchange Account All Coins Balance
-	e.UpdateAllBalancesByUser(nil)
+func (e *Cryptopia) UpdateAllBalances() error { // Get Exchange Account All Coins Balance
+	return e.UpdateAllBalancesByUser(nil)
 }
 
 /*Get Exchange Account All Coins Balance
@@ -160,21 +282,17 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)
 Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Get Coin Availamount and store in balanceMap*/
-func (e *Cryptopia) UpdateAllBalancesByUser(u *user.User) {
+Step 5: Get Coin Balance (market.Balance) and store in balanceMap*/
+func (e *Cryptopia) UpdateAllBalancesByUser(u *user.User) error {
 	var uInstance *Cryptopia
 	if u != nil {
-		uInstance = &Cryptopia{}
-		uInstance.API_KEY = u.API_KEY
-		uInstance.API_SECRET = u.API_SECRET
-		// uInstance.MakerDB = e.MakerDB
+		uInstance = e.ForUser(u)
 	} else {
 		uInstance = e
 	}
 
 	if uInstance.API_KEY == "" || uInstance.API_SECRET == "" {
-		log.Printf("Cryptopia API Key or Secret Key are nil.")
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := JsonResponse{}
@@ -183,60 +301,230 @@ func (e *Cryptopia) UpdateAllBalancesByUser(u *user.User) {
 
 	jsonBalanceReturn := uInstance.ApiKeyPost(make(map[string]interface{}), strRequest)
 	if err := json.Unmarshal([]byte(jsonBalanceReturn), &jsonResponse); err != nil {
-		log.Printf("Cryptopia Get Balance Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrNetwork, "Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
 	} else if !jsonResponse.Success {
-		log.Printf("Cryptopia Get Balance Err: %v %v", jsonResponse.Error, jsonResponse.Message)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrRejected, "%v %v", jsonResponse.Error, jsonResponse.Message)
 	}
 
 	if err := json.Unmarshal(jsonResponse.Data, &accountBalance); err != nil {
-		log.Printf("Cryptopia Get Balance Data Unmarshal Err: %v %s", err, jsonResponse.Data)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
 	} else {
+		now := time.Now().UnixNano() / 1e6
 		for _, data := range accountBalance {
 			c := coin.GetCoin(e.GetCode(data.Symbol))
 			if c != nil {
-				balanceMap.Set(c.Code, data.Available)
+				balance := &market.Balance{}
+				balance.Coin = c
+				balance.Available = data.Available
+				balance.Locked = data.Total - data.Available //held for trades, pending withdraw and unconfirmed
+				balance.Total = data.Total
+				balance.Timestamp = now
+				uInstance.balanceMap.Set(c.Code, balance)
 			} else {
 				// TODO: Add new coins
 				// log.Printf("%s %v", e.GetCode(data.Symbol), c)
 			}
 		}
 	}
+	return nil
 }
 
 /*Withdraw the coin to another address
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Check the success of withdraw*/
-func (e *Cryptopia) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) bool {
+Step 1: Submit the withdrawal, tag is sent as the PaymentId
+Step 2: The data of the response is the withdrawal id*/
+func (e *Cryptopia) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) (*exchange.WithdrawalResult, error) {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		log.Printf("Cryptopia API Key or Secret Key are nil.")
-		return false
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := JsonResponse{}
+	var withdrawalID int
 	strRequest := "/api/SubmitWithdraw"
 
 	mapParams := make(map[string]interface{})
 	mapParams["Currency"] = e.GetSymbol(coin.Code)
 	mapParams["Address"] = addr
-	mapParams["PaymentId"] = coin.Code
-	mapParams["Amount"] = fmt.Sprintf("%f", quantity)
+	if tag != "" {
+		mapParams["PaymentId"] = tag
+	}
+	mapParams["Amount"] = quantity
 
 	jsonSubmitWithdraw := e.ApiKeyPost(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonSubmitWithdraw), &jsonResponse); err != nil {
-		log.Printf("Cryptopia Withdraw Json Unmarshal failed: %v %v", err, jsonSubmitWithdraw)
-		return false
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonSubmitWithdraw)
+	} else if !jsonResponse.Success {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrRejected, "%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &withdrawalID); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	withdrawal := &exchange.WithdrawalResult{}
+	withdrawal.ID = fmt.Sprintf("%d", withdrawalID)
+	withdrawal.Coin = coin
+	withdrawal.Quantity = quantity
+	withdrawal.Address = addr
+	withdrawal.Tag = tag
+	withdrawal.Status = exchange.TransferPending
+	withdrawal.Timestamp = time.Now().UnixNano() / 1e6
+	return withdrawal, nil
+}
+
+/*Update the Status of the Withdrawal
+Find the withdrawal by id in the latest 1000 withdrawals*/
+func (e *Cryptopia) WithdrawalStatus(withdrawal *exchange.WithdrawalResult) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	transactions, err := e.getTransactions(exchange.Withdrawal)
+	if err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		if fmt.Sprintf("%d", t.ID) == withdrawal.ID {
+			withdrawal.Fee = t.Fee
+			withdrawal.TxHash = t.TxID
+			withdrawal.Status = transferStatusOf(t.Status)
+			return nil
+		}
+	}
+	return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrNotFound, "withdrawal %s is not in the latest withdrawals", withdrawal.ID)
+}
+
+/*Get the Deposit Address of the Coin
+The coins using a payment id return the payment id in Address and the shared address in BaseAddress*/
+func (e *Cryptopia) GetDepositAddress(coin *coin.Coin) (*exchange.DepositAddress, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := JsonResponse{}
+	depositAddress := DepositAddress{}
+	strRequest := "/api/GetDepositAddress"
+
+	mapParams := make(map[string]interface{})
+	mapParams["Currency"] = e.GetSymbol(coin.Code)
+
+	jsonAddress := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonAddress), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonAddress)
+	} else if !jsonResponse.Success {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrRejected, "%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &depositAddress); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	address := &exchange.DepositAddress{}
+	address.Coin = coin
+	if depositAddress.BaseAddress != "" {
+		address.Address = depositAddress.BaseAddress
+		address.Tag = depositAddress.Address
+	} else {
+		address.Address = depositAddress.Address
+	}
+	return address, nil
+}
+
+/*Get the Deposits and Withdrawals Since the Time, nil coin: all coins
+Step 1: Get the latest 1000 deposits and withdrawals (GetTransactions)
+Step 2: Filter by coin and time, then sort by time*/
+func (e *Cryptopia) GetTransfers(coin *coin.Coin, since time.Time) ([]*exchange.Transfer, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	transfers := []*exchange.Transfer{}
+	for _, transferType := range []exchange.TransferType{exchange.Deposit, exchange.Withdrawal} {
+		transactions, err := e.getTransactions(transferType)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range transactions {
+			if coin != nil && !strings.EqualFold(t.Currency, e.GetSymbol(coin.Code)) {
+				continue
+			}
+			timestamp, err := time.Parse("2006-01-02T15:04:05.999999999", t.Timestamp)
+			if err != nil {
+				log.Printf("Cryptopia GetTransfers transaction %d time %s Err: %v", t.ID, t.Timestamp, err)
+				continue
+			}
+			if timestamp.Before(since) {
+				continue
+			}
+
+			transfer := &exchange.Transfer{}
+			transfer.ID = fmt.Sprintf("%d", t.ID)
+			transfer.Coin = coin
+			if transfer.Coin == nil {
+				transfer.Coin = e.getCoinBySymbol(t.Currency)
+			}
+			transfer.Type = transferType
+			transfer.Quantity = t.Amount
+			transfer.Fee = t.Fee
+			transfer.Address = t.Address
+			transfer.TxHash = t.TxID
+			transfer.Status = transferStatusOf(t.Status)
+			transfer.Timestamp = timestamp.UnixNano() / 1e6
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].Timestamp < transfers[j].Timestamp
+	})
+	return transfers, nil
+}
+
+// the latest 1000 deposits or withdrawals
+func (e *Cryptopia) getTransactions(transferType exchange.TransferType) (Transactions, error) {
+	jsonResponse := JsonResponse{}
+	transactions := Transactions{}
+	strRequest := "/api/GetTransactions"
+
+	mapParams := make(map[string]interface{})
+	mapParams["Count"] = 1000
+	if transferType == exchange.Deposit {
+		mapParams["Type"] = "Deposit"
+	} else {
+		mapParams["Type"] = "Withdraw"
+	}
+
+	jsonTransactions := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonTransactions), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getTransactions", exchange.ErrNetwork, "GetTransactions Unmarshal Err: %v %v", err, jsonTransactions)
 	} else if !jsonResponse.Success {
-		log.Printf("Cryptopia Withdraw failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
-		return false
+		return nil, exchange.Errorf(e.GetName(), "GetTransactions", exchange.ErrRejected, "%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &transactions); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getTransactions", exchange.ErrUnknown, "GetTransactions Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+	return transactions, nil
+}
+
+func (e *Cryptopia) getCoinBySymbol(symbol string) *coin.Coin {
+	return coin.GetCoin(e.GetCode(symbol))
+}
+
+// deposit: UnConfirmed, Confirmed  withdraw: Pending, Processing, Complete, Canceled
+func transferStatusOf(status string) exchange.TransferStatus {
+	switch status {
+	case "Confirmed", "Complete":
+		return exchange.TransferCompleted
+	case "Canceled", "Cancelled":
+		return exchange.TransferCanceled
+	case "Failed", "Error":
+		return exchange.TransferFailed
+	default:
+		return exchange.TransferPending
 	}
+}
 
-	return true
+// the API has no WebSocket, the orders, fills and balances are polled by REST
+func (e *Cryptopia) StreamUserData(stop <-chan struct{}) (<-chan *exchange.UserEvent, error) {
+	return exchange.PollUserData(e, 5*time.Second, stop), nil
 }
 
 /*Get the Status of a Singal Order
@@ -247,7 +535,7 @@ Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
 Step 5: Change Order Status (Status reference ../market/market.go)*/
 func (e *Cryptopia) OrderStatus(order *market.Order) error { // Get the Status of a Singal Order
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return fmt.Errorf("Cryptopia API Key or Secret Key are nil.")
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := JsonResponse{}
@@ -259,13 +547,13 @@ func (e *Cryptopia) OrderStatus(order *market.Order) error { // Get the Status o
 
 	jsonOrderStatus := e.ApiKeyPost(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonOrderStatus), &jsonResponse); err != nil {
-		return fmt.Errorf("Cryptopia OrderStatus Unmarshal Err: %v %v", err, jsonOrderStatus)
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrderStatus)
 	} else if !jsonResponse.Success {
-		return fmt.Errorf("Cryptopia Get OrderStatus failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrRejected, "Get OrderStatus failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
 	}
 
 	if err := json.Unmarshal(jsonResponse.Data, &orderStatus); err != nil {
-		return fmt.Errorf("Cryptopia Get OrderStatus Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrUnknown, "Get OrderStatus Data Unmarshal Err: %v %s", err, jsonResponse.Data)
 	} else {
 		for _, list := range orderStatus {
 			orderIDStr := fmt.Sprintf("%d", list.OrderID)
@@ -283,14 +571,235 @@ func (e *Cryptopia) OrderStatus(order *market.Order) error { // Get the Status o
 	return nil
 }
 
+// the API queries one order at a time
+func (e *Cryptopia) OrdersStatus(orders []*market.Order) error {
+	return exchange.OrdersStatusEach(e, orders)
+}
+
+/*Get an Order by the Client Order ID
+Cryptopia doesn't support client order id, only the orders placed by this instance can be found.
+The order may still exist when it is not found, so ErrUnsupported is returned instead of ErrNotFound*/
+func (e *Cryptopia) OrderByClientID(pair *pair.Pair, clientOrderID string) (*market.Order, error) {
+	if tmp, ok := e.clientOrderMap.Get(clientOrderID); ok {
+		return tmp.(*market.Order), nil
+	}
+	return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrUnsupported, "client order id %s is not placed by this instance", clientOrderID)
+}
+
 func (e *Cryptopia) ListOrders() (*[]market.Order, error) {
-	return nil, nil
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.OrderList(orders), nil
 }
 
-func (e *Cryptopia) CancelAllOrder() error {
+/*Get the Open Orders
+Step 1: Get the open orders of the market, or all markets if the query has no pair
+Step 2: Sort by order id, so the pages are stable
+Step 3: Filter by pair and page (exchange.PageOrders)*/
+func (e *Cryptopia) ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := JsonResponse{}
+	openOrders := TradeHistory{}
+	strRequest := "/api/GetOpenOrders"
+
+	mapParams := make(map[string]interface{})
+	if query != nil && query.Pair != nil {
+		mapParams["Market"] = e.getMarket(query.Pair)
+	}
+
+	jsonOrders := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonOrders), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrders)
+	} else if !jsonResponse.Success {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrRejected, "%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &openOrders); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	sort.Slice(openOrders, func(i, j int) bool {
+		return openOrders[i].OrderID < openOrders[j].OrderID
+	})
+
+	orders := []*market.Order{}
+	for _, o := range openOrders {
+		p := e.getPairByMarket(o.Market)
+		if p == nil {
+			log.Printf("Cryptopia ListOpenOrders order %d market %s is not in the pair list", o.OrderID, o.Market)
+			continue
+		}
+
+		order := &market.Order{}
+		order.Pair = p
+		order.OrderID = fmt.Sprintf("%d", o.OrderID)
+		order.Rate = o.Rate
+		order.Quantity = o.Amount
+		order.Side = o.Type
+		order.DealQuantity = o.Amount - o.Remaining
+		if order.DealQuantity > 0 {
+			order.DealRate = o.Rate //the API doesn't provide the average deal rate
+			order.Status = market.Partial
+		} else {
+			order.Status = market.New
+		}
+		orders = append(orders, order)
+	}
+	return exchange.PageOrders(orders, query), nil
+}
+
+// the market of the pair, eg: DOT/BTC
+func (e *Cryptopia) getMarket(pair *pair.Pair) string {
+	return fmt.Sprintf("%s/%s", e.GetSymbol(pair.Target.Code), e.GetSymbol(pair.Base.Code))
+}
+
+func (e *Cryptopia) getPairByMarket(code string) *pair.Pair {
+	for _, p := range e.pairList {
+		if strings.EqualFold(e.getMarket(p), code) {
+			return p
+		}
+	}
 	return nil
 }
 
+/*Get the Executed Trades Since the Time, nil pair: all pairs
+Step 1: Get the latest 1000 trades of the account
+Step 2: Filter by time and convert, the API doesn't provide the order id and the liquidity
+The fee is charged in pair.Base*/
+func (e *Cryptopia) GetFills(pair *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := JsonResponse{}
+	myTrades := MyTrades{}
+	strRequest := "/api/GetTradeHistory"
+
+	mapParams := make(map[string]interface{})
+	mapParams["Count"] = 1000
+	if pair != nil {
+		mapParams["Market"] = e.getMarket(pair)
+	}
+
+	jsonTrades := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonTrades), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonTrades)
+	} else if !jsonResponse.Success {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrRejected, "%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &myTrades); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	trades := []*market.Trade{}
+	for _, t := range myTrades {
+		timestamp, err := time.Parse("2006-01-02T15:04:05.999999999", t.TimeStamp)
+		if err != nil {
+			log.Printf("Cryptopia GetFills trade %d time %s Err: %v", t.TradeID, t.TimeStamp, err)
+			continue
+		}
+		if timestamp.Before(since) {
+			continue
+		}
+		p := e.getPairByMarket(t.Market)
+		if p == nil {
+			log.Printf("Cryptopia GetFills trade %d market %s is not in the pair list", t.TradeID, t.Market)
+			continue
+		}
+
+		trade := &market.Trade{}
+		trade.TradeID = fmt.Sprintf("%d", t.TradeID)
+		trade.Pair = p
+		trade.Rate = t.Rate
+		trade.Quantity = t.Amount
+		trade.Fee = t.Fee
+		trade.FeeCoin = p.Base
+		trade.Timestamp = timestamp.UnixNano() / 1e6
+		trade.Liquidity = market.LiquidityUnknown
+		if t.Type == "Sell" {
+			trade.Side = market.Sell
+		} else {
+			trade.Side = market.Buy
+		}
+		trades = append(trades, trade)
+	}
+
+	sort.Slice(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+/*Cancel All Order of All Pairs*/
+func (e *Cryptopia) CancelAllOrder() error {
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+/*Cancel All Orders of the Pair, nil pair: all pairs
+CancelTrade API with Type All or TradePair, the API returns only the canceled order ids
+Step 1: List the open orders, so the canceled orders in the report have the pair, rate and quantity
+Step 2: Cancel by Type All or TradePair
+Step 3: Report the listed order of each canceled id, an order placed in between only has the id and the pair of the request*/
+func (e *Cryptopia) CancelAllOrders(pair *pair.Pair) (*exchange.CancelReport, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	openOrders, err := e.ListOpenOrders(&market.OrderQuery{Pair: pair})
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]*market.Order)
+	for _, order := range openOrders {
+		listed[order.OrderID] = order
+	}
+
+	jsonResponse := JsonResponse{}
+	canceledIDs := []int64{}
+	strRequest := "/api/CancelTrade"
+
+	mapParams := make(map[string]interface{})
+	if pair == nil {
+		mapParams["Type"] = "All"
+	} else if pairID, ok := e.pairIDMap[pair.Name]; ok {
+		mapParams["Type"] = "TradePair"
+		mapParams["TradePairId"] = pairID
+	} else {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrNotFound, "Cryptopia does not have the pair %s", pair.Name)
+	}
+
+	jsonCancelAll := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonCancelAll), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonCancelAll)
+	} else if !jsonResponse.Success {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrRejected, "%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &canceledIDs); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	report := &exchange.CancelReport{Pair: pair}
+	for _, id := range canceledIDs {
+		order, ok := listed[fmt.Sprintf("%d", id)]
+		if !ok {
+			order = &market.Order{}
+			order.OrderID = fmt.Sprintf("%d", id)
+			order.Pair = pair
+		}
+		order.Status = market.Canceling
+		report.Canceled = append(report.Canceled, order)
+	}
+	return report, nil
+}
+
 /*Cancel an Order
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
@@ -299,7 +808,7 @@ Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
 Step 5: Change Order Status (order.Status = market.Canceling)*/
 func (e *Cryptopia) CancelOrder(order *market.Order) error { // Get/Post(Depend on API) Cancel
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return fmt.Errorf("Cryptopia API Key or Secret Key are nil.")
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := JsonResponse{}
@@ -311,9 +820,9 @@ func (e *Cryptopia) CancelOrder(order *market.Order) error { // Get/Post(Depend
 
 	jsonCancelOrder := e.ApiKeyPost(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonCancelOrder), &jsonResponse); err != nil {
-		return fmt.Errorf("Cryptopia CancelOrder Unmarshal Err: %v %v", err, jsonCancelOrder)
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonCancelOrder)
 	} else if !jsonResponse.Success {
-		return fmt.Errorf("Cryptopia CancelOrder failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
 	}
 
 	order.Status = market.Canceling
@@ -321,15 +830,17 @@ func (e *Cryptopia) CancelOrder(order *market.Order) error { // Get/Post(Depend
 	return nil
 }
 
-/*Place a limit Sell Order
+/*Place an Order
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
-func (e *Cryptopia) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+Step 2: Check the Request is supported by GetCapabilities (exchange.CheckOrderRequest)
+Step 3: Map Side, Order Type, Time in Force and Post Only to API Params
+Step 4: Call ApiKey Function & Create a new Order*/
+func (e *Cryptopia) PlaceOrder(request *market.OrderRequest) (*market.Order, error) {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return nil, fmt.Errorf("Cryptopia API Key or Secret Key are nil.")
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if err := exchange.CheckOrderRequest(e.GetName(), e.GetCapabilities(), request); err != nil {
+		return nil, err
 	}
 
 	jsonResponse := JsonResponse{}
@@ -337,90 +848,52 @@ func (e *Cryptopia) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.
 	strRequest := "/api/SubmitTrade"
 
 	mapParams := make(map[string]interface{})
-	mapParams["Market"] = fmt.Sprintf("%s/%s", e.GetSymbol(pair.Target.Code), e.GetSymbol(pair.Base.Code))
-	mapParams["Type"] = "Sell"
-	mapParams["Rate"] = rate
-	mapParams["Amount"] = quantity
+	mapParams["Market"] = e.getMarket(request.Pair)
+	mapParams["Type"] = string(request.Side)
+	mapParams["Rate"] = request.Rate
+	mapParams["Amount"] = request.Quantity
 
 	jsonPlaceReturn := e.ApiKeyPost(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonPlaceReturn), &jsonResponse); err != nil {
-		return nil, fmt.Errorf("Cryptopia LimitSell Unmarshal Err: %v %v", err, jsonPlaceReturn)
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonPlaceReturn)
 	} else if !jsonResponse.Success {
-		return nil, fmt.Errorf("Cryptopia LimitSell failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrRejected, "%v Message:%v", jsonResponse.Error, jsonResponse.Message)
 	}
 
 	if err := json.Unmarshal(jsonResponse.Data, &placeOrder); err != nil {
-		return nil, fmt.Errorf("Cryptopia LimitSell Data Unmarshal Err: %v %s", err, jsonResponse.Data)
-	} else {
-		order := &market.Order{}
-		order.Pair = pair
-		order.Rate = rate
-		order.Quantity = quantity
-		order.Side = "Sell"
-		order.JsonResponse = jsonPlaceReturn
-		if placeOrder.OrderID != 0 {
-			order.OrderID = fmt.Sprintf("%d", placeOrder.OrderID)
-			order.FilledOrders = placeOrder.FilledOrders
-			order.Status = market.New
-		} else if len(placeOrder.FilledOrders) > 0 {
-			order.OrderID = "Filled"
-			order.FilledOrders = placeOrder.FilledOrders
-			order.Status = market.Filled
-		}
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
 
-		return order, nil
+	order := &market.Order{}
+	order.ClientOrderID = request.ClientOrderID
+	order.Pair = request.Pair
+	order.Rate = request.Rate
+	order.Quantity = request.Quantity
+	order.Side = string(request.Side)
+	order.JsonResponse = jsonPlaceReturn
+	if placeOrder.OrderID != 0 {
+		order.OrderID = fmt.Sprintf("%d", placeOrder.OrderID)
+		order.FilledOrders = placeOrder.FilledOrders
+		order.Status = market.New
+	} else if len(placeOrder.FilledOrders) > 0 {
+		order.OrderID = "Filled"
+		order.FilledOrders = placeOrder.FilledOrders
+		order.Status = market.Filled
 	}
+	e.clientOrderMap.Set(request.ClientOrderID, order)
+	return order, nil
+}
+
+/*Place a limit Sell Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Cryptopia) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Sell, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
 }
 
 /*Place a limit Buy Order
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Cryptopia) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
-	if e.API_KEY == "" || e.API_SECRET == "" {
-		return nil, fmt.Errorf("Cryptopia API Key or Secret Key are nil.")
-	}
-
-	jsonResponse := JsonResponse{}
-	placeOrder := PlaceOrder{}
-	strRequest := "/api/SubmitTrade"
-
-	mapParams := make(map[string]interface{})
-	mapParams["Market"] = fmt.Sprintf("%s/%s", e.GetSymbol(pair.Target.Code), e.GetSymbol(pair.Base.Code))
-	mapParams["Type"] = "Buy"
-	mapParams["Rate"] = rate
-	mapParams["Amount"] = quantity
-
-	jsonPlaceReturn := e.ApiKeyPost(mapParams, strRequest)
-	if err := json.Unmarshal([]byte(jsonPlaceReturn), &jsonResponse); err != nil {
-		return nil, fmt.Errorf("Cryptopia LimitBuy Unmarshal Err: %v %v", err, jsonPlaceReturn)
-	} else if !jsonResponse.Success {
-		return nil, fmt.Errorf("Cryptopia LimitBuy failed:%v Message:%v", jsonResponse.Error, jsonResponse.Message)
-	}
-
-	if err := json.Unmarshal(jsonResponse.Data, &placeOrder); err != nil {
-		return nil, fmt.Errorf("Cryptopia LimitBuy Data Unmarshal Err: %v %s", err, jsonResponse.Data)
-	} else {
-		order := &market.Order{}
-		order.Pair = pair
-		order.Rate = rate
-		order.Quantity = quantity
-		order.Side = "Buy"
-		order.JsonResponse = jsonPlaceReturn
-		if placeOrder.OrderID != 0 {
-			order.OrderID = fmt.Sprintf("%d", placeOrder.OrderID)
-			order.FilledOrders = placeOrder.FilledOrders
-			order.Status = market.New
-		} else if len(placeOrder.FilledOrders) > 0 {
-			order.OrderID = "Filled"
-			order.FilledOrders = placeOrder.FilledOrders
-			order.Status = market.Filled
-		}
-
-		return order, nil
-	}
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Buy, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
 }
 
 /*************** Signature Http Request ***************/
@@ -430,10 +903,10 @@ Step 2: Create mapParams Depend on API Signature request
 Step 3: Add HttpGetRequest below strUrl if API has different requests*/
 func (e *Cryptopia) ApiKeyPost(mapParams map[string]interface{}, strRequestPath string) string {
 	strMethod := "POST"
-	Nonce := strconv.FormatInt(time.Now().UnixNano(), 10)
+	Nonce := strconv.FormatInt(e.clock.Nonce(), 10)
 
 	//Signature Request Params
-	strUrl := API_URL + strRequestPath
+	strUrl := e.API_URL + strRequestPath
 
 	jsonParams := ""
 	bytesParams, err := json.Marshal(mapParams)
@@ -2,14 +2,18 @@ package cryptopia
 
 import (
 	"strings"
+	"sync"
 
 	"../../coin"
 	"../../exchange"
 	"../../pair"
 )
 
-func (e *Cryptopia) UpdatePairConstrain() {
-	pairData := GetCryptopiaPair()
+func (e *Cryptopia) UpdatePairConstrain() error {
+	pairData := e.GetCryptopiaPair()
+	if pairData == nil {
+		return exchange.Errorf(e.GetName(), "UpdatePairConstrain", exchange.ErrUnknown, "trade pairs are not available")
+	}
 	pairConstrainMap := make(map[*pair.Pair]*exchange.PairConstrain)
 	//If Exchange doesn't provide constrain info, Leave blank
 	//Modify according to type and structure
@@ -27,11 +31,14 @@ func (e *Cryptopia) UpdatePairConstrain() {
 		pairConstrainMap[pairConstrain.Pair] = pairConstrain
 
 	}
-	// return pairConstrainMap
+	return nil
 }
 
-func (e *Cryptopia) UpdateCoinConstrain() {
-	coinInfo := GetCryptopiaCoin()
+func (e *Cryptopia) UpdateCoinConstrain() error {
+	coinInfo := e.GetCryptopiaCoin()
+	if coinInfo == nil {
+		return exchange.Errorf(e.GetName(), "UpdateCoinConstrain", exchange.ErrUnknown, "currencies are not available")
+	}
 	coinConstrainMap := make(map[*coin.Coin]*exchange.CoinConstrain)
 	//If Exchange doesn't provide constrain info, Leave cryptopia
 	//Modify according to type and structure
@@ -51,25 +58,28 @@ func (e *Cryptopia) UpdateCoinConstrain() {
 		coinConstrainMap[coinConstrain.Coin] = coinConstrain
 
 	}
-	// return coinConstrainMap
+	return nil
 }
 
 /***************************************************/
-var symbolMap = make(map[string]string)
+var symbolMap = make(map[string]string) //read only after FixSymbol
+var symbolOnce sync.Once
 
 func (e *Cryptopia) FixSymbol() { //key: exchange specific    val： bitontop standard
-	symbolMap["ACC"] = "ACC1"
-	symbolMap["BCS"] = "BCS1"
-	symbolMap["BITS"] = "BITS1"
-	symbolMap["CAP"] = "CAP1"
-	symbolMap["CAT"] = "CAT1"
-	symbolMap["CMT"] = "CMT1"
-	symbolMap["HAV"] = "HAV1"
-	symbolMap["HC"] = "HC1"
-	symbolMap["IQ"] = "IQ1"
-	symbolMap["LDC"] = "LDC1"
-	symbolMap["QBT"] = "QBT1"
-	symbolMap["VCC"] = "VCC1"
+	symbolOnce.Do(func() {
+		symbolMap["ACC"] = "ACC1"
+		symbolMap["BCS"] = "BCS1"
+		symbolMap["BITS"] = "BITS1"
+		symbolMap["CAP"] = "CAP1"
+		symbolMap["CAT"] = "CAT1"
+		symbolMap["CMT"] = "CMT1"
+		symbolMap["HAV"] = "HAV1"
+		symbolMap["HC"] = "HC1"
+		symbolMap["IQ"] = "IQ1"
+		symbolMap["LDC"] = "LDC1"
+		symbolMap["QBT"] = "QBT1"
+		symbolMap["VCC"] = "VCC1"
+	})
 }
 
 func (e *Cryptopia) GetSymbol(code string) string { // get exchange standard
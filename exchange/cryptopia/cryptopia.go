@@ -2,11 +2,11 @@ package cryptopia
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	cmap "github.com/orcaman/concurrent-map"
 
@@ -15,6 +15,7 @@ import (
 	"../../exchange"
 	"../../market"
 	"../../pair"
+	"../../user"
 )
 
 type Cryptopia struct {
@@ -25,55 +26,104 @@ type Cryptopia struct {
 	RedisDB      int
 	API_KEY      string
 	API_SECRET   string
-}
+	API_URL      string //the REST endpoint, API_URL by default
+
+	pairList   []*pair.Pair //the pairs on this exchange
+	coinList   []*coin.Coin
+	balanceMap cmap.ConcurrentMap
+	userMap    cmap.ConcurrentMap //credentials: *Cryptopia, the instances of other users
+	clock      *exchange.Clock    //server time of the signed requests, shared with the user instances
 
-var pairList = make([]*pair.Pair, 0) //the last num is the number of pairs on this exchange
-var coinList = make([]*coin.Coin, 0)
-var balanceMap cmap.ConcurrentMap
+	clientOrderMap cmap.ConcurrentMap //ClientOrderID: *market.Order, Cryptopia doesn't keep client order id
+	pairIDMap      map[string]int     //pair.Name: TradePairId, read only after InitPairs
+}
 
-// var balanceMap = make(map[*coin.Coin]float64)
+// the name of Cryptopia in the registry
+const NAME exchange.ExchangeName = "CRYPTOPIA"
 
-var instance *Cryptopia
-var once sync.Once
+func init() {
+	exchange.Register(NAME, func(config *exchange.Config) (exchange.Exchange, error) {
+		return CreateCryptopia(config), nil
+	})
+}
 
 /***************************************************/
 func CreateCryptopia(config *exchange.Config) *Cryptopia {
-	once.Do(func() {
-		instance = &Cryptopia{}
-		instance.Name = "Cryptopia"
-		instance.Website = "https://www.cryptopia.co.nz/"
-
-		instance.RedisManager = db.CreateRedisManager()
-		instance.RedisServer = config.RedisServer
-		instance.RedisDB = config.RedisDB
-
-		instance.API_KEY = config.API_KEY
-		instance.API_SECRET = config.API_SECRET
-
-		if balanceMap == nil {
-			balanceMap = cmap.New()
-		}
+	instance := &Cryptopia{}
+	instance.Name = "Cryptopia"
+	instance.Website = "https://www.cryptopia.co.nz/"
+
+	instance.RedisManager = db.SharedRedisManager(string(NAME))
+	instance.RedisServer = config.RedisServer
+	instance.RedisDB = config.RedisDB
+
+	instance.API_KEY = config.API_KEY
+	instance.API_SECRET = config.API_SECRET
+	instance.API_URL = API_URL
+	if config.API_URL != "" {
+		instance.API_URL = strings.TrimSuffix(config.API_URL, "/")
+	}
 
-		instance.FixSymbol()
-		instance.InitCoins()
-		instance.InitPairs()
-	})
+	instance.pairList = make([]*pair.Pair, 0)
+	instance.coinList = make([]*coin.Coin, 0)
+	instance.balanceMap = cmap.New()
+	instance.userMap = cmap.New()
+	instance.clock = exchange.NewClock(NAME, nil)
+	instance.clientOrderMap = cmap.New()
+	instance.pairIDMap = make(map[string]int)
+
+	instance.FixSymbol()
+	instance.InitCoins()
+	instance.InitPairs()
 	return instance
 }
 
 func (e *Cryptopia) GetMakerDB() *db.Redis {
-	key := string(exchange.CRYPTOPIA)
+	key := fmt.Sprintf("%s-%s-%d", NAME, e.RedisServer, e.RedisDB) // the instances share the manager
 	d := e.RedisManager.Get(key)
 	if d == nil {
 		d = db.CreateRedis()
-		d.Init(instance.RedisServer, instance.RedisDB)
+		d.Init(e.RedisServer, e.RedisDB)
 		e.RedisManager.Add(key, d)
 	}
 	return d
 }
 
+/*Get the Instance of Another User
+The user instance shares the pairs, coins and Redis of this instance, but has its own API Key and balances*/
+func (e *Cryptopia) ForUser(u *user.User) *Cryptopia {
+	key := u.CredentialKey()
+	if tmp, ok := e.userMap.Get(key); ok {
+		return tmp.(*Cryptopia)
+	}
+
+	uInstance := &Cryptopia{}
+	uInstance.Name = e.Name
+	uInstance.Website = e.Website
+	uInstance.RedisManager = e.RedisManager
+	uInstance.RedisServer = e.RedisServer
+	uInstance.RedisDB = e.RedisDB
+	uInstance.API_KEY = u.API_KEY
+	uInstance.API_SECRET = u.API_SECRET
+	uInstance.API_URL = e.API_URL
+
+	uInstance.pairList = e.pairList
+	uInstance.coinList = e.coinList
+	uInstance.balanceMap = cmap.New()
+	uInstance.userMap = e.userMap
+	uInstance.clock = e.clock
+	uInstance.clientOrderMap = cmap.New()
+	uInstance.pairIDMap = e.pairIDMap
+
+	if !e.userMap.SetIfAbsent(key, uInstance) {
+		tmp, _ := e.userMap.Get(key)
+		return tmp.(*Cryptopia)
+	}
+	return uInstance
+}
+
 func (e *Cryptopia) InitPairs() {
-	pairData := GetCryptopiaPair()
+	pairData := e.GetCryptopiaPair()
 
 	for _, symbol := range *pairData {
 		//Modify according to type and structure
@@ -81,13 +131,14 @@ func (e *Cryptopia) InitPairs() {
 		target := coin.GetCoin(e.GetCode(symbol.Symbol))
 		if base != nil && target != nil {
 			pair := pair.GetPair(base, target)
-			pairList = append(pairList, pair)
+			e.pairList = append(e.pairList, pair)
+			e.pairIDMap[pair.Name] = symbol.ID
 		}
 	}
 }
 
 func (e *Cryptopia) InitCoins() {
-	coinInfo := GetCryptopiaCoin()
+	coinInfo := e.GetCryptopiaCoin()
 
 	for _, data := range *coinInfo {
 		//Modify according to type and structure
@@ -98,7 +149,7 @@ func (e *Cryptopia) InitCoins() {
 			c.Name = data.Name
 			coin.AddCoin(c)
 		}
-		coinList = append(coinList, c)
+		e.coinList = append(e.coinList, c)
 	}
 }
 
@@ -108,15 +159,15 @@ func (e *Cryptopia) UpdateMaker(pair *pair.Pair, maker *market.Maker) error {
 	if err != nil {
 		return err
 	}
-	key := fmt.Sprintf("%s-%s", exchange.CRYPTOPIA, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	return e.GetMakerDB().Set(key, string(m))
 }
 
 func (e *Cryptopia) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
-	key := fmt.Sprintf("%s-%s", exchange.CRYPTOPIA, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Cryptopia does not have the pair : %v", pair.Name))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrNotFound, "does not have the pair : %v", pair.Name)
 	}
 	if str, ok := val.(string); ok {
 
@@ -124,7 +175,7 @@ func (e *Cryptopia) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
 			return nil, err
 		}
 	} else {
-		return nil, errors.New(fmt.Sprintf("Cryptopia GetMaker Key: %v can't convert to string: %v", key, val))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrUnknown, "Key: %v can't convert to string: %v", key, val)
 	}
 	return maker, err
 }
@@ -135,7 +186,7 @@ func (e *Cryptopia) SetCoins() error {
 }
 
 func (e *Cryptopia) GetCoins() []*coin.Coin {
-	return coinList
+	return e.coinList
 }
 
 func (e *Cryptopia) SetPairs() error {
@@ -143,12 +194,17 @@ func (e *Cryptopia) SetPairs() error {
 }
 
 func (e *Cryptopia) GetPairs() []*pair.Pair {
-	return pairList
+	return e.pairList
 }
 
 /*Get Exchange A Pair
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Cryptopia) GetPair(key string) *pair.Pair {
+	for _, p := range e.pairList {
+		if p.Name == key {
+			return p
+		}
+	}
 	return nil
 }
 
@@ -168,15 +224,36 @@ func (e *Cryptopia) HasPair(pair *pair.Pair) bool {
 
 /*************** pairs on the exchanges ***************/
 func (e *Cryptopia) GetName() exchange.ExchangeName {
-	return exchange.CRYPTOPIA
+	return NAME
+}
+
+// the offset of the server time measured by the signed requests
+func (e *Cryptopia) GetClockSkew() time.Duration {
+	return e.clock.Skew()
 }
 
 func (e *Cryptopia) GetFee(pair *pair.Pair) float64 { // Taker fee for each coin
-	return 0.002 //Taker Fee: 0.2%
+	return e.GetTradeFee(pair).Taker
+}
+
+/*Get Exchange Maker & Taker Fee
+The API doesn't provide the fee of the account, 0.2% for both*/
+func (e *Cryptopia) GetTradeFee(pair *pair.Pair) *exchange.TradeFee {
+	return &exchange.TradeFee{
+		Pair:   pair,
+		Maker:  0.002,
+		Taker:  0.002,
+		Source: exchange.SourceStatic,
+	}
+}
+
+// the fee is static, nothing to update
+func (e *Cryptopia) UpdateFees() error {
+	return nil
 }
 
 func (e *Cryptopia) GetLotSize(pair *pair.Pair) float64 { // stepSize for quantity
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.CRYPTOPIA, pair.Name)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Cryptopia GetLotSize Key: %v Err: %s\n", key, err)
@@ -195,7 +272,7 @@ func (e *Cryptopia) GetLotSize(pair *pair.Pair) float64 { // stepSize for quanti
 	return constrain.LotSize //return 0.00100000
 }
 func (e *Cryptopia) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for price
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.CRYPTOPIA, pair.Name)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Cryptopia GetPriceFilter Key: %v Err: %s\n", key, err)
@@ -214,29 +291,59 @@ func (e *Cryptopia) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for pr
 	return constrain.TickSize
 }
 
-func (e *Cryptopia) GetConstrainFetchMethod(pair *pair.Pair) *exchange.ConstrainFetchMethod {
+func (e *Cryptopia) GetCapabilities() *exchange.Capabilities {
 	constrainFetchMethod := &exchange.ConstrainFetchMethod{}
-	constrainFetchMethod.Fee = true
+	constrainFetchMethod.Fee = false
 	constrainFetchMethod.LotSize = true
 	constrainFetchMethod.TickSize = true
 	constrainFetchMethod.TxFee = true
 	constrainFetchMethod.Withdraw = true
 	constrainFetchMethod.Deposit = true
 	constrainFetchMethod.Confirmation = true
-	return constrainFetchMethod
+
+	capabilities := &exchange.Capabilities{}
+	capabilities.OrderTypes = []market.OrderType{market.LimitOrder}
+	capabilities.TimeInForce = []market.TimeInForce{market.GTC}
+	capabilities.PostOnly = false
+	capabilities.ClientOrderID = false
+	capabilities.CancelAll = true
+	capabilities.ListOrders = true
+	capabilities.Withdraw = true
+	capabilities.DepositAddress = true
+	capabilities.TransferHistory = true
+	capabilities.WebSocketMarketData = false
+	capabilities.WebSocketUserData = false
+	capabilities.BatchOrderBooks = false
+	capabilities.FeeSource = exchange.SourceStatic
+	capabilities.ConstrainSource = constrainFetchMethod
+	return capabilities
 }
 
 /*************** coins on the exchanges ***************/
 func (e *Cryptopia) GetBalance(coin *coin.Coin) float64 {
-	if tmp, ok := balanceMap.Get(coin.Code); ok {
-		return tmp.(float64)
+	if tmp, ok := e.balanceMap.Get(coin.Code); ok {
+		return tmp.(*market.Balance).Available
 	} else {
 		return 0.0
 	}
 }
 
+/*Get the Balances of All Coins
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Cryptopia) GetBalances() []*market.Balance {
+	balances := []*market.Balance{}
+	for _, tmp := range e.balanceMap.Items() {
+		balance := *tmp.(*market.Balance)
+		balances = append(balances, &balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Coin.Code < balances[j].Coin.Code
+	})
+	return balances
+}
+
 func (e *Cryptopia) GetTxFee(coin *coin.Coin) float64 { // Withdraw Fee
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.CRYPTOPIA, coin.Code)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, coin.Code)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Cryptopia GetTxFee Key: %v Err: %s\n", key, err)
@@ -256,7 +363,7 @@ func (e *Cryptopia) GetTxFee(coin *coin.Coin) float64 { // Withdraw Fee
 }
 
 func (e *Cryptopia) GetConfirmation(coin *coin.Coin) int { // deposit confirmations
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.CRYPTOPIA, coin.Code)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, coin.Code)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Cryptopia GetConfirmation Key: %v Err: %s\n", key, err)
@@ -276,7 +383,7 @@ func (e *Cryptopia) GetConfirmation(coin *coin.Coin) int { // deposit confirmati
 }
 
 func (e *Cryptopia) CanWithdraw(coin *coin.Coin) bool { // does withdraw enable
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.CRYPTOPIA, coin.Code)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, coin.Code)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Cryptopia CanWithdraw Key: %v Err: %s\n", key, err)
@@ -295,7 +402,7 @@ func (e *Cryptopia) CanWithdraw(coin *coin.Coin) bool { // does withdraw enable
 	return constrain.Withdraw
 }
 func (e *Cryptopia) CanDeposit(coin *coin.Coin) bool { // does deposit enable
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.CRYPTOPIA, coin.Code)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, coin.Code)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Cryptopia CanDeposit Key: %v Err: %s\n", key, err)
@@ -100,3 +100,61 @@ type TradeHistory []struct {
 	Remaining   float64 `json:"Remaining"`
 	TimeStamp   string  `json:"TimeStamp"`
 }
+
+type MyTrades []struct {
+	TradeID     int     `json:"TradeId"`
+	TradePairID int     `json:"TradePairId"`
+	Market      string  `json:"Market"`
+	Type        string  `json:"Type"`
+	Rate        float64 `json:"Rate"`
+	Amount      float64 `json:"Amount"`
+	Total       float64 `json:"Total"`
+	Fee         float64 `json:"Fee"`
+	TimeStamp   string  `json:"TimeStamp"`
+}
+
+type DepositAddress struct {
+	Currency    string `json:"Currency"`
+	Address     string `json:"Address"`
+	BaseAddress string `json:"BaseAddress"`
+}
+
+type Transactions []struct {
+	ID            int     `json:"Id"`
+	Currency      string  `json:"Currency"`
+	TxID          string  `json:"TxId"`
+	Type          string  `json:"Type"`
+	Amount        float64 `json:"Amount"`
+	Fee           float64 `json:"Fee"`
+	Status        string  `json:"Status"`
+	Confirmations int     `json:"Confirmations"`
+	Timestamp     string  `json:"Timestamp"`
+	Address       string  `json:"Address"`
+}
+
+type MarketData struct {
+	TradePairID int     `json:"TradePairId"`
+	Label       string  `json:"Label"`
+	AskPrice    float64 `json:"AskPrice"`
+	BidPrice    float64 `json:"BidPrice"`
+	Low         float64 `json:"Low"`
+	High        float64 `json:"High"`
+	Volume      float64 `json:"Volume"`
+	LastPrice   float64 `json:"LastPrice"`
+	BuyVolume   float64 `json:"BuyVolume"`
+	SellVolume  float64 `json:"SellVolume"`
+	Change      float64 `json:"Change"`
+	Open        float64 `json:"Open"`
+	Close       float64 `json:"Close"`
+	BaseVolume  float64 `json:"BaseVolume"`
+}
+
+type MarketHistory struct {
+	TradePairID int     `json:"TradePairId"`
+	Label       string  `json:"Label"`
+	Type        string  `json:"Type"`
+	Price       float64 `json:"Price"`
+	Amount      float64 `json:"Amount"`
+	Total       float64 `json:"Total"`
+	Timestamp   int64   `json:"Timestamp"`
+}
@@ -0,0 +1,68 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type ErrorKind string
+
+const (
+	ErrUnknown     ErrorKind = "Unknown"
+	ErrNetwork     ErrorKind = "Network"     // no valid response from the exchange, the request may or may not be executed
+	ErrAuth        ErrorKind = "Auth"        // API Key or Secret Key missing or rejected
+	ErrRejected    ErrorKind = "Rejected"    // the exchange received and refused the request
+	ErrNotFound    ErrorKind = "NotFound"    // the data is not available, eg. constrain not in Redis
+	ErrUnsupported ErrorKind = "Unsupported" // the exchange does not provide the function
+	ErrCanceled    ErrorKind = "Canceled"    // context canceled or deadline exceeded
+)
+
+// Error is the typed error returned by ExchangeV2
+type Error struct {
+	Exchange ExchangeName
+	Op       string
+	Kind     ErrorKind
+	Err      error
+}
+
+func NewError(name ExchangeName, op string, kind ErrorKind, err error) *Error {
+	return &Error{Exchange: name, Op: op, Kind: kind, Err: err}
+}
+
+func Errorf(name ExchangeName, op string, kind ErrorKind, format string, a ...interface{}) *Error {
+	return NewError(name, op, kind, fmt.Errorf(format, a...))
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s %s [%s]", e.Exchange, e.Op, e.Kind)
+	}
+	return fmt.Sprintf("%s %s [%s]: %v", e.Exchange, e.Op, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+/*Get the ErrorKind of an error, the *Error wrapped by fmt.Errorf("%w") keeps its kind
+nil error: ""
+context error: ErrCanceled
+not *Error: ErrUnknown*/
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrCanceled
+	}
+	return ErrUnknown
+}
+
+func IsKind(err error, kind ErrorKind) bool {
+	return KindOf(err) == kind
+}
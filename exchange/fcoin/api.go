@@ -62,7 +62,7 @@ func (e *Fcoin) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	symbol := strings.ToLower(e.GetPairCode(p))
 
 	strRequestUrl := fmt.Sprintf("/market/depth/L150/%s", symbol)
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	maker := &market.Maker{}
 	maker.WorkerIP = exchange.GetExternalIP()
@@ -70,17 +70,18 @@ func (e *Fcoin) OrderBook(p *pair.Pair) (*market.Maker, error) {
 
 	jsonMarketDepthReturn := exchange.HttpGetRequest(strUrl, nil)
 	if err := json.Unmarshal([]byte(jsonMarketDepthReturn), &jsonResponse); err != nil {
-		return nil, fmt.Errorf("Fcoin OrderBook json Unmarshal error: %v %v", err, jsonMarketDepthReturn)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonMarketDepthReturn)
 	} else if jsonResponse.Status != 0 {
-		return nil, fmt.Errorf("Fcoin OrderBook failed:%v Message:%v", jsonResponse.Status, jsonResponse.Message)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Status, jsonResponse.Message)
 	}
 
 	if err := json.Unmarshal(jsonResponse.Data, &orderBook); err != nil {
-		return nil, fmt.Errorf("Fcoin OrderBook json Unmarshal error:%v %s", err, jsonResponse.Data)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrUnknown, "json Unmarshal error:%v %s", err, jsonResponse.Data)
 	}
 
 	//Convert Exchange Struct to Maker
 	maker.Timestamp = float64(orderBook.Ts)
+	maker.LastUpdateID = orderBook.Seq
 	var buyRate float64
 	for i, bid := range orderBook.Bids {
 		var buydata market.Order
@@ -116,16 +117,181 @@ func (e *Fcoin) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	return maker, nil
 }
 
+/*Get the Ticker of the Pair
+ticker: [last, last volume, bid, bid volume, ask, ask volume, 24h open, 24h high, 24h low, 24h base volume, 24h quote volume]*/
+func (e *Fcoin) Ticker(p *pair.Pair) (*market.Ticker, error) {
+	jsonResponse := JsonResponse{}
+	tickerData := TickerData{}
+	symbol := strings.ToLower(e.GetPairCode(p))
+
+	strRequestUrl := fmt.Sprintf("/market/ticker/%s", symbol)
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTickerReturn)
+	} else if jsonResponse.Status != 0 {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Status, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &tickerData); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrUnknown, "Data Unmarshal error: %v %s", err, jsonResponse.Data)
+	}
+	if len(tickerData.Ticker) < 11 {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrUnknown, "Data error: %s", jsonResponse.Data)
+	}
+
+	ticker := &market.Ticker{}
+	ticker.Pair = p
+	ticker.Last = tickerData.Ticker[0]
+	ticker.Bid = tickerData.Ticker[2]
+	ticker.Ask = tickerData.Ticker[4]
+	ticker.High = tickerData.Ticker[7]
+	ticker.Low = tickerData.Ticker[8]
+	ticker.Volume = tickerData.Ticker[9]
+	ticker.QuoteVolume = tickerData.Ticker[10]
+	ticker.Timestamp = time.Now().UnixNano() / 1e6
+	return ticker, nil
+}
+
+/*Get the Tickers of All the Pairs
+Fcoin doesn't have a batch ticker API, the pairs are requested one by one*/
+func (e *Fcoin) Tickers() ([]*market.Ticker, error) {
+	tickers := []*market.Ticker{}
+	for _, p := range e.pairList {
+		ticker, err := e.Ticker(p)
+		if err != nil {
+			return nil, err
+		}
+		tickers = append(tickers, ticker)
+	}
+	return tickers, nil
+}
+
+/*Get the Trades of the Market Since the Time
+/market/trades returns the latest trades (limit 100), side is the aggressor side*/
+func (e *Fcoin) RecentTrades(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	jsonResponse := JsonResponse{}
+	data := []*MarketTrade{}
+	symbol := strings.ToLower(e.GetPairCode(p))
+
+	strRequestUrl := fmt.Sprintf("/market/trades/%s", symbol)
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["limit"] = "100"
+
+	jsonTradesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonTradesReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTradesReturn)
+	} else if jsonResponse.Status != 0 {
+		return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Status, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &data); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrUnknown, "Data Unmarshal error: %v %s", err, jsonResponse.Data)
+	}
+
+	trades := []*market.Trade{}
+	for _, t := range data {
+		if t.Ts < since.UnixNano()/1e6 {
+			continue
+		}
+		trade := &market.Trade{}
+		trade.TradeID = fmt.Sprintf("%d", t.ID)
+		trade.Pair = p
+		trade.Rate = t.Price
+		trade.Quantity = t.Amount
+		trade.Timestamp = t.Ts
+		if t.Side == "sell" {
+			trade.Side = market.Sell
+		} else {
+			trade.Side = market.Buy
+		}
+		trade.Liquidity = market.LiquidityTaker
+		trades = append(trades, trade)
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+// the resolutions of /market/candles
+var candleResolutions = map[time.Duration]string{
+	time.Minute:        "M1",
+	3 * time.Minute:    "M3",
+	5 * time.Minute:    "M5",
+	15 * time.Minute:   "M15",
+	30 * time.Minute:   "M30",
+	time.Hour:          "H1",
+	4 * time.Hour:      "H4",
+	6 * time.Hour:      "H6",
+	24 * time.Hour:     "D1",
+	7 * 24 * time.Hour: "W1",
+}
+
+/*Get the Candles of the Pair Since the Time
+/market/candles returns the latest candles (limit 100) newest first, the resolutions it doesn't support are built from the recent trades*/
+func (e *Fcoin) Candles(p *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error) {
+	resolution, ok := candleResolutions[interval]
+	if !ok {
+		return exchange.CandlesFromTrades(e, p, interval, since)
+	}
+
+	jsonResponse := JsonResponse{}
+	data := []*CandleData{}
+	symbol := strings.ToLower(e.GetPairCode(p))
+
+	strRequestUrl := fmt.Sprintf("/market/candles/%s/%s", resolution, symbol)
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["limit"] = "100"
+
+	jsonCandlesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonCandlesReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonCandlesReturn)
+	} else if jsonResponse.Status != 0 {
+		return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Status, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &data); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrUnknown, "Data Unmarshal error: %v %s", err, jsonResponse.Data)
+	}
+
+	start := since.Truncate(interval).Unix()
+	candles := []*market.Candle{}
+	for _, c := range data {
+		if c.ID < start {
+			continue
+		}
+		candle := &market.Candle{}
+		candle.Pair = p
+		candle.Timestamp = c.ID * 1000
+		candle.Open = c.Open
+		candle.High = c.High
+		candle.Low = c.Low
+		candle.Close = c.Close
+		candle.Volume = c.BaseVol
+		candle.QuoteVolume = c.QuoteVol
+		candles = append(candles, candle)
+	}
+
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].Timestamp < candles[j].Timestamp
+	})
+	return candles, nil
+}
+
 /*Get Coins Information (If API provide)
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)*/
-func GetFcoinCoin() []string {
+func (e *Fcoin) GetFcoinCoin() []string {
 	jsonResponse := JsonResponse{}
 	coinsData := []string{}
 
 	strRequestUrl := "/public/currencies"
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	jsonCurrencyReturn := exchange.HttpGetRequest(strUrl, nil)
 	if err := json.Unmarshal([]byte(jsonCurrencyReturn), &jsonResponse); err != nil {
@@ -148,12 +314,12 @@ func GetFcoinCoin() []string {
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)*/
-func GetFcoinPair() *PairsData {
+func (e *Fcoin) GetFcoinPair() *PairsData {
 	jsonResponse := JsonResponse{}
 	pairsData := &PairsData{}
 
 	strRequestUrl := "/public/symbols"
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	jsonSymbolReturn := exchange.HttpGetRequest(strUrl, nil)
 	if err := json.Unmarshal([]byte(jsonSymbolReturn), &jsonResponse); err != nil {
@@ -172,9 +338,30 @@ func GetFcoinPair() *PairsData {
 	return pairsData
 }
 
+/*Get the Server Time
+Used by the clock of the signed requests*/
+func (e *Fcoin) GetFcoinTime() (time.Time, error) {
+	jsonResponse := JsonResponse{}
+	var serverTime int64
+
+	strRequestUrl := "/public/server-time"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTimeReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTimeReturn), &jsonResponse); err != nil {
+		return time.Time{}, exchange.Errorf(e.GetName(), "GetFcoinTime", exchange.ErrNetwork, "Get Server Time Unmarshal Err: %v %v", err, jsonTimeReturn)
+	} else if jsonResponse.Status != 0 {
+		return time.Time{}, exchange.Errorf(e.GetName(), "GetFcoinTime", exchange.ErrRejected, "Get Server Time Err: %v %s", jsonResponse.Status, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &serverTime); err != nil {
+		return time.Time{}, exchange.Errorf(e.GetName(), "GetFcoinTime", exchange.ErrUnknown, "Get Server Time Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+	return time.Unix(0, serverTime*1e6), nil
+}
+
 /*************** Private API ***************/
-func (e *Fcoin) UpdateAllBalances() {
-	e.UpdateAllBalancesByUser(nil)
+func (e *Fcoin) UpdateAllBalances() error {
+	return e.UpdateAllBalancesByUser(nil)
 }
 
 /*Get Exchange Account All Coins Balance  --reference Cryptopia
@@ -182,21 +369,17 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)
 Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Get Coin Availamount and store in balanceMap*/
-func (e *Fcoin) UpdateAllBalancesByUser(u *user.User) {
+Step 5: Get Coin Balance (market.Balance) and store in balanceMap*/
+func (e *Fcoin) UpdateAllBalancesByUser(u *user.User) error {
 	var uInstance *Fcoin
 	if u != nil {
-		uInstance = &Fcoin{}
-		uInstance.API_KEY = u.API_KEY
-		uInstance.API_SECRET = u.API_SECRET
-		// uInstance.MakerDB = e.MakerDB
+		uInstance = e.ForUser(u)
 	} else {
 		uInstance = e
 	}
 
 	if uInstance.API_KEY == "" || uInstance.API_SECRET == "" {
-		log.Printf("Blank API Key or Secret Key are nil.")
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := JsonResponse{}
@@ -206,63 +389,66 @@ func (e *Fcoin) UpdateAllBalancesByUser(u *user.User) {
 	jsonBalanceReturn := uInstance.ApiKeyGet(nil, strRequest)
 	// log.Printf("jsonBalanceReturn: %v", jsonBalanceReturn)
 	if err := json.Unmarshal([]byte(jsonBalanceReturn), &jsonResponse); err != nil {
-		log.Printf("Fcoin Get Balance Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrNetwork, "Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
 	} else if jsonResponse.Status != 0 {
-		log.Printf("Fcoin Get Balance Err: %v %s", jsonResponse.Status, jsonResponse.Message)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrRejected, "%v %s", jsonResponse.Status, jsonResponse.Message)
 	}
 
 	if err := json.Unmarshal(jsonResponse.Data, &accountBalance); err != nil {
-		log.Printf("Fcoin Get Balance Data Unmarshal Err: %v %v", err, jsonResponse.Data)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
 	} else {
+		now := time.Now().UnixNano() / 1e6
 		for _, data := range accountBalance {
 			c := coin.GetCoin(e.GetCode(data.Currency))
 			if c != nil {
 				available, err := strconv.ParseFloat(data.Available, 64)
-				if err == nil {
-					balanceMap.Set(c.Code, available)
+				if err != nil {
+					continue
 				}
+				frozen, _ := strconv.ParseFloat(data.Frozen, 64)
+				total, err := strconv.ParseFloat(data.Balance, 64)
+				if err != nil {
+					total = available + frozen
+				}
+
+				balance := &market.Balance{}
+				balance.Coin = c
+				balance.Available = available
+				balance.Locked = frozen
+				balance.Total = total
+				balance.Timestamp = now
+				uInstance.balanceMap.Set(c.Code, balance)
 			}
 		}
 	}
 
 	//TODO: GetBalance
+	return nil
 }
 
 /*Withdraw the coin to another address
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Check the success of withdraw*/
-func (e *Fcoin) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) bool {
-	if e.API_KEY == "" || e.API_SECRET == "" {
-		log.Printf("Fcoin API Key or Secret Key are nil.")
-		return false
-	}
+Fcoin API doesn't provide the withdrawal, /broker/otc/assets/transfer/out only moves the assets between the Fcoin accounts*/
+func (e *Fcoin) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) (*exchange.WithdrawalResult, error) {
+	return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrUnsupported, "withdraw is not supported")
+}
 
-	jsonResponse := JsonResponse{}
-	strRequest := "/broker/otc/assets/transfer/out"
+func (e *Fcoin) WithdrawalStatus(withdrawal *exchange.WithdrawalResult) error {
+	return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrUnsupported, "withdraw is not supported")
+}
 
-	mapParams := make(map[string]string)
-	mapParams["Currency"] = e.GetSymbol(coin.Code)
-	mapParams["Amount"] = fmt.Sprintf("%f", quantity)
-	//mapParams["Address"] = addr
-	//mapParams["PaymentId"] = coin.Code
-
-	jsonSubmitWithdraw := e.ApiKeyPost(mapParams, strRequest)
-	log.Printf("jsonSubmitWithdraw: %+v", jsonSubmitWithdraw)
-	if err := json.Unmarshal([]byte(jsonSubmitWithdraw), &jsonResponse); err != nil {
-		log.Printf("Fcoin Withdraw Json Unmarshal failed: %v %v", err, jsonSubmitWithdraw)
-		return false
-	} else if jsonResponse.Status != 0 {
-		log.Printf("Fcoin Withdraw failed: %v Message: %v", jsonResponse.Status, jsonResponse.Message)
-		return false
-	}
+/*Get the Deposit Address of the Coin
+Fcoin API doesn't provide the deposit address and the deposit and withdrawal history*/
+func (e *Fcoin) GetDepositAddress(coin *coin.Coin) (*exchange.DepositAddress, error) {
+	return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrUnsupported, "deposit address is not supported")
+}
+
+func (e *Fcoin) GetTransfers(coin *coin.Coin, since time.Time) ([]*exchange.Transfer, error) {
+	return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrUnsupported, "transfer history is not supported")
+}
 
-	return false
+// the API has no authenticated WebSocket, the orders, fills and balances are polled by REST
+func (e *Fcoin) StreamUserData(stop <-chan struct{}) (<-chan *exchange.UserEvent, error) {
+	return exchange.PollUserData(e, 5*time.Second, stop), nil
 }
 
 /*Get the Status of a Singal Order  --reference Cryptopia
@@ -270,15 +456,15 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)
 Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Change Order Status (Status reference ../market/market.go)*/
+Step 5: Change Order Status (Status reference ../market/market.go) and the Deal from filled_amount & executed_value*/
 func (e *Fcoin) OrderStatus(order *market.Order) error {
 	//log.Printf("=========OrderStatus order===%+v=========", order) // ============================================
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return fmt.Errorf("Fcoin API Key or Secret Key are nil.")
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := JsonResponse{}
-	orderStatus := TradeHistory{}
+	orderStatus := OrderData{}
 	strRequest := fmt.Sprintf("/orders/%s", order.OrderID)
 
 	mapParams := make(map[string]string)
@@ -286,51 +472,297 @@ func (e *Fcoin) OrderStatus(order *market.Order) error {
 
 	jsonOrderStatus := e.ApiKeyGet(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonOrderStatus), &jsonResponse); err != nil {
-		return fmt.Errorf("Fcoin OrderStatus Unmarshal Err: %v %v", err, jsonOrderStatus)
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrderStatus)
 	} else if jsonResponse.Status != 0 {
-		return fmt.Errorf("Fcoin Get OrderStatus failed: %v Message :%v", jsonResponse.Status, jsonResponse.Message)
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrRejected, "Get OrderStatus failed: %v Message :%v", jsonResponse.Status, jsonResponse.Message)
 	}
 
-	if err := json.Unmarshal(jsonResponse.Data, &orderStatus); err != nil {
-		return fmt.Errorf("Fcoin Get OrderStatus Data Unmarshal Err: %v %v", err, jsonResponse.Data)
-	} else {
-		for _, orderState := range orderStatus {
-			if orderState.ID == order.OrderID {
-				switch orderState.State {
-				case "submitted":
-					order.Status = market.New
-				case "partial_filled":
-					order.Status = market.Partial
-				case "partial_canceled":
-					order.Status = market.Canceled
-				case "pending_cancel":
-					order.Status = market.Canceling
-				case "canceled":
-					order.Status = market.Canceled
-				case "filled":
-					order.Status = market.Filled
-				default:
-					order.Status = market.Other
+	if err := json.Unmarshal(jsonResponse.Data, &orderStatus); err != nil { //the data is the order, not a list
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrUnknown, "Get OrderStatus Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	} else if orderStatus.ID == order.OrderID {
+		deal := toOrder(order.Pair, &orderStatus)
+		order.Status = deal.Status
+		order.DealQuantity = deal.DealQuantity
+		order.DealRate = deal.DealRate
+	}
+
+	switch order.Status {
+	case market.Filled, market.Canceled, market.Rejected, market.Expired:
+		e.forgetClientOrder(order.OrderID)
+	}
+
+	return nil
+}
+
+// the API queries one order at a time
+func (e *Fcoin) OrdersStatus(orders []*market.Order) error {
+	return exchange.OrdersStatusEach(e, orders)
+}
+
+/*Get an Order by the Client Order ID
+Fcoin doesn't support client order id, only the open orders placed by this instance can be found.
+The map is in memory: after a restart, or once OrderStatus sees the order closed, the lookup returns ErrUnsupported.
+The order may still exist when it is not found, so ErrUnsupported is returned instead of ErrNotFound*/
+func (e *Fcoin) OrderByClientID(pair *pair.Pair, clientOrderID string) (*market.Order, error) {
+	if tmp, ok := e.clientOrderMap.Get(clientOrderID); ok {
+		return tmp.(*market.Order), nil
+	}
+	return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrUnsupported, "client order id %s is not placed by this instance", clientOrderID)
+}
+
+// drop the client order id of the closed order, so the map only keeps the orders which may be looked up again
+func (e *Fcoin) forgetClientOrder(orderID string) {
+	for clientOrderID, tmp := range e.clientOrderMap.Items() {
+		if tmp.(*market.Order).OrderID == orderID {
+			e.clientOrderMap.Remove(clientOrderID)
+		}
+	}
+}
+
+func (e *Fcoin) ListOrders() (*[]market.Order, error) {
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.OrderList(orders), nil
+}
+
+/*Get the Open Orders
+Step 1: The API only lists the orders of one symbol, all the pairs are requested if the query has no pair
+Step 2: Sort by created time, so the pages are stable
+Step 3: Filter by pair and page (exchange.PageOrders)*/
+func (e *Fcoin) ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	pairs := e.pairList
+	if query != nil && query.Pair != nil {
+		pairs = []*pair.Pair{query.Pair}
+	}
+
+	openOrders := TradeHistory{}
+	pairOfOrder := make(map[string]*pair.Pair)
+	for _, p := range pairs {
+		pairOrders, err := e.getOrders(p, "submitted,partial_filled", time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range pairOrders {
+			pairOfOrder[o.ID] = p
+		}
+		openOrders = append(openOrders, pairOrders...)
+	}
+
+	sort.Slice(openOrders, func(i, j int) bool {
+		return openOrders[i].CreatedAt < openOrders[j].CreatedAt
+	})
+
+	orders := []*market.Order{}
+	for i := range openOrders {
+		orders = append(orders, toOrder(pairOfOrder[openOrders[i].ID], &openOrders[i]))
+	}
+	return exchange.PageOrders(orders, query), nil
+}
+
+const ordersPageLimit = 100 // the max limit of /orders
+
+/*Get the Orders of the Pair in the States Created Since the Time, eg: submitted,partial_filled
+Step 1: The API returns the newest orders first, page back with the "before" cursor until a short page or an order before since
+Step 2: The cursor is exclusive, use the oldest created_at + 1 so the orders of the same time are not skipped, skip the orders already seen
+Step 3: Return an error instead of a truncated list if a full page has no new order
+Zero since: all the orders*/
+func (e *Fcoin) getOrders(pair *pair.Pair, states string, since time.Time) (TradeHistory, error) {
+	sinceMs := 0
+	if !since.IsZero() {
+		sinceMs = int(since.UnixNano() / 1e6)
+	}
+
+	orders := TradeHistory{}
+	seen := make(map[string]bool)
+	before := 0
+	for {
+		page, err := e.getOrdersPage(pair, states, before)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		oldest := 0
+		for _, o := range page {
+			if oldest == 0 || o.CreatedAt < oldest {
+				oldest = o.CreatedAt
+			}
+			if seen[o.ID] || o.CreatedAt < sinceMs {
+				continue
+			}
+			seen[o.ID] = true
+			orders = append(orders, o)
+			added++
+		}
+
+		if len(page) < ordersPageLimit || oldest < sinceMs {
+			return orders, nil
+		} else if added == 0 {
+			return nil, exchange.Errorf(e.GetName(), "getOrders", exchange.ErrUnknown, "more than %d %s orders of %s at %d, the orders can't be paged", ordersPageLimit, states, pair.Name, oldest)
+		}
+		before = oldest + 1
+	}
+}
+
+// a page of the orders created before the cursor (ms), 0: the newest orders
+func (e *Fcoin) getOrdersPage(pair *pair.Pair, states string, before int) (TradeHistory, error) {
+	jsonResponse := JsonResponse{}
+	orders := TradeHistory{}
+	strRequest := "/orders"
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = strings.ToLower(e.GetPairCode(pair))
+	mapParams["states"] = states
+	mapParams["limit"] = strconv.Itoa(ordersPageLimit)
+	if before > 0 {
+		mapParams["before"] = strconv.Itoa(before)
+	}
+
+	jsonOrders := e.ApiKeyGet(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonOrders), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getOrders", exchange.ErrNetwork, "Get Orders Unmarshal Err: %v %v", err, jsonOrders)
+	} else if jsonResponse.Status != 0 {
+		return nil, exchange.Errorf(e.GetName(), "getOrders", exchange.ErrRejected, "%v Message:%v", jsonResponse.Status, jsonResponse.Message)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &orders); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getOrders", exchange.ErrUnknown, "Get Orders Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+	return orders, nil
+}
+
+/*Get the Executed Trades Since the Time, nil pair: all pairs
+Fcoin only provides the fills of an order, the orders have no finish time
+Step 1: Page the closed orders with fills created since the time, and the partial filled open orders of any time,
+	all the pairs are requested if pair is nil
+Step 2: Get the match results of each order (a signed request per order), keep the fills since the time
+Step 3: Sort by time
+An order created before since and closed after it is found only while it is still open
+The fee is charged in the received coin: buy in pair.Target, sell in pair.Base*/
+func (e *Fcoin) GetFills(pair *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	pairs := e.pairList
+	if pair != nil {
+		pairs = append(pairs[:0:0], pair)
+	}
+
+	trades := []*market.Trade{}
+	for _, p := range pairs {
+		orders, err := e.getOrders(p, "filled,partial_canceled", since)
+		if err != nil {
+			return nil, err
+		}
+		openOrders, err := e.getOrders(p, "partial_filled", time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, openOrders...)
+
+		for _, o := range orders {
+			jsonResponse := JsonResponse{}
+			matchResults := []*MatchResult{}
+			strRequest := fmt.Sprintf("/orders/%s/match-results", o.ID)
+
+			jsonMatchResults := e.ApiKeyGet(make(map[string]string), strRequest)
+			if err := json.Unmarshal([]byte(jsonMatchResults), &jsonResponse); err != nil {
+				return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonMatchResults)
+			} else if jsonResponse.Status != 0 {
+				return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrRejected, "%v Message:%v", jsonResponse.Status, jsonResponse.Message)
+			}
+			if err := json.Unmarshal(jsonResponse.Data, &matchResults); err != nil {
+				return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
+			}
+
+			for i, m := range matchResults {
+				if m.CreatedAt < since.UnixNano()/1e6 {
+					continue
 				}
+
+				trade := &market.Trade{}
+				trade.TradeID = fmt.Sprintf("%s-%d", o.ID, i)
+				trade.OrderID = o.ID
+				trade.Pair = p
+				trade.Rate, _ = strconv.ParseFloat(m.Price, 64)
+				trade.Quantity, _ = strconv.ParseFloat(m.FilledAmount, 64)
+				trade.Fee, _ = strconv.ParseFloat(m.FillFees, 64)
+				trade.Timestamp = m.CreatedAt
+				trade.Liquidity = market.LiquidityUnknown
+				if m.Side == "sell" {
+					trade.Side = market.Sell
+					trade.FeeCoin = p.Base
+				} else {
+					trade.Side = market.Buy
+					trade.FeeCoin = p.Target
+				}
+				trades = append(trades, trade)
 			}
 		}
 	}
 
-	return nil
+	sort.Slice(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
 }
 
-func (e *Fcoin) ListOrders() (*[]market.Order, error) {
-	return nil, nil
+func toOrder(pair *pair.Pair, data *OrderData) *market.Order {
+	order := &market.Order{}
+	order.Pair = pair
+	order.OrderID = data.ID
+	order.Rate, _ = strconv.ParseFloat(data.Price, 64)
+	order.Quantity, _ = strconv.ParseFloat(data.Amount, 64)
+	order.DealQuantity, _ = strconv.ParseFloat(data.FilledAmount, 64)
+	if executedValue, err := strconv.ParseFloat(data.ExecutedValue, 64); err == nil && order.DealQuantity > 0 {
+		order.DealRate = executedValue / order.DealQuantity
+	}
+	if data.Side == "sell" {
+		order.Side = string(market.Sell)
+	} else {
+		order.Side = string(market.Buy)
+	}
+	order.Status = statusOf(data.State)
+	return order
 }
 
-/*Cancel All Order
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Change Order Status (order.Status = market.Canceling)*/
+func statusOf(state string) market.OrderStatus {
+	switch state {
+	case "submitted":
+		return market.New
+	case "partial_filled":
+		return market.Partial
+	case "partial_canceled":
+		return market.Canceled
+	case "pending_cancel":
+		return market.Canceling
+	case "canceled":
+		return market.Canceled
+	case "filled":
+		return market.Filled
+	}
+	return market.Other
+}
+
+/*Cancel All Order of All Pairs*/
 func (e *Fcoin) CancelAllOrder() error {
-	return nil
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+/*Cancel All Orders of the Pair, nil pair: all pairs
+The API doesn't have cancel all, list the open orders then cancel them (exchange.CancelOpenOrders)*/
+func (e *Fcoin) CancelAllOrders(pair *pair.Pair) (*exchange.CancelReport, error) {
+	return exchange.CancelOpenOrders(e, pair)
 }
 
 /*Cancel an Order  --reference Cryptopia
@@ -341,7 +773,7 @@ Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
 Step 5: Change Order Status (order.Status = market.Canceling)*/
 func (e *Fcoin) CancelOrder(order *market.Order) error {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return fmt.Errorf("Fcoin API Key or Secret Key are nil.")
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := JsonResponse{}
@@ -351,9 +783,9 @@ func (e *Fcoin) CancelOrder(order *market.Order) error {
 
 	jsonCancelOrder := e.ApiKeyPost(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonCancelOrder), &jsonResponse); err != nil {
-		return fmt.Errorf("Fcoin CancelOrder Unmarshal Err: %v %v", err, jsonCancelOrder)
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonCancelOrder)
 	} else if jsonResponse.Status != 0 {
-		return fmt.Errorf("Fcoin CancelOrder failed:%v Message:%v", jsonResponse.Status, jsonResponse.Message)
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrRejected, "failed:%v Message:%v", jsonResponse.Status, jsonResponse.Message)
 	}
 
 	order.Status = market.Canceling
@@ -361,15 +793,17 @@ func (e *Fcoin) CancelOrder(order *market.Order) error {
 	return nil
 }
 
-/*Place a limit Sell Order  --reference Cryptopia
+/*Place an Order  --reference Cryptopia
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
-func (e *Fcoin) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+Step 2: Check the Request is supported by GetCapabilities (exchange.CheckOrderRequest)
+Step 3: Map Side, Order Type, Time in Force and Post Only to API Params
+Step 4: Call ApiKey Function & Create a new Order*/
+func (e *Fcoin) PlaceOrder(request *market.OrderRequest) (*market.Order, error) {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return nil, fmt.Errorf("Fcoin API Key or Secret Key are nil.")
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if err := exchange.CheckOrderRequest(e.GetName(), e.GetCapabilities(), request); err != nil {
+		return nil, err
 	}
 
 	jsonResponse := JsonResponse{}
@@ -377,83 +811,58 @@ func (e *Fcoin) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Orde
 	strRequest := "/orders"
 
 	mapParams := make(map[string]string)
-	mapParams["symbol"] = strings.ToLower(e.GetPairCode(pair))
-	mapParams["type"] = "limit"
-	mapParams["side"] = "sell"
-	mapParams["price"] = fmt.Sprint(rate)
-	mapParams["amount"] = fmt.Sprint(quantity)
+	mapParams["symbol"] = strings.ToLower(e.GetPairCode(request.Pair))
+	mapParams["side"] = strings.ToLower(string(request.Side))
+	mapParams["amount"] = fmt.Sprint(request.Quantity)
+	switch request.Type {
+	case market.LimitOrder:
+		mapParams["type"] = "limit"
+		mapParams["price"] = fmt.Sprint(request.Rate)
+	case market.MarketOrder:
+		mapParams["type"] = "market"
+		if request.Side == market.Buy { // the amount of market buy is the base coin to spend
+			if request.Rate <= 0 {
+				return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrRejected, "market buy needs Rate to compute the %s amount", request.Pair.Base.Code)
+			}
+			mapParams["amount"] = fmt.Sprint(request.Quantity * request.Rate)
+		}
+	}
 
 	jsonPlaceReturn := e.ApiKeyPost(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonPlaceReturn), &jsonResponse); err != nil {
-		return nil, fmt.Errorf("Fcoin LimitSell Unmarshal Err: %v %v", err, jsonPlaceReturn)
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonPlaceReturn)
 	} else if jsonResponse.Status != 0 {
-		return nil, fmt.Errorf("Fcoin LimitSell failed:%v Message:%v", jsonResponse.Status, jsonResponse.Message)
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrRejected, "%v Message:%v", jsonResponse.Status, jsonResponse.Message)
 	}
 
 	if err := json.Unmarshal(jsonResponse.Data, &placeOrder); err != nil {
-		return nil, fmt.Errorf("Fcoin LimitSell Data Unmarshal Err: %v %v", err, jsonResponse.Data)
-	} else {
-		order := &market.Order{
-			OrderID:      placeOrder,
-			Pair:         pair,
-			Rate:         rate,
-			Quantity:     quantity,
-			Side:         "Sell",
-			Status:       market.New,
-			JsonResponse: jsonPlaceReturn,
-		}
-		return order, nil
-	}
-
-	return nil, nil
-}
-
-/*Place a limit Buy Order  --reference Cryptopia
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
-func (e *Fcoin) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
-
-	if e.API_KEY == "" || e.API_SECRET == "" {
-		return nil, fmt.Errorf("Fcoin API Key or Secret Key are nil.")
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Data)
 	}
 
-	jsonResponse := JsonResponse{}
-	var placeOrder string
-	strRequest := "/orders"
-
-	mapParams := make(map[string]string)
-	mapParams["symbol"] = strings.ToLower(e.GetPairCode(pair))
-	mapParams["type"] = "limit"
-	mapParams["side"] = "buy"
-	mapParams["price"] = fmt.Sprint(rate)
-	mapParams["amount"] = fmt.Sprint(quantity)
-
-	jsonPlaceReturn := e.ApiKeyPost(mapParams, strRequest)
-	if err := json.Unmarshal([]byte(jsonPlaceReturn), &jsonResponse); err != nil {
-		return nil, fmt.Errorf("Fcoin LimitBuy Unmarshal Err: %v %v", err, jsonPlaceReturn)
-	} else if jsonResponse.Status != 0 {
-		return nil, fmt.Errorf("Fcoin LimitBuy failed:%v Message:%v", jsonResponse.Status, jsonResponse.Message)
+	order := &market.Order{
+		OrderID:       placeOrder,
+		ClientOrderID: request.ClientOrderID,
+		Pair:          request.Pair,
+		Rate:          request.Rate,
+		Quantity:      request.Quantity,
+		Side:          string(request.Side),
+		Status:        market.New,
+		JsonResponse:  jsonPlaceReturn,
 	}
+	e.clientOrderMap.Set(request.ClientOrderID, order)
+	return order, nil
+}
 
-	if err := json.Unmarshal(jsonResponse.Data, &placeOrder); err != nil {
-		return nil, fmt.Errorf("Fcoin LimitBuy Data Unmarshal Err: %v %v", err, jsonResponse.Data)
-	} else {
-		order := &market.Order{
-			OrderID:      placeOrder,
-			Pair:         pair,
-			Rate:         rate,
-			Quantity:     quantity,
-			Side:         "Buy",
-			Status:       market.New,
-			JsonResponse: jsonPlaceReturn,
-		}
-		return order, nil
-	}
+/*Place a limit Sell Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Fcoin) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Sell, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
 
-	return nil, nil
+/*Place a limit Buy Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Fcoin) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Buy, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
 }
 
 /*************** Signature Http Request ***************/
@@ -463,10 +872,10 @@ Step 2: Create mapParams Depend on API Signature request
 Step 3: Add HttpGetRequest below strUrl if API has different requests*/
 func (e *Fcoin) ApiKeyGet(mapParams map[string]string, strRequestPath string) string {
 	strMethod := "GET"
-	timestamp := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
+	timestamp := strconv.FormatInt(e.clock.Now().UnixNano()/1e6, 10)
 
 	//Signature Request Params
-	strUrl := API_URL + strRequestPath
+	strUrl := e.API_URL + strRequestPath
 
 	var strRequestUrl string
 	if nil == mapParams {
@@ -513,10 +922,10 @@ Step 2: Create mapParams Depend on API Signature request
 Step 3: Add HttpGetRequest below strUrl if API has different requests*/
 func (e *Fcoin) ApiKeyPost(mapParams map[string]string, strRequestPath string) string {
 	strMethod := "POST"
-	timestamp := strconv.FormatInt(time.Now().UnixNano()/1e6, 10) //time.Now().UTC().Format("2006-01-02T15:04:05")
+	timestamp := strconv.FormatInt(e.clock.Now().UnixNano()/1e6, 10) //time.Now().UTC().Format("2006-01-02T15:04:05")
 
 	//Signature Request Params
-	strUrl := API_URL + strRequestPath
+	strUrl := e.API_URL + strRequestPath
 
 	jsonParams := ""
 	bytesParams, err := json.Marshal(mapParams)
@@ -564,7 +973,7 @@ func (e *Fcoin) ApiKeyPost(mapParams map[string]string, strRequestPath string) s
 	return string(body)
 }
 
-//Signature加密
+// Signature加密
 func ComputeHmac1(strMessage string, strSecret string) string {
 	key := []byte(strSecret)
 	h := hmac.New(sha1.New, key)
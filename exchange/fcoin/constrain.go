@@ -4,6 +4,7 @@ import (
 	//	"strconv"
 	"math"
 	"strings"
+	"sync"
 
 	"../../coin"
 	"../../exchange"
@@ -18,8 +19,11 @@ Step 4: Get Each Symbol
 Step 5: Identify Base & Target and Get Pair
 Step 6: Add LotSize  - float64
 Step 7: Add TickSize  - float64*/
-func (e *Fcoin) UpdatePairConstrain() {
-	pairData := GetFcoinPair()
+func (e *Fcoin) UpdatePairConstrain() error {
+	pairData := e.GetFcoinPair()
+	if pairData == nil {
+		return exchange.Errorf(e.GetName(), "UpdatePairConstrain", exchange.ErrUnknown, "symbols are not available")
+	}
 
 	pairConstrainMap := make(map[*pair.Pair]*exchange.PairConstrain)
 	//If Exchange doesn't provide constrain info, Leave blank
@@ -37,7 +41,7 @@ func (e *Fcoin) UpdatePairConstrain() {
 
 		pairConstrainMap[pairConstrain.Pair] = pairConstrain
 	}
-
+	return nil
 }
 
 /*Update Coins Constrain  --If API provide those information
@@ -50,8 +54,8 @@ Step 6: Add TxFee - float64
 Step 7: Add Withdraw Status - Bool
 Step 7: Add Deposite Status - Bool
 Step 7: Add Confirmation - Int*/
-func (e *Fcoin) UpdateCoinConstrain() {
-	/* coinInfo := GetFcoinCoin()
+func (e *Fcoin) UpdateCoinConstrain() error {
+	/* coinInfo := e.GetFcoinCoin()
 	//If Exchange doesn't provide constrain info, Leave blank
 	//Modify according to type and structure
 	for _, data := range coinInfo {
@@ -66,23 +70,27 @@ func (e *Fcoin) UpdateCoinConstrain() {
 			log.Printf("Fcoin UpdateCoinConstrain Marshal err: %s\n", err)
 		}
 		if coinConstrain.Coin != nil {
-			key := fmt.Sprintf("%s-Constrain-%s", exchange.FCOIN, coinConstrain.Coin.Code)
+			key := fmt.Sprintf("%s-Constrain-%s", NAME, coinConstrain.Coin.Code)
 			err = e.GetMakerDB().Set(key, string(l))
 			if err != nil {
 				log.Printf("Fcoin UpdateCoinConstrain Set DB err: %s\n", err)
 			}
 		}
 	} */
+	return exchange.Errorf(e.GetName(), "UpdateCoinConstrain", exchange.ErrUnsupported, "coin constrain is not provided by the API")
 }
 
 /***************************************************/
-var symbolMap = make(map[string]string)
+var symbolMap = make(map[string]string) //read only after FixSymbol
+var symbolOnce sync.Once
 
 /*Standard Coin Code
 Coin has same code but it is different currency
 Fix the coin code to bitontop standard*/
 func (e *Fcoin) FixSymbol() { //key: exchange specific    val： bitontop standard
-	symbolMap["-"] = ""
+	symbolOnce.Do(func() {
+		symbolMap["-"] = ""
+	})
 }
 
 /*Get Exchange Standard Code*/
@@ -2,11 +2,11 @@ package fcoin
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	cmap "github.com/orcaman/concurrent-map"
 
@@ -15,6 +15,7 @@ import (
 	"../../exchange"
 	"../../market"
 	"../../pair"
+	"../../user"
 )
 
 type Fcoin struct {
@@ -25,65 +26,114 @@ type Fcoin struct {
 	RedisDB      int
 	API_KEY      string
 	API_SECRET   string
+	API_URL      string //the REST endpoint, API_URL by default
 	WalletStatus []exchange.Wallet_Stat
-}
 
-var pairList = make([]*pair.Pair, 0) //the last num is the number of pairs on this exchange
-var coinList = make([]*coin.Coin, 0)
-var balanceMap cmap.ConcurrentMap
+	pairList   []*pair.Pair //the pairs on this exchange
+	coinList   []*coin.Coin
+	balanceMap cmap.ConcurrentMap
+	userMap    cmap.ConcurrentMap //credentials: *Fcoin, the instances of other users
+	clock      *exchange.Clock    //server time of the signed requests, shared with the user instances
+
+	clientOrderMap cmap.ConcurrentMap //ClientOrderID: *market.Order, Fcoin doesn't keep client order id, removed when the order is closed
+}
 
-// var balanceMap = make(map[*coin.Coin]float64)
+// the name of Fcoin in the registry
+const NAME exchange.ExchangeName = "FCOIN"
 
-var instance *Fcoin
-var once sync.Once
+func init() {
+	exchange.Register(NAME, func(config *exchange.Config) (exchange.Exchange, error) {
+		return CreateFcoin(config), nil
+	})
+}
 
 /***************************************************/
 /*Create New Exchange
-Add Exchange Name(Capital Letter) to meta.go
+Name the Exchange (Capital Letter) in NAME
+Register the Create function in init(), the exchange is then available in ExchangeManager
 Name: Exchange Name
 Website: Exchange Website URL
 MakerDB: Exchange Redis Server & Number(Import from Config)
 Execute Coins & Pairs Initial
 API_KEY: Import from Config
 API_SECRET: Import from Config
+API_URL: Import from Config, empty: API_URL
 WalletStatus: If API doesn't provide Wallet Status, import data from Postgres*/
 func CreateFcoin(config *exchange.Config) *Fcoin {
-	once.Do(func() {
-		instance = &Fcoin{}
-		instance.Name = "Fcoin"
-		instance.Website = "https://www.fcoin.com/"
-
-		instance.RedisManager = db.CreateRedisManager()
-		instance.RedisServer = config.RedisServer
-		instance.RedisDB = config.RedisDB
-
-		instance.API_KEY = config.API_KEY
-		instance.API_SECRET = config.API_SECRET
+	instance := &Fcoin{}
+	instance.Name = "Fcoin"
+	instance.Website = "https://www.fcoin.com/"
+
+	instance.RedisManager = db.SharedRedisManager(string(NAME))
+	instance.RedisServer = config.RedisServer
+	instance.RedisDB = config.RedisDB
+
+	instance.API_KEY = config.API_KEY
+	instance.API_SECRET = config.API_SECRET
+	instance.API_URL = API_URL
+	if config.API_URL != "" {
+		instance.API_URL = strings.TrimSuffix(config.API_URL, "/")
+	}
 
-		instance.WalletStatus = config.WalletStatus
+	instance.WalletStatus = config.WalletStatus
 
-		if balanceMap == nil {
-			balanceMap = cmap.New()
-		}
+	instance.pairList = make([]*pair.Pair, 0)
+	instance.coinList = make([]*coin.Coin, 0)
+	instance.balanceMap = cmap.New()
+	instance.userMap = cmap.New()
+	instance.clock = exchange.NewClock(NAME, instance.GetFcoinTime)
+	instance.clientOrderMap = cmap.New()
 
-		instance.FixSymbol()
-		instance.InitCoins()
-		instance.InitPairs()
-	})
+	instance.FixSymbol()
+	instance.InitCoins()
+	instance.InitPairs()
 	return instance
 }
 
 func (e *Fcoin) GetMakerDB() *db.Redis {
-	key := string(exchange.FCOIN)
+	key := fmt.Sprintf("%s-%s-%d", NAME, e.RedisServer, e.RedisDB) // the instances share the manager
 	d := e.RedisManager.Get(key)
 	if d == nil {
 		d = db.CreateRedis()
-		d.Init(instance.RedisServer, instance.RedisDB)
+		d.Init(e.RedisServer, e.RedisDB)
 		e.RedisManager.Add(key, d)
 	}
 	return d
 }
 
+/*Get the Instance of Another User
+The user instance shares the pairs, coins and Redis of this instance, but has its own API Key and balances*/
+func (e *Fcoin) ForUser(u *user.User) *Fcoin {
+	key := u.CredentialKey()
+	if tmp, ok := e.userMap.Get(key); ok {
+		return tmp.(*Fcoin)
+	}
+
+	uInstance := &Fcoin{}
+	uInstance.Name = e.Name
+	uInstance.Website = e.Website
+	uInstance.RedisManager = e.RedisManager
+	uInstance.RedisServer = e.RedisServer
+	uInstance.RedisDB = e.RedisDB
+	uInstance.API_KEY = u.API_KEY
+	uInstance.API_SECRET = u.API_SECRET
+	uInstance.API_URL = e.API_URL
+	uInstance.WalletStatus = e.WalletStatus
+
+	uInstance.pairList = e.pairList
+	uInstance.coinList = e.coinList
+	uInstance.balanceMap = cmap.New()
+	uInstance.userMap = e.userMap
+	uInstance.clock = e.clock
+	uInstance.clientOrderMap = cmap.New()
+
+	if !e.userMap.SetIfAbsent(key, uInstance) {
+		tmp, _ := e.userMap.Get(key)
+		return tmp.(*Fcoin)
+	}
+	return uInstance
+}
+
 /*Initial the Pairs of Exchange
 Step 1: Change Instance Name (e *<exchange Instance Name>)
 Step 2: Get API Data
@@ -94,7 +144,7 @@ Step 6: Get Coin
 Step 7: Add Pair to Exchange Pairs Arrary*/
 func (e *Fcoin) InitPairs() {
 
-	pairData := GetFcoinPair()
+	pairData := e.GetFcoinPair()
 	if pairData != nil {
 		//		fmt.Printf("pair not nil: %v ---", pairData.Data)
 		for _, symbol := range *pairData {
@@ -103,7 +153,7 @@ func (e *Fcoin) InitPairs() {
 			target := coin.GetCoin(e.GetCode(symbol.BaseCurrency))
 			if base != nil && target != nil {
 				pair := pair.GetPair(base, target)
-				pairList = append(pairList, pair)
+				e.pairList = append(e.pairList, pair)
 			}
 		}
 	}
@@ -126,7 +176,7 @@ Step 5: if the coin doesn't exist in coinmap, Add the coin in coinmap
 	- Blocktime: the time of the block created
 	- Blocklast: the last block of the chain*/
 func (e *Fcoin) InitCoins() {
-	coinInfo := GetFcoinCoin()
+	coinInfo := e.GetFcoinCoin()
 
 	if coinInfo != nil {
 		for _, data := range coinInfo {
@@ -138,7 +188,7 @@ func (e *Fcoin) InitCoins() {
 				//				c.Name = data.FullName
 				coin.AddCoin(c)
 			}
-			coinList = append(coinList, c)
+			e.coinList = append(e.coinList, c)
 		}
 	}
 }
@@ -152,7 +202,7 @@ func (e *Fcoin) UpdateMaker(pair *pair.Pair, maker *market.Maker) error {
 	if err != nil {
 		return err
 	}
-	key := fmt.Sprintf("%s-%s", exchange.FCOIN, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	return e.GetMakerDB().Set(key, string(m))
 }
 
@@ -161,10 +211,10 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>
 Step 3: Change Error Exchange Name    <exchange Name> does not have the pair*/
 func (e *Fcoin) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
-	key := fmt.Sprintf("%s-%s", exchange.FCOIN, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Fcoin does not have the pair : %v", pair.Name))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrNotFound, "does not have the pair : %v", pair.Name)
 	}
 	if str, ok := val.(string); ok {
 
@@ -172,7 +222,7 @@ func (e *Fcoin) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
 			return nil, err
 		}
 	} else {
-		return nil, errors.New(fmt.Sprintf("Fcoin GetMaker Key: %v can't convert to string: %v", key, val))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrUnknown, "Key: %v can't convert to string: %v", key, val)
 	}
 	return maker, err
 }
@@ -183,7 +233,7 @@ func (e *Fcoin) SetCoins() error {
 }
 
 func (e *Fcoin) GetCoins() []*coin.Coin {
-	return coinList
+	return e.coinList
 }
 
 func (e *Fcoin) SetPairs() error {
@@ -193,12 +243,17 @@ func (e *Fcoin) SetPairs() error {
 /*Get Exchange All Pairs
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Fcoin) GetPairs() []*pair.Pair {
-	return pairList
+	return e.pairList
 }
 
 /*Get Exchange A Pair
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Fcoin) GetPair(key string) *pair.Pair {
+	for _, p := range e.pairList {
+		if p.Name == key {
+			return p
+		}
+	}
 	return nil
 }
 
@@ -226,14 +281,35 @@ func (e *Fcoin) HasPair(pair *pair.Pair) bool {
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>*/
 func (e *Fcoin) GetName() exchange.ExchangeName {
-	return exchange.FCOIN
+	return NAME
+}
+
+// the offset of the server time measured by the signed requests
+func (e *Fcoin) GetClockSkew() time.Duration {
+	return e.clock.Skew()
 }
 
 /*Get Exchange Taker Fee
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Return base on the taker fee that exchange provides*/
 func (e *Fcoin) GetFee(pair *pair.Pair) float64 { // Taker fee for each coin
-	return 0.002 //Taker Fee: 0.2%
+	return e.GetTradeFee(pair).Taker
+}
+
+/*Get Exchange Maker & Taker Fee
+The API doesn't provide the fee of the account, 0.2% for both*/
+func (e *Fcoin) GetTradeFee(pair *pair.Pair) *exchange.TradeFee {
+	return &exchange.TradeFee{
+		Pair:   pair,
+		Maker:  0.002,
+		Taker:  0.002,
+		Source: exchange.SourceStatic,
+	}
+}
+
+// the fee is static, nothing to update
+func (e *Fcoin) UpdateFees() error {
+	return nil
 }
 
 /*Get Pair LotSize(Quantity)
@@ -247,7 +323,7 @@ Step 2:
 	Condition 2: API doesn't provides this information
 		return Minimum value*/
 func (e *Fcoin) GetLotSize(pair *pair.Pair) float64 {
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.FCOIN, pair.Name)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Fcoin GetLotSize Key: %v Err: %s\n", key, err)
@@ -277,7 +353,7 @@ Step 2:
 	Condition 2: API doesn't provides this information
 		return Minimum value*/
 func (e *Fcoin) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for price
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.FCOIN, pair.Name)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Fcoin GetPriceFilter Key: %v Err: %s\n", key, err)
@@ -297,29 +373,59 @@ func (e *Fcoin) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for price
 	//	return 0.00000001
 }
 
-func (e *Fcoin) GetConstrainFetchMethod(pair *pair.Pair) *exchange.ConstrainFetchMethod {
+func (e *Fcoin) GetCapabilities() *exchange.Capabilities {
 	constrainFetchMethod := &exchange.ConstrainFetchMethod{}
-	constrainFetchMethod.Fee = true
+	constrainFetchMethod.Fee = false
 	constrainFetchMethod.LotSize = true
 	constrainFetchMethod.TickSize = true
 	constrainFetchMethod.TxFee = false
 	constrainFetchMethod.Withdraw = false
 	constrainFetchMethod.Deposit = false
 	constrainFetchMethod.Confirmation = false
-	return constrainFetchMethod
+
+	capabilities := &exchange.Capabilities{}
+	capabilities.OrderTypes = []market.OrderType{market.LimitOrder, market.MarketOrder}
+	capabilities.TimeInForce = []market.TimeInForce{market.GTC}
+	capabilities.PostOnly = false
+	capabilities.ClientOrderID = false
+	capabilities.CancelAll = true
+	capabilities.ListOrders = true
+	capabilities.Withdraw = false
+	capabilities.DepositAddress = false
+	capabilities.TransferHistory = false
+	capabilities.WebSocketMarketData = true
+	capabilities.WebSocketUserData = false
+	capabilities.BatchOrderBooks = false
+	capabilities.FeeSource = exchange.SourceStatic
+	capabilities.ConstrainSource = constrainFetchMethod
+	return capabilities
 }
 
 /*************** coins on the exchanges ***************/
 /*Get Coin Balance
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Fcoin) GetBalance(coin *coin.Coin) float64 {
-	if tmp, ok := balanceMap.Get(coin.Code); ok {
-		return tmp.(float64)
+	if tmp, ok := e.balanceMap.Get(coin.Code); ok {
+		return tmp.(*market.Balance).Available
 	} else {
 		return 0.0
 	}
 }
 
+/*Get the Balances of All Coins
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Fcoin) GetBalances() []*market.Balance {
+	balances := []*market.Balance{}
+	for _, tmp := range e.balanceMap.Items() {
+		balance := *tmp.(*market.Balance)
+		balances = append(balances, &balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Coin.Code < balances[j].Coin.Code
+	})
+	return balances
+}
+
 /*Get Coin Withdraw Fee
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2:
@@ -331,7 +437,7 @@ Step 2:
 	Condition 2: API doesn't provides this information
 		return Minimum value*/
 func (e *Fcoin) GetTxFee(coin *coin.Coin) float64 { // Withdraw Fee
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.FCOIN, coin.Code)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, coin.Code)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Fcoin GetTxFee Key: %v Err: %s\n", key, err)
@@ -361,7 +467,7 @@ Step 2:
 	Condition 2: API doesn't provides this information
 		return 0*/
 func (e *Fcoin) GetConfirmation(coin *coin.Coin) int { // deposit confirmations
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.CRYPTOPIA, coin.Code)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, coin.Code)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Fcoin GetConfirmation Key: %v Err: %s\n", key, err)
@@ -42,7 +42,9 @@ type AccountBalances []struct {
 	Balance   string `json:"balance"`
 }
 
-type TradeHistory []struct {
+type TradeHistory []OrderData
+
+type OrderData struct {
 	ID            string `json:"id"`
 	Symbol        string `json:"symbol"`
 	Type          string `json:"type"`
@@ -56,3 +58,47 @@ type TradeHistory []struct {
 	CreatedAt     int    `json:"created_at"`
 	Source        string `json:"source"`
 }
+
+type MatchResult struct {
+	Price        string `json:"price"`
+	FillFees     string `json:"fill_fees"`
+	FilledAmount string `json:"filled_amount"`
+	Side         string `json:"side"`
+	Type         string `json:"type"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+type TickerData struct {
+	Type   string    `json:"type"`
+	Seq    int64     `json:"seq"`
+	Ticker []float64 `json:"ticker"`
+}
+
+type MarketTrade struct {
+	ID     int64   `json:"id"`
+	Amount float64 `json:"amount"`
+	Ts     int64   `json:"ts"`
+	Side   string  `json:"side"`
+	Price  float64 `json:"price"`
+}
+
+type CandleData struct {
+	ID       int64   `json:"id"`
+	Seq      int64   `json:"seq"`
+	Open     float64 `json:"open"`
+	Close    float64 `json:"close"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Count    int     `json:"count"`
+	BaseVol  float64 `json:"base_vol"`
+	QuoteVol float64 `json:"quote_vol"`
+}
+
+// the depth of the WebSocket, the levels are flattened [price, amount, price, amount, ...]
+type WsDepth struct {
+	Type string    `json:"type"`
+	Ts   int64     `json:"ts"`
+	Seq  int64     `json:"seq"`
+	Bids []float64 `json:"bids"`
+	Asks []float64 `json:"asks"`
+}
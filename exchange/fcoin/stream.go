@@ -0,0 +1,85 @@
+package fcoin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"../../exchange"
+	"../../market"
+	"../../pair"
+)
+
+/*The WebSocket Endpoint URL*/
+const (
+	WS_URL string = "wss://api.fcoin.com/v2/ws"
+
+	wsHeartbeat = 20 * time.Second // Fcoin closes the connection without ping
+)
+
+/*Stream the Order Books by WebSocket
+Step 1: Subscribe the depth.L20 topic of the pairs
+Step 2: Each message is the whole book of 20 levels with seq, the book drops the messages out of order*/
+func (e *Fcoin) StreamOrderBook(pairs []*pair.Pair, stop <-chan struct{}) (<-chan *market.Maker, error) {
+	if len(pairs) == 0 {
+		return nil, exchange.Errorf(e.GetName(), "StreamOrderBook", exchange.ErrRejected, "no pair to stream")
+	}
+
+	topics := []string{}
+	books := make(map[string]*exchange.OrderBookEngine) //topic: book, read only
+	for _, p := range pairs {
+		topic := fmt.Sprintf("depth.L20.%s", strings.ToLower(e.GetPairCode(p)))
+		topics = append(topics, topic)
+		books[topic] = exchange.NewOrderBookEngine(e.GetName(), p, 0, e.OrderBook)
+	}
+
+	out := make(chan *market.Maker, exchange.StreamBuffer)
+	client := exchange.NewWsClient(e.GetName(), WS_URL, func(c *exchange.WsClient, message []byte) {
+		depth := WsDepth{}
+		if err := json.Unmarshal(message, &depth); err != nil {
+			log.Printf("Fcoin StreamOrderBook Unmarshal Err: %v %s", err, message)
+			return
+		}
+		if book, ok := books[depth.Type]; ok {
+			book.Apply(toBookUpdate(&depth))
+			exchange.OfferMaker(out, book.Maker())
+		}
+	})
+	client.Heartbeat = wsHeartbeat
+	client.Ping = func() interface{} {
+		return map[string]interface{}{
+			"cmd":  "ping",
+			"args": []int64{time.Now().UnixNano() / 1e6},
+			"id":   "ping",
+		}
+	}
+	client.Subscribe(map[string]interface{}{
+		"cmd":  "sub",
+		"args": topics,
+		"id":   "depth",
+	})
+	client.Start()
+
+	go func() {
+		<-stop
+		client.Close()
+		close(out)
+	}()
+	return out, nil
+}
+
+func toBookUpdate(depth *WsDepth) *exchange.BookUpdate {
+	update := &exchange.BookUpdate{}
+	update.Snapshot = true
+	update.LastID = int(depth.Seq)
+	update.Timestamp = float64(depth.Ts)
+	for i := 0; i+1 < len(depth.Bids); i += 2 {
+		update.Bids = append(update.Bids, market.Order{Rate: depth.Bids[i], Quantity: depth.Bids[i+1]})
+	}
+	for i := 0; i+1 < len(depth.Asks); i += 2 {
+		update.Asks = append(update.Asks, market.Order{Rate: depth.Asks[i], Quantity: depth.Asks[i+1]})
+	}
+	return update
+}
@@ -0,0 +1,45 @@
+package exchange
+
+import (
+	"sort"
+
+	"../pair"
+)
+
+// the fee rate from the 30 day trade volume on
+type FeeTier struct {
+	Volume float64
+	Rate   float64
+}
+
+// the maker and taker fee rate of a pair, eg: 0.0026 is 0.26%
+type TradeFee struct {
+	Pair      *pair.Pair
+	Maker     float64
+	Taker     float64
+	Source    Source // SourceAPI: the rate of the account, SourceStatic: the fee schedule of the exchange
+	Timestamp int64  // in milliseconds, when the fee was refreshed
+}
+
+/*The Fee Rate of the Trade Volume
+The rate of the highest tier the volume reaches, the rate of the lowest tier if it reaches none.
+-1 if there is no tier*/
+func TierRate(tiers []FeeTier, volume float64) float64 {
+	if len(tiers) == 0 {
+		return -1
+	}
+
+	sorted := make([]FeeTier, len(tiers))
+	copy(sorted, tiers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Volume < sorted[j].Volume
+	})
+
+	rate := sorted[0].Rate
+	for _, tier := range sorted {
+		if volume >= tier.Volume {
+			rate = tier.Rate
+		}
+	}
+	return rate
+}
@@ -0,0 +1,1381 @@
+package huobi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"../../coin"
+	"../../exchange"
+	"../../market"
+	"../../pair"
+	"../../user"
+)
+
+/*The Base Endpoint URL*/
+const (
+	API_URL string = "https://api.huobi.pro"
+
+	batchCancelSize = 50 // the order ids in one batchcancel request at most
+	feeSymbolsSize  = 10 // the symbols in one transact-fee-rate request at most
+)
+
+/*API Base Knowledge
+Path: API function. Usually after the base endpoint URL
+Method:
+	Get - Call a URL, API return a response
+	Post - Call a URL & send a request, API return a response
+Public API:
+	It doesn't need authorization/signature , can be called by browser to get response.
+	using exchange.HttpGetRequest/exchange.HttpPostRequest
+Private API:
+	Authorization/Signature is requried. The signature request should look at Exchange API Document.
+	using ApiKeyGet/ApiKeyPost
+Response:
+	Response is a json structure.
+	Copy the json to https://transform.now.sh/json-to-go/ convert to go Struct.
+	Add the go Struct to model.go
+
+ex. Get /market/depth
+Get - Method
+/market/depth - Path*/
+
+/*************** Public API ***************/
+/*Get Pair Market Depth
+Step 1: Get Exchange Pair Code ex. symbol := e.GetPairCode(p)
+Step 2: Get the depth without aggregation (step0), 150 levels of each side
+Step 3: Convert the response to Standard Maker struct, version is the sequence of the depth*/
+func (e *Huobi) OrderBook(p *pair.Pair) (*market.Maker, error) {
+	jsonResponse := JsonResponse{}
+	orderBook := OrderBook{}
+	symbol := e.GetPairCode(p)
+
+	strRequestUrl := "/market/depth"
+	strUrl := e.API_URL + strRequestUrl
+
+	maker := &market.Maker{}
+	maker.WorkerIP = exchange.GetExternalIP()
+	maker.BeforeTimestamp = float64(time.Now().UnixNano() / 1e6)
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = symbol
+	mapParams["type"] = "step0"
+
+	jsonOrderbook := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonOrderbook), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonOrderbook)
+	} else if err := e.responseErr("OrderBook", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Tick, &orderBook); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrUnknown, "json Unmarshal error: %v %s", err, jsonResponse.Tick)
+	}
+	maker.AfterTimestamp = float64(time.Now().UnixNano() / 1e6)
+
+	maker.Bids = toLevels(orderBook.Bids)
+	maker.Asks = toLevels(orderBook.Asks)
+	maker.LastUpdateID = int(orderBook.Version)
+	maker.Timestamp = float64(orderBook.Ts)
+	return maker, nil
+}
+
+// the levels of the depth: [[price, amount], ...]
+func toLevels(data [][]float64) []market.Order {
+	levels := []market.Order{}
+	for _, d := range data {
+		if len(d) < 2 {
+			continue
+		}
+		levels = append(levels, market.Order{Rate: d[0], Quantity: d[1]})
+	}
+	return levels
+}
+
+/*Get the Ticker of the Pair
+/market/detail/merged: the 24h detail with the best bid & ask, amount is in the target, vol in the base*/
+func (e *Huobi) Ticker(p *pair.Pair) (*market.Ticker, error) {
+	jsonResponse := JsonResponse{}
+	tickerData := TickerData{}
+
+	strRequestUrl := "/market/detail/merged"
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTickerReturn)
+	} else if err := e.responseErr("Ticker", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Tick, &tickerData); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrUnknown, "json Unmarshal error: %v %s", err, jsonResponse.Tick)
+	}
+
+	ticker := &market.Ticker{}
+	ticker.Pair = p
+	if len(tickerData.Bid) > 0 {
+		ticker.Bid = tickerData.Bid[0]
+	}
+	if len(tickerData.Ask) > 0 {
+		ticker.Ask = tickerData.Ask[0]
+	}
+	ticker.Last = tickerData.Close
+	ticker.High = tickerData.High
+	ticker.Low = tickerData.Low
+	ticker.Volume = tickerData.Amount
+	ticker.QuoteVolume = tickerData.Vol
+	ticker.Timestamp = jsonResponse.Ts
+	return ticker, nil
+}
+
+/*Get the Tickers of All the Pairs
+/market/tickers returns the 24h detail of all the symbols*/
+func (e *Huobi) Tickers() ([]*market.Ticker, error) {
+	jsonResponse := JsonResponse{}
+	tickersData := TickersData{}
+
+	strRequestUrl := "/market/tickers"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Tickers", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTickerReturn)
+	} else if err := e.responseErr("Tickers", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &tickersData); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Tickers", exchange.ErrUnknown, "json Unmarshal error: %v %s", err, jsonResponse.Data)
+	}
+
+	tickers := []*market.Ticker{}
+	for _, data := range tickersData {
+		p := e.getPairByCode(data.Symbol)
+		if p == nil {
+			continue
+		}
+		ticker := &market.Ticker{}
+		ticker.Pair = p
+		ticker.Bid = data.Bid
+		ticker.Ask = data.Ask
+		ticker.Last = data.Close
+		ticker.High = data.High
+		ticker.Low = data.Low
+		ticker.Volume = data.Amount
+		ticker.QuoteVolume = data.Vol
+		ticker.Timestamp = jsonResponse.Ts
+		tickers = append(tickers, ticker)
+	}
+	sort.Slice(tickers, func(i, j int) bool {
+		return tickers[i].Pair.Name < tickers[j].Pair.Name
+	})
+	return tickers, nil
+}
+
+/*Get the Trades of the Market Since the Time
+/market/history/trade has no time param, it returns the latest 2000 trades at most,
+the trades before since are dropped. direction is the side of the aggressor*/
+func (e *Huobi) RecentTrades(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	jsonResponse := JsonResponse{}
+	tradesData := TradesData{}
+
+	strRequestUrl := "/market/history/trade"
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+	mapParams["size"] = "2000"
+
+	jsonTradesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonTradesReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonTradesReturn)
+	} else if err := e.responseErr("RecentTrades", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &tradesData); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrUnknown, "json Unmarshal error: %v %s", err, jsonResponse.Data)
+	}
+
+	sinceMs := since.UnixNano() / 1e6
+	trades := []*market.Trade{}
+	for _, data := range tradesData {
+		for _, t := range data.Data {
+			if t.Ts < sinceMs {
+				continue
+			}
+			trade := &market.Trade{}
+			trade.TradeID = strconv.FormatInt(t.TradeID, 10)
+			trade.Pair = p
+			trade.Rate = t.Price
+			trade.Quantity = t.Amount
+			trade.Timestamp = t.Ts
+			if t.Direction == "sell" {
+				trade.Side = market.Sell
+			} else {
+				trade.Side = market.Buy
+			}
+			trade.Liquidity = market.LiquidityTaker
+			trades = append(trades, trade)
+		}
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+// the periods of /market/history/kline
+var klinePeriods = map[time.Duration]string{
+	time.Minute:        "1min",
+	5 * time.Minute:    "5min",
+	15 * time.Minute:   "15min",
+	30 * time.Minute:   "30min",
+	time.Hour:          "60min",
+	4 * time.Hour:      "4hour",
+	24 * time.Hour:     "1day",
+	7 * 24 * time.Hour: "1week",
+}
+
+/*Get the Candles of the Pair Since the Time
+/market/history/kline returns the latest 2000 candles at most, newest first, the candles before since are dropped.
+The periods it doesn't support are built from the recent trades
+id: the open time in seconds, amount: the volume in the target, vol: in the base*/
+func (e *Huobi) Candles(p *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error) {
+	period, ok := klinePeriods[interval]
+	if !ok {
+		return exchange.CandlesFromTrades(e, p, interval, since)
+	}
+
+	jsonResponse := JsonResponse{}
+	klinesData := KlinesData{}
+
+	strRequestUrl := "/market/history/kline"
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+	mapParams["period"] = period
+	mapParams["size"] = "2000"
+
+	jsonCandlesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonCandlesReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrNetwork, "json Unmarshal error: %v %v", err, jsonCandlesReturn)
+	} else if err := e.responseErr("Candles", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &klinesData); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrUnknown, "json Unmarshal error: %v %s", err, jsonResponse.Data)
+	}
+
+	sinceMs := since.Truncate(interval).UnixNano() / 1e6
+	candles := []*market.Candle{}
+	for _, k := range klinesData {
+		if k.ID*1000 < sinceMs {
+			continue
+		}
+		candle := &market.Candle{}
+		candle.Pair = p
+		candle.Timestamp = k.ID * 1000
+		candle.Open = k.Open
+		candle.High = k.High
+		candle.Low = k.Low
+		candle.Close = k.Close
+		candle.Volume = k.Amount
+		candle.QuoteVolume = k.Vol
+		candles = append(candles, candle)
+	}
+
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].Timestamp < candles[j].Timestamp
+	})
+	return candles, nil
+}
+
+/*Get Coins & Pairs Information
+The symbols with the base & quote currency, the state and the precisions of each symbol*/
+func (e *Huobi) getPairsData() (PairsData, error) {
+	jsonResponse := JsonResponse{}
+	pairsData := PairsData{}
+
+	strRequestUrl := "/v1/common/symbols"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonSymbolsReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonSymbolsReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getPairsData", exchange.ErrNetwork, "Get Pairs Json Unmarshal Err: %v %v", err, jsonSymbolsReturn)
+	} else if err := e.responseErr("GetPairs", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &pairsData); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getPairsData", exchange.ErrUnknown, "Get Pairs Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+	return pairsData, nil
+}
+
+/*Get the Chains of All Coins
+The withdraw fee, the withdraw & deposit status and the confirmations of each chain*/
+func (e *Huobi) getCurrencies() (CurrenciesData, error) {
+	jsonResponse := JsonResponse{}
+	currencies := CurrenciesData{}
+
+	strRequestUrl := "/v2/reference/currencies"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonCurrencyReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonCurrencyReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getCurrencies", exchange.ErrNetwork, "Get Coins Json Unmarshal Err: %v %v", err, jsonCurrencyReturn)
+	} else if err := e.responseErr("GetCoins", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &currencies); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getCurrencies", exchange.ErrUnknown, "Get Coins Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+	return currencies, nil
+}
+
+/*Get the Server Time
+Used by the clock of the signed requests*/
+func (e *Huobi) getServerTime() (time.Time, error) {
+	jsonResponse := JsonResponse{}
+	var serverTime int64
+
+	strRequestUrl := "/v1/common/timestamp"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTimeReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTimeReturn), &jsonResponse); err != nil {
+		return time.Time{}, exchange.Errorf(e.GetName(), "getServerTime", exchange.ErrNetwork, "Get Server Time Unmarshal Err: %v %v", err, jsonTimeReturn)
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &serverTime); err != nil || serverTime == 0 {
+		return time.Time{}, exchange.Errorf(e.GetName(), "getServerTime", exchange.ErrRejected, "Get Server Time Err: %v", jsonTimeReturn)
+	}
+	return time.Unix(0, serverTime*1e6), nil
+}
+
+// the order books are polled by REST, the gzip WebSocket of the API is not used
+func (e *Huobi) StreamOrderBook(pairs []*pair.Pair, stop <-chan struct{}) (<-chan *market.Maker, error) {
+	return exchange.PollOrderBook(e, pairs, 5*time.Second, stop), nil
+}
+
+/*************** Private API ***************/
+/*Get the Spot Account ID
+The orders and the balances are of an account, the spot account of the API Key is kept after the first request*/
+func (e *Huobi) getAccountID() (string, error) {
+	e.accountLock.Lock()
+	defer e.accountLock.Unlock()
+	if e.accountID != "" {
+		return e.accountID, nil
+	}
+
+	jsonResponse := JsonResponse{}
+	accountsData := AccountsData{}
+	strRequest := "/v1/account/accounts"
+
+	jsonAccountsReturn := e.ApiKeyGet(make(map[string]string), strRequest)
+	if err := json.Unmarshal([]byte(jsonAccountsReturn), &jsonResponse); err != nil {
+		return "", exchange.Errorf(e.GetName(), "getAccountID", exchange.ErrNetwork, "Get Accounts Json Unmarshal Err: %v %v", err, jsonAccountsReturn)
+	} else if err := e.responseErr("GetAccounts", &jsonResponse); err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &accountsData); err != nil {
+		return "", exchange.Errorf(e.GetName(), "getAccountID", exchange.ErrUnknown, "Get Accounts Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	for _, account := range accountsData {
+		if account.Type == "spot" && account.State == "working" {
+			e.accountID = strconv.FormatInt(account.ID, 10)
+			return e.accountID, nil
+		}
+	}
+	return "", exchange.Errorf(e.GetName(), "GetAccounts", exchange.ErrNotFound, "no working spot account")
+}
+
+func (e *Huobi) UpdateAllBalances() error {
+	return e.UpdateAllBalancesByUser(nil)
+}
+
+/*Get Exchange Account All Coins Balance
+Step 1: Get the balance of the spot account (signed)
+Step 2: Each currency has a trade (available) and a frozen (locked) balance
+Step 3: Get Coin Balance (market.Balance) and store in balanceMap*/
+func (e *Huobi) UpdateAllBalancesByUser(u *user.User) error {
+	var uInstance *Huobi
+	if u != nil {
+		uInstance = e.ForUser(u)
+	} else {
+		uInstance = e
+	}
+
+	if uInstance.API_KEY == "" || uInstance.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	accountID, err := uInstance.getAccountID()
+	if err != nil {
+		return err
+	}
+
+	jsonResponse := JsonResponse{}
+	accountBalances := AccountBalances{}
+	strRequest := fmt.Sprintf("/v1/account/accounts/%s/balance", accountID)
+
+	jsonBalanceReturn := uInstance.ApiKeyGet(make(map[string]string), strRequest)
+	if err := json.Unmarshal([]byte(jsonBalanceReturn), &jsonResponse); err != nil {
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrNetwork, "Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
+	} else if err := uInstance.responseErr("UpdateAllBalances", &jsonResponse); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &accountBalances); err != nil {
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrUnknown, "Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	now := time.Now().UnixNano() / 1e6
+	balances := make(map[string]*market.Balance)
+	for _, data := range accountBalances.List {
+		c := coin.GetCoin(e.GetCode(data.Currency))
+		if c == nil {
+			continue
+		}
+		quantity, err := strconv.ParseFloat(data.Balance, 64)
+		if err != nil {
+			continue
+		}
+
+		balance, ok := balances[c.Code]
+		if !ok {
+			balance = &market.Balance{}
+			balance.Coin = c
+			balance.Timestamp = now
+			balances[c.Code] = balance
+		}
+		switch data.Type {
+		case "trade":
+			balance.Available += quantity
+		case "frozen":
+			balance.Locked += quantity
+		}
+		balance.Total = balance.Available + balance.Locked
+	}
+	for code, balance := range balances {
+		uInstance.balanceMap.Set(code, balance)
+	}
+	return nil
+}
+
+/*Withdraw the coin to another address
+Withdraw by the default chain of the coin, the fee is the transactFeeWithdraw of UpdateCoinConstrain*/
+func (e *Huobi) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) (*exchange.WithdrawalResult, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := JsonResponse{}
+	var withdrawID int64
+	strRequest := "/v1/dw/withdraw/api/create"
+
+	fee := e.GetTxFee(coin)
+	mapParams := make(map[string]interface{})
+	mapParams["currency"] = e.GetSymbol(coin.Code)
+	mapParams["address"] = addr
+	if tag != "" {
+		mapParams["addr-tag"] = tag
+	}
+	mapParams["amount"] = strconv.FormatFloat(quantity, 'f', -1, 64)
+	if fee > 0 {
+		mapParams["fee"] = strconv.FormatFloat(fee, 'f', -1, 64)
+	}
+
+	jsonSubmitWithdraw := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonSubmitWithdraw), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonSubmitWithdraw)
+	} else if err := e.responseErr("Withdraw", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &withdrawID); err != nil || withdrawID == 0 {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrUnknown, "no id in response: %v", jsonSubmitWithdraw)
+	}
+
+	withdrawal := &exchange.WithdrawalResult{}
+	withdrawal.ID = strconv.FormatInt(withdrawID, 10)
+	withdrawal.Coin = coin
+	withdrawal.Quantity = quantity
+	withdrawal.Fee = fee
+	withdrawal.Address = addr
+	withdrawal.Tag = tag
+	withdrawal.Status = exchange.TransferPending
+	withdrawal.Timestamp = time.Now().UnixNano() / 1e6
+	return withdrawal, nil
+}
+
+/*Update the Status of the Withdrawal
+Find the withdrawal by the id in the recent withdrawals of the coin*/
+func (e *Huobi) WithdrawalStatus(withdrawal *exchange.WithdrawalResult) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if withdrawal == nil || withdrawal.Coin == nil {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrRejected, "withdrawal or its coin is nil")
+	}
+
+	history, err := e.getTransfers(withdrawal.Coin, "withdraw")
+	if err != nil {
+		return err
+	}
+	for _, w := range history {
+		if strconv.FormatInt(w.ID, 10) == withdrawal.ID {
+			withdrawal.Fee = w.Fee
+			withdrawal.TxHash = w.TxHash
+			withdrawal.Status = withdrawStatusOf(w.State)
+			withdrawal.Timestamp = w.CreatedAt
+			return nil
+		}
+	}
+	return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrNotFound, "withdrawal %s is not in the recent withdrawals", withdrawal.ID)
+}
+
+// the latest 500 deposits or withdrawals (transferType: deposit or withdraw) of the coin, nil coin: all coins
+func (e *Huobi) getTransfers(coin *coin.Coin, transferType string) (TransfersData, error) {
+	jsonResponse := JsonResponse{}
+	transfers := TransfersData{}
+	strRequest := "/v1/query/deposit-withdraw"
+
+	mapParams := make(map[string]string)
+	if coin != nil {
+		mapParams["currency"] = e.GetSymbol(coin.Code)
+	}
+	mapParams["type"] = transferType
+	mapParams["size"] = "500"
+
+	jsonTransfers := e.ApiKeyGet(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonTransfers), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getTransfers", exchange.ErrNetwork, "Get Transfers Unmarshal Err: %v %v", err, jsonTransfers)
+	} else if err := e.responseErr("GetTransfers", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &transfers); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getTransfers", exchange.ErrUnknown, "Get Transfers Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+	return transfers, nil
+}
+
+// state of the withdrawal: submitted, reexamine, pass, pre-transfer, wallet-transfer, confirmed,
+// canceled, repealed, reject, wallet-reject, confirm-error
+func withdrawStatusOf(state string) exchange.TransferStatus {
+	switch state {
+	case "confirmed":
+		return exchange.TransferCompleted
+	case "canceled", "repealed":
+		return exchange.TransferCanceled
+	case "reject", "wallet-reject", "confirm-error":
+		return exchange.TransferFailed
+	default:
+		return exchange.TransferPending
+	}
+}
+
+// state of the deposit: unknown, confirming, confirmed, safe, orphan
+func depositStatusOf(state string) exchange.TransferStatus {
+	switch state {
+	case "confirmed", "safe":
+		return exchange.TransferCompleted
+	case "orphan":
+		return exchange.TransferFailed
+	default:
+		return exchange.TransferPending
+	}
+}
+
+/*Get the Deposit Address of the Coin
+The address of the default chain*/
+func (e *Huobi) GetDepositAddress(coin *coin.Coin) (*exchange.DepositAddress, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := JsonResponse{}
+	addresses := DepositAddresses{}
+	strRequest := "/v2/account/deposit/address"
+
+	currency := e.GetSymbol(coin.Code)
+	mapParams := make(map[string]string)
+	mapParams["currency"] = currency
+
+	jsonAddress := e.ApiKeyGet(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonAddress), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonAddress)
+	} else if err := e.responseErr("GetDepositAddress", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &addresses); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrUnknown, "Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	address := &exchange.DepositAddress{}
+	address.Coin = coin
+	for _, a := range addresses {
+		if a.Address != "" && (address.Address == "" || a.Chain == currency) {
+			address.Address = a.Address
+			address.Tag = a.AddressTag
+		}
+	}
+	if address.Address == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrNotFound, "no deposit address for %s", coin.Code)
+	}
+	return address, nil
+}
+
+/*Get the Deposits and Withdrawals of the Coin Since the Time
+Step 1: Get the latest deposits and withdrawals, nil coin: all coins
+Step 2: Drop the transfers before since and sort by time*/
+func (e *Huobi) GetTransfers(coin *coin.Coin, since time.Time) ([]*exchange.Transfer, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	deposits, err := e.getTransfers(coin, "deposit")
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, err := e.getTransfers(coin, "withdraw")
+	if err != nil {
+		return nil, err
+	}
+
+	sinceMs := since.UnixNano() / 1e6
+	transfers := []*exchange.Transfer{}
+	for _, t := range append(deposits, withdrawals...) {
+		if t.CreatedAt < sinceMs {
+			continue
+		}
+		transfer := &exchange.Transfer{}
+		transfer.ID = strconv.FormatInt(t.ID, 10)
+		transfer.Coin = e.transferCoin(coin, t.Currency)
+		transfer.Quantity = t.Amount
+		transfer.Fee = t.Fee
+		transfer.Address = t.Address
+		transfer.Tag = t.AddressTag
+		transfer.TxHash = t.TxHash
+		transfer.Timestamp = t.CreatedAt
+		if t.Type == "deposit" {
+			transfer.Type = exchange.Deposit
+			transfer.Status = depositStatusOf(t.State)
+		} else {
+			transfer.Type = exchange.Withdrawal
+			transfer.Status = withdrawStatusOf(t.State)
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].Timestamp < transfers[j].Timestamp
+	})
+	return transfers, nil
+}
+
+// the coin of the query, or the coin of the transfer if the query is for all coins
+func (e *Huobi) transferCoin(query *coin.Coin, symbol string) *coin.Coin {
+	if query != nil {
+		return query
+	}
+	return coin.GetCoin(e.GetCode(symbol))
+}
+
+/*Update the Maker & Taker Fee of the Account
+Step 1: Get the fee rates of the pairs, 10 symbols in each request, ex. 0.002 is 0.2%
+Step 2: Store the actual rates of each pair in feeMap, GetTradeFee returns them*/
+func (e *Huobi) UpdateFees() error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	symbols := []string{}
+	for _, p := range e.pairList {
+		symbols = append(symbols, e.GetPairCode(p))
+	}
+
+	now := time.Now().UnixNano() / 1e6
+	for start := 0; start < len(symbols); start += feeSymbolsSize {
+		end := start + feeSymbolsSize
+		if end > len(symbols) {
+			end = len(symbols)
+		}
+
+		jsonResponse := JsonResponse{}
+		feeRates := TransactFeeRates{}
+		strRequest := "/v2/reference/transact-fee-rate"
+
+		mapParams := make(map[string]string)
+		mapParams["symbols"] = strings.Join(symbols[start:end], ",")
+
+		jsonTradeFee := e.ApiKeyGet(mapParams, strRequest)
+		if err := json.Unmarshal([]byte(jsonTradeFee), &jsonResponse); err != nil {
+			return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonTradeFee)
+		} else if err := e.responseErr("UpdateFees", &jsonResponse); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(jsonResponse.Data, &feeRates); err != nil {
+			return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrUnknown, "Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+		}
+
+		for _, data := range feeRates {
+			p := e.getPairByCode(data.Symbol)
+			if p == nil {
+				continue
+			}
+			fee := &exchange.TradeFee{}
+			fee.Pair = p
+			fee.Maker, _ = strconv.ParseFloat(data.ActualMakerRate, 64)
+			fee.Taker, _ = strconv.ParseFloat(data.ActualTakerRate, 64)
+			fee.Source = exchange.SourceAPI
+			fee.Timestamp = now
+			e.feeMap.Set(p.Name, fee)
+		}
+	}
+	return nil
+}
+
+/*Get the Status of a Singal Order
+Step 1: Query the order with the order id
+Step 2: Change Order Status and Deal (Status reference ../market/market.go)*/
+func (e *Huobi) OrderStatus(order *market.Order) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := JsonResponse{}
+	orderStatus := Order{}
+	strRequest := fmt.Sprintf("/v1/order/orders/%s", order.OrderID)
+
+	jsonOrderStatus := e.ApiKeyGet(make(map[string]string), strRequest)
+	if err := json.Unmarshal([]byte(jsonOrderStatus), &jsonResponse); err != nil {
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrderStatus)
+	} else if err := e.responseErr("OrderStatus", &jsonResponse); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &orderStatus); err != nil {
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrUnknown, "Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	updated := toOrder(order.Pair, &orderStatus)
+	order.Status = updated.Status
+	order.DealQuantity = updated.DealQuantity
+	order.DealRate = updated.DealRate
+	return nil
+}
+
+// the API queries one order at a time
+func (e *Huobi) OrdersStatus(orders []*market.Order) error {
+	return exchange.OrdersStatusEach(e, orders)
+}
+
+/*Get an Order by the Client Order ID
+Step 1: Query the order with clientOrderId
+Step 2: ErrNotFound if the exchange doesn't know the order (base-record-invalid), it is safe to place it again
+The closed orders are found by the client order id for 2 hours after they are closed*/
+func (e *Huobi) OrderByClientID(p *pair.Pair, clientOrderID string) (*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := JsonResponse{}
+	orderStatus := Order{}
+	strRequest := "/v1/order/orders/getClientOrder"
+
+	mapParams := make(map[string]string)
+	mapParams["clientOrderId"] = clientOrderID
+
+	jsonOrderStatus := e.ApiKeyGet(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonOrderStatus), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrderStatus)
+	} else if err := e.responseErr("OrderByClientID", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &orderStatus); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrUnknown, "Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+	return toOrder(p, &orderStatus), nil
+}
+
+func toOrder(pair *pair.Pair, o *Order) *market.Order {
+	order := &market.Order{}
+	order.Pair = pair
+	order.OrderID = strconv.FormatInt(o.ID, 10)
+	order.ClientOrderID = o.ClientOrderID
+	order.Rate, _ = strconv.ParseFloat(o.Price, 64)
+	order.Quantity, _ = strconv.ParseFloat(o.Amount, 64)
+
+	dealAmount, cashAmount := o.FieldAmount, o.FieldCashAmount
+	if dealAmount == "" {
+		dealAmount, cashAmount = o.FilledAmount, o.FilledCashAmount
+	}
+	order.DealQuantity, _ = strconv.ParseFloat(dealAmount, 64)
+	if cash, err := strconv.ParseFloat(cashAmount, 64); err == nil && order.DealQuantity > 0 {
+		order.DealRate = cash / order.DealQuantity
+	}
+	if strings.HasPrefix(o.Type, "sell") {
+		order.Side = string(market.Sell)
+	} else {
+		order.Side = string(market.Buy)
+	}
+	order.Status = statusOf(o.State)
+	return order
+}
+
+func statusOf(state string) market.OrderStatus {
+	switch state {
+	case "created", "submitted":
+		return market.New
+	case "partial-filled":
+		return market.Partial
+	case "filled":
+		return market.Filled
+	case "canceling":
+		return market.Canceling
+	case "canceled", "partial-canceled":
+		return market.Canceled
+	}
+	return market.Other
+}
+
+func (e *Huobi) ListOrders() (*[]market.Order, error) {
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.OrderList(orders), nil
+}
+
+/*Get the Open Orders
+Step 1: Get the open orders of the spot account, of the pair if the query has a pair
+Step 2: Sort by order time, so the pages are stable
+Step 3: Filter by pair and page (exchange.PageOrders)*/
+func (e *Huobi) ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	accountID, err := e.getAccountID()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonResponse := JsonResponse{}
+	openOrders := []*Order{}
+	strRequest := "/v1/order/openOrders"
+
+	mapParams := make(map[string]string)
+	mapParams["account-id"] = accountID
+	if query != nil && query.Pair != nil {
+		mapParams["symbol"] = e.GetPairCode(query.Pair)
+	}
+	mapParams["size"] = "500"
+
+	jsonOrders := e.ApiKeyGet(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonOrders), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrders)
+	} else if err := e.responseErr("ListOpenOrders", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &openOrders); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrUnknown, "Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	sort.SliceStable(openOrders, func(i, j int) bool {
+		return openOrders[i].CreatedAt < openOrders[j].CreatedAt
+	})
+
+	orders := []*market.Order{}
+	for _, o := range openOrders {
+		p := e.getPairByCode(o.Symbol)
+		if p == nil {
+			log.Printf("Huobi ListOpenOrders order %d pair %s is not in the pair list", o.ID, o.Symbol)
+			continue
+		}
+		orders = append(orders, toOrder(p, o))
+	}
+	return exchange.PageOrders(orders, query), nil
+}
+
+// the pair of the symbol, eg: ethbtc
+func (e *Huobi) getPairByCode(code string) *pair.Pair {
+	return e.pairCodeMap[strings.ToLower(code)]
+}
+
+/*Cancel an Order
+Step 1: Submit the cancel of the order id
+Step 2: Change Order Status (order.Status = market.Canceling)
+An order which is already closed: ErrNotFound (order-orderstate-error)*/
+func (e *Huobi) CancelOrder(order *market.Order) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := JsonResponse{}
+	strRequest := fmt.Sprintf("/v1/order/orders/%s/submitcancel", order.OrderID)
+
+	jsonCancelOrder := e.ApiKeyPost(make(map[string]interface{}), strRequest)
+	if err := json.Unmarshal([]byte(jsonCancelOrder), &jsonResponse); err != nil {
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonCancelOrder)
+	} else if err := e.responseErr("CancelOrder", &jsonResponse); err != nil {
+		return err
+	}
+
+	order.Status = market.Canceling
+
+	return nil
+}
+
+/*Get the Executed Trades Since the Time, nil pair: the pairs traded since the time
+matchresults only returns the trades of one symbol in the last 48 hours,
+for a nil pair each pair of tradedPairs is queried*/
+func (e *Huobi) GetFills(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if p == nil {
+		return e.getAllFills(since)
+	}
+
+	jsonResponse := JsonResponse{}
+	matchResults := MatchResults{}
+	strRequest := "/v1/order/matchresults"
+
+	mapParams := make(map[string]string)
+	mapParams["symbol"] = e.GetPairCode(p)
+	mapParams["start-time"] = fmt.Sprint(since.UnixNano() / 1e6)
+	mapParams["size"] = "500"
+
+	jsonTrades := e.ApiKeyGet(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonTrades), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonTrades)
+	} else if err := e.responseErr("GetFills", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &matchResults); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrUnknown, "Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	trades := []*market.Trade{}
+	for _, t := range matchResults {
+		trade := &market.Trade{}
+		trade.TradeID = strconv.FormatInt(t.TradeID, 10)
+		trade.OrderID = strconv.FormatInt(t.OrderID, 10)
+		trade.Pair = p
+		trade.Rate, _ = strconv.ParseFloat(t.Price, 64)
+		trade.Quantity, _ = strconv.ParseFloat(t.FilledAmount, 64)
+		trade.Fee, _ = strconv.ParseFloat(t.FilledFees, 64)
+		trade.FeeCoin = coin.GetCoin(e.GetCode(t.FeeCurrency))
+		trade.Timestamp = t.CreatedAt
+		if strings.HasPrefix(t.Type, "sell") {
+			trade.Side = market.Sell
+		} else {
+			trade.Side = market.Buy
+		}
+		if t.Role == "maker" {
+			trade.Liquidity = market.LiquidityMaker
+		} else {
+			trade.Liquidity = market.LiquidityTaker
+		}
+		trades = append(trades, trade)
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+// the fills of all the pairs traded since the time, sorted by time
+func (e *Huobi) getAllFills(since time.Time) ([]*market.Trade, error) {
+	pairs, err := e.tradedPairs(since)
+	if err != nil {
+		return nil, err
+	}
+
+	trades := []*market.Trade{}
+	for _, p := range pairs {
+		fills, err := e.GetFills(p, since)
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, fills...)
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+/*The Pairs which may have Trades Since the Time
+Step 1: The pairs of the open orders, they may be partially filled
+Step 2: The pairs of the orders closed since the time (order history), history only has the last 48 hours*/
+func (e *Huobi) tradedPairs(since time.Time) ([]*pair.Pair, error) {
+	open, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonResponse := JsonResponse{}
+	history := []*Order{}
+	strRequest := "/v1/order/history"
+
+	if oldest := time.Now().Add(-48 * time.Hour); since.Before(oldest) {
+		since = oldest
+	}
+	mapParams := make(map[string]string)
+	mapParams["start-time"] = fmt.Sprint(since.UnixNano() / 1e6)
+	mapParams["size"] = "1000"
+
+	jsonOrders := e.ApiKeyGet(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonOrders), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrNetwork, "Order History Unmarshal Err: %v %v", err, jsonOrders)
+	} else if err := e.responseErr("GetFills", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &history); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrUnknown, "Order History Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+	}
+
+	pairs := []*pair.Pair{}
+	traded := make(map[string]bool) //pair name: in pairs
+	add := func(p *pair.Pair) {
+		if p != nil && !traded[p.Name] {
+			traded[p.Name] = true
+			pairs = append(pairs, p)
+		}
+	}
+	for _, order := range open {
+		add(order.Pair)
+	}
+	for _, o := range history {
+		add(e.getPairByCode(o.Symbol))
+	}
+	return pairs, nil
+}
+
+/*Cancel All Order of All Pairs*/
+func (e *Huobi) CancelAllOrder() error {
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+/*Cancel All Orders of the Pair, nil pair: all pairs
+batchcancel cancels up to 50 order ids and answers the result of each order
+Step 1: List the open orders
+Step 2: Cancel them 50 at a time, a failed batch doesn't stop the others
+The orders closed in between fail with ErrNotFound*/
+func (e *Huobi) CancelAllOrders(p *pair.Pair) (*exchange.CancelReport, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	orders, err := e.ListOpenOrders(&market.OrderQuery{Pair: p})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &exchange.CancelReport{Pair: p}
+	for start := 0; start < len(orders); start += batchCancelSize {
+		end := start + batchCancelSize
+		if end > len(orders) {
+			end = len(orders)
+		}
+		batch := orders[start:end]
+
+		orderIDs := []string{}
+		for _, order := range batch {
+			orderIDs = append(orderIDs, order.OrderID)
+		}
+
+		jsonResponse := JsonResponse{}
+		batchCancel := BatchCancel{}
+		strRequest := "/v1/order/orders/batchcancel"
+
+		mapParams := make(map[string]interface{})
+		mapParams["order-ids"] = orderIDs
+
+		jsonCancelAll := e.ApiKeyPost(mapParams, strRequest)
+		if err := json.Unmarshal([]byte(jsonCancelAll), &jsonResponse); err != nil {
+			err = exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonCancelAll)
+			addCancelFailures(report, batch, err)
+			continue
+		} else if err := e.responseErr("CancelAllOrders", &jsonResponse); err != nil {
+			addCancelFailures(report, batch, err)
+			continue
+		}
+		if err := json.Unmarshal(jsonResponse.Data, &batchCancel); err != nil {
+			err = exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrUnknown, "Result Unmarshal Err: %v %s", err, jsonResponse.Data)
+			addCancelFailures(report, batch, err)
+			continue
+		}
+
+		results := make(map[string]error)
+		for _, id := range batchCancel.Success {
+			results[id] = nil
+		}
+		for _, failed := range batchCancel.Failed {
+			results[failed.OrderID] = exchange.Errorf(e.GetName(), "CancelAllOrders", errKind(failed.ErrCode), "%s %s", failed.ErrCode, failed.ErrMsg)
+		}
+		for _, order := range batch {
+			err, ok := results[order.OrderID]
+			if !ok {
+				err = exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrUnknown, "order %s is not in the batchcancel result", order.OrderID)
+			}
+			if err != nil {
+				report.Failed = append(report.Failed, &exchange.CancelFailure{Order: order, Err: err})
+				continue
+			}
+			order.Status = market.Canceling
+			report.Canceled = append(report.Canceled, order)
+		}
+	}
+	return report, nil
+}
+
+func addCancelFailures(report *exchange.CancelReport, orders []*market.Order, err error) {
+	for _, order := range orders {
+		report.Failed = append(report.Failed, &exchange.CancelFailure{Order: order, Err: err})
+	}
+}
+
+/*Place an Order
+Step 1: Check the Request is supported by GetCapabilities (exchange.CheckOrderRequest)
+Step 2: Map Side, Order Type, Time in Force and Post Only to the order type, eg. buy-limit
+	Limit: -limit, Post Only: -limit-maker, IOC: -ioc, FOK: -limit-fok
+	Market: sell-market, the amount of buy-market is in the base, it is not supported
+	Stop Limit: -stop-limit or -stop-limit-fok, triggered by the price gte (buy) or lte (sell) the stop price
+Step 3: Call ApiKey Function & Create a new Order from the order id of the response*/
+func (e *Huobi) PlaceOrder(request *market.OrderRequest) (*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if err := exchange.CheckOrderRequest(e.GetName(), e.GetCapabilities(), request); err != nil {
+		return nil, err
+	}
+
+	side := "buy"
+	operator := "gte"
+	if request.Side == market.Sell {
+		side = "sell"
+		operator = "lte"
+	}
+
+	mapParams := make(map[string]interface{})
+	switch request.Type {
+	case market.LimitOrder:
+		switch {
+		case request.PostOnly:
+			mapParams["type"] = side + "-limit-maker"
+		case request.TimeInForce == market.IOC:
+			mapParams["type"] = side + "-ioc"
+		case request.TimeInForce == market.FOK:
+			mapParams["type"] = side + "-limit-fok"
+		default:
+			mapParams["type"] = side + "-limit"
+		}
+		mapParams["price"] = strconv.FormatFloat(request.Rate, 'f', -1, 64)
+	case market.MarketOrder:
+		if request.Side == market.Buy {
+			return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrUnsupported, "market buy order is placed by the amount of the base, it is not supported")
+		}
+		mapParams["type"] = side + "-market"
+	case market.StopLimitOrder:
+		if request.PostOnly || request.TimeInForce == market.IOC {
+			return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrUnsupported, "post only or IOC stop limit order is not supported")
+		}
+		if request.TimeInForce == market.FOK {
+			mapParams["type"] = side + "-stop-limit-fok"
+		} else {
+			mapParams["type"] = side + "-stop-limit"
+		}
+		mapParams["price"] = strconv.FormatFloat(request.Rate, 'f', -1, 64)
+		mapParams["stop-price"] = strconv.FormatFloat(request.StopRate, 'f', -1, 64)
+		mapParams["operator"] = operator
+	}
+
+	accountID, err := e.getAccountID()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonResponse := JsonResponse{}
+	var orderID string
+	strRequest := "/v1/order/orders/place"
+
+	mapParams["account-id"] = accountID
+	mapParams["symbol"] = e.GetPairCode(request.Pair)
+	mapParams["amount"] = strconv.FormatFloat(request.Quantity, 'f', -1, 64)
+	mapParams["client-order-id"] = request.ClientOrderID
+	mapParams["source"] = "spot-api"
+
+	jsonPlaceReturn := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonPlaceReturn), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonPlaceReturn)
+	} else if err := e.responseErr("PlaceOrder", &jsonResponse); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(jsonResponse.Data, &orderID); err != nil || orderID == "" {
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrUnknown, "no order id in response: %v", jsonPlaceReturn)
+	}
+
+	order := &market.Order{}
+	order.Pair = request.Pair
+	order.OrderID = orderID
+	order.ClientOrderID = request.ClientOrderID
+	order.Rate = request.Rate
+	order.Quantity = request.Quantity
+	order.Side = string(request.Side)
+	order.Status = market.New
+	order.JsonResponse = jsonPlaceReturn
+	return order, nil
+}
+
+/*Place a limit Sell Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Huobi) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Sell, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
+
+/*Place a limit Buy Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Huobi) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Buy, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
+
+// the orders, fills (of the traded pairs, see GetFills) and balances are polled by REST, the WebSocket of the API is not used
+func (e *Huobi) StreamUserData(stop <-chan struct{}) (<-chan *exchange.UserEvent, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "StreamUserData", exchange.ErrAuth, "Huobi API Key or Secret Key are nil.")
+	}
+	return exchange.PollUserData(e, 5*time.Second, stop), nil
+}
+
+/*The Error of the Response, nil if it is not an error
+v1 answers the errors with {"status": "error", "err-code": "base-record-invalid", "err-msg": "..."}
+v2 answers them with {"code": 1003, "message": "..."}, 200 is ok*/
+func (e *Huobi) responseErr(op string, jsonResponse *JsonResponse) error {
+	switch {
+	case jsonResponse.Status == "error":
+		return exchange.Errorf(e.GetName(), op, errKind(jsonResponse.ErrCode), "%s %s", jsonResponse.ErrCode, jsonResponse.ErrMsg)
+	case jsonResponse.Status == "" && jsonResponse.Code != 0 && jsonResponse.Code != 200:
+		kind := exchange.ErrRejected
+		switch jsonResponse.Code {
+		case 1002, 1003: //unauthorized, invalid signature
+			kind = exchange.ErrAuth
+		}
+		return exchange.Errorf(e.GetName(), op, kind, "%d %s", jsonResponse.Code, jsonResponse.Message)
+	}
+	return nil
+}
+
+// the kind of the v1 err-code
+func errKind(code string) exchange.ErrorKind {
+	switch code {
+	case "base-record-invalid", "order-orderstate-error": //the order is not found, the order is already closed
+		return exchange.ErrNotFound
+	case "api-signature-not-valid", "api-signature-check-failed", "login-required":
+		return exchange.ErrAuth
+	case "too-many-request", "gateway-internal-error":
+		return exchange.ErrNetwork
+	}
+	return exchange.ErrRejected
+}
+
+/*************** Signature Http Request ***************/
+/*Method: GET and Signature is required
+Step 1: Add AccessKeyId, SignatureMethod, SignatureVersion and Timestamp (UTC of the server clock) to mapParams
+Step 2: Sign "GET\n<host>\n<path>\n<sorted query>" by HMAC-SHA256 of the API Secret, base64 encoded
+Step 3: Append the signature to the query as Signature*/
+func (e *Huobi) ApiKeyGet(mapParams map[string]string, strRequestPath string) string {
+	strUrl := e.signedUrl("GET", mapParams, strRequestPath)
+	return exchange.HttpGetRequest(strUrl, nil)
+}
+
+/*Method: POST and Signature is required
+Step 1: Sign the query of the auth params only, the same as ApiKeyGet
+Step 2: Send mapParams as the json body*/
+func (e *Huobi) ApiKeyPost(mapParams map[string]interface{}, strRequestPath string) string {
+	strMethod := "POST"
+	strUrl := e.signedUrl(strMethod, make(map[string]string), strRequestPath)
+
+	bytesParams, err := json.Marshal(mapParams)
+	if nil != err {
+		return err.Error()
+	}
+
+	httpClient := &http.Client{}
+
+	request, err := http.NewRequest(strMethod, strUrl, strings.NewReader(string(bytesParams)))
+	if nil != err {
+		return err.Error()
+	}
+	request.Header.Add("User-Agent", "Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/39.0.2171.71 Safari/537.36")
+	request.Header.Add("Content-Type", "application/json")
+
+	response, err := httpClient.Do(request)
+	if nil != err {
+		return err.Error()
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if nil != err {
+		return err.Error()
+	}
+
+	return string(body)
+}
+
+// the url with the signed query of signature v2
+func (e *Huobi) signedUrl(strMethod string, mapParams map[string]string, strRequestPath string) string {
+	mapParams["AccessKeyId"] = e.API_KEY
+	mapParams["SignatureMethod"] = "HmacSHA256"
+	mapParams["SignatureVersion"] = "2"
+	mapParams["Timestamp"] = e.clock.Now().UTC().Format("2006-01-02T15:04:05")
+
+	host := e.API_URL
+	if u, err := url.Parse(e.API_URL); err == nil {
+		host = u.Host
+	}
+
+	strParams := Map2UrlQuery(mapParams)
+	signMessage := strMethod + "\n" + strings.ToLower(host) + "\n" + strRequestPath + "\n" + strParams
+	signature := ComputeHmac256(signMessage, e.API_SECRET)
+
+	return e.API_URL + strRequestPath + "?" + strParams + "&Signature=" + url.QueryEscape(signature)
+}
+
+//Signature加密 HMAC-SHA256, base64 encoded
+func ComputeHmac256(strMessage string, strSecret string) string {
+	key := []byte(strSecret)
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(strMessage))
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// 将map格式的请求参数转换为字符串格式的
+// mapParams: map格式的参数键值对
+// return: 查询字符串, url encoded & sorted by key in ASCII order, the same query is signed and sent
+func Map2UrlQuery(mapParams map[string]string) string {
+	values := url.Values{}
+	for key, value := range mapParams {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
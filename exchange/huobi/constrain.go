@@ -0,0 +1,125 @@
+package huobi
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"../../coin"
+	"../../exchange"
+	"../../pair"
+)
+
+/*Update Pairs Constrain
+Step 1: Get the symbols
+Step 2: Get Each Symbol, Identify Base & Target and Get Pair
+Step 3: Add LotSize - 10^-amount-precision
+Step 4: Add TickSize - 10^-price-precision*/
+func (e *Huobi) UpdatePairConstrain() error {
+	pairsData, err := e.getPairsData()
+	if err != nil {
+		return err
+	}
+
+	for _, data := range pairsData {
+		if p := e.getPairByCode(data.Symbol); p != nil {
+			e.setPairConstrain(p, data.AmountPrecision, data.PricePrecision, data.State)
+		}
+	}
+	return nil
+}
+
+func (e *Huobi) setPairConstrain(p *pair.Pair, amountPrecision, pricePrecision int, state string) {
+	pairConstrain := &exchange.PairConstrain{}
+	pairConstrain.Pair = p
+	pairConstrain.LotSize = math.Pow10(-amountPrecision)
+	pairConstrain.TickSize = math.Pow10(-pricePrecision)
+	if state != "online" {
+		pairConstrain.Issue = state
+	}
+	e.pairConstrainMap.Set(p.Name, pairConstrain)
+}
+
+/*Update Coins Constrain
+Step 1: Get the reference currencies (public) with the chains of each coin
+Step 2: Get the coin (Use Standard Code ex. e.GetCode(coin))
+Step 3: Use the default chain of the coin
+Step 4: Add TxFee, Withdraw & Deposit Status and Confirmation*/
+func (e *Huobi) UpdateCoinConstrain() error {
+	currencies, err := e.getCurrencies()
+	if err != nil {
+		return err
+	}
+
+	for _, data := range currencies {
+		c := coin.GetCoin(e.GetCode(data.Currency))
+		if c == nil {
+			continue
+		}
+
+		chain := defaultChain(data.Currency, data.Chains)
+		if chain == nil {
+			continue
+		}
+
+		coinConstrain := &exchange.CoinConstrain{}
+		coinConstrain.Coin = c
+		coinConstrain.TxFee, _ = strconv.ParseFloat(chain.TransactFeeWithdraw, 64)
+		coinConstrain.Withdraw = data.InstStatus == "normal" && chain.WithdrawStatus == "allowed"
+		coinConstrain.Deposit = data.InstStatus == "normal" && chain.DepositStatus == "allowed"
+		coinConstrain.Confirmation = chain.NumOfConfirmations
+		if data.InstStatus != "normal" {
+			coinConstrain.Issue = data.InstStatus
+		}
+		e.coinConstrainMap.Set(c.Code, coinConstrain)
+	}
+	return nil
+}
+
+// the chain named as the currency is the default one, eg. btc of btc, usdt of usdt (omni), the first chain otherwise
+func defaultChain(currency string, chains []*Chain) *Chain {
+	for _, chain := range chains {
+		if chain.Chain == currency {
+			return chain
+		}
+	}
+	if len(chains) > 0 {
+		return chains[0]
+	}
+	return nil
+}
+
+/***************************************************/
+var symbolMap = make(map[string]string) //read only after FixSymbol
+var symbolOnce sync.Once
+
+/*Standard Coin Code
+Coin has same code but it is different currency
+Fix the coin code to bitontop standard*/
+func (e *Huobi) FixSymbol() { //key: exchange specific    val： bitontop standard
+	symbolOnce.Do(func() {
+		symbolMap["-"] = ""
+	})
+}
+
+/*Get Exchange Standard Code
+Huobi uses the codes in lower case, eg. btc*/
+func (e *Huobi) GetSymbol(code string) string {
+	code = strings.ToUpper(code)
+	for k, v := range symbolMap {
+		if code == v {
+			return strings.ToLower(k)
+		}
+	}
+	return strings.ToLower(code)
+}
+
+/*Get Bitontop Standard Code*/
+func (e *Huobi) GetCode(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if val, ok := symbolMap[symbol]; ok {
+		return val
+	}
+	return symbol
+}
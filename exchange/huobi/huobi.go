@@ -0,0 +1,447 @@
+package huobi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cmap "github.com/orcaman/concurrent-map"
+
+	"../../coin"
+	"../../db"
+	"../../exchange"
+	"../../market"
+	"../../pair"
+	"../../user"
+)
+
+type Huobi struct {
+	Name         string `bson:"name"`
+	Website      string `bson:"website"`
+	RedisManager *db.RedisManager
+	RedisServer  string
+	RedisDB      int
+	API_KEY      string
+	API_SECRET   string
+	API_URL      string //the REST endpoint, API_URL by default
+	WalletStatus []exchange.Wallet_Stat
+
+	pairList         []*pair.Pair //the pairs on this exchange
+	coinList         []*coin.Coin
+	balanceMap       cmap.ConcurrentMap
+	userMap          cmap.ConcurrentMap //credentials: *Huobi, the instances of other users
+	clock            *exchange.Clock    //server time of the signed requests, shared with the user instances
+	feeMap           cmap.ConcurrentMap //pair name: *exchange.TradeFee, the fees of this account from UpdateFees
+	pairConstrainMap cmap.ConcurrentMap //pair name: *exchange.PairConstrain, amount-precision and price-precision of the symbols
+	coinConstrainMap cmap.ConcurrentMap //coin code: *exchange.CoinConstrain, the default chain of the reference currencies
+
+	pairCodeMap map[string]*pair.Pair //symbol eg. ethbtc: *pair.Pair, read only after InitPairs
+
+	accountLock sync.Mutex
+	accountID   string //the spot account of the API Key, found by the first request which needs it
+}
+
+// the name of Huobi in the registry
+const NAME exchange.ExchangeName = "HUOBI"
+
+func init() {
+	exchange.Register(NAME, func(config *exchange.Config) (exchange.Exchange, error) {
+		return CreateHuobi(config), nil
+	})
+}
+
+/***************************************************/
+/*Create New Exchange
+Name the Exchange (Capital Letter) in NAME
+Register the Create function in init(), the exchange is then available in ExchangeManager
+Name: Exchange Name
+Website: Exchange Website URL
+MakerDB: Exchange Redis Server & Number(Import from Config)
+Execute Coins & Pairs Initial
+API_KEY: Import from Config
+API_SECRET: Import from Config
+API_URL: Import from Config, empty: API_URL
+WalletStatus: If API doesn't provide Wallet Status, import data from Postgres*/
+func CreateHuobi(config *exchange.Config) *Huobi {
+	instance := &Huobi{}
+	instance.Name = "Huobi"
+	instance.Website = "https://www.huobi.com/"
+
+	instance.RedisManager = db.SharedRedisManager(string(NAME))
+	instance.RedisServer = config.RedisServer
+	instance.RedisDB = config.RedisDB
+
+	instance.API_KEY = config.API_KEY
+	instance.API_SECRET = config.API_SECRET
+	instance.API_URL = API_URL
+	if config.API_URL != "" {
+		instance.API_URL = strings.TrimSuffix(config.API_URL, "/")
+	}
+
+	instance.WalletStatus = config.WalletStatus
+
+	instance.pairList = make([]*pair.Pair, 0)
+	instance.coinList = make([]*coin.Coin, 0)
+	instance.balanceMap = cmap.New()
+	instance.userMap = cmap.New()
+	instance.clock = exchange.NewClock(NAME, instance.getServerTime)
+	instance.feeMap = cmap.New()
+	instance.pairConstrainMap = cmap.New()
+	instance.coinConstrainMap = cmap.New()
+	instance.pairCodeMap = make(map[string]*pair.Pair)
+
+	instance.FixSymbol()
+	instance.InitCoins()
+	instance.InitPairs()
+	return instance
+}
+
+func (e *Huobi) GetMakerDB() *db.Redis {
+	key := fmt.Sprintf("%s-%s-%d", NAME, e.RedisServer, e.RedisDB) // the instances share the manager
+	d := e.RedisManager.Get(key)
+	if d == nil {
+		d = db.CreateRedis()
+		d.Init(e.RedisServer, e.RedisDB)
+		e.RedisManager.Add(key, d)
+	}
+	return d
+}
+
+/*Get the Instance of Another User
+The user instance shares the pairs, coins, constrains and Redis of this instance,
+but has its own API Key, spot account and balances*/
+func (e *Huobi) ForUser(u *user.User) *Huobi {
+	key := u.CredentialKey()
+	if tmp, ok := e.userMap.Get(key); ok {
+		return tmp.(*Huobi)
+	}
+
+	uInstance := &Huobi{}
+	uInstance.Name = e.Name
+	uInstance.Website = e.Website
+	uInstance.RedisManager = e.RedisManager
+	uInstance.RedisServer = e.RedisServer
+	uInstance.RedisDB = e.RedisDB
+	uInstance.API_KEY = u.API_KEY
+	uInstance.API_SECRET = u.API_SECRET
+	uInstance.API_URL = e.API_URL
+	uInstance.WalletStatus = e.WalletStatus
+
+	uInstance.pairList = e.pairList
+	uInstance.coinList = e.coinList
+	uInstance.balanceMap = cmap.New()
+	uInstance.userMap = e.userMap
+	uInstance.clock = e.clock
+	uInstance.feeMap = cmap.New()
+	uInstance.pairConstrainMap = e.pairConstrainMap
+	uInstance.coinConstrainMap = e.coinConstrainMap
+	uInstance.pairCodeMap = e.pairCodeMap
+
+	if !e.userMap.SetIfAbsent(key, uInstance) {
+		tmp, _ := e.userMap.Get(key)
+		return tmp.(*Huobi)
+	}
+	return uInstance
+}
+
+/*Initial the Pairs of Exchange
+Step 1: Get the symbols
+Step 2: Skip the symbols which are not online
+Step 3: Identify Base (quote-currency) & Target (base-currency) and Get Pair
+Step 4: Add the pair constrain from the precisions*/
+func (e *Huobi) InitPairs() {
+	pairsData, err := e.getPairsData()
+	if err != nil {
+		log.Printf("Huobi InitPairs Err: %v", err)
+		return
+	}
+
+	for _, data := range pairsData {
+		if data.State != "online" {
+			continue
+		}
+		base := coin.GetCoin(e.GetCode(data.QuoteCurrency))
+		target := coin.GetCoin(e.GetCode(data.BaseCurrency))
+		if base != nil && target != nil {
+			p := pair.GetPair(base, target)
+			e.pairList = append(e.pairList, p)
+			e.pairCodeMap[data.Symbol] = p
+			e.setPairConstrain(p, data.AmountPrecision, data.PricePrecision, data.State)
+		}
+	}
+}
+
+/*Initial the Coins of Exchange
+Step 1: Get the symbols
+Step 2: Get the base-currency and quote-currency of each symbol
+Step 3: Check the coin (Use Standard Code ex. e.GetCode(coin)) exists or not
+Step 4: if the coin doesn't exist in coinmap, Add the coin in coinmap
+	- Code: General Short Code*/
+func (e *Huobi) InitCoins() {
+	pairsData, err := e.getPairsData()
+	if err != nil {
+		log.Printf("Huobi InitCoins Err: %v", err)
+		return
+	}
+
+	added := make(map[string]bool)
+	for _, data := range pairsData {
+		for _, currency := range []string{data.BaseCurrency, data.QuoteCurrency} {
+			code := e.GetCode(currency)
+			if added[code] {
+				continue
+			}
+			added[code] = true
+
+			c := coin.GetCoin(code)
+			if c == nil {
+				c = &coin.Coin{}
+				c.Code = code
+				coin.AddCoin(c)
+			}
+			e.coinList = append(e.coinList, c)
+		}
+	}
+}
+
+/***************************************************/
+/*Upload updated Maker to Redis
+Step 1: Change Instance Name (e *<exchange Instance Name>)
+Step 2: Change Exchange Name exchange.<Capital Letter Exchange Name>*/
+func (e *Huobi) UpdateMaker(pair *pair.Pair, maker *market.Maker) error {
+	m, err := json.Marshal(maker)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
+	return e.GetMakerDB().Set(key, string(m))
+}
+
+/*Get Maker from Redis
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>
+Step 3: Change Error Exchange Name    <exchange Name> does not have the pair*/
+func (e *Huobi) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
+	val, err := e.GetMakerDB().Get(key)
+	if err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrNotFound, "does not have the pair : %v", pair.Name)
+	}
+	if str, ok := val.(string); ok {
+		if err := json.Unmarshal([]byte(str), &maker); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrUnknown, "Key: %v can't convert to string: %v", key, val)
+	}
+	return maker, err
+}
+
+/***************************************************/
+func (e *Huobi) SetCoins() error {
+	return nil
+}
+
+func (e *Huobi) GetCoins() []*coin.Coin {
+	return e.coinList
+}
+
+func (e *Huobi) SetPairs() error {
+	return nil
+}
+
+/*Get Exchange All Pairs
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Huobi) GetPairs() []*pair.Pair {
+	return e.pairList
+}
+
+/*Get Exchange A Pair
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Huobi) GetPair(key string) *pair.Pair {
+	for _, p := range e.pairList {
+		if p.Name == key {
+			return p
+		}
+	}
+	return nil
+}
+
+/*Get Pair Code base on Exchange
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Change Format of Code   ex. ethbtc in Huobi*/
+func (e *Huobi) GetPairCode(pair *pair.Pair) string {
+	code := fmt.Sprintf("%s%s", e.GetSymbol(pair.Target.Code), e.GetSymbol(pair.Base.Code))
+	return code
+}
+
+/*Check the exchange has the pair
+The symbols which are online*/
+func (e *Huobi) HasPair(pair *pair.Pair) bool {
+	return e.GetPair(pair.Name) != nil
+}
+
+/*************** pairs on the exchanges ***************/
+/*Get Exchange Name
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>*/
+func (e *Huobi) GetName() exchange.ExchangeName {
+	return NAME
+}
+
+// the offset of the server time measured by the signed requests
+func (e *Huobi) GetClockSkew() time.Duration {
+	return e.clock.Skew()
+}
+
+/*Get Exchange Taker Fee
+Step 1: Change Instance Name    (e *<exchange Instance Name>)
+Step 2: Change Return base on the taker fee that exchange provides*/
+func (e *Huobi) GetFee(pair *pair.Pair) float64 { // Taker fee for each coin
+	return e.GetTradeFee(pair).Taker
+}
+
+/*Get Exchange Maker & Taker Fee
+The actual rates of the account after UpdateFees, 0.2% for both before*/
+func (e *Huobi) GetTradeFee(pair *pair.Pair) *exchange.TradeFee {
+	if tmp, ok := e.feeMap.Get(pair.Name); ok {
+		return tmp.(*exchange.TradeFee)
+	}
+	return &exchange.TradeFee{
+		Pair:   pair,
+		Maker:  0.002,
+		Taker:  0.002,
+		Source: exchange.SourceStatic,
+	}
+}
+
+/*Get Pair LotSize(Quantity)
+10^-amount-precision of the symbol, kept by InitPairs and UpdatePairConstrain*/
+func (e *Huobi) GetLotSize(pair *pair.Pair) float64 {
+	if tmp, ok := e.pairConstrainMap.Get(pair.Name); ok {
+		return tmp.(*exchange.PairConstrain).LotSize
+	}
+	return 0.00000001
+}
+
+/*Get Pair PriceFilter(Price)
+10^-price-precision of the symbol, kept by InitPairs and UpdatePairConstrain*/
+func (e *Huobi) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for price
+	if tmp, ok := e.pairConstrainMap.Get(pair.Name); ok {
+		return tmp.(*exchange.PairConstrain).TickSize
+	}
+	return 0.00000001
+}
+
+func (e *Huobi) GetCapabilities() *exchange.Capabilities {
+	constrainFetchMethod := &exchange.ConstrainFetchMethod{}
+	constrainFetchMethod.Fee = true
+	constrainFetchMethod.LotSize = true
+	constrainFetchMethod.TickSize = true
+	constrainFetchMethod.TxFee = true
+	constrainFetchMethod.Withdraw = true
+	constrainFetchMethod.Deposit = true
+	constrainFetchMethod.Confirmation = true
+
+	capabilities := &exchange.Capabilities{}
+	capabilities.OrderTypes = []market.OrderType{market.LimitOrder, market.MarketOrder, market.StopLimitOrder}
+	capabilities.TimeInForce = []market.TimeInForce{market.GTC, market.IOC, market.FOK}
+	capabilities.PostOnly = true
+	capabilities.ClientOrderID = true
+	capabilities.CancelAll = true
+	capabilities.ListOrders = true
+	capabilities.Withdraw = true
+	capabilities.DepositAddress = true
+	capabilities.TransferHistory = true
+	capabilities.WebSocketMarketData = false
+	capabilities.WebSocketUserData = false
+	capabilities.BatchOrderBooks = false
+	capabilities.FeeSource = exchange.SourceAPI
+	capabilities.ConstrainSource = constrainFetchMethod
+	return capabilities
+}
+
+/*************** coins on the exchanges ***************/
+/*Get Coin Balance
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Huobi) GetBalance(coin *coin.Coin) float64 {
+	if tmp, ok := e.balanceMap.Get(coin.Code); ok {
+		return tmp.(*market.Balance).Available
+	} else {
+		return 0.0
+	}
+}
+
+/*Get the Balances of All Coins
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Huobi) GetBalances() []*market.Balance {
+	balances := []*market.Balance{}
+	for _, tmp := range e.balanceMap.Items() {
+		balance := *tmp.(*market.Balance)
+		balances = append(balances, &balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Coin.Code < balances[j].Coin.Code
+	})
+	return balances
+}
+
+/*Get Coin Withdraw Fee
+The transactFeeWithdraw of the default chain, kept by UpdateCoinConstrain*/
+func (e *Huobi) GetTxFee(coin *coin.Coin) float64 { // Withdraw Fee
+	if constrain := e.getCoinConstrain(coin); constrain != nil {
+		return constrain.TxFee
+	}
+	return 0
+}
+
+/*Get Coin Confirmation
+The numOfConfirmations of the default chain, kept by UpdateCoinConstrain*/
+func (e *Huobi) GetConfirmation(coin *coin.Coin) int { // deposit confirmations
+	if constrain := e.getCoinConstrain(coin); constrain != nil {
+		return constrain.Confirmation
+	}
+	return 0
+}
+
+/*Check Coin Withdraw Enable
+The withdrawStatus of the default chain, kept by UpdateCoinConstrain
+false before UpdateCoinConstrain*/
+func (e *Huobi) CanWithdraw(coin *coin.Coin) bool { // does withdraw enable
+	if constrain := e.getCoinConstrain(coin); constrain != nil {
+		return constrain.Withdraw
+	}
+	return false
+}
+
+/*Check Coin Deposit Enable
+The depositStatus of the default chain, kept by UpdateCoinConstrain
+false before UpdateCoinConstrain*/
+func (e *Huobi) CanDeposit(coin *coin.Coin) bool { // does deposit enable
+	if constrain := e.getCoinConstrain(coin); constrain != nil {
+		return constrain.Deposit
+	}
+	return false
+}
+
+func (e *Huobi) getCoinConstrain(coin *coin.Coin) *exchange.CoinConstrain {
+	if coin == nil {
+		return nil
+	}
+	if tmp, ok := e.coinConstrainMap.Get(coin.Code); ok {
+		return tmp.(*exchange.CoinConstrain)
+	}
+	return nil
+}
+
+/*Get trading website URL
+Step 1: Find the website's Exchange page, copy it's URL
+Step 2: Change the pair's syntax to match the URL syntax
+*/
+func (e *Huobi) GetTradingWebURL(pair *pair.Pair) string {
+	return fmt.Sprintf("https://www.huobi.com/en-us/exchange/%s_%s", e.GetSymbol(pair.Target.Code), e.GetSymbol(pair.Base.Code))
+}
@@ -0,0 +1,204 @@
+package huobi
+
+import "encoding/json"
+
+/*The Envelope of the Responses
+v1: status ok or error with err-code & err-msg, the depth and the tickers of a pair are in tick
+v2: code 200 or the error code with message*/
+type JsonResponse struct {
+	Status  string          `json:"status"`
+	ErrCode string          `json:"err-code"`
+	ErrMsg  string          `json:"err-msg"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Ts      int64           `json:"ts"`
+	Data    json.RawMessage `json:"data"`
+	Tick    json.RawMessage `json:"tick"`
+}
+
+type PairsData []struct {
+	BaseCurrency    string  `json:"base-currency"`
+	QuoteCurrency   string  `json:"quote-currency"`
+	PricePrecision  int     `json:"price-precision"`
+	AmountPrecision int     `json:"amount-precision"`
+	SymbolPartition string  `json:"symbol-partition"`
+	Symbol          string  `json:"symbol"`
+	State           string  `json:"state"`
+	ValuePrecision  int     `json:"value-precision"`
+	MinOrderAmt     float64 `json:"min-order-amt"`
+	MaxOrderAmt     float64 `json:"max-order-amt"`
+	MinOrderValue   float64 `json:"min-order-value"`
+}
+
+type OrderBook struct {
+	Bids    [][]float64 `json:"bids"`
+	Asks    [][]float64 `json:"asks"`
+	Version int64       `json:"version"`
+	Ts      int64       `json:"ts"`
+}
+
+type TickerData struct {
+	Symbol string    `json:"symbol"`
+	Open   float64   `json:"open"`
+	Close  float64   `json:"close"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Amount float64   `json:"amount"`
+	Vol    float64   `json:"vol"`
+	Bid    []float64 `json:"bid"`
+	Ask    []float64 `json:"ask"`
+}
+
+// the tickers of all the pairs have the best prices as numbers, eg: bid & bidSize
+type TickersData []struct {
+	Symbol  string  `json:"symbol"`
+	Open    float64 `json:"open"`
+	Close   float64 `json:"close"`
+	High    float64 `json:"high"`
+	Low     float64 `json:"low"`
+	Amount  float64 `json:"amount"`
+	Vol     float64 `json:"vol"`
+	Bid     float64 `json:"bid"`
+	BidSize float64 `json:"bidSize"`
+	Ask     float64 `json:"ask"`
+	AskSize float64 `json:"askSize"`
+}
+
+type TradesData []struct {
+	ID   int64 `json:"id"`
+	Ts   int64 `json:"ts"`
+	Data []struct {
+		ID        json.Number `json:"id"`
+		TradeID   int64       `json:"trade-id"`
+		Price     float64     `json:"price"`
+		Amount    float64     `json:"amount"`
+		Direction string      `json:"direction"`
+		Ts        int64       `json:"ts"`
+	} `json:"data"`
+}
+
+type KlinesData []struct {
+	ID     int64   `json:"id"`
+	Open   float64 `json:"open"`
+	Close  float64 `json:"close"`
+	Low    float64 `json:"low"`
+	High   float64 `json:"high"`
+	Amount float64 `json:"amount"`
+	Vol    float64 `json:"vol"`
+	Count  int     `json:"count"`
+}
+
+type AccountsData []struct {
+	ID      int64  `json:"id"`
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+	State   string `json:"state"`
+}
+
+type AccountBalances struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+	List  []struct {
+		Currency string `json:"currency"`
+		Type     string `json:"type"`
+		Balance  string `json:"balance"`
+	} `json:"list"`
+}
+
+/*The Order
+The order of /v1/order/orders has field-amount, field-cash-amount and field-fees,
+the open orders have filled-amount, filled-cash-amount and filled-fees*/
+type Order struct {
+	ID               int64  `json:"id"`
+	Symbol           string `json:"symbol"`
+	AccountID        int64  `json:"account-id"`
+	ClientOrderID    string `json:"client-order-id"`
+	Amount           string `json:"amount"`
+	Price            string `json:"price"`
+	StopPrice        string `json:"stop-price"`
+	CreatedAt        int64  `json:"created-at"`
+	Type             string `json:"type"`
+	FieldAmount      string `json:"field-amount"`
+	FieldCashAmount  string `json:"field-cash-amount"`
+	FieldFees        string `json:"field-fees"`
+	FilledAmount     string `json:"filled-amount"`
+	FilledCashAmount string `json:"filled-cash-amount"`
+	FilledFees       string `json:"filled-fees"`
+	FinishedAt       int64  `json:"finished-at"`
+	CanceledAt       int64  `json:"canceled-at"`
+	Source           string `json:"source"`
+	State            string `json:"state"`
+}
+
+type MatchResults []struct {
+	ID           int64  `json:"id"`
+	OrderID      int64  `json:"order-id"`
+	MatchID      int64  `json:"match-id"`
+	TradeID      int64  `json:"trade-id"`
+	Symbol       string `json:"symbol"`
+	Type         string `json:"type"`
+	Price        string `json:"price"`
+	FilledAmount string `json:"filled-amount"`
+	FilledFees   string `json:"filled-fees"`
+	FeeCurrency  string `json:"fee-currency"`
+	Role         string `json:"role"`
+	CreatedAt    int64  `json:"created-at"`
+}
+
+type BatchCancel struct {
+	Success []string `json:"success"`
+	Failed  []struct {
+		OrderID string `json:"order-id"`
+		ErrCode string `json:"err-code"`
+		ErrMsg  string `json:"err-msg"`
+	} `json:"failed"`
+}
+
+type TransactFeeRates []struct {
+	Symbol          string `json:"symbol"`
+	MakerFeeRate    string `json:"makerFeeRate"`
+	TakerFeeRate    string `json:"takerFeeRate"`
+	ActualMakerRate string `json:"actualMakerRate"`
+	ActualTakerRate string `json:"actualTakerRate"`
+}
+
+type CurrenciesData []struct {
+	Currency   string   `json:"currency"`
+	InstStatus string   `json:"instStatus"`
+	Chains     []*Chain `json:"chains"`
+}
+
+type Chain struct {
+	Chain               string `json:"chain"`
+	DisplayName         string `json:"displayName"`
+	NumOfConfirmations  int    `json:"numOfConfirmations"`
+	DepositStatus       string `json:"depositStatus"`
+	MinDepositAmt       string `json:"minDepositAmt"`
+	WithdrawStatus      string `json:"withdrawStatus"`
+	MinWithdrawAmt      string `json:"minWithdrawAmt"`
+	WithdrawFeeType     string `json:"withdrawFeeType"`
+	TransactFeeWithdraw string `json:"transactFeeWithdraw"`
+}
+
+type DepositAddresses []struct {
+	Currency   string `json:"currency"`
+	Address    string `json:"address"`
+	AddressTag string `json:"addressTag"`
+	Chain      string `json:"chain"`
+}
+
+type TransfersData []struct {
+	ID         int64   `json:"id"`
+	Type       string  `json:"type"`
+	Currency   string  `json:"currency"`
+	Chain      string  `json:"chain"`
+	TxHash     string  `json:"tx-hash"`
+	Amount     float64 `json:"amount"`
+	Address    string  `json:"address"`
+	AddressTag string  `json:"address-tag"`
+	Fee        float64 `json:"fee"`
+	State      string  `json:"state"`
+	CreatedAt  int64   `json:"created-at"`
+	UpdatedAt  int64   `json:"updated-at"`
+}
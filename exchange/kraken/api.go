@@ -7,10 +7,12 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"reflect"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -59,22 +61,22 @@ func (e *Kraken) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	response := ResponseReturn{}
 
 	strRequestUrl := "/public/Depth"
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	mapParams := make(map[string]string)
 	mapParams["pair"] = e.GetPairCode(p)
 
 	jsonResponseReturn := exchange.HttpGetRequest(strUrl, mapParams)
 	if err := json.Unmarshal([]byte(jsonResponseReturn), &response); err != nil {
-		return nil, fmt.Errorf("Kraken Unmarshal Response error: %s", err)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrNetwork, "Unmarshal Response error: %s", err)
 	}
 	if len(response.Error) != 0 {
-		return nil, fmt.Errorf("Kraken OrderBook error: %s", response.Error)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", errKind(response.Error), "%s", response.Error)
 	}
 
 	data := make(map[string]*OrderBook)
 	if err := json.Unmarshal(response.Result, &data); err != nil {
-		return nil, fmt.Errorf("Kraken Unmarshal Result error: %s", err)
+		return nil, exchange.Errorf(e.GetName(), "OrderBook", exchange.ErrUnknown, "Unmarshal Result error: %s", err)
 	}
 
 	//Convert Exchange Struct to Maker
@@ -116,15 +118,256 @@ func (e *Kraken) OrderBook(p *pair.Pair) (*market.Maker, error) {
 	return maker, nil
 }
 
+/*Get the Ticker of the Pair*/
+func (e *Kraken) Ticker(p *pair.Pair) (*market.Ticker, error) {
+	tickers, err := e.getTickers([]*pair.Pair{p})
+	if err != nil {
+		return nil, err
+	}
+	if len(tickers) == 0 {
+		return nil, exchange.Errorf(e.GetName(), "Ticker", exchange.ErrNotFound, "%s does not have the pair %s", e.GetName(), p.Name)
+	}
+	return tickers[0], nil
+}
+
+/*Get the Tickers of All the Pairs
+/public/Ticker accepts many pairs, all the pairs are requested in one call*/
+func (e *Kraken) Tickers() ([]*market.Ticker, error) {
+	return e.getTickers(e.pairList)
+}
+
+func (e *Kraken) getTickers(pairs []*pair.Pair) ([]*market.Ticker, error) {
+	response := ResponseReturn{}
+
+	strRequestUrl := "/public/Ticker"
+	strUrl := e.API_URL + strRequestUrl
+
+	codes := make([]string, len(pairs))
+	for i, p := range pairs {
+		codes[i] = e.GetPairCode(p)
+	}
+	mapParams := make(map[string]string)
+	mapParams["pair"] = strings.Join(codes, ",")
+
+	jsonTickerReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonTickerReturn), &response); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getTickers", exchange.ErrNetwork, "Ticker Unmarshal Response error: %s", err)
+	}
+	if len(response.Error) != 0 {
+		return nil, exchange.Errorf(e.GetName(), "getTickers", errKind(response.Error), "Ticker error: %s", response.Error)
+	}
+
+	data := make(map[string]*TickerData)
+	if err := json.Unmarshal(response.Result, &data); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getTickers", exchange.ErrUnknown, "Ticker Unmarshal Result error: %s", err)
+	}
+
+	now := time.Now().UnixNano() / 1e6
+	tickers := []*market.Ticker{}
+	for code, t := range data {
+		p := e.getPairByCode(code)
+		if p == nil || len(t.Ask) == 0 || len(t.Bid) == 0 || len(t.Close) == 0 || len(t.Volume) < 2 || len(t.High) < 2 || len(t.Low) < 2 {
+			continue
+		}
+
+		ticker := &market.Ticker{}
+		ticker.Pair = p
+		ticker.Ask, _ = strconv.ParseFloat(t.Ask[0], 64)
+		ticker.Bid, _ = strconv.ParseFloat(t.Bid[0], 64)
+		ticker.Last, _ = strconv.ParseFloat(t.Close[0], 64)
+		//index 0: today, index 1: last 24 hours
+		ticker.High, _ = strconv.ParseFloat(t.High[1], 64)
+		ticker.Low, _ = strconv.ParseFloat(t.Low[1], 64)
+		ticker.Volume, _ = strconv.ParseFloat(t.Volume[1], 64)
+		if len(t.VWAP) > 1 {
+			vwap, _ := strconv.ParseFloat(t.VWAP[1], 64)
+			ticker.QuoteVolume = ticker.Volume * vwap
+		}
+		ticker.Timestamp = now
+		tickers = append(tickers, ticker)
+	}
+
+	sort.Slice(tickers, func(i, j int) bool {
+		return tickers[i].Pair.Name < tickers[j].Pair.Name
+	})
+	return tickers, nil
+}
+
+/*Get the Trades of the Market Since the Time
+Step 1: /public/Trades returns up to 1000 trades after the cursor, the first cursor is the time in nanoseconds
+Step 2: Request with the "last" cursor of the response until less than 1000 trades are returned
+trade: [price, volume, time, buy/sell, market/limit, miscellaneous, trade id]*/
+func (e *Kraken) RecentTrades(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	strRequestUrl := "/public/Trades"
+	strUrl := e.API_URL + strRequestUrl
+
+	trades := []*market.Trade{}
+	cursor := fmt.Sprint(since.UnixNano())
+	for {
+		response := ResponseReturn{}
+		mapParams := make(map[string]string)
+		mapParams["pair"] = e.GetPairCode(p)
+		mapParams["since"] = cursor
+
+		jsonTradesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+		if err := json.Unmarshal([]byte(jsonTradesReturn), &response); err != nil {
+			return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrNetwork, "Unmarshal Response error: %s", err)
+		}
+		if len(response.Error) != 0 {
+			return nil, exchange.Errorf(e.GetName(), "RecentTrades", errKind(response.Error), "%s", response.Error)
+		}
+
+		data := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(response.Result, &data); err != nil {
+			return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrUnknown, "Unmarshal Result error: %s", err)
+		}
+
+		last := ""
+		page := [][]interface{}{}
+		for key, raw := range data {
+			if key == "last" {
+				var err error
+				if last, err = lastCursor(raw); err != nil {
+					return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrUnknown, "Unmarshal last error: %s %s", err, raw)
+				}
+			} else if err := json.Unmarshal(raw, &page); err != nil {
+				return nil, exchange.Errorf(e.GetName(), "RecentTrades", exchange.ErrUnknown, "Unmarshal Trades error: %s", err)
+			}
+		}
+
+		for _, t := range page {
+			if len(t) < 4 {
+				continue
+			}
+			trade := &market.Trade{}
+			trade.Pair = p
+			trade.Rate, _ = strconv.ParseFloat(fmt.Sprint(t[0]), 64)
+			trade.Quantity, _ = strconv.ParseFloat(fmt.Sprint(t[1]), 64)
+			timestamp, _ := strconv.ParseFloat(fmt.Sprint(t[2]), 64)
+			trade.Timestamp = int64(timestamp * 1000)
+			if t[3] == "s" {
+				trade.Side = market.Sell
+			} else {
+				trade.Side = market.Buy
+			}
+			if len(t) > 6 {
+				trade.TradeID = fmt.Sprint(t[6])
+			}
+			trade.Liquidity = market.LiquidityTaker
+			trades = append(trades, trade)
+		}
+
+		if len(page) < 1000 || last == "" || last == cursor {
+			break
+		}
+		cursor = last
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+// the cursor is a string of nanoseconds or a number of trade id
+func lastCursor(raw json.RawMessage) (string, error) {
+	var last interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&last); err != nil {
+		return "", err
+	}
+	return fmt.Sprint(last), nil
+}
+
+// the intervals of /public/OHLC in minutes
+var ohlcIntervals = map[time.Duration]int{
+	time.Minute:         1,
+	5 * time.Minute:     5,
+	15 * time.Minute:    15,
+	30 * time.Minute:    30,
+	time.Hour:           60,
+	4 * time.Hour:       240,
+	24 * time.Hour:      1440,
+	7 * 24 * time.Hour:  10080,
+	15 * 24 * time.Hour: 21600,
+}
+
+/*Get the Candles of the Pair Since the Time
+/public/OHLC returns the latest 720 candles, the intervals it doesn't support are built from the recent trades
+candle: [time, open, high, low, close, vwap, volume, count]*/
+func (e *Kraken) Candles(p *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error) {
+	minutes, ok := ohlcIntervals[interval]
+	if !ok {
+		return exchange.CandlesFromTrades(e, p, interval, since)
+	}
+
+	response := ResponseReturn{}
+
+	strRequestUrl := "/public/OHLC"
+	strUrl := e.API_URL + strRequestUrl
+
+	mapParams := make(map[string]string)
+	mapParams["pair"] = e.GetPairCode(p)
+	mapParams["interval"] = fmt.Sprint(minutes)
+	mapParams["since"] = fmt.Sprint(since.Truncate(interval).Unix() - 1)
+
+	jsonCandlesReturn := exchange.HttpGetRequest(strUrl, mapParams)
+	if err := json.Unmarshal([]byte(jsonCandlesReturn), &response); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrNetwork, "Unmarshal Response error: %s", err)
+	}
+	if len(response.Error) != 0 {
+		return nil, exchange.Errorf(e.GetName(), "Candles", errKind(response.Error), "%s", response.Error)
+	}
+
+	data := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(response.Result, &data); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrUnknown, "Unmarshal Result error: %s", err)
+	}
+
+	candles := []*market.Candle{}
+	for key, raw := range data {
+		if key == "last" {
+			continue
+		}
+		bars := [][]interface{}{}
+		if err := json.Unmarshal(raw, &bars); err != nil {
+			return nil, exchange.Errorf(e.GetName(), "Candles", exchange.ErrUnknown, "Unmarshal OHLC error: %s", err)
+		}
+		for _, bar := range bars {
+			if len(bar) < 7 {
+				continue
+			}
+			timestamp, _ := strconv.ParseFloat(fmt.Sprint(bar[0]), 64)
+			candle := &market.Candle{}
+			candle.Pair = p
+			candle.Timestamp = int64(timestamp) * 1000
+			candle.Open, _ = strconv.ParseFloat(fmt.Sprint(bar[1]), 64)
+			candle.High, _ = strconv.ParseFloat(fmt.Sprint(bar[2]), 64)
+			candle.Low, _ = strconv.ParseFloat(fmt.Sprint(bar[3]), 64)
+			candle.Close, _ = strconv.ParseFloat(fmt.Sprint(bar[4]), 64)
+			vwap, _ := strconv.ParseFloat(fmt.Sprint(bar[5]), 64)
+			candle.Volume, _ = strconv.ParseFloat(fmt.Sprint(bar[6]), 64)
+			candle.QuoteVolume = candle.Volume * vwap
+			candles = append(candles, candle)
+		}
+	}
+
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].Timestamp < candles[j].Timestamp
+	})
+	return candles, nil
+}
+
 /*Get Coins Information (If API provide)
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)*/
-func GetKrakenCoin() map[string]*CoinData {
+func (e *Kraken) GetKrakenCoin() map[string]*CoinData {
 	response := ResponseReturn{}
 
 	strRequestUrl := "/public/Assets"
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	jsonResponseReturn := exchange.HttpGetRequest(strUrl, nil)
 	if err := json.Unmarshal([]byte(jsonResponseReturn), &response); err != nil {
@@ -149,20 +392,20 @@ func GetKrakenCoin() map[string]*CoinData {
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)*/
-func GetKrakenPair() map[string]*PairData {
+func (e *Kraken) GetKrakenPair() map[string]*PairData {
 	response := ResponseReturn{}
 
 	strRequestUrl := "/public/AssetPairs"
-	strUrl := API_URL + strRequestUrl
+	strUrl := e.API_URL + strRequestUrl
 
 	jsonResponseReturn := exchange.HttpGetRequest(strUrl, nil)
 	if err := json.Unmarshal([]byte(jsonResponseReturn), &response); err != nil {
 		log.Printf("Kraken Unmarshal Response error: %s", err)
-		return make(map[string]*PairData)
+		return nil
 	}
 	if len(response.Error) != 0 {
 		log.Printf("Kraken Get Coin error: %s", response.Error)
-		return make(map[string]*PairData)
+		return nil
 	}
 
 	pairsInfo := make(map[string]*PairData)
@@ -174,9 +417,31 @@ func GetKrakenPair() map[string]*PairData {
 	return pairsInfo
 }
 
+/*Get the Server Time
+Used by the clock of the signed requests*/
+func (e *Kraken) GetKrakenTime() (time.Time, error) {
+	response := ResponseReturn{}
+	serverTime := ServerTime{}
+
+	strRequestUrl := "/public/Time"
+	strUrl := e.API_URL + strRequestUrl
+
+	jsonTimeReturn := exchange.HttpGetRequest(strUrl, nil)
+	if err := json.Unmarshal([]byte(jsonTimeReturn), &response); err != nil {
+		return time.Time{}, exchange.Errorf(e.GetName(), "GetKrakenTime", exchange.ErrNetwork, "Get Server Time Unmarshal Err: %v %v", err, jsonTimeReturn)
+	}
+	if len(response.Error) != 0 {
+		return time.Time{}, exchange.Errorf(e.GetName(), "GetKrakenTime", errKind(response.Error), "Get Server Time Err: %v", response.Error)
+	}
+	if err := json.Unmarshal(response.Result, &serverTime); err != nil {
+		return time.Time{}, exchange.Errorf(e.GetName(), "GetKrakenTime", exchange.ErrUnknown, "Get Server Time Result Unmarshal Err: %v %s", err, response.Result)
+	}
+	return time.Unix(serverTime.UnixTime, 0), nil
+}
+
 /*************** Private API ***************/
-func (e *Kraken) UpdateAllBalances() {
-	e.UpdateAllBalancesByUser(nil)
+func (e *Kraken) UpdateAllBalances() error {
+	return e.UpdateAllBalancesByUser(nil)
 }
 
 /*Get Exchange Account All Coins Balance  --reference Binance
@@ -184,21 +449,17 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
 Step 3: Modify API Path(strRequestUrl)
 Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Get Coin Availamount and store in balanceMap*/
-func (e *Kraken) UpdateAllBalancesByUser(u *user.User) {
+Step 5: Get Coin Balance (market.Balance) and store in balanceMap*/
+func (e *Kraken) UpdateAllBalancesByUser(u *user.User) error {
 	var uInstance *Kraken
 	if u != nil {
-		uInstance = &Kraken{}
-		uInstance.API_KEY = u.API_KEY
-		uInstance.API_SECRET = u.API_SECRET
-		// uInstance.MakerDB = e.MakerDB
+		uInstance = e.ForUser(u)
 	} else {
 		uInstance = e
 	}
 
 	if uInstance.API_KEY == "" || uInstance.API_SECRET == "" {
-		log.Printf("Kraken API Key or Secret Key are nil.")
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := ResponseReturn{} //JsonResponse{}
@@ -206,79 +467,400 @@ func (e *Kraken) UpdateAllBalancesByUser(u *user.User) {
 	accountBalance := AccountBalances{}
 	strRequest := "/private/Balance"
 	mapParams := make(map[string]string)
-	//mapParams["pair"] = e.GetPairCode(p)
 
 	jsonBalanceReturn := uInstance.ApiKeyPost(mapParams, strRequest)
-	log.Printf("==jsonBalanceReturn: %v", jsonBalanceReturn) //=====
-
 	if err := json.Unmarshal([]byte(jsonBalanceReturn), &jsonResponse); err != nil {
-		log.Printf("Kraken Get Balance Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrNetwork, "Json Unmarshal Err: %v %v", err, jsonBalanceReturn)
 	}
-	log.Printf("==jsonResponse: %v", jsonResponse) //=====
 	if len(jsonResponse.Error) != 0 {
-		log.Printf("Kraken Get Balance Err: %v ", jsonResponse.Error)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
 	}
 
 	if err := json.Unmarshal(jsonResponse.Result, &accountBalance); err != nil {
-		log.Printf("Kraken Get Balance Data Unmarshal Err: %v %v", err, jsonResponse.Result)
-		return
+		return exchange.Errorf(e.GetName(), "UpdateAllBalances", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
 	} else {
-		structInf := reflect.Indirect(reflect.ValueOf(accountBalance))
-		for i := 0; i < structInf.NumField(); i++ {
-			fieldName := structInf.Type().Field(i).Name
-			fieldValue := structInf.Field(i)
-			c := coin.GetCoin(e.GetCode(fieldName))
-			if c != nil {
-				available, err := strconv.ParseFloat(fieldValue.String(), 64)
-				if err == nil {
-					balanceMap.Set(c.Code, available)
+		now := time.Now().UnixNano() / 1e6
+		balances := make(map[string]*market.Balance)
+		for symbol, value := range accountBalance {
+			total, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				log.Printf("Kraken Get Balance %s is not a number: %v", symbol, value)
+				continue
+			}
+
+			c := e.registerCoin(symbol)
+			balance, ok := balances[c.Code]
+			if !ok {
+				balance = &market.Balance{}
+				balance.Coin = c
+				balance.Timestamp = now
+				balances[c.Code] = balance
+			}
+			balance.Total += total
+			if strings.Contains(symbol, ".") { //staked .S & rewards .M can't be traded
+				balance.Locked += total
+			}
+		}
+
+		// Kraken returns the total balance, the open orders hold the rest
+		orders, err := uInstance.ListOpenOrders(nil)
+		if err != nil {
+			return err // the cached balances are kept, the available can't be known
+		}
+		for _, order := range orders {
+			remain := order.Quantity - order.DealQuantity
+			if order.Side == string(market.Sell) {
+				if balance, ok := balances[order.Pair.Target.Code]; ok {
+					balance.Locked += remain
 				}
+			} else if balance, ok := balances[order.Pair.Base.Code]; ok {
+				balance.Locked += remain * order.Rate
 			}
 		}
-	}
-	//TODO: GetBalance
 
+		for code, balance := range balances {
+			balance.Available = balance.Total - balance.Locked
+			if balance.Available < 0 {
+				balance.Available = 0
+			}
+			uInstance.balanceMap.Set(code, balance)
+		}
+		for _, code := range uInstance.balanceMap.Keys() {
+			if _, ok := balances[code]; !ok {
+				uInstance.balanceMap.Remove(code) // not held anymore
+			}
+		}
+	}
+	return nil
 }
 
 /*Withdraw the coin to another address
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Call ApiKey Function (Depend on API request)
-Step 5: Check the success of withdraw*/
-func (e *Kraken) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) bool {
+Step 1: Get the fee of the withdrawal (WithdrawInfo)
+Step 2: Withdraw, the reference id is the withdrawal id
+addr is the withdrawal key name set up on the account, Kraken doesn't accept an address by API*/
+func (e *Kraken) Withdraw(coin *coin.Coin, quantity float64, addr, tag string) (*exchange.WithdrawalResult, error) {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		log.Printf("Kraken API Key or Secret Key are nil.")
-		return false
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := ResponseReturn{}
-	strRequest := "/private/Withdraw"
+	withdrawInfo := WithdrawInfo{}
+	strRequest := "/private/WithdrawInfo"
 
 	mapParams := make(map[string]string)
-
 	//key = withdrawal key name, as set up on your account
 	mapParams["key"] = addr
 	//asset = asset being withdrawn
 	mapParams["asset"] = e.GetSymbol(coin.Code)
-	mapParams["Amount"] = fmt.Sprintf("%f", quantity)
+	mapParams["amount"] = strconv.FormatFloat(quantity, 'f', -1, 64)
+
+	jsonWithdrawInfo := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonWithdrawInfo), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonWithdrawInfo)
+	}
+	if len(jsonResponse.Error) != 0 {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Result, &withdrawInfo); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+	}
+
+	jsonResponse = ResponseReturn{}
+	withdrawResponse := WithdrawResponse{}
+	strRequest = "/private/Withdraw"
 
 	jsonSubmitWithdraw := e.ApiKeyPost(mapParams, strRequest)
-	log.Printf("jsonSubmitWithdraw: %+v", jsonSubmitWithdraw)
 	if err := json.Unmarshal([]byte(jsonSubmitWithdraw), &jsonResponse); err != nil {
-		log.Printf("Kraken Withdraw Json Unmarshal failed: %v %v", err, jsonSubmitWithdraw)
-		return false
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonSubmitWithdraw)
 	}
 	if len(jsonResponse.Error) != 0 {
-		log.Printf("Kraken jsonSubmitWithdraw Unmarshal Err: %v ", jsonResponse.Error)
-		return false
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Result, &withdrawResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "Withdraw", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
 	}
 
-	return false
 	//Note that the first withdrawal to an address will still have to be confirmed manually by
 	//clicking a link sent to the user via e-mail, even if the withdrawal request is made via the API.
+	withdrawal := &exchange.WithdrawalResult{}
+	withdrawal.ID = withdrawResponse.RefID
+	withdrawal.Coin = coin
+	withdrawal.Quantity = quantity
+	withdrawal.Fee = withdrawInfo.Fee
+	withdrawal.Address = addr
+	withdrawal.Tag = tag
+	withdrawal.Status = exchange.TransferPending
+	withdrawal.Timestamp = time.Now().UnixNano() / 1e6
+	return withdrawal, nil
+}
+
+/*Update the Status of the Withdrawal
+Find the withdrawal by the reference id in the recent withdrawals of the coin (WithdrawStatus)*/
+func (e *Kraken) WithdrawalStatus(withdrawal *exchange.WithdrawalResult) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if withdrawal == nil || withdrawal.Coin == nil {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrRejected, "withdrawal or its coin is nil")
+	}
+
+	jsonResponse := ResponseReturn{}
+	transferStatus := []*TransferStatus{}
+	strRequest := "/private/WithdrawStatus"
+
+	mapParams := make(map[string]string)
+	mapParams["asset"] = e.GetSymbol(withdrawal.Coin.Code)
+
+	jsonWithdrawStatus := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonWithdrawStatus), &jsonResponse); err != nil {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonWithdrawStatus)
+	}
+	if len(jsonResponse.Error) != 0 {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Result, &transferStatus); err != nil {
+		return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+	}
+
+	for _, t := range transferStatus {
+		if t.RefID == withdrawal.ID {
+			withdrawal.Fee = t.Fee
+			withdrawal.TxHash = t.TxID
+			withdrawal.Status = transferStatusOf(t.Status, t.StatusProp)
+			withdrawal.Timestamp = t.Time * 1000
+			return nil
+		}
+	}
+	return exchange.Errorf(e.GetName(), "WithdrawalStatus", exchange.ErrNotFound, "withdrawal %s is not in the recent withdrawals", withdrawal.ID)
+}
+
+/*Get the Deposit Address of the Coin
+Step 1: Get the first deposit method of the coin
+Step 2: Get the addresses of the method, generate one if there is no address yet*/
+func (e *Kraken) GetDepositAddress(coin *coin.Coin) (*exchange.DepositAddress, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := ResponseReturn{}
+	methods := []*DepositMethod{}
+	strRequest := "/private/DepositMethods"
+
+	mapParams := make(map[string]string)
+	mapParams["asset"] = e.GetSymbol(coin.Code)
+
+	jsonMethods := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonMethods), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonMethods)
+	}
+	if len(jsonResponse.Error) != 0 {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Result, &methods); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+	}
+	if len(methods) == 0 {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrNotFound, "no deposit method for %s", coin.Code)
+	}
+
+	addresses, err := e.getDepositAddresses(coin, methods[0].Method, false)
+	if err == nil && len(addresses) == 0 && methods[0].GenAddress {
+		addresses, err = e.getDepositAddresses(coin, methods[0].Method, true)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", exchange.ErrNotFound, "no deposit address for %s method %s", coin.Code, methods[0].Method)
+	}
+
+	address := &exchange.DepositAddress{}
+	address.Coin = coin
+	address.Address = addresses[0].Address
+	address.Tag = addresses[0].Tag
+	if address.Tag == "" {
+		address.Tag = addresses[0].Memo
+	}
+	return address, nil
+}
+
+func (e *Kraken) getDepositAddresses(coin *coin.Coin, method string, generate bool) ([]*DepositAddress, error) {
+	jsonResponse := ResponseReturn{}
+	addresses := []*DepositAddress{}
+	strRequest := "/private/DepositAddresses"
+
+	mapParams := make(map[string]string)
+	mapParams["asset"] = e.GetSymbol(coin.Code)
+	mapParams["method"] = method
+	if generate {
+		mapParams["new"] = "true"
+	}
+
+	jsonAddresses := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonAddresses), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getDepositAddresses", exchange.ErrNetwork, "GetDepositAddress Unmarshal Err: %v %v", err, jsonAddresses)
+	}
+	if len(jsonResponse.Error) != 0 {
+		return nil, exchange.Errorf(e.GetName(), "GetDepositAddress", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Result, &addresses); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "getDepositAddresses", exchange.ErrUnknown, "GetDepositAddress Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+	}
+	return addresses, nil
+}
+
+/*Get the Deposits and Withdrawals of the Coin Since the Time
+Step 1: Get the recent deposits (DepositStatus) and withdrawals (WithdrawStatus)
+Step 2: Sort by time
+Kraken requires the asset, nil coin is not supported*/
+func (e *Kraken) GetTransfers(coin *coin.Coin, since time.Time) ([]*exchange.Transfer, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if coin == nil {
+		return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrUnsupported, "transfers of all coins are not supported, the coin is required")
+	}
+
+	transfers := []*exchange.Transfer{}
+	for strRequest, transferType := range map[string]exchange.TransferType{"/private/DepositStatus": exchange.Deposit, "/private/WithdrawStatus": exchange.Withdrawal} {
+		jsonResponse := ResponseReturn{}
+		transferStatus := []*TransferStatus{}
+
+		mapParams := make(map[string]string)
+		mapParams["asset"] = e.GetSymbol(coin.Code)
+
+		jsonTransfers := e.ApiKeyPost(mapParams, strRequest)
+		if err := json.Unmarshal([]byte(jsonTransfers), &jsonResponse); err != nil {
+			return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonTransfers)
+		}
+		if len(jsonResponse.Error) != 0 {
+			return nil, exchange.Errorf(e.GetName(), "GetTransfers", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+		}
+		if err := json.Unmarshal(jsonResponse.Result, &transferStatus); err != nil {
+			return nil, exchange.Errorf(e.GetName(), "GetTransfers", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+		}
+
+		for _, t := range transferStatus {
+			if t.Time < since.Unix() {
+				continue
+			}
+
+			transfer := &exchange.Transfer{}
+			transfer.ID = t.RefID
+			transfer.Coin = coin
+			transfer.Type = transferType
+			transfer.Quantity = t.Amount
+			transfer.Fee = t.Fee
+			transfer.Address = t.Info
+			transfer.TxHash = t.TxID
+			transfer.Status = transferStatusOf(t.Status, t.StatusProp)
+			transfer.Timestamp = t.Time * 1000
+			transfers = append(transfers, transfer)
+		}
+	}
+
+	sort.Slice(transfers, func(i, j int) bool {
+		return transfers[i].Timestamp < transfers[j].Timestamp
+	})
+	return transfers, nil
+}
+
+// status: Initial, Pending, Settled, Success, Failure  status-prop: return, onhold, cancel-pending, canceled
+func transferStatusOf(status, statusProp string) exchange.TransferStatus {
+	switch {
+	case statusProp == "canceled":
+		return exchange.TransferCanceled
+	case status == "Success":
+		return exchange.TransferCompleted
+	case status == "Failure":
+		return exchange.TransferFailed
+	default:
+		return exchange.TransferPending
+	}
+}
+
+/*Update the Maker & Taker Fee of the Account
+Step 1: Get the 30 day volume and the fee of each pair from TradeVolume
+Step 2: Use the fee schedule at the volume for the pairs TradeVolume does not return
+Step 3: Store the fees in feeMap, GetTradeFee returns them*/
+func (e *Kraken) UpdateFees() error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := ResponseReturn{}
+	tradeVolume := TradeVolume{}
+	strRequest := "/private/TradeVolume"
+
+	codes := make([]string, len(e.pairList))
+	for i, p := range e.pairList {
+		codes[i] = e.GetPairCode(p)
+	}
+	mapParams := make(map[string]string)
+	mapParams["pair"] = strings.Join(codes, ",")
+	mapParams["fee-info"] = "true"
+
+	jsonTradeVolume := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonTradeVolume), &jsonResponse); err != nil {
+		return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonTradeVolume)
+	}
+	if len(jsonResponse.Error) != 0 {
+		return exchange.Errorf(e.GetName(), "UpdateFees", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Result, &tradeVolume); err != nil {
+		return exchange.Errorf(e.GetName(), "UpdateFees", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+	}
+
+	now := time.Now().UnixNano() / 1e6
+	fees := make(map[string]*exchange.TradeFee)
+	for _, p := range e.pairList {
+		fee := e.scheduleFee(p, tradeVolume.Volume)
+		fee.Source = exchange.SourceAPI
+		fee.Timestamp = now
+		fees[p.Name] = fee
+	}
+	for code, info := range tradeVolume.Fees {
+		if p := e.getPairByCode(code); p != nil && fees[p.Name] != nil {
+			fees[p.Name].Taker = info.Fee / 100
+			fees[p.Name].Maker = info.Fee / 100 //replaced by fees_maker if the pair has maker fees
+		}
+	}
+	for code, info := range tradeVolume.FeesMaker {
+		if p := e.getPairByCode(code); p != nil && fees[p.Name] != nil {
+			fees[p.Name].Maker = info.Fee / 100
+		}
+	}
+
+	for name, fee := range fees {
+		e.feeMap.Set(name, fee)
+	}
+	return nil
+}
+
+/*Get the Token of the Private WebSocket
+The token should be used in 15 minutes, the subscriptions stay valid after it expires*/
+func (e *Kraken) getWebSocketsToken() (string, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return "", exchange.Errorf(e.GetName(), "getWebSocketsToken", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := ResponseReturn{}
+	token := WebSocketsToken{}
+	strRequest := "/private/GetWebSocketsToken"
+
+	mapParams := make(map[string]string)
+
+	jsonToken := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonToken), &jsonResponse); err != nil {
+		return "", exchange.Errorf(e.GetName(), "getWebSocketsToken", exchange.ErrNetwork, "GetWebSocketsToken Unmarshal Err: %v %v", err, jsonToken)
+	}
+	if len(jsonResponse.Error) != 0 {
+		return "", exchange.Errorf(e.GetName(), "StreamUserData", exchange.ErrAuth, "%+v", jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Result, &token); err != nil {
+		return "", exchange.Errorf(e.GetName(), "getWebSocketsToken", exchange.ErrUnknown, "GetWebSocketsToken Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+	}
+	return token.Token, nil
 }
 
 /*Get the Status of a Singal Order  --reference Binance
@@ -289,60 +871,410 @@ Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
 Step 5: Change Order Status (Status reference ../market/market.go)*/
 func (e *Kraken) OrderStatus(order *market.Order) error {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return fmt.Errorf("Kraken API Key or Secret Key are nil.")
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	orders, err := e.queryOrders([]string{order.OrderID})
+	if err != nil {
+		return err
+	}
+	o, ok := orders[order.OrderID]
+	if !ok {
+		return exchange.Errorf(e.GetName(), "OrderStatus", exchange.ErrNotFound, "order %s is not found", order.OrderID)
+	}
+	updateOrder(order, e.toOrder(order.Pair, order.OrderID, o))
+	return nil
+}
+
+/*Get the Status of Many Orders
+QueryOrders takes 50 txids at most, the orders are queried in batches of 50
+The orders Kraken doesn't return are left unchanged and ErrNotFound is returned after the others are updated, as OrderStatus*/
+func (e *Kraken) OrdersStatus(orders []*market.Order) error {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return exchange.Errorf(e.GetName(), "OrdersStatus", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
+	const batch = 50
+	var missing error
+	for start := 0; start < len(orders); start += batch {
+		end := start + batch
+		if end > len(orders) {
+			end = len(orders)
+		}
+
+		txids := make([]string, 0, end-start)
+		for _, order := range orders[start:end] {
+			txids = append(txids, order.OrderID)
+		}
+		result, err := e.queryOrders(txids)
+		if err != nil {
+			return err
+		}
+		for _, order := range orders[start:end] {
+			o, ok := result[order.OrderID]
+			if !ok {
+				if missing == nil {
+					missing = exchange.Errorf(e.GetName(), "OrdersStatus", exchange.ErrNotFound, "order %s is not found", order.OrderID)
+				}
+				continue
+			}
+			updateOrder(order, e.toOrder(order.Pair, order.OrderID, o))
+		}
+	}
+	return missing
+}
+
+// the orders of the txids from QueryOrders, keyed by txid
+func (e *Kraken) queryOrders(txids []string) (map[string]*Order, error) {
 	jsonResponse := ResponseReturn{}
-	orderStatus := Order{}
-	strRequest := "/private/QueryOrders" //"/private/OpenOrders"
+	orders := make(map[string]*Order)
+	strRequest := "/private/QueryOrders"
 
 	mapParams := make(map[string]string)
-	mapParams["order_txid"] = order.OrderID
+	mapParams["txid"] = strings.Join(txids, ",")
 
 	jsonOrderStatus := e.ApiKeyPost(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonOrderStatus), &jsonResponse); err != nil {
-		return fmt.Errorf("Kraken OrderStatus Unmarshal Err: %v %v", err, jsonOrderStatus)
+		return nil, exchange.Errorf(e.GetName(), "queryOrders", exchange.ErrNetwork, "OrderStatus Unmarshal Err: %v %v", err, jsonOrderStatus)
 	}
 	if len(jsonResponse.Error) != 0 {
-		log.Printf("Kraken jsonOrderStatus Unmarshal Err: %v ", jsonResponse.Error)
-		return nil
+		return nil, exchange.Errorf(e.GetName(), "OrderStatus", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Result, &orders); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "queryOrders", exchange.ErrUnknown, "OrderStatus Data Unmarshal Err: %v %s", err, jsonResponse.Result)
 	}
+	return orders, nil
+}
 
-	if err := json.Unmarshal(jsonResponse.Result, &orderStatus); err != nil {
-		return fmt.Errorf("Kraken Get OrderStatus Data Unmarshal Err: %v %s", err, jsonResponse.Result)
-	} else {
+// copy the status and the deal of the order from the exchange
+func updateOrder(order, updated *market.Order) {
+	order.Status = updated.Status
+	order.StatusMessage = updated.StatusMessage
+	order.DealQuantity = updated.DealQuantity
+	order.DealRate = updated.DealRate
+}
+
+/*Get an Order by the Client Order ID
+Kraken only keeps an int32 userref, the client order id is hashed to the userref when the order is placed,
+so other orders may have the same userref
+Step 1: Search the open and the closed orders with the userref
+Step 2: More than one order: keep the orders of the pair in the order description
+Step 3: Still more than one: ErrUnknown, it can't tell which one is the order
+Step 4: ErrNotFound if the order is in neither, it is safe to place it again*/
+func (e *Kraken) OrderByClientID(pair *pair.Pair, clientOrderID string) (*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
 
-		if orderStatus.TransactionID == order.OrderID {
-			if strings.Contains(orderStatus.Misc, "partial") {
-				order.Status = market.Partial
+	userref := userRef(clientOrderID)
+	matched := []*market.Order{}
+	for _, strRequest := range []string{"/private/OpenOrders", "/private/ClosedOrders"} {
+		jsonResponse := ResponseReturn{}
+		mapParams := make(map[string]string)
+		mapParams["userref"] = fmt.Sprint(userref)
+
+		jsonOrders := e.ApiKeyPost(mapParams, strRequest)
+		if err := json.Unmarshal([]byte(jsonOrders), &jsonResponse); err != nil {
+			return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrders)
+		}
+		if len(jsonResponse.Error) != 0 {
+			return nil, exchange.Errorf(e.GetName(), "OrderByClientID", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+		}
+
+		var orders map[string]*Order
+		if strRequest == "/private/OpenOrders" {
+			openOrders := OpenOrders{}
+			if err := json.Unmarshal(jsonResponse.Result, &openOrders); err != nil {
+				return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+			}
+			orders = openOrders.Open
+		} else {
+			closedOrders := ClosedOrders{}
+			if err := json.Unmarshal(jsonResponse.Result, &closedOrders); err != nil {
+				return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
 			}
+			orders = closedOrders.Closed
 		}
-		// need change
-		/* for _, list := range orderStatus {
-			orderIDStr := fmt.Sprintf("%d", list.OrderID)
-			if orderIDStr == order.OrderID {
-				if list.Remaining == 0 {
-					order.Status = market.Filled
-				} else if list.Remaining == list.Amount {
-					order.Status = market.New
-				} else {
-					order.Status = market.Partial
-				}
+
+		for txid, o := range orders {
+			if o.UserRef != userref {
+				continue
 			}
-		} */
+			order := e.toOrder(e.getPairByCode(o.Description.Pair), txid, o)
+			order.ClientOrderID = clientOrderID
+			matched = append(matched, order)
+		}
 	}
 
-	return nil
+	if len(matched) > 1 && pair != nil {
+		samePair := []*market.Order{}
+		for _, order := range matched {
+			if order.Pair != nil && order.Pair.Name == pair.Name {
+				samePair = append(samePair, order)
+			}
+		}
+		matched = samePair
+	}
+	switch len(matched) {
+	case 0:
+		return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrNotFound, "client order id %s is not found", clientOrderID)
+	case 1:
+		return matched[0], nil
+	}
+	txids := []string{}
+	for _, order := range matched {
+		txids = append(txids, order.OrderID)
+	}
+	sort.Strings(txids)
+	return nil, exchange.Errorf(e.GetName(), "OrderByClientID", exchange.ErrUnknown, "client order id %s matches the orders %v by userref %d", clientOrderID, txids, userref)
+}
+
+// Kraken userref is int32, numeric client order id is used as it is, others are hashed
+func userRef(clientOrderID string) int32 {
+	if ref, err := strconv.ParseInt(clientOrderID, 10, 32); err == nil {
+		return int32(ref)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(clientOrderID))
+	return int32(h.Sum32() & 0x7fffffff)
+}
+
+func (e *Kraken) toOrder(pair *pair.Pair, txid string, o *Order) *market.Order {
+	order := &market.Order{}
+	order.Pair = pair
+	order.OrderID = txid
+	order.Rate = o.Description.Price
+	order.Quantity = o.Volume
+	order.DealRate = o.Price
+	if o.AveragePrice != 0 {
+		order.DealRate = o.AveragePrice
+	}
+	order.DealQuantity = o.VolumeExecuted
+	if o.Description.Type == "sell" {
+		order.Side = string(market.Sell)
+	} else {
+		order.Side = string(market.Buy)
+	}
+
+	switch o.Status {
+	case "pending", "open":
+		if o.VolumeExecuted > 0 {
+			order.Status = market.Partial
+		} else {
+			order.Status = market.New
+		}
+	case "closed":
+		order.Status = market.Filled
+	case "canceled":
+		order.Status = market.Canceled
+	case "expired":
+		order.Status = market.Expired
+	default:
+		order.Status = market.Other
+	}
+	order.StatusMessage = o.Reason
+	return order
 }
 
 func (e *Kraken) ListOrders() (*[]market.Order, error) {
-	return nil, nil
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+	return exchange.OrderList(orders), nil
 }
 
-func (e *Kraken) CancelAllOrder() error {
+/*Get the Open Orders
+Step 1: Get all the open orders, the API can't filter by pair
+Step 2: Sort by open time, so the pages are stable
+Step 3: Filter by pair and page (exchange.PageOrders)*/
+func (e *Kraken) ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	jsonResponse := ResponseReturn{}
+	openOrders := OpenOrders{}
+	strRequest := "/private/OpenOrders"
+
+	mapParams := make(map[string]string)
+
+	jsonOrders := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonOrders), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonOrders)
+	}
+	if len(jsonResponse.Error) != 0 {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+	}
+	if err := json.Unmarshal(jsonResponse.Result, &openOrders); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "ListOpenOrders", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+	}
+
+	txids := make([]string, 0, len(openOrders.Open))
+	for txid := range openOrders.Open {
+		txids = append(txids, txid)
+	}
+	sort.Slice(txids, func(i, j int) bool {
+		return openOrders.Open[txids[i]].OpenTime < openOrders.Open[txids[j]].OpenTime
+	})
+
+	orders := []*market.Order{}
+	for _, txid := range txids {
+		o := openOrders.Open[txid]
+		p := e.getPairByCode(o.Description.Pair)
+		if p == nil {
+			log.Printf("Kraken ListOpenOrders order %s pair %s is not in the pair list", txid, o.Description.Pair)
+			continue
+		}
+		orders = append(orders, e.toOrder(p, txid, o))
+	}
+	return exchange.PageOrders(orders, query), nil
+}
+
+// the pair of the code in the order or trade, eg: ETHXBT, XETHXXBT
+func (e *Kraken) getPairByCode(code string) *pair.Pair {
+	if p, ok := e.pairCodeMap[code]; ok {
+		return p
+	}
+	for _, p := range e.pairList {
+		if strings.EqualFold(e.GetPairCode(p), code) {
+			return p
+		}
+	}
 	return nil
 }
 
+/*Get the Executed Trades Since the Time, nil pair: all pairs
+Step 1: TradesHistory returns 50 trades each call, request with offset until all the trades are received
+Step 2: Sort by time
+Kraken charges the fee in the quote currency (pair.Base) by default*/
+func (e *Kraken) GetFills(pair *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	trades := []*market.Trade{}
+	for offset := 0; ; {
+		jsonResponse := ResponseReturn{}
+		tradesHistory := TradesHistory{}
+		strRequest := "/private/TradesHistory"
+
+		mapParams := make(map[string]string)
+		mapParams["start"] = fmt.Sprint(since.Unix())
+		mapParams["ofs"] = fmt.Sprint(offset)
+
+		jsonTrades := e.ApiKeyPost(mapParams, strRequest)
+		if err := json.Unmarshal([]byte(jsonTrades), &jsonResponse); err != nil {
+			return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonTrades)
+		}
+		if len(jsonResponse.Error) != 0 {
+			return nil, exchange.Errorf(e.GetName(), "GetFills", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+		}
+		if err := json.Unmarshal(jsonResponse.Result, &tradesHistory); err != nil {
+			return nil, exchange.Errorf(e.GetName(), "GetFills", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+		}
+
+		for txid, t := range tradesHistory.Trades {
+			p := e.getPairByCode(t.Pair)
+			if p == nil || (pair != nil && p.Name != pair.Name) {
+				continue
+			}
+
+			trade := &market.Trade{}
+			trade.TradeID = txid
+			trade.OrderID = t.OrderTxID
+			trade.Pair = p
+			trade.Rate = t.Price
+			trade.Quantity = t.Volume
+			trade.Fee = t.Fee
+			trade.FeeCoin = p.Base
+			trade.Timestamp = int64(t.Time * 1000)
+			if t.Type == "sell" {
+				trade.Side = market.Sell
+			} else {
+				trade.Side = market.Buy
+			}
+			if t.Maker {
+				trade.Liquidity = market.LiquidityMaker
+			} else {
+				trade.Liquidity = market.LiquidityTaker
+			}
+			trades = append(trades, trade)
+		}
+
+		offset += len(tradesHistory.Trades)
+		if len(tradesHistory.Trades) == 0 || offset >= tradesHistory.Count {
+			break
+		}
+	}
+
+	sort.Slice(trades, func(i, j int) bool {
+		return trades[i].Timestamp < trades[j].Timestamp
+	})
+	return trades, nil
+}
+
+/*Cancel All Order of All Pairs*/
+func (e *Kraken) CancelAllOrder() error {
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+/*Cancel All Orders of the Pair, nil pair: all pairs
+CancelAll API cancels the orders of all pairs and only returns the count
+Step 1: The pair is set, list the open orders of the pair then cancel them (exchange.CancelOpenOrders)
+Step 2: List the open orders, call CancelAll
+Step 3: List the open orders again, the orders still open are failed*/
+func (e *Kraken) CancelAllOrders(pair *pair.Pair) (*exchange.CancelReport, error) {
+	if pair != nil {
+		return exchange.CancelOpenOrders(e, pair)
+	}
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonResponse := ResponseReturn{}
+	strRequest := "/private/CancelAll"
+
+	mapParams := make(map[string]string)
+
+	jsonCancelAll := e.ApiKeyPost(mapParams, strRequest)
+	if err := json.Unmarshal([]byte(jsonCancelAll), &jsonResponse); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonCancelAll)
+	}
+	if len(jsonResponse.Error) != 0 {
+		return nil, exchange.Errorf(e.GetName(), "CancelAllOrders", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
+	}
+
+	openOrders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		return nil, err
+	}
+	stillOpen := make(map[string]bool)
+	for _, order := range openOrders {
+		stillOpen[order.OrderID] = true
+	}
+
+	report := &exchange.CancelReport{}
+	for _, order := range orders {
+		if stillOpen[order.OrderID] {
+			report.Failed = append(report.Failed, &exchange.CancelFailure{Order: order, Err: exchange.Errorf(e.GetName(), "CancelAllOrders", exchange.ErrRejected, "order %s is still open", order.OrderID)})
+		} else {
+			order.Status = market.Canceling
+			report.Canceled = append(report.Canceled, order)
+		}
+	}
+	return report, nil
+}
+
 /*Cancel an Order  --reference Binance
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Add Model of API Response
@@ -351,7 +1283,7 @@ Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
 Step 5: Change Order Status (order.Status = market.Canceling)*/
 func (e *Kraken) CancelOrder(order *market.Order) error {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return fmt.Errorf("Kraken API Key or Secret Key are nil.")
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
 	}
 
 	jsonResponse := ResponseReturn{}
@@ -362,10 +1294,10 @@ func (e *Kraken) CancelOrder(order *market.Order) error {
 
 	jsonCancelOrder := e.ApiKeyPost(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonCancelOrder), &jsonResponse); err != nil {
-		return fmt.Errorf("Kraken CancelOrder Unmarshal Err: %v %v", err, jsonCancelOrder)
+		return exchange.Errorf(e.GetName(), "CancelOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonCancelOrder)
 	}
 	if len(jsonResponse.Error) != 0 {
-		return fmt.Errorf("Kraken CancelOrder Unmarshal Err: %+v", jsonResponse.Error)
+		return exchange.Errorf(e.GetName(), "CancelOrder", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
 	}
 
 	order.Status = market.Canceling
@@ -373,15 +1305,17 @@ func (e *Kraken) CancelOrder(order *market.Order) error {
 	return nil
 }
 
-/*Place a limit Sell Order  --reference Binance
+/*Place an Order  --reference Binance
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
-func (e *Kraken) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+Step 2: Check the Request is supported by GetCapabilities (exchange.CheckOrderRequest)
+Step 3: Map Side, Order Type, Time in Force and Post Only to API Params
+Step 4: Call ApiKey Function & Create a new Order*/
+func (e *Kraken) PlaceOrder(request *market.OrderRequest) (*market.Order, error) {
 	if e.API_KEY == "" || e.API_SECRET == "" {
-		return nil, fmt.Errorf("Kraken API Key or Secret Key are nil.")
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrAuth, "API Key or Secret Key are nil")
+	}
+	if err := exchange.CheckOrderRequest(e.GetName(), e.GetCapabilities(), request); err != nil {
+		return nil, err
 	}
 
 	jsonResponse := ResponseReturn{}
@@ -389,82 +1323,82 @@ func (e *Kraken) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Ord
 	strRequest := "/private/AddOrder"
 
 	mapParams := make(map[string]string)
-	mapParams["pair"] = strings.ToLower(e.GetPairCode(pair))
-	mapParams["type"] = "sell"
-	mapParams["ordertype"] = "limit"
-	mapParams["price"] = fmt.Sprint(rate)
-	mapParams["volume"] = fmt.Sprint(quantity)
+	mapParams["pair"] = strings.ToLower(e.GetPairCode(request.Pair))
+	mapParams["type"] = strings.ToLower(string(request.Side))
+	mapParams["volume"] = fmt.Sprint(request.Quantity)
+	switch request.Type {
+	case market.LimitOrder:
+		mapParams["ordertype"] = "limit"
+		mapParams["price"] = fmt.Sprint(request.Rate)
+	case market.MarketOrder:
+		mapParams["ordertype"] = "market"
+	case market.StopLimitOrder:
+		mapParams["ordertype"] = "stop-loss-limit"
+		mapParams["price"] = fmt.Sprint(request.StopRate) //trigger price
+		mapParams["price2"] = fmt.Sprint(request.Rate)    //limit price
+	}
+	if request.Type != market.MarketOrder {
+		mapParams["timeinforce"] = string(request.TimeInForce)
+	}
+	if request.PostOnly {
+		mapParams["oflags"] = "post"
+	}
+	mapParams["userref"] = fmt.Sprint(userRef(request.ClientOrderID))
 
 	jsonPlaceReturn := e.ApiKeyPost(mapParams, strRequest)
 	if err := json.Unmarshal([]byte(jsonPlaceReturn), &jsonResponse); err != nil {
-		return nil, fmt.Errorf("Kraken LimitSell Unmarshal Err: %v %v", err, jsonPlaceReturn)
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrNetwork, "Unmarshal Err: %v %v", err, jsonPlaceReturn)
 	}
 	if len(jsonResponse.Error) != 0 {
-		return nil, fmt.Errorf("Kraken LimitSell Unmarshal Err: %+v", jsonResponse.Error)
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", errKind(jsonResponse.Error), "%+v", jsonResponse.Error)
 	}
 	if err := json.Unmarshal(jsonResponse.Result, &placeOrder); err != nil {
-		return nil, fmt.Errorf("Kraken LimitSell Data Unmarshal Err: %v %v", err, jsonResponse.Result)
-	} else {
-		order := &market.Order{
-			OrderID:      placeOrder.TransactionIds[0],
-			Pair:         pair,
-			Rate:         rate,
-			Quantity:     quantity,
-			Side:         "Sell",
-			Status:       market.New,
-			JsonResponse: jsonPlaceReturn,
-		}
-		return order, nil
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrUnknown, "Data Unmarshal Err: %v %s", err, jsonResponse.Result)
+	}
+	if len(placeOrder.TransactionIds) == 0 {
+		return nil, exchange.Errorf(e.GetName(), "PlaceOrder", exchange.ErrUnknown, "no txid in response: %s", jsonResponse.Result)
 	}
 
-	return nil, nil
-}
-
-/*Place a limit Buy Order  --reference Binance
-Step 1: Change Instance Name    (e *<exchange Instance Name>)
-Step 2: Add Model of API Response
-Step 3: Modify API Path(strRequestUrl)
-Step 4: Create mapParams & Call ApiKey Function (Depend on API request)
-Step 5: Create a new Order*/
-func (e *Kraken) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
-	if e.API_KEY == "" || e.API_SECRET == "" {
-		return nil, fmt.Errorf("Kraken API Key or Secret Key are nil.")
+	order := &market.Order{
+		OrderID:       placeOrder.TransactionIds[0],
+		ClientOrderID: request.ClientOrderID,
+		Pair:          request.Pair,
+		Rate:          request.Rate,
+		Quantity:      request.Quantity,
+		Side:          string(request.Side),
+		Status:        market.New,
+		JsonResponse:  jsonPlaceReturn,
 	}
+	return order, nil
+}
 
-	jsonResponse := ResponseReturn{}
-	placeOrder := AddOrderResponse{}
-	strRequest := "/private/AddOrder"
+/*Place a limit Sell Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Kraken) LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Sell, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
 
-	mapParams := make(map[string]string)
-	mapParams["pair"] = strings.ToLower(e.GetPairCode(pair))
-	mapParams["type"] = "buy"
-	mapParams["ordertype"] = "limit"
-	mapParams["price"] = fmt.Sprint(rate)
-	mapParams["volume"] = fmt.Sprint(quantity)
+/*Place a limit Buy Order
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Kraken) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	return e.PlaceOrder(&market.OrderRequest{Pair: pair, Side: market.Buy, Type: market.LimitOrder, Quantity: quantity, Rate: rate})
+}
 
-	jsonPlaceReturn := e.ApiKeyPost(mapParams, strRequest)
-	if err := json.Unmarshal([]byte(jsonPlaceReturn), &jsonResponse); err != nil {
-		return nil, fmt.Errorf("Kraken LimitBuy Unmarshal Err: %v %v", err, jsonPlaceReturn)
-	}
-	if len(jsonResponse.Error) != 0 {
-		return nil, fmt.Errorf("Kraken LimitBuy Unmarshal Err: %+v", jsonResponse.Error)
-	}
-	if err := json.Unmarshal(jsonResponse.Result, &placeOrder); err != nil {
-		return nil, fmt.Errorf("Kraken LimitBuy Data Unmarshal Err: %v %v", err, jsonResponse.Result)
-	} else {
-		order := &market.Order{
-			OrderID:      placeOrder.TransactionIds[0],
-			Pair:         pair,
-			Rate:         rate,
-			Quantity:     quantity,
-			Side:         "Buy",
-			Status:       market.New,
-			JsonResponse: jsonPlaceReturn,
+/*The Kind of the Error List
+Kraken answers the errors as "<severity><category>:<message>", eg. EAPI:Invalid key, EOrder:Unknown order*/
+func errKind(errs []interface{}) exchange.ErrorKind {
+	for _, e := range errs {
+		msg := fmt.Sprint(e)
+		switch {
+		case strings.HasPrefix(msg, "EAPI:Invalid key"), strings.HasPrefix(msg, "EAPI:Invalid signature"), strings.HasPrefix(msg, "EGeneral:Permission denied"):
+			return exchange.ErrAuth
+		case strings.HasPrefix(msg, "EOrder:Unknown order"):
+			return exchange.ErrNotFound
+		case strings.HasPrefix(msg, "EAPI:Rate limit"), strings.HasPrefix(msg, "EAPI:Invalid nonce"), strings.HasPrefix(msg, "EService:"), strings.HasPrefix(msg, "EGeneral:Temporary lockout"):
+			return exchange.ErrNetwork
 		}
-		return order, nil
 	}
-
-	return nil, nil
+	return exchange.ErrRejected
 }
 
 /*************** Signature Http Request ***************/
@@ -472,40 +1406,36 @@ func (e *Kraken) LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Orde
 /*Method: POST and Signature is required  --reference Binance
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Create mapParams Depend on API Signature request
-Step 3: Add HttpGetRequest below strUrl if API has different requests*/
+Step 3: Add HttpGetRequest below strUrl if API has different requests
+Kraken: the params are form encoded, the two-factor password (Two_Factor) is sent as otp, a two-factor err is answered as EAPI:Invalid key*/
 func (e *Kraken) ApiKeyPost(mapParams map[string]string, strRequestPath string) string {
 	strMethod := "POST"
 
 	//Signature Request Params
-	mapParams["nonce"] = fmt.Sprintf("%d", time.Now().UnixNano())
-	if e.Two_Factor != "" {
-		mapParams["otp"] = e.Two_Factor
+	mapParams["nonce"] = fmt.Sprintf("%d", e.clock.Nonce())
+	otp, err := e.Two_Factor.Code(e.clock.Now())
+	if err != nil { // answered like the key rejected by Kraken, the callers report ErrAuth by errKind
+		body, _ := json.Marshal(map[string][]string{"error": {fmt.Sprintf("EAPI:Invalid key, two-factor err: %v", err)}})
+		return string(body)
+	}
+	if otp != "" {
+		mapParams["otp"] = otp
 	}
-	// two-factor password added
-	mapParams["otp"] = "kraken123."
-	Signature := ComputeHmac512(strRequestPath, mapParams, e.API_SECRET)
 
-	strUrl := API_URL + strRequestPath
+	strUrl := e.API_URL + strRequestPath
+	strPath := strUrl
+	if u, err := url.Parse(strUrl); err == nil {
+		strPath = u.Path // the URI path is signed, eg. /0/private/Balance
+	}
+	Signature := ComputeHmac512(strPath, mapParams, e.API_SECRET)
 
 	httpClient := &http.Client{}
 
-	jsonParams := ""
-	if nil != mapParams {
-		bytesParams, _ := json.Marshal(mapParams)
-		jsonParams = string(bytesParams)
-	}
-
-	request, err := http.NewRequest(strMethod, strUrl, strings.NewReader(jsonParams))
+	request, err := http.NewRequest(strMethod, strUrl, strings.NewReader(Map2UrlQuery(mapParams)))
 	if nil != err {
 		return err.Error()
 	}
-	//request.Header.Add("User-Agent", "Mozilla/5.0 (Windows NT 6.1; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/39.0.2171.71 Safari/537.36")
-	request.Header.Add("Content-Type", "application/json")
-	//Signature = Signature + "111"
-	//Signature correct
-	log.Printf("Key!!!: %v", e.API_KEY)       //============
-	log.Printf("Secret!!!: %v", e.API_SECRET) //============
-	log.Printf("Signature!!!: %v", Signature) //============
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 	request.Header.Add("API-Key", e.API_KEY)
 	request.Header.Add("API-Sign", Signature)
 
@@ -523,11 +1453,10 @@ func (e *Kraken) ApiKeyPost(mapParams map[string]string, strRequestPath string)
 	return string(body)
 }
 
-//Signature加密
+// Signature加密 HMAC-SHA512 of (URI path + SHA256(nonce + POST data)) with the base64 decoded secret
 func ComputeHmac512(strPath string, mapParams map[string]string, strSecret string) string {
-	bytesParams, _ := json.Marshal(mapParams)
 	sha := sha256.New()
-	sha.Write(bytesParams)
+	sha.Write([]byte(mapParams["nonce"] + Map2UrlQuery(mapParams)))
 	shaSum := sha.Sum(nil)
 
 	strMessage := fmt.Sprintf("%s%s", strPath, string(shaSum))
@@ -538,3 +1467,14 @@ func ComputeHmac512(strPath string, mapParams map[string]string, strSecret strin
 
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
+
+// 将map格式的请求参数转换为字符串格式的
+// mapParams: map格式的参数键值对
+// return: 查询字符串, form encoded & sorted by key, the same body is signed and sent
+func Map2UrlQuery(mapParams map[string]string) string {
+	values := url.Values{}
+	for key, value := range mapParams {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
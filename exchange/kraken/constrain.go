@@ -3,6 +3,7 @@ package kraken
 import (
 	"math"
 	"strings"
+	"sync"
 
 	"../../coin"
 	"../../exchange"
@@ -17,8 +18,11 @@ Step 4: Get Each Symbol
 Step 5: Identify Base & Target and Get Pair
 Step 6: Add LotSize  - float64
 Step 7: Add TickSize  - float64*/
-func (e *Kraken) UpdatePairConstrain() {//map[*pair.Pair]*exchange.PairConstrain {
-	pairData := GetKrakenPair()
+func (e *Kraken) UpdatePairConstrain() error {
+	pairData := e.GetKrakenPair()
+	if pairData == nil {
+		return exchange.Errorf(e.GetName(), "UpdatePairConstrain", exchange.ErrUnknown, "AssetPairs is not available")
+	}
 	pairConstrainMap := make(map[*pair.Pair]*exchange.PairConstrain)
 	//If Exchange doesn't provide constrain info, Leave kraken
 	//Modify according to type and structure
@@ -38,14 +42,14 @@ func (e *Kraken) UpdatePairConstrain() {//map[*pair.Pair]*exchange.PairConstrain
 		// 	log.Printf("Kraken UpdatePairConstrain Marshal err: %s\n", err)
 		// }
 		// if pairConstrain.Pair.Name != "" {
-		// 	key := fmt.Sprintf("%s-Constrain-%s", exchange.KRAKEN, pairConstrain.Pair.Name)
+		// 	key := fmt.Sprintf("%s-Constrain-%s", NAME, pairConstrain.Pair.Name)
 		// 	err = e.GetMakerDB().Set(key, string(l))
 		// 	if err != nil {
 		// 		log.Printf("Kraken UpdatePairConstrain Set DB err: %s\n", err)
 		// 	}
 		// }
 	}
-	//return pairConstrainMap
+	return nil
 }
 
 /*Update Coins Constrain  --If API provide those information
@@ -58,38 +62,41 @@ Step 6: Add TxFee - float64
 Step 7: Add Withdraw Status - Bool
 Step 7: Add Deposite Status - Bool
 Step 7: Add Confirmation - Int*/
-func (e *Kraken) UpdateCoinConstrain() {//map[*coin.Coin]*exchange.CoinConstrain {
-	//return nil
+func (e *Kraken) UpdateCoinConstrain() error {
+	return exchange.Errorf(e.GetName(), "UpdateCoinConstrain", exchange.ErrUnsupported, "coin constrain is not provided by the API")
 }
 
 /***************************************************/
-var symbolMap = make(map[string]string)
+var symbolMap = make(map[string]string) //read only after FixSymbol
+var symbolOnce sync.Once
 
 /*Standard Coin Code
 Coin has same code but it is different currency
 Fix the coin code to bitontop standard*/
 func (e *Kraken) FixSymbol() { //key: exchange specific    val： bitontop standard
-	symbolMap["XXBT"] = "BTC"
-	symbolMap["XDAO"] = "DAO"
-	symbolMap["XETC"] = "ETC"
-	symbolMap["XETH"] = "ETH"
-	symbolMap["XICN"] = "ICN"
-	symbolMap["XLTC"] = "LTC"
-	symbolMap["XMLN"] = "MLN"
-	symbolMap["XNMC"] = "NMC"
-	symbolMap["XREP"] = "REP"
-	symbolMap["XXDG"] = "XDG"
-	symbolMap["XXLM"] = "XLM"
-	symbolMap["XXMR"] = "XMR"
-	symbolMap["XXRP"] = "XRP"
-	symbolMap["XXVN"] = "XVN"
-	symbolMap["XZEC"] = "ZEC"
-	symbolMap["ZCAD"] = "CAD"
-	symbolMap["ZEUR"] = "EUR"
-	symbolMap["ZGBP"] = "GBP"
-	symbolMap["ZJPY"] = "JPY"
-	symbolMap["ZKRW"] = "KRW"
-	symbolMap["ZUSD"] = "USD"
+	symbolOnce.Do(func() {
+		symbolMap["XXBT"] = "BTC"
+		symbolMap["XDAO"] = "DAO"
+		symbolMap["XETC"] = "ETC"
+		symbolMap["XETH"] = "ETH"
+		symbolMap["XICN"] = "ICN"
+		symbolMap["XLTC"] = "LTC"
+		symbolMap["XMLN"] = "MLN"
+		symbolMap["XNMC"] = "NMC"
+		symbolMap["XREP"] = "REP"
+		symbolMap["XXDG"] = "XDG"
+		symbolMap["XXLM"] = "XLM"
+		symbolMap["XXMR"] = "XMR"
+		symbolMap["XXRP"] = "XRP"
+		symbolMap["XXVN"] = "XVN"
+		symbolMap["XZEC"] = "ZEC"
+		symbolMap["ZCAD"] = "CAD"
+		symbolMap["ZEUR"] = "EUR"
+		symbolMap["ZGBP"] = "GBP"
+		symbolMap["ZJPY"] = "JPY"
+		symbolMap["ZKRW"] = "KRW"
+		symbolMap["ZUSD"] = "USD"
+	})
 }
 
 /*Get Exchange Standard Code*/
@@ -104,11 +111,21 @@ func (e *Kraken) GetSymbol(code string) string {
 	return code
 }
 
-/*Get Bitontop Standard Code*/
+/*Get Bitontop Standard Code
+The staking suffix is removed, eg: DOT.S is DOT, XBT.M is BTC
+The X/Z prefix of the legacy assets is optional, eg: XXBT & XBT are BTC*/
 func (e *Kraken) GetCode(symbol string) string {
 	symbol = strings.ToUpper(symbol)
+	if i := strings.Index(symbol, "."); i > 0 {
+		symbol = symbol[:i]
+	}
 	if val, ok := symbolMap[symbol]; ok {
 		return val
 	}
+	for _, prefix := range []string{"X", "Z"} {
+		if val, ok := symbolMap[prefix+symbol]; ok {
+			return val
+		}
+	}
 	return symbol
 }
@@ -2,10 +2,11 @@ package kraken
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
-	"sync"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/orcaman/concurrent-map"
 
@@ -14,6 +15,7 @@ import (
 	"../../exchange"
 	"../../market"
 	"../../pair"
+	"../../user"
 )
 
 type Kraken struct {
@@ -24,64 +26,138 @@ type Kraken struct {
 	RedisDB      int
 	API_KEY      string
 	API_SECRET   string
-	Two_Factor   string
+	API_URL      string          //the REST endpoint, API_URL by default
+	WS_URL       string          //the WebSocket endpoint of the books, WS_URL by default
+	WS_AUTH_URL  string          //the WebSocket endpoint of the user data, WS_AUTH_URL by default
+	Two_Factor   *user.TwoFactor //nil if the API Key has no two-factor password
 	WalletStatus []exchange.Wallet_Stat
+
+	pairList   []*pair.Pair //the pairs on this exchange
+	coinList   []*coin.Coin
+	balanceMap cmap.ConcurrentMap
+	userMap    cmap.ConcurrentMap //credentials: *Kraken, the instances of other users
+	clock      *exchange.Clock    //server time of the signed requests, shared with the user instances
+	feeMap     cmap.ConcurrentMap //pair name: *exchange.TradeFee, the fees of this account from UpdateFees
+
+	pairCodeMap map[string]*pair.Pair   //pair key, altname and wsname of AssetPairs: *pair.Pair, read only after InitPairs
+	feeTierMap  map[string]*feeSchedule //pair name: the fee schedule of AssetPairs, read only after InitPairs
+	wsNameMap   map[string]string       //pair name: wsname of AssetPairs eg. XBT/USD, read only after InitPairs
 }
 
-var pairList = make([]*pair.Pair, 0) //the last num is the number of pairs on this exchange
-var coinList = make([]*coin.Coin, 0)
-var balanceMap cmap.ConcurrentMap
+// the maker and taker tiers of a pair, the rate is a fraction eg: 0.0026
+type feeSchedule struct {
+	maker []exchange.FeeTier
+	taker []exchange.FeeTier
+}
 
-// var balanceMap = make(map[*coin.Coin]float64)
+// the name of Kraken in the registry
+const NAME exchange.ExchangeName = "KRAKEN"
 
-var instance *Kraken
-var once sync.Once
+func init() {
+	exchange.Register(NAME, func(config *exchange.Config) (exchange.Exchange, error) {
+		return CreateKraken(config), nil
+	})
+}
 
 /***************************************************/
 /*Create New Exchange
-Add Exchange Name(Capital Letter) to meta.go
+Name the Exchange (Capital Letter) in NAME
+Register the Create function in init(), the exchange is then available in ExchangeManager
 Name: Exchange Name
 Website: Exchange Website URL
 MakerDB: Exchange Redis Server & Number(Import from Config)
 Execute Coins & Pairs Initial
 API_KEY: Import from Config
 API_SECRET: Import from Config
+API_URL: Import from Config, empty: API_URL
+WS_URL & WS_AUTH_URL: WS_URL & WS_AUTH_URL, replaced eg. by a local stand-in in the tests
+Two_Factor: Import from Config, the otp of the private API
 WalletStatus: If API doesn't provide Wallet Status, import data from Postgres*/
 func CreateKraken(config *exchange.Config) *Kraken {
-	once.Do(func() {
-		instance = &Kraken{}
-		instance.Name = "Kraken"
-		instance.Website = "https://www.kraken.com/"
-
-		instance.RedisManager = db.CreateRedisManager()
-		instance.RedisServer = config.RedisServer
-		instance.RedisDB = config.RedisDB
-
-		instance.API_KEY = config.API_KEY
-		instance.API_SECRET = config.API_SECRET
-
-		if balanceMap == nil {
-			balanceMap = cmap.New()
-		}
-
-		instance.FixSymbol()
-		instance.InitCoins()
-		instance.InitPairs()
-	})
+	instance := &Kraken{}
+	instance.Name = "Kraken"
+	instance.Website = "https://www.kraken.com/"
+
+	instance.RedisManager = db.SharedRedisManager(string(NAME))
+	instance.RedisServer = config.RedisServer
+	instance.RedisDB = config.RedisDB
+
+	instance.API_KEY = config.API_KEY
+	instance.API_SECRET = config.API_SECRET
+	instance.API_URL = API_URL
+	if config.API_URL != "" {
+		instance.API_URL = strings.TrimSuffix(config.API_URL, "/")
+	}
+	instance.WS_URL = WS_URL
+	instance.WS_AUTH_URL = WS_AUTH_URL
+	instance.Two_Factor = config.Two_Factor
+
+	instance.pairList = make([]*pair.Pair, 0)
+	instance.coinList = make([]*coin.Coin, 0)
+	instance.balanceMap = cmap.New()
+	instance.userMap = cmap.New()
+	instance.clock = exchange.NewClock(NAME, instance.GetKrakenTime)
+	instance.feeMap = cmap.New()
+	instance.pairCodeMap = make(map[string]*pair.Pair)
+	instance.feeTierMap = make(map[string]*feeSchedule)
+	instance.wsNameMap = make(map[string]string)
+
+	instance.FixSymbol()
+	instance.InitCoins()
+	instance.InitPairs()
 	return instance
 }
 
 func (e *Kraken) GetMakerDB() *db.Redis {
-	key := string(exchange.KRAKEN)
+	key := fmt.Sprintf("%s-%s-%d", NAME, e.RedisServer, e.RedisDB) // the instances share the manager
 	d := e.RedisManager.Get(key)
 	if d == nil {
 		d = db.CreateRedis()
-		d.Init(instance.RedisServer, instance.RedisDB)
+		d.Init(e.RedisServer, e.RedisDB)
 		e.RedisManager.Add(key, d)
 	}
 	return d
 }
 
+/*Get the Instance of Another User
+The user instance shares the pairs, coins and Redis of this instance, but has its own API Key and balances*/
+func (e *Kraken) ForUser(u *user.User) *Kraken {
+	key := u.CredentialKey()
+	if tmp, ok := e.userMap.Get(key); ok {
+		return tmp.(*Kraken)
+	}
+
+	uInstance := &Kraken{}
+	uInstance.Name = e.Name
+	uInstance.Website = e.Website
+	uInstance.RedisManager = e.RedisManager
+	uInstance.RedisServer = e.RedisServer
+	uInstance.RedisDB = e.RedisDB
+	uInstance.API_KEY = u.API_KEY
+	uInstance.API_SECRET = u.API_SECRET
+	uInstance.API_URL = e.API_URL
+	uInstance.WS_URL = e.WS_URL
+	uInstance.WS_AUTH_URL = e.WS_AUTH_URL
+	uInstance.Two_Factor = u.Two_Factor
+	uInstance.WalletStatus = e.WalletStatus
+
+	uInstance.pairList = e.pairList
+	uInstance.coinList = e.coinList
+	uInstance.balanceMap = cmap.New()
+	uInstance.userMap = e.userMap
+	uInstance.clock = e.clock
+	uInstance.feeMap = cmap.New()
+	uInstance.pairCodeMap = e.pairCodeMap
+	uInstance.feeTierMap = e.feeTierMap
+	uInstance.wsNameMap = e.wsNameMap
+
+	if !e.userMap.SetIfAbsent(key, uInstance) {
+		tmp, _ := e.userMap.Get(key)
+		return tmp.(*Kraken)
+	}
+	return uInstance
+}
+
 /*Initial the Pairs of Exchange
 Step 1: Change Instance Name (e *<exchange Instance Name>)
 Step 2: Get API Data
@@ -91,15 +167,25 @@ Step 5: Get Coin Standard Code ex. e.GetCode(base)
 Step 6: Get Coin
 Step 7: Add Pair to Exchange Pairs Arrary*/
 func (e *Kraken) InitPairs() {
-	pairData := GetKrakenPair()
+	pairData := e.GetKrakenPair()
 
-	for _, symbol := range pairData {
+	for key, symbol := range pairData {
 		//Modify according to type and structure
 		base := coin.GetCoin(e.GetCode(symbol.Quote))
 		target := coin.GetCoin(e.GetCode(symbol.Base))
 		if base != nil && target != nil {
 			pair := pair.GetPair(base, target)
-			pairList = append(pairList, pair)
+			e.pairList = append(e.pairList, pair)
+			e.pairCodeMap[key] = pair
+			e.pairCodeMap[symbol.Altname] = pair
+			if symbol.Wsname != "" {
+				e.pairCodeMap[symbol.Wsname] = pair
+				e.wsNameMap[pair.Name] = symbol.Wsname
+			}
+			e.feeTierMap[pair.Name] = &feeSchedule{
+				maker: feeTiers(symbol.FeesMaker),
+				taker: feeTiers(symbol.Fees),
+			}
 		}
 	}
 }
@@ -120,20 +206,30 @@ Step 5: if the coin doesn't exist in coinmap, Add the coin in coinmap
 	- Blocktime: the time of the block created
 	- Blocklast: the last block of the chain*/
 func (e *Kraken) InitCoins() {
-	coinInfo := GetKrakenCoin()
+	coinInfo := e.GetKrakenCoin()
 
+	listed := make(map[string]bool)
 	for key, _ := range coinInfo {
 		//Modify according to type and structure
-		c := coin.GetCoin(e.GetCode(key))
-		if c == nil {
-			c = &coin.Coin{}
-			c.Code = e.GetCode(key)
-			coin.AddCoin(c)
+		c := e.registerCoin(key)
+		if !listed[c.Code] { //the staking assets are the same coin, eg: DOT & DOT.S
+			listed[c.Code] = true
+			e.coinList = append(e.coinList, c)
 		}
-		coinList = append(coinList, c)
 	}
 }
 
+// the coin of the Kraken asset code, added to the coin registry if it is unknown
+func (e *Kraken) registerCoin(symbol string) *coin.Coin {
+	c := coin.GetCoin(e.GetCode(symbol))
+	if c == nil {
+		c = &coin.Coin{}
+		c.Code = e.GetCode(symbol)
+		coin.AddCoin(c)
+	}
+	return c
+}
+
 /***************************************************/
 /*Upload updated Maker to Redis
 Step 1: Change Instance Name (e *<exchange Instance Name>)
@@ -143,7 +239,7 @@ func (e *Kraken) UpdateMaker(pair *pair.Pair, maker *market.Maker) error {
 	if err != nil {
 		return err
 	}
-	key := fmt.Sprintf("%s-%s", exchange.KRAKEN, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	return e.GetMakerDB().Set(key, string(m))
 }
 
@@ -152,10 +248,10 @@ Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>
 Step 3: Change Error Exchange Name    <exchange Name> does not have the pair*/
 func (e *Kraken) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
-	key := fmt.Sprintf("%s-%s", exchange.KRAKEN, pair.Name)
+	key := fmt.Sprintf("%s-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Kraken does not have the pair : %v", pair.Name))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrNotFound, "does not have the pair : %v", pair.Name)
 	}
 	if str, ok := val.(string); ok {
 
@@ -163,7 +259,7 @@ func (e *Kraken) GetMaker(pair *pair.Pair) (maker *market.Maker, err error) {
 			return nil, err
 		}
 	} else {
-		return nil, errors.New(fmt.Sprintf("Kraken GetMaker Key: %v can't convert to string: %v", key, val))
+		return nil, exchange.Errorf(e.GetName(), "GetMaker", exchange.ErrUnknown, "Key: %v can't convert to string: %v", key, val)
 	}
 	return maker, err
 }
@@ -174,7 +270,7 @@ func (e *Kraken) SetCoins() error {
 }
 
 func (e *Kraken) GetCoins() []*coin.Coin {
-	return coinList
+	return e.coinList
 }
 
 func (e *Kraken) SetPairs() error {
@@ -184,7 +280,18 @@ func (e *Kraken) SetPairs() error {
 /*Get Exchange All Pairs
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Kraken) GetPairs() []*pair.Pair {
-	return pairList
+	return e.pairList
+}
+
+/*Get Exchange A Pair
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Kraken) GetPair(key string) *pair.Pair {
+	for _, p := range e.pairList {
+		if p.Name == key {
+			return p
+		}
+	}
+	return nil
 }
 
 /*Get Pair Code base on Exchange
@@ -217,14 +324,59 @@ func (e *Kraken) HasPair(pair *pair.Pair) bool {
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Exchange Name    exchange.<Capital Letter Exchange Name>*/
 func (e *Kraken) GetName() exchange.ExchangeName {
-	return exchange.KRAKEN
+	return NAME
+}
+
+// the offset of the server time measured by the signed requests
+func (e *Kraken) GetClockSkew() time.Duration {
+	return e.clock.Skew()
 }
 
 /*Get Exchange Taker Fee
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2: Change Return base on the taker fee that exchange provides*/
 func (e *Kraken) GetFee(pair *pair.Pair) float64 { // Taker fee for each coin
-	return 0.0026 //Taker Fee: 0.2%
+	return e.GetTradeFee(pair).Taker
+}
+
+/*Get Exchange Maker & Taker Fee
+The fees of the account after UpdateFees, the lowest tier of AssetPairs before*/
+func (e *Kraken) GetTradeFee(pair *pair.Pair) *exchange.TradeFee {
+	if tmp, ok := e.feeMap.Get(pair.Name); ok {
+		return tmp.(*exchange.TradeFee)
+	}
+	return e.scheduleFee(pair, 0)
+}
+
+// the fee of the pair at the 30 day volume, 0.0016 maker 0.0026 taker if AssetPairs has no tier
+func (e *Kraken) scheduleFee(pair *pair.Pair, volume float64) *exchange.TradeFee {
+	fee := &exchange.TradeFee{
+		Pair:   pair,
+		Maker:  0.0016,
+		Taker:  0.0026,
+		Source: exchange.SourceStatic,
+	}
+	if schedule, ok := e.feeTierMap[pair.Name]; ok {
+		if rate := exchange.TierRate(schedule.taker, volume); rate >= 0 {
+			fee.Taker = rate
+			fee.Maker = rate //pairs without fees_maker charge the taker fee for both
+		}
+		if rate := exchange.TierRate(schedule.maker, volume); rate >= 0 {
+			fee.Maker = rate
+		}
+	}
+	return fee
+}
+
+// the [volume, percent fee] tiers of AssetPairs
+func feeTiers(data [][]float64) []exchange.FeeTier {
+	tiers := []exchange.FeeTier{}
+	for _, d := range data {
+		if len(d) == 2 {
+			tiers = append(tiers, exchange.FeeTier{Volume: d[0], Rate: d[1] / 100})
+		}
+	}
+	return tiers
 }
 
 /*Get Pair LotSize(Quantity)
@@ -238,7 +390,7 @@ Step 2:
 	Condition 2: API doesn't provides this information
 		return Minimum value*/
 func (e *Kraken) GetLotSize(pair *pair.Pair) float64 {
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.KRAKEN, pair.Name)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Kraken GetPriceFilter Key: %v Err: %s\n", key, err)
@@ -266,7 +418,7 @@ Step 2:
 	Condition 2: API doesn't provides this information
 		return Minimum value*/
 func (e *Kraken) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for price
-	key := fmt.Sprintf("%s-Constrain-%s", exchange.KRAKEN, pair.Name)
+	key := fmt.Sprintf("%s-Constrain-%s", NAME, pair.Name)
 	val, err := e.GetMakerDB().Get(key)
 	if err != nil {
 		log.Printf("Kraken GetPriceFilter Key: %v Err: %s\n", key, err)
@@ -283,29 +435,59 @@ func (e *Kraken) GetPriceFilter(pair *pair.Pair) float64 { // tickSize for price
 	return constrain.TickSize
 }
 
-func (e *Kraken) GetConstrainFetchMethod(pair *pair.Pair) *exchange.ConstrainFetchMethod {
+func (e *Kraken) GetCapabilities() *exchange.Capabilities {
 	constrainFetchMethod := &exchange.ConstrainFetchMethod{}
-	constrainFetchMethod.Fee = true
+	constrainFetchMethod.Fee = false
 	constrainFetchMethod.LotSize = true
 	constrainFetchMethod.TickSize = true
 	constrainFetchMethod.TxFee = false
 	constrainFetchMethod.Withdraw = false
 	constrainFetchMethod.Deposit = false
 	constrainFetchMethod.Confirmation = false
-	return constrainFetchMethod
+
+	capabilities := &exchange.Capabilities{}
+	capabilities.OrderTypes = []market.OrderType{market.LimitOrder, market.MarketOrder, market.StopLimitOrder}
+	capabilities.TimeInForce = []market.TimeInForce{market.GTC, market.IOC}
+	capabilities.PostOnly = true
+	capabilities.ClientOrderID = true
+	capabilities.CancelAll = true
+	capabilities.ListOrders = true
+	capabilities.Withdraw = true
+	capabilities.DepositAddress = true
+	capabilities.TransferHistory = true
+	capabilities.WebSocketMarketData = true
+	capabilities.WebSocketUserData = true
+	capabilities.BatchOrderBooks = false
+	capabilities.FeeSource = exchange.SourceAPI
+	capabilities.ConstrainSource = constrainFetchMethod
+	return capabilities
 }
 
 /*************** coins on the exchanges ***************/
 /*Get Coin Balance
 Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
 func (e *Kraken) GetBalance(coin *coin.Coin) float64 {
-	if tmp, ok := balanceMap.Get(coin.Code); ok {
-		return tmp.(float64)
+	if tmp, ok := e.balanceMap.Get(coin.Code); ok {
+		return tmp.(*market.Balance).Available
 	} else {
 		return 0.0
 	}
 }
 
+/*Get the Balances of All Coins
+Step 1: Change Instance Name    (e *<exchange Instance Name>)*/
+func (e *Kraken) GetBalances() []*market.Balance {
+	balances := []*market.Balance{}
+	for _, tmp := range e.balanceMap.Items() {
+		balance := *tmp.(*market.Balance)
+		balances = append(balances, &balance)
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Coin.Code < balances[j].Coin.Code
+	})
+	return balances
+}
+
 /*Get Coin Withdraw Fee
 Step 1: Change Instance Name    (e *<exchange Instance Name>)
 Step 2:
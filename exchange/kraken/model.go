@@ -19,6 +19,7 @@ type CoinData struct {
 
 type PairData struct {
 	Altname           string        `json:"altname"`
+	Wsname            string        `json:"wsname"`
 	AclassBase        string        `json:"aclass_base"`
 	Base              string        `json:"base"`
 	AclassQuote       string        `json:"aclass_quote"`
@@ -41,68 +42,184 @@ type OrderBook struct {
 	Asks [][]interface{} `json:"asks"`
 }
 
-/* type AccountBalances map[string]*AccountDetails
-type AccountDetails float64
-type AccountDetail2 struct {
-	name float64
-} */
-
-type AccountBalances struct {
-	ADA  float64 `json:"ADA,string"`
-	BCH  float64 `json:"BCH,string"`
-	DASH float64 `json:"DASH,string"`
-	EOS  float64 `json:"EOS,string"`
-	GNO  float64 `json:"GNO,string"`
-	QTUM float64 `json:"QTUM,string"`
-	KFEE float64 `json:"KFEE,string"`
-	USDT float64 `json:"USDT,string"`
-	XDAO float64 `json:"XDAO,string"`
-	XETC float64 `json:"XETC,string"`
-	XETH float64 `json:"XETH,string"`
-	XICN float64 `json:"XICN,string"`
-	XLTC float64 `json:"XLTC,string"`
-	XMLN float64 `json:"XMLN,string"`
-	XNMC float64 `json:"XNMC,string"`
-	XREP float64 `json:"XREP,string"`
-	XXBT float64 `json:"XXBT,string"`
-	XXDG float64 `json:"XXDG,string"`
-	XXLM float64 `json:"XXLM,string"`
-	XXMR float64 `json:"XXMR,string"`
-	XXRP float64 `json:"XXRP,string"`
-	XTZ  float64 `json:"XTZ,string"`
-	XXVN float64 `json:"XXVN,string"`
-	XZEC float64 `json:"XZEC,string"`
-	ZCAD float64 `json:"ZCAD,string"`
-	ZEUR float64 `json:"ZEUR,string"`
-	ZGBP float64 `json:"ZGBP,string"`
-	ZJPY float64 `json:"ZJPY,string"`
-	ZKRW float64 `json:"ZKRW,string"`
-	ZUSD float64 `json:"ZUSD,string"`
-}
+// Kraken asset code: the total balance, eg: "XXBT": "0.0100000000", "DOT.S": "5.0000000000"
+type AccountBalances map[string]string
 
 type Order struct {
 	TransactionID  string           `json:"-"`
 	ReferenceID    string           `json:"refid"`
-	UserRef        int              `json:"userref"`
+	UserRef        int32            `json:"userref"`
 	Status         string           `json:"status"`
 	OpenTime       float64          `json:"opentm"`
 	StartTime      float64          `json:"starttm"`
 	ExpireTime     float64          `json:"expiretm"`
-	Description    string 			`json:"descr"`
-	Volume         string           `json:"vol"`
+	Description    OrderDescription `json:"descr"`
+	Volume         float64          `json:"vol,string"`
 	VolumeExecuted float64          `json:"vol_exec,string"`
 	Cost           float64          `json:"cost,string"`
 	Fee            float64          `json:"fee,string"`
 	Price          float64          `json:"price,string"`
-	StopPrice      float64          `json:"stopprice.string"`
+	StopPrice      float64          `json:"stopprice,string"`
 	LimitPrice     float64          `json:"limitprice,string"`
 	Misc           string           `json:"misc"`
 	OrderFlags     string           `json:"oflags"`
 	CloseTime      float64          `json:"closetm"`
 	Reason         string           `json:"reason"`
+	AveragePrice   float64          `json:"avg_price,string"` // the WebSocket has avg_price instead of price
+}
+
+type OrderDescription struct {
+	Pair      string  `json:"pair"`
+	Type      string  `json:"type"`
+	OrderType string  `json:"ordertype"`
+	Price     float64 `json:"price,string"`
+	Price2    float64 `json:"price2,string"`
+	Leverage  string  `json:"leverage"`
+	Order     string  `json:"order"`
+	Close     string  `json:"close"`
+}
+
+type OpenOrders struct {
+	Open map[string]*Order `json:"open"`
+}
+
+type ClosedOrders struct {
+	Closed map[string]*Order `json:"closed"`
+	Count  int               `json:"count"`
 }
 
 type AddOrderResponse struct {
-	Description    string 			`json:"descr"`
-	TransactionIds []string         `json:"txid"`
+	Description struct {
+		Order string `json:"order"`
+		Close string `json:"close"`
+	} `json:"descr"`
+	TransactionIds []string `json:"txid"`
+}
+
+type TradesHistory struct {
+	Trades map[string]*TradeData `json:"trades"`
+	Count  int                   `json:"count"`
+}
+
+type TradeData struct {
+	OrderTxID string  `json:"ordertxid"`
+	Pair      string  `json:"pair"`
+	Time      float64 `json:"time"`
+	Type      string  `json:"type"`
+	OrderType string  `json:"ordertype"`
+	Price     float64 `json:"price,string"`
+	Cost      float64 `json:"cost,string"`
+	Fee       float64 `json:"fee,string"`
+	Volume    float64 `json:"vol,string"`
+	Margin    float64 `json:"margin,string"`
+	Misc      string  `json:"misc"`
+	Maker     bool    `json:"maker"`
+}
+
+type DepositMethod struct {
+	Method     string      `json:"method"`
+	Limit      interface{} `json:"limit"`
+	Fee        float64     `json:"fee,string"`
+	GenAddress bool        `json:"gen-address"`
+}
+
+type DepositAddress struct {
+	Address  string `json:"address"`
+	ExpireTm string `json:"expiretm"`
+	New      bool   `json:"new"`
+	Tag      string `json:"tag"`
+	Memo     string `json:"memo"`
+}
+
+type TransferStatus struct {
+	Method     string  `json:"method"`
+	Aclass     string  `json:"aclass"`
+	Asset      string  `json:"asset"`
+	RefID      string  `json:"refid"`
+	TxID       string  `json:"txid"`
+	Info       string  `json:"info"`
+	Amount     float64 `json:"amount,string"`
+	Fee        float64 `json:"fee,string"`
+	Time       int64   `json:"time"`
+	Status     string  `json:"status"`
+	StatusProp string  `json:"status-prop"`
+}
+
+type WithdrawInfo struct {
+	Method string  `json:"method"`
+	Limit  float64 `json:"limit,string"`
+	Amount float64 `json:"amount,string"`
+	Fee    float64 `json:"fee,string"`
+}
+
+type WithdrawResponse struct {
+	RefID string `json:"refid"`
+}
+
+type TickerData struct {
+	Ask    []string `json:"a"`
+	Bid    []string `json:"b"`
+	Close  []string `json:"c"`
+	Volume []string `json:"v"`
+	VWAP   []string `json:"p"`
+	Trades []int    `json:"t"`
+	Low    []string `json:"l"`
+	High   []string `json:"h"`
+	Open   string   `json:"o"`
+}
+
+type ServerTime struct {
+	UnixTime int64  `json:"unixtime"`
+	Rfc1123  string `json:"rfc1123"`
+}
+
+type TradeVolume struct {
+	Currency  string              `json:"currency"`
+	Volume    float64             `json:"volume,string"`
+	Fees      map[string]*FeeInfo `json:"fees"`
+	FeesMaker map[string]*FeeInfo `json:"fees_maker"`
+}
+
+type FeeInfo struct {
+	Fee        float64 `json:"fee,string"`
+	MinFee     float64 `json:"minfee,string"`
+	MaxFee     float64 `json:"maxfee,string"`
+	NextFee    float64 `json:"nextfee,string"`
+	NextVolume float64 `json:"nextvolume,string"`
+	TierVolume float64 `json:"tiervolume,string"`
+}
+
+// the book of the WebSocket, as & bs: the snapshot, a & b: the updates, [price, volume, timestamp]
+type WsBook struct {
+	AsksSnapshot [][]string `json:"as"`
+	BidsSnapshot [][]string `json:"bs"`
+	Asks         [][]string `json:"a"`
+	Bids         [][]string `json:"b"`
+	Checksum     string     `json:"c"`
+}
+
+type WsEvent struct {
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	Pair         string `json:"pair"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+type WebSocketsToken struct {
+	Token   string `json:"token"`
+	Expires int    `json:"expires"`
+}
+
+type WsOwnTrade struct {
+	OrderTxID string  `json:"ordertxid"`
+	PosTxID   string  `json:"postxid"`
+	Pair      string  `json:"pair"`
+	Time      float64 `json:"time,string"`
+	Type      string  `json:"type"`
+	OrderType string  `json:"ordertype"`
+	Price     float64 `json:"price,string"`
+	Cost      float64 `json:"cost,string"`
+	Fee       float64 `json:"fee,string"`
+	Volume    float64 `json:"vol,string"`
+	Margin    float64 `json:"margin,string"`
 }
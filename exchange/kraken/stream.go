@@ -0,0 +1,328 @@
+package kraken
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"../../exchange"
+	"../../market"
+	"../../pair"
+)
+
+/*The WebSocket Endpoint URL*/
+const (
+	WS_URL string = "wss://ws.kraken.com"
+
+	wsDepth     = 10               // the depth of the book subscription: 10, 25, 100, 500 or 1000
+	wsChecksum  = 10               // the levels of each side in the checksum
+	wsPairs     = 50               // the pairs in one subscription message
+	wsHeartbeat = 30 * time.Second // Kraken sends heartbeat every second when the channels are idle
+)
+
+/*Stream the Order Books by WebSocket
+Step 1: Subscribe the book of the pairs by wsname
+Step 2: Keep the book of each pair from the snapshot (as, bs) and the updates (a, b), volume 0 removes the level
+Step 3: Verify the checksum (c) of the updates, on a mismatch subscribe the pair again and drop its updates until the new snapshot
+Step 4: Offer the whole book to the channel after each message*/
+func (e *Kraken) StreamOrderBook(pairs []*pair.Pair, stop <-chan struct{}) (<-chan *market.Maker, error) {
+	if len(pairs) == 0 {
+		return nil, exchange.Errorf(e.GetName(), "StreamOrderBook", exchange.ErrRejected, "no pair to stream")
+	}
+
+	names := []string{}
+	for _, p := range pairs {
+		name, ok := e.wsNameMap[p.Name]
+		if !ok {
+			return nil, exchange.Errorf(e.GetName(), "StreamOrderBook", exchange.ErrNotFound, "Kraken does not have the pair : %v", p.Name)
+		}
+		names = append(names, name)
+	}
+
+	out := make(chan *market.Maker, exchange.StreamBuffer)
+	books := make(map[string]*exchange.OrderBookEngine) //pair name: book, only used in the read goroutine
+	texts := make(map[string]*bookText)                 //pair name: the levels as Kraken sends them for the checksum
+	resubscribing := make(map[string]bool)              //pair name: the updates are dropped until the new snapshot
+	client := exchange.NewWsClient(e.GetName(), e.WS_URL, func(c *exchange.WsClient, message []byte) {
+		p, wsBooks, err := e.readBook(message)
+		if err != nil {
+			log.Printf("Kraken StreamOrderBook %v", err)
+			return
+		}
+		if p == nil || len(wsBooks) == 0 {
+			return
+		}
+		if resubscribing[p.Name] && !wsBooks[0].isSnapshot() {
+			return
+		}
+		delete(resubscribing, p.Name)
+
+		book, ok := books[p.Name]
+		if !ok {
+			book = exchange.NewOrderBookEngine(e.GetName(), p, wsDepth, e.OrderBook)
+			books[p.Name] = book
+			texts[p.Name] = &bookText{bids: make(map[float64][]string), asks: make(map[float64][]string)}
+		}
+		checksum := ""
+		for _, wsBook := range wsBooks {
+			if err := book.Apply(wsBook.toUpdate()); err != nil {
+				log.Printf("Kraken StreamOrderBook %v", err)
+				return
+			}
+			texts[p.Name].apply(wsBook)
+			if wsBook.Checksum != "" {
+				checksum = wsBook.Checksum
+			}
+		}
+
+		maker := book.Maker()
+		texts[p.Name].trim(maker)
+		if checksum != "" && !texts[p.Name].verify(maker, checksum) {
+			log.Printf("Kraken StreamOrderBook %s checksum %s mismatch, subscribe the book again", p.Name, checksum)
+			resubscribing[p.Name] = true
+			if err := e.resubscribeBook(c, e.wsNameMap[p.Name]); err != nil {
+				log.Printf("Kraken StreamOrderBook %s resubscribe %v", p.Name, err) // the reconnect subscribes again
+			}
+			return
+		}
+		exchange.OfferMaker(out, maker)
+	})
+	client.Heartbeat = wsHeartbeat
+	client.Ping = func() interface{} {
+		return map[string]string{"event": "ping"}
+	}
+
+	for start := 0; start < len(names); start += wsPairs {
+		end := start + wsPairs
+		if end > len(names) {
+			end = len(names)
+		}
+		client.Subscribe(bookSubscription("subscribe", names[start:end]))
+	}
+	client.Start()
+
+	go func() {
+		<-stop
+		client.Close()
+		close(out)
+	}()
+	return out, nil
+}
+
+// the subscribe or unsubscribe message of the books of the pairs by wsname
+func bookSubscription(event string, names []string) map[string]interface{} {
+	return map[string]interface{}{
+		"event": event,
+		"pair":  names,
+		"subscription": map[string]interface{}{
+			"name":  "book",
+			"depth": wsDepth,
+		},
+	}
+}
+
+// unsubscribe and subscribe the book of the pair again, Kraken sends a new snapshot
+func (e *Kraken) resubscribeBook(c *exchange.WsClient, name string) error {
+	if err := c.Send(bookSubscription("unsubscribe", []string{name})); err != nil {
+		return err
+	}
+	return c.Send(bookSubscription("subscribe", []string{name}))
+}
+
+/*Read a Message of the WebSocket
+The events (heartbeat, pong, subscriptionStatus) are objects, the books are arrays:
+[channelID, {book}, ({book},) "book-10", "XBT/USD"]
+Return the pair and the books of the message, nil pair if the message is not a book.
+Kraken has no sequence, the updates are applied in the order of the messages*/
+func (e *Kraken) readBook(message []byte) (*pair.Pair, []*WsBook, error) {
+	if len(message) == 0 || message[0] != '[' {
+		return nil, nil, readEvent(message)
+	}
+
+	data := []json.RawMessage{}
+	if err := json.Unmarshal(message, &data); err != nil {
+		return nil, nil, exchange.Errorf(e.GetName(), "readBook", exchange.ErrNetwork, "Unmarshal Book Err: %v %s", err, message)
+	}
+	if len(data) < 4 {
+		return nil, nil, exchange.Errorf(e.GetName(), "readBook", exchange.ErrUnknown, "unknown message: %s", message)
+	}
+
+	var name string
+	if err := json.Unmarshal(data[len(data)-1], &name); err != nil {
+		return nil, nil, exchange.Errorf(e.GetName(), "readBook", exchange.ErrNetwork, "Unmarshal Pair Err: %v %s", err, message)
+	}
+	p := e.getPairByCode(name)
+	if p == nil {
+		return nil, nil, exchange.Errorf(e.GetName(), "readBook", exchange.ErrNotFound, "does not have the pair : %v", name)
+	}
+
+	wsBooks := []*WsBook{}
+	for _, raw := range data[1 : len(data)-2] {
+		wsBook := &WsBook{}
+		if err := json.Unmarshal(raw, wsBook); err != nil {
+			return nil, nil, exchange.Errorf(e.GetName(), "readBook", exchange.ErrUnknown, "Unmarshal Book Err: %v %s", err, raw)
+		}
+		wsBooks = append(wsBooks, wsBook)
+	}
+	return p, wsBooks, nil
+}
+
+func (b *WsBook) isSnapshot() bool {
+	return b.AsksSnapshot != nil || b.BidsSnapshot != nil
+}
+
+func (b *WsBook) toUpdate() *exchange.BookUpdate {
+	update := &exchange.BookUpdate{}
+	if b.isSnapshot() {
+		update.Snapshot = true
+		update.Bids = toLevels(b.BidsSnapshot)
+		update.Asks = toLevels(b.AsksSnapshot)
+	} else {
+		update.Bids = toLevels(b.Bids)
+		update.Asks = toLevels(b.Asks)
+	}
+	return update
+}
+
+// [price, volume, timestamp], volume 0 removes the level
+func toLevels(data [][]string) []market.Order {
+	levels := []market.Order{}
+	for _, level := range data {
+		if len(level) < 2 {
+			continue
+		}
+		rate, err := strconv.ParseFloat(level[0], 64)
+		if err != nil {
+			continue
+		}
+		quantity, err := strconv.ParseFloat(level[1], 64)
+		if err != nil {
+			continue
+		}
+		levels = append(levels, market.Order{Rate: rate, Quantity: quantity})
+	}
+	return levels
+}
+
+/*The Checksum of the Book
+CRC32 of the top 10 asks from the lowest rate, then the top 10 bids from the highest rate.
+Each level is [price, volume] as Kraken sends them, written without "." and the leading zeros*/
+func Checksum(asks, bids [][]string) uint32 {
+	text := strings.Builder{}
+	for _, side := range [][][]string{asks, bids} {
+		for i, level := range side {
+			if i == wsChecksum {
+				break
+			}
+			for _, s := range level[:2] {
+				text.WriteString(strings.TrimLeft(strings.Replace(s, ".", "", 1), "0"))
+			}
+		}
+	}
+	return crc32.ChecksumIEEE([]byte(text.String()))
+}
+
+// the levels of a book as Kraken sends them, rate: [price, volume], the checksum is computed on the text
+type bookText struct {
+	bids map[float64][]string
+	asks map[float64][]string
+}
+
+func (t *bookText) apply(wsBook *WsBook) {
+	if wsBook.isSnapshot() {
+		t.bids = make(map[float64][]string)
+		t.asks = make(map[float64][]string)
+		applyText(t.bids, wsBook.BidsSnapshot)
+		applyText(t.asks, wsBook.AsksSnapshot)
+		return
+	}
+	applyText(t.bids, wsBook.Bids)
+	applyText(t.asks, wsBook.Asks)
+}
+
+func applyText(side map[float64][]string, data [][]string) {
+	for _, level := range data {
+		if len(level) < 2 {
+			continue
+		}
+		rate, err := strconv.ParseFloat(level[0], 64)
+		if err != nil {
+			continue
+		}
+		quantity, err := strconv.ParseFloat(level[1], 64)
+		if err != nil {
+			continue
+		}
+		if quantity == 0 {
+			delete(side, rate)
+		} else {
+			side[rate] = level[:2]
+		}
+	}
+}
+
+// remove the levels out of the depth of the book
+func (t *bookText) trim(maker *market.Maker) {
+	for _, side := range []struct {
+		text   map[float64][]string
+		levels []market.Order
+	}{{t.bids, maker.Bids}, {t.asks, maker.Asks}} {
+		kept := make(map[float64]bool, len(side.levels))
+		for _, level := range side.levels {
+			kept[level.Rate] = true
+		}
+		for rate := range side.text {
+			if !kept[rate] {
+				delete(side.text, rate)
+			}
+		}
+	}
+}
+
+// the checksum of the top levels of the book equals c of the update
+func (t *bookText) verify(maker *market.Maker, checksum string) bool {
+	c, err := strconv.ParseUint(checksum, 10, 32)
+	if err != nil {
+		return false
+	}
+	asks, ok := topText(t.asks, maker.Asks)
+	if !ok {
+		return false
+	}
+	bids, ok := topText(t.bids, maker.Bids)
+	if !ok {
+		return false
+	}
+	return Checksum(asks, bids) == uint32(c)
+}
+
+// the text of the top levels in the order of the book, false if a level has no text
+func topText(side map[float64][]string, levels []market.Order) ([][]string, bool) {
+	top := [][]string{}
+	for i, level := range levels {
+		if i == wsChecksum {
+			break
+		}
+		text, ok := side[level.Rate]
+		if !ok {
+			return nil, false
+		}
+		top = append(top, text)
+	}
+	return top, true
+}
+
+// the error of the event message (heartbeat, pong, subscriptionStatus)
+func readEvent(message []byte) error {
+	event := WsEvent{}
+	if err := json.Unmarshal(message, &event); err != nil {
+		return exchange.Errorf(NAME, "readEvent", exchange.ErrNetwork, "Unmarshal Event Err: %v %s", err, message)
+	}
+	if event.Status == "error" {
+		return exchange.Errorf(NAME, "readEvent", exchange.ErrUnknown, "%s %s: %s", event.Event, event.Pair, event.ErrorMessage)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package kraken
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"../../exchange"
+	"../../market"
+)
+
+/*The Private WebSocket Endpoint URL*/
+const (
+	WS_AUTH_URL string = "wss://ws-auth.kraken.com"
+
+	wsBalanceInterval = 15 * time.Second // the WebSocket doesn't push the balances
+	wsFillsOverlap    = time.Minute      // the backfill starts before the last fill, for the trades which show up late
+)
+
+// the state of the user stream, only used in the goroutine of the client
+type userState struct {
+	orders     map[string]*Order // txid: the open order merged from the messages
+	connected  bool              // a connection was made before, the next connects are reconnects
+	snapshot   bool              // the next openOrders message is the snapshot of the connection
+	fills      map[string]int64  // trade id: the timestamp of the fills sent since fillsSince
+	fillsSince time.Time         // the backfill after a reconnect starts here
+}
+
+/*Stream the Orders, Fills and Balances of the Account
+Step 1: Get a new token (GetWebSocketsToken) on each connect, subscribe openOrders and ownTrades with it
+Step 2: openOrders sends all the open orders first, then only the changed fields of an order:
+	the fields are merged into the order of the txid, the order is sent when its status or deal changed
+Step 3: ownTrades sends each new trade once, the past trades are not sent (snapshot false)
+Step 4: After a reconnect, the fills missed since the last fill are got by REST (GetFills) and
+	the orders missing from the new openOrders snapshot are updated by OrderStatus, as exchange.PollUserData
+Step 5: Poll the balances by REST (exchange.PollBalances)*/
+func (e *Kraken) StreamUserData(stop <-chan struct{}) (<-chan *exchange.UserEvent, error) {
+	if e.API_KEY == "" || e.API_SECRET == "" {
+		return nil, exchange.Errorf(e.GetName(), "StreamUserData", exchange.ErrAuth, "Kraken API Key or Secret Key are nil.")
+	}
+
+	out := make(chan *exchange.UserEvent, exchange.StreamBuffer)
+	state := &userState{
+		orders:     make(map[string]*Order),
+		fills:      make(map[string]int64),
+		fillsSince: time.Now(),
+	}
+	client := exchange.NewWsClient(e.GetName(), e.WS_AUTH_URL, func(c *exchange.WsClient, message []byte) {
+		events, err := e.readUserData(message, state)
+		if err != nil {
+			log.Printf("Kraken StreamUserData %v", err)
+			return
+		}
+		for _, event := range events {
+			if !exchange.SendUserEvent(out, event, stop) {
+				return
+			}
+		}
+	})
+	client.Heartbeat = wsHeartbeat
+	client.Ping = func() interface{} {
+		return map[string]string{"event": "ping"}
+	}
+	client.OnConnect = func(c *exchange.WsClient) error {
+		token, err := e.getWebSocketsToken()
+		if err != nil {
+			return err
+		}
+		if err := c.Send(map[string]interface{}{
+			"event": "subscribe",
+			"subscription": map[string]interface{}{
+				"name":  "openOrders",
+				"token": token,
+			},
+		}); err != nil {
+			return err
+		}
+		if err := c.Send(map[string]interface{}{
+			"event": "subscribe",
+			"subscription": map[string]interface{}{
+				"name":     "ownTrades",
+				"token":    token,
+				"snapshot": false,
+			},
+		}); err != nil {
+			return err
+		}
+
+		state.snapshot = true
+		if !state.connected {
+			state.connected = true
+			return nil
+		}
+		for _, event := range e.backfillFills(state) {
+			if !exchange.SendUserEvent(out, event, stop) {
+				return nil
+			}
+		}
+		return nil
+	}
+	client.Start()
+
+	go func() {
+		exchange.PollBalances(e, out, wsBalanceInterval, stop)
+		client.Close()
+		close(out)
+	}()
+	return out, nil
+}
+
+/*Read a Message of the Private WebSocket
+The events are objects, the data are arrays: [[{id: {...}}, ...], "openOrders" or "ownTrades", {"sequence": n}]
+The orders which are closed, canceled or expired are removed from the state after they are sent,
+the first openOrders message of a connection is the snapshot of the open orders*/
+func (e *Kraken) readUserData(message []byte, state *userState) ([]*exchange.UserEvent, error) {
+	if len(message) == 0 || message[0] != '[' {
+		return nil, readEvent(message)
+	}
+
+	data := []json.RawMessage{}
+	if err := json.Unmarshal(message, &data); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "readUserData", exchange.ErrNetwork, "Unmarshal User Data Err: %v %s", err, message)
+	}
+	if len(data) < 2 {
+		return nil, exchange.Errorf(e.GetName(), "readUserData", exchange.ErrUnknown, "unknown message: %s", message)
+	}
+
+	var channel string
+	if err := json.Unmarshal(data[1], &channel); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "readUserData", exchange.ErrNetwork, "Unmarshal Channel Err: %v %s", err, message)
+	}
+	items := []map[string]json.RawMessage{}
+	if err := json.Unmarshal(data[0], &items); err != nil {
+		return nil, exchange.Errorf(e.GetName(), "readUserData", exchange.ErrNetwork, "Unmarshal %s Err: %v %s", channel, err, message)
+	}
+
+	events := []*exchange.UserEvent{}
+	snapshot := make(map[string]bool) //txid: the order is in the message
+	for _, item := range items {
+		for id, raw := range item {
+			switch channel {
+			case "openOrders":
+				snapshot[id] = true
+				o, ok := state.orders[id]
+				if !ok {
+					o = &Order{}
+					state.orders[id] = o
+				}
+				last := e.toOrder(nil, id, o)
+				if err := json.Unmarshal(raw, o); err != nil {
+					return events, exchange.Errorf(e.GetName(), "readUserData", exchange.ErrUnknown, "Unmarshal Order Err: %v %s", err, raw)
+				}
+
+				p := e.getPairByCode(o.Description.Pair)
+				if p == nil {
+					log.Printf("Kraken StreamUserData order %s pair %s is not in the pair list", id, o.Description.Pair)
+					continue
+				}
+				order := e.toOrder(p, id, o)
+				if order.Status != market.New && order.Status != market.Partial {
+					delete(state.orders, id)
+				}
+				if ok && last.Status == order.Status && last.DealQuantity == order.DealQuantity {
+					continue
+				}
+				events = append(events, exchange.NewOrderEvent(order))
+
+			case "ownTrades":
+				t := WsOwnTrade{}
+				if err := json.Unmarshal(raw, &t); err != nil {
+					return events, exchange.Errorf(e.GetName(), "readUserData", exchange.ErrUnknown, "Unmarshal Trade Err: %v %s", err, raw)
+				}
+				p := e.getPairByCode(t.Pair)
+				if p == nil {
+					log.Printf("Kraken StreamUserData trade %s pair %s is not in the pair list", id, t.Pair)
+					continue
+				}
+
+				trade := &market.Trade{}
+				trade.TradeID = id
+				trade.OrderID = t.OrderTxID
+				trade.Pair = p
+				trade.Rate = t.Price
+				trade.Quantity = t.Volume
+				trade.Fee = t.Fee
+				trade.FeeCoin = p.Base
+				trade.Timestamp = int64(t.Time * 1000)
+				if t.Type == "sell" {
+					trade.Side = market.Sell
+				} else {
+					trade.Side = market.Buy
+				}
+				if state.sent(trade) {
+					continue
+				}
+				events = append(events, exchange.NewFillEvent(trade))
+			}
+		}
+	}
+
+	if channel == "openOrders" && state.snapshot {
+		state.snapshot = false
+		events = append(events, e.reconcileOrders(state, snapshot)...)
+	}
+	return events, nil
+}
+
+/*The Orders Missing from the Snapshot of a New Connection
+They are closed while disconnected, updated by OrderStatus and sent with the final status.
+ErrNotFound removes the order, the other errors keep it for the next snapshot*/
+func (e *Kraken) reconcileOrders(state *userState, snapshot map[string]bool) []*exchange.UserEvent {
+	events := []*exchange.UserEvent{}
+	for id, o := range state.orders {
+		if snapshot[id] {
+			continue
+		}
+		p := e.getPairByCode(o.Description.Pair)
+		if p == nil {
+			delete(state.orders, id)
+			continue
+		}
+		order := e.toOrder(p, id, o)
+		if err := e.OrderStatus(order); err != nil {
+			if exchange.IsKind(err, exchange.ErrNotFound) {
+				delete(state.orders, id)
+			}
+			log.Printf("Kraken StreamUserData OrderStatus %s err: %v", id, err)
+			continue
+		}
+		if order.Status == market.New || order.Status == market.Partial {
+			continue // still open
+		}
+		delete(state.orders, id)
+		events = append(events, exchange.NewOrderEvent(order))
+	}
+	return events
+}
+
+/*The Fills Missed while Disconnected
+ownTrades doesn't send the past trades, they are got by REST (GetFills) since the last fill,
+the fills sent already are skipped*/
+func (e *Kraken) backfillFills(state *userState) []*exchange.UserEvent {
+	trades, err := e.GetFills(nil, state.fillsSince)
+	if err != nil {
+		log.Printf("Kraken StreamUserData GetFills err: %v", err)
+		return nil
+	}
+
+	events := []*exchange.UserEvent{}
+	for _, trade := range trades {
+		if state.sent(trade) {
+			continue
+		}
+		events = append(events, exchange.NewFillEvent(trade))
+	}
+	return events
+}
+
+/*Record the Fill to be Sent
+true if it was sent already. The backfill starts wsFillsOverlap before the last fill,
+the older fills are forgotten*/
+func (s *userState) sent(trade *market.Trade) bool {
+	if _, ok := s.fills[trade.TradeID]; ok {
+		return true
+	}
+	s.fills[trade.TradeID] = trade.Timestamp
+
+	if since := time.Unix(0, trade.Timestamp*int64(time.Millisecond)).Add(-wsFillsOverlap); since.After(s.fillsSince) {
+		s.fillsSince = since
+		for id, timestamp := range s.fills {
+			if timestamp < since.UnixNano()/1e6 {
+				delete(s.fills, id)
+			}
+		}
+	}
+	return false
+}
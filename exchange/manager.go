@@ -1,7 +1,9 @@
 package exchange
 
 import (
+	"fmt"
 	"sync"
+	"time"
 
 	"../coin"
 	"../market"
@@ -12,6 +14,7 @@ import (
 type Exchange interface {
 	GetName() ExchangeName
 	GetTradingWebURL(pair *pair.Pair) string
+	GetClockSkew() time.Duration //server time - local time, used by the signed requests
 
 	// FixSymbol()
 	GetCode(symbol string) string //get own standard code  eg:  BTC|ABC
@@ -30,91 +33,225 @@ type Exchange interface {
 	GetMaker(pair *pair.Pair) (maker *market.Maker, err error)
 	UpdateMaker(pair *pair.Pair, maker *market.Maker) error
 
-	GetConstrainFetchMethod(pair *pair.Pair) *ConstrainFetchMethod
+	GetCapabilities() *Capabilities
 
 	GetLotSize(pair *pair.Pair) float64     //stepSize    for  quantity
 	GetPriceFilter(pair *pair.Pair) float64 //tickSize    for  price
 
-	GetFee(pair *pair.Pair) float64   //the exchange fee for the pair
-	GetTxFee(coin *coin.Coin) float64 //the tx fee for withdraw the coin
+	GetFee(pair *pair.Pair) float64        //the taker fee for the pair
+	GetTradeFee(pair *pair.Pair) *TradeFee //the maker and taker fee for the pair
+	UpdateFees() error                     //fetch the fees of the account, the fee schedule is used before
+	GetTxFee(coin *coin.Coin) float64      //the tx fee for withdraw the coin
 
 	CanWithdraw(coin *coin.Coin) bool // is enable withdraw
 	CanDeposit(coin *coin.Coin) bool  // is enable deposit
 
-	Withdraw(coin *coin.Coin, quantity float64, addr, tag string) bool
+	Withdraw(coin *coin.Coin, quantity float64, addr, tag string) (*WithdrawalResult, error)
+	WithdrawalStatus(withdrawal *WithdrawalResult) error //update the status, fee and tx hash of the withdrawal
+	GetDepositAddress(coin *coin.Coin) (*DepositAddress, error)
+	GetTransfers(coin *coin.Coin, since time.Time) ([]*Transfer, error) //deposits and withdrawals, nil coin: all coins
 
+	PlaceOrder(request *market.OrderRequest) (*market.Order, error) //market, stop limit, IOC/FOK and post only, see GetCapabilities
 	LimitSell(pair *pair.Pair, quantity, rate float64) (*market.Order, error)
 	LimitBuy(pair *pair.Pair, quantity, rate float64) (*market.Order, error)
 
 	OrderStatus(order *market.Order) error
+	OrdersStatus(orders []*market.Order) error                                    //update many orders, in one request if the exchange supports
+	OrderByClientID(pair *pair.Pair, clientOrderID string) (*market.Order, error) //ErrNotFound only when the order is surely not placed
 	CancelOrder(order *market.Order) error
-	CancelAllOrder() error //TODO need to impl cancel all order for exchanges
-	ListOrders() (*[]market.Order, error)
+	CancelAllOrder() error                                              //cancel the open orders of all pairs
+	CancelAllOrders(pair *pair.Pair) (*CancelReport, error)             //nil pair: all pairs
+	ListOrders() (*[]market.Order, error)                               //all the open orders
+	ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error)   //open orders filtered by pair and paged
+	GetFills(pair *pair.Pair, since time.Time) ([]*market.Trade, error) //executed trades of the account, nil pair: all pairs
 
-	GetBalance(coin *coin.Coin) float64
-	UpdateAllBalances()
+	GetBalance(coin *coin.Coin) float64 //available balance
+	GetBalances() []*market.Balance     //balances of all coins, refreshed by UpdateAllBalances
+	UpdateAllBalances() error
+	StreamUserData(stop <-chan struct{}) (<-chan *UserEvent, error) //order, fill and balance events by WebSocket or REST polling, closed after stop is closed
 
 	OrderBook(p *pair.Pair) (*market.Maker, error)
+	Ticker(pair *pair.Pair) (*market.Ticker, error)
+	Tickers() ([]*market.Ticker, error)                                     //tickers of all the pairs
+	RecentTrades(pair *pair.Pair, since time.Time) ([]*market.Trade, error) //public trades of the pair, oldest first
+	Candles(pair *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error)
+	StreamOrderBook(pairs []*pair.Pair, stop <-chan struct{}) (<-chan *market.Maker, error) //WebSocket or REST polling, closed after stop is closed
 
-	UpdatePairConstrain()
-	UpdateCoinConstrain()
+	UpdatePairConstrain() error
+	UpdateCoinConstrain() error
 
-	UpdateAllBalancesByUser(u *user.User)
+	UpdateAllBalancesByUser(u *user.User) error
 }
 
-type ExchangeManager struct{}
+type ExchangeManager struct {
+	lock sync.RWMutex
+}
 
 var instance *ExchangeManager
 var once sync.Once
 
-var exMap = make(map[ExchangeName]Exchange)
+// the key of an exchange in the manager, the accounts of the same exchange are kept apart
+type exKey struct {
+	name    ExchangeName
+	account string
+}
+
+var exMap = make(map[exKey]Exchange)
+var v2Map = make(map[exKey]ExchangeV2) // the v2 wrapper of the exchange in exMap, it keeps the balance cache between GetV2 calls
 var exList = make([]Exchange, 0)
-var supportList = make([]ExchangeName, 0)
 
 func CreateExchangeManager() *ExchangeManager {
 	once.Do(func() {
 		instance = &ExchangeManager{}
-		instance.init()
 	})
 	return instance
 }
 
-func (e *ExchangeManager) init() {
-	e.initExchangeNames()
+// add the exchange of the default account, see AddAccount
+func (e *ExchangeManager) Add(exchange Exchange) {
+	e.AddAccount("", exchange)
 }
 
-func (e *ExchangeManager) Add(exchange Exchange) {
-	key := exchange.GetName()
+/*Add the Exchange of an Account
+The exchange is keyed by its name and the account id, the exchange with the same name and account is replaced*/
+func (e *ExchangeManager) AddAccount(account string, exchange Exchange) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	key := exKey{exchange.GetName(), account}
+	if old, ok := exMap[key]; ok {
+		for i, ex := range exList {
+			if ex == old {
+				exList = append(exList[:i], exList[i+1:]...)
+				break
+			}
+		}
+	}
 	exMap[key] = exchange
+	v2Map[key] = Upgrade(exchange)
 	exList = append(exList, exchange)
 }
 
+/*Create a new instance of a registered exchange, the instance is not added to the manager*/
+func (e *ExchangeManager) Build(name ExchangeName, config *Config) (Exchange, error) {
+	factory, ok := GetFactory(name)
+	if !ok {
+		return nil, fmt.Errorf("%s is not registered, import the exchange package first", name)
+	}
+	if config == nil {
+		return nil, fmt.Errorf("%s config is nil", name)
+	}
+	return factory(config)
+}
+
+/*Create a registered exchange from config and add it to the manager as the exchange of config.Account_ID*/
+func (e *ExchangeManager) Init(name ExchangeName, config *Config) (Exchange, error) {
+	ex, err := e.Build(name, config)
+	if err != nil {
+		return nil, err
+	}
+	e.AddAccount(config.Account_ID, ex)
+	return ex, nil
+}
+
+// exchanges which can be created by the manager
 func (e *ExchangeManager) GetSupportExchanges() []ExchangeName {
-	return supportList
+	return RegisteredExchanges()
+}
+
+// exchanges which are created and added to the manager
+func (e *ExchangeManager) GetExchanges() []Exchange {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	exchanges := make([]Exchange, len(exList))
+	copy(exchanges, exList)
+	return exchanges
 }
 
+// the exchange of the default account, or the first added account if there is no default one
 func (e *ExchangeManager) Get(name ExchangeName) Exchange {
-	return exMap[name]
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if ex, ok := exMap[exKey{name, ""}]; ok {
+		return ex
+	}
+	for _, ex := range exList {
+		if ex.GetName() == name {
+			return ex
+		}
+	}
+	return nil
 }
 
-func (e *ExchangeManager) GetStr(name string) Exchange {
-	for _, v := range e.GetSupportExchanges() {
-		// log.Printf(" string(%v) == %v", string(v), name)
-		if string(v) == name {
-			return e.Get(v)
+// the exchange of the account, nil if it is not added
+func (e *ExchangeManager) GetAccount(name ExchangeName, account string) Exchange {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	return exMap[exKey{name, account}]
+}
+
+/*Get the Exchange with the v2 interface, nil if it is not added
+The same wrapper is returned for the same exchange until it is replaced, like Get*/
+func (e *ExchangeManager) GetV2(name ExchangeName) ExchangeV2 {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if v2, ok := v2Map[exKey{name, ""}]; ok {
+		return v2
+	}
+	for _, ex := range exList {
+		if ex.GetName() != name {
+			continue
+		}
+		for key, v1 := range exMap {
+			if v1 == ex {
+				return v2Map[key]
+			}
 		}
 	}
 	return nil
 }
 
+// the exchange of the account with the v2 interface, nil if it is not added
+func (e *ExchangeManager) GetAccountV2(name ExchangeName, account string) ExchangeV2 {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	return v2Map[exKey{name, account}]
+}
+
+func (e *ExchangeManager) GetStr(name string) Exchange {
+	return e.Get(ExchangeName(name))
+}
+
 func (e *ExchangeManager) Quantity() int {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
 	return len(exList)
 }
 
 func (e *ExchangeManager) GetById(i int) Exchange {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
 	return exList[i]
 }
 
+// exchanges added to the manager which support all the features
+func (e *ExchangeManager) FilterByCapability(features ...Feature) []Exchange {
+	exchanges := []Exchange{}
+	for _, ex := range e.GetExchanges() {
+		if len(ex.GetCapabilities().Missing(features...)) == 0 {
+			exchanges = append(exchanges, ex)
+		}
+	}
+	return exchanges
+}
+
 func (e *ExchangeManager) SubsetPairs(e1, e2 Exchange) []*pair.Pair {
 	var pairs []*pair.Pair
 	ep1 := e1.GetPairs()
@@ -2,17 +2,9 @@ package exchange
 
 type ExchangeName string
 
+// the exchanges without an adapter, an adapter declares its own NAME and registers it
 const (
-	BLANK     ExchangeName = "BLANK"
-	CRYPTOPIA ExchangeName = "CRYPTOPIA"
-	FCOIN     ExchangeName = "FCOIN"
 	COINEAL   ExchangeName = "COINEAL"
 	ITIGER    ExchangeName = "ITIGER"
 	BITFOREX    ExchangeName = "BITFOREX"
-	KRAKEN    ExchangeName = "KRAKEN"
-	BITRUE    ExchangeName = "BITRUE"
 )
-
-func (e *ExchangeManager) initExchangeNames() {
-	supportList = append(supportList, CRYPTOPIA)
-}
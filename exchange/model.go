@@ -3,6 +3,7 @@ package exchange
 import (
 	"../coin"
 	"../pair"
+	"../user"
 )
 
 type Config struct {
@@ -11,6 +12,8 @@ type Config struct {
 	Account_ID   string
 	API_KEY      string
 	API_SECRET   string
+	Two_Factor   *user.TwoFactor //nil if the API Key has no two-factor password
+	API_URL      string          //the REST endpoint, empty: the default of the exchange, eg. a local stand-in in the tests
 	WalletStatus []Wallet_Stat
 }
 
@@ -45,4 +48,62 @@ type Wallet_Stat struct {
 	Withdraw bool
 	Deposit  bool
 	TxFee    float64
+	Chain    string // the withdraw chain on the exchange, eg: ERC20 or TRC20 for USDT, empty: not configured
+}
+
+type DepositAddress struct {
+	Coin    *coin.Coin
+	Address string
+	Tag     string // tag, memo or payment id, empty if the chain doesn't use it
+}
+
+type TransferType string
+
+const (
+	Deposit    TransferType = "Deposit"
+	Withdrawal TransferType = "Withdrawal"
+)
+
+type TransferStatus string
+
+const (
+	TransferPending   TransferStatus = "Pending" // waiting for review or confirmations
+	TransferCompleted TransferStatus = "Completed"
+	TransferFailed    TransferStatus = "Failed"
+	TransferCanceled  TransferStatus = "Canceled"
+)
+
+// a deposit or withdrawal of the account
+type Transfer struct {
+	ID        string
+	Coin      *coin.Coin
+	Type      TransferType
+	Quantity  float64
+	Fee       float64
+	Address   string
+	Tag       string
+	TxHash    string // empty before it is broadcast
+	Status    TransferStatus
+	Timestamp int64 // in milliseconds
+}
+
+/*The Withdrawal Placed by Withdraw
+Quantity is the requested amount, Fee is the fee charged by the exchange (0 if unknown yet).
+The coin left the exchange when TxHash is set or the Status is TransferCompleted,
+update it by WithdrawalStatus until the Status is not TransferPending*/
+type WithdrawalResult struct {
+	ID        string // the withdrawal id on the exchange
+	Coin      *coin.Coin
+	Quantity  float64
+	Fee       float64
+	Address   string
+	Tag       string
+	TxHash    string
+	Status    TransferStatus
+	Timestamp int64 // in milliseconds
+}
+
+// the coin has been broadcast to the chain
+func (w *WithdrawalResult) Sent() bool {
+	return w.TxHash != "" || w.Status == TransferCompleted
 }
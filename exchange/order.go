@@ -0,0 +1,177 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"../market"
+)
+
+var clientOrderSeq uint32
+
+/*Generate a Client Order ID
+Unique in the process and sortable by time, 23 chars of [a-z0-9] which fits the limits of the exchanges*/
+func NewClientOrderID() string {
+	seq := atomic.AddUint32(&clientOrderSeq, 1)
+	return fmt.Sprintf("crd%016x%04x", time.Now().UnixNano(), seq&0xffff)
+}
+
+/*Check the Order Request Before Sending it to the Exchange
+Empty Type, TimeInForce and ClientOrderID are filled with LimitOrder, GTC and a new client order id.
+The function or option the exchange lacks: ErrUnsupported
+The request itself is invalid: ErrRejected*/
+func CheckOrderRequest(name ExchangeName, capabilities *Capabilities, request *market.OrderRequest) error {
+	if request == nil || request.Pair == nil {
+		return Errorf(name, "PlaceOrder", ErrRejected, "order request or pair is nil")
+	}
+	if request.Type == "" {
+		request.Type = market.LimitOrder
+	}
+	if request.TimeInForce == "" {
+		request.TimeInForce = market.GTC
+	}
+	if request.ClientOrderID == "" {
+		request.ClientOrderID = NewClientOrderID()
+	}
+
+	if request.Side != market.Buy && request.Side != market.Sell {
+		return Errorf(name, "PlaceOrder", ErrRejected, "invalid side %q", request.Side)
+	}
+	if request.Quantity <= 0 {
+		return Errorf(name, "PlaceOrder", ErrRejected, "invalid quantity %v", request.Quantity)
+	}
+
+	if !capabilities.SupportOrderType(request.Type) {
+		return Errorf(name, "PlaceOrder", ErrUnsupported, "%s order is not supported", request.Type)
+	}
+	switch request.Type {
+	case market.LimitOrder:
+		if request.Rate <= 0 {
+			return Errorf(name, "PlaceOrder", ErrRejected, "invalid rate %v for limit order", request.Rate)
+		}
+	case market.StopLimitOrder:
+		if request.Rate <= 0 || request.StopRate <= 0 {
+			return Errorf(name, "PlaceOrder", ErrRejected, "invalid rate %v or stop rate %v for stop limit order", request.Rate, request.StopRate)
+		}
+	case market.MarketOrder:
+		if request.PostOnly {
+			return Errorf(name, "PlaceOrder", ErrRejected, "market order can't be post only")
+		}
+		// market orders are filled immediately, time in force doesn't apply
+		return nil
+	}
+
+	if !capabilities.SupportTimeInForce(request.TimeInForce) {
+		return Errorf(name, "PlaceOrder", ErrUnsupported, "time in force %s is not supported", request.TimeInForce)
+	}
+	if request.PostOnly {
+		if !capabilities.PostOnly {
+			return Errorf(name, "PlaceOrder", ErrUnsupported, "post only is not supported")
+		}
+		if request.TimeInForce != market.GTC {
+			return Errorf(name, "PlaceOrder", ErrRejected, "post only order can't be %s", request.TimeInForce)
+		}
+	}
+	return nil
+}
+
+/*Place an Order Without Double Placing
+Step 1: Set the client order id of the request if it is empty
+Step 2: PlaceOrder, return if there is a response from the exchange (the order or ErrRejected, ErrAuth...)
+Step 3: The response is lost (ErrNetwork or ErrUnknown), look up the order by the client order id
+	- found: return the order, it has been placed
+	- ErrNotFound: the order is not placed, try again
+	- other errors: the order state is unknown, return the error instead of placing it again
+ErrCanceled is returned as it is, the context was done before the order is sent*/
+func SubmitOrder(ctx context.Context, ex ExchangeV2, request *market.OrderRequest, attempts int) (*market.Order, error) {
+	if request != nil && request.ClientOrderID == "" {
+		request.ClientOrderID = NewClientOrderID()
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		var order *market.Order
+		order, err = ex.PlaceOrder(ctx, request)
+		if err == nil {
+			return order, nil
+		}
+		if kind := KindOf(err); kind != ErrNetwork && kind != ErrUnknown {
+			return nil, err
+		}
+
+		order, lookupErr := ex.OrderByClientID(ctx, request.Pair, request.ClientOrderID)
+		if lookupErr == nil {
+			return order, nil
+		}
+		if !IsKind(lookupErr, ErrNotFound) {
+			return nil, NewError(ex.GetName(), "SubmitOrder", KindOf(lookupErr), fmt.Errorf("order %s state is unknown after %v: %v", request.ClientOrderID, err, lookupErr))
+		}
+	}
+	return nil, err
+}
+
+/*Filter the Orders by the Pair of the Query and Page them by Offset and Limit
+The exchanges sort the orders before paging, so the pages are stable between calls*/
+func PageOrders(orders []*market.Order, query *market.OrderQuery) []*market.Order {
+	if query == nil {
+		return orders
+	}
+
+	filtered := []*market.Order{}
+	for _, order := range orders {
+		if query.Pair == nil || (order.Pair != nil && order.Pair.Name == query.Pair.Name) {
+			filtered = append(filtered, order)
+		}
+	}
+
+	if query.Offset >= len(filtered) {
+		return []*market.Order{}
+	}
+	if query.Offset > 0 {
+		filtered = filtered[query.Offset:]
+	}
+	if query.Limit > 0 && query.Limit < len(filtered) {
+		filtered = filtered[:query.Limit]
+	}
+	return filtered
+}
+
+// convert the orders to the result of v1 ListOrders
+func OrderList(orders []*market.Order) *[]market.Order {
+	list := make([]market.Order, len(orders))
+	for i, order := range orders {
+		list[i] = *order
+	}
+	return &list
+}
+
+/*Update the Orders One by One
+For the exchanges without a batch order API. All the orders are updated even if some fail,
+the first error is returned*/
+func OrdersStatusEach(ex Exchange, orders []*market.Order) error {
+	var first error
+	for _, order := range orders {
+		if err := ex.OrderStatus(order); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+/*Reconcile the Deal of the Order with its Fills
+DealQuantity is the sum of the fill quantity, DealRate is the average rate weighted by quantity*/
+func ApplyFills(order *market.Order, trades []*market.Trade) {
+	quantity, total := 0.0, 0.0
+	for _, trade := range trades {
+		if trade.OrderID == order.OrderID {
+			quantity += trade.Quantity
+			total += trade.Quantity * trade.Rate
+		}
+	}
+	if quantity > 0 {
+		order.DealQuantity = quantity
+		order.DealRate = total / quantity
+	}
+}
@@ -0,0 +1,50 @@
+package exchange
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory creates an exchange instance from its config
+type Factory func(config *Config) (Exchange, error)
+
+var factoryMap = make(map[ExchangeName]Factory)
+var factoryLock sync.RWMutex
+
+/*Register an Exchange Factory
+Each exchange package calls Register in its init(),
+importing the package (eg. import _ "../exchange/kraken") is enough to make it available in ExchangeManager*/
+func Register(name ExchangeName, factory Factory) {
+	factoryLock.Lock()
+	defer factoryLock.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("exchange: Register factory for %s is nil", name))
+	}
+	if _, ok := factoryMap[name]; ok {
+		panic(fmt.Sprintf("exchange: Register called twice for %s", name))
+	}
+	factoryMap[name] = factory
+}
+
+func GetFactory(name ExchangeName) (Factory, bool) {
+	factoryLock.RLock()
+	defer factoryLock.RUnlock()
+
+	factory, ok := factoryMap[name]
+	return factory, ok
+}
+
+// all registered exchange names, sorted
+func RegisteredExchanges() []ExchangeName {
+	factoryLock.RLock()
+	defer factoryLock.RUnlock()
+
+	names := make([]ExchangeName, 0, len(factoryMap))
+	for name := range factoryMap {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
@@ -0,0 +1,292 @@
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"../market"
+	"../pair"
+	"github.com/gorilla/websocket"
+)
+
+// the channel size of the streams, see OfferMaker
+const StreamBuffer = 256
+
+/*The Reconnecting WebSocket Client of the Streams
+The subscriptions are sent again after each reconnect. With Heartbeat the client pings in the interval
+and reconnects if nothing arrives in 2 intervals. The reconnect waits MinBackoff after a connection
+which received messages, doubling up to MaxBackoff while the connection keeps failing*/
+type WsClient struct {
+	Name       ExchangeName
+	URL        string
+	Heartbeat  time.Duration      // 0: no ping and no read deadline
+	Ping       func() interface{} // the ping message of the exchange, nil: WebSocket ping frame
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	Dialer     *websocket.Dialer
+	Endpoint   func() (string, error)  // the URL of each connect, eg. with a new listen key, nil: URL
+	OnConnect  func(c *WsClient) error // called after each connect before the subscriptions, eg. to subscribe with a new token
+
+	onMessage func(c *WsClient, message []byte)
+
+	lock          sync.Mutex
+	conn          *websocket.Conn
+	writeLock     sync.Mutex // gorilla allows one writer at a time
+	subscriptions []interface{}
+
+	started  bool // guarded by lock, Close waits the read goroutine only if started
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+/*Create the WebSocket Client
+onMessage is called for each message in the read goroutine of the client, it should not block*/
+func NewWsClient(name ExchangeName, url string, onMessage func(c *WsClient, message []byte)) *WsClient {
+	return &WsClient{
+		Name:       name,
+		URL:        url,
+		MinBackoff: time.Second,
+		MaxBackoff: 30 * time.Second,
+		Dialer:     websocket.DefaultDialer,
+		onMessage:  onMessage,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// connect and keep the connection until Close, once, Start after Close does nothing
+func (c *WsClient) Start() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.started {
+		return
+	}
+	select {
+	case <-c.stop:
+		return
+	default:
+	}
+	c.started = true
+	go c.run()
+}
+
+/*Stop the Client
+Wait for the read goroutine if started, onMessage is not called after Close*/
+func (c *WsClient) Close() {
+	c.lock.Lock()
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	started := c.started
+	c.lock.Unlock()
+
+	if started {
+		<-c.done
+	}
+}
+
+/*Subscribe a Channel
+The message is sent as JSON now if connected, and again after each reconnect*/
+func (c *WsClient) Subscribe(message interface{}) error {
+	c.lock.Lock()
+	c.subscriptions = append(c.subscriptions, message)
+	conn := c.conn
+	c.lock.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	return c.write(conn, message)
+}
+
+// send the message as JSON once, eg: the pong of the exchange ping
+func (c *WsClient) Send(message interface{}) error {
+	c.lock.Lock()
+	conn := c.conn
+	c.lock.Unlock()
+
+	if conn == nil {
+		return NewError(c.Name, "WebSocket", ErrNetwork, errors.New("not connected"))
+	}
+	return c.write(conn, message)
+}
+
+func (c *WsClient) write(conn *websocket.Conn, message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (c *WsClient) run() {
+	defer close(c.done)
+
+	backoff := c.MinBackoff
+	for {
+		received, err := c.connect()
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+		log.Printf("%s WebSocket %s reconnect: %v", c.Name, c.URL, err)
+
+		if received {
+			backoff = c.MinBackoff
+		}
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > c.MaxBackoff {
+			backoff = c.MaxBackoff
+		}
+	}
+}
+
+// one connection, return when it fails or the client is closed, received: any message arrived
+func (c *WsClient) connect() (received bool, err error) {
+	url := c.URL
+	if c.Endpoint != nil {
+		if url, err = c.Endpoint(); err != nil {
+			return false, err
+		}
+	}
+	conn, _, err := c.Dialer.Dial(url, nil)
+	if err != nil {
+		return false, err
+	}
+
+	c.lock.Lock()
+	c.conn = conn
+	subscriptions := append([]interface{}{}, c.subscriptions...)
+	c.lock.Unlock()
+
+	closed := make(chan struct{})
+	defer func() {
+		close(closed)
+		c.lock.Lock()
+		c.conn = nil
+		c.lock.Unlock()
+		conn.Close()
+	}()
+	go func() {
+		select {
+		case <-c.stop:
+			conn.Close() // unblock ReadMessage
+		case <-closed:
+		}
+	}()
+
+	if c.OnConnect != nil {
+		if err := c.OnConnect(c); err != nil {
+			return false, err
+		}
+	}
+	for _, message := range subscriptions {
+		if err := c.write(conn, message); err != nil {
+			return false, err
+		}
+	}
+
+	if c.Heartbeat > 0 {
+		conn.SetReadDeadline(time.Now().Add(2 * c.Heartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * c.Heartbeat))
+		})
+		go c.ping(conn, closed)
+	}
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		if c.Heartbeat > 0 {
+			conn.SetReadDeadline(time.Now().Add(2 * c.Heartbeat))
+		}
+		c.onMessage(c, message)
+	}
+}
+
+func (c *WsClient) ping(conn *websocket.Conn, closed chan struct{}) {
+	ticker := time.NewTicker(c.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+		}
+
+		var err error
+		if c.Ping != nil {
+			err = c.write(conn, c.Ping())
+		} else {
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+		}
+		if err != nil {
+			return // the read deadline drops the connection
+		}
+	}
+}
+
+/*Offer the Maker to the Stream without Blocking
+The maker is dropped if the channel is full, the next maker has the whole book.
+Blocking would stop the read goroutine and the heartbeat of the connection*/
+func OfferMaker(out chan<- *market.Maker, maker *market.Maker) bool {
+	select {
+	case out <- maker:
+		return true
+	default:
+		return false
+	}
+}
+
+/*Poll the Order Books by REST
+For the exchanges without a stream. Each pair is polled once in the interval,
+the channel is closed after stop is closed*/
+func PollOrderBook(ex Exchange, pairs []*pair.Pair, interval time.Duration, stop <-chan struct{}) <-chan *market.Maker {
+	out := make(chan *market.Maker, StreamBuffer)
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for _, p := range pairs {
+				maker, err := ex.OrderBook(p)
+				if err != nil {
+					log.Printf("%s PollOrderBook %s err: %v", ex.GetName(), p.Name, err)
+					continue
+				}
+				maker.Pair = p
+				select {
+				case out <- maker:
+				case <-stop:
+					return
+				}
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,242 @@
+package exchange
+
+import (
+	"log"
+	"time"
+
+	"../market"
+)
+
+// the kind of the user data event
+type UserEventType string
+
+const (
+	UserOrder   UserEventType = "Order"   // the status or the deal of an order changed
+	UserFill    UserEventType = "Fill"    // a trade of the account
+	UserBalance UserEventType = "Balance" // the balance of a coin changed
+)
+
+/*An Event of the Account
+One of Order, Trade and Balance is set by Type, the values are copies owned by the consumer*/
+type UserEvent struct {
+	Type      UserEventType
+	Order     *market.Order
+	Trade     *market.Trade
+	Balance   *market.Balance
+	Timestamp int64 // in milliseconds, when the event is received
+}
+
+func NewOrderEvent(order *market.Order) *UserEvent {
+	o := *order
+	return &UserEvent{Type: UserOrder, Order: &o, Timestamp: time.Now().UnixNano() / 1e6}
+}
+
+func NewFillEvent(trade *market.Trade) *UserEvent {
+	t := *trade
+	return &UserEvent{Type: UserFill, Trade: &t, Timestamp: time.Now().UnixNano() / 1e6}
+}
+
+func NewBalanceEvent(balance *market.Balance) *UserEvent {
+	b := *balance
+	return &UserEvent{Type: UserBalance, Balance: &b, Timestamp: time.Now().UnixNano() / 1e6}
+}
+
+/*Send the Event to the User Stream
+Unlike the makers, the events are not dropped: it blocks until the event is taken or stop is closed.
+A consumer which falls behind the heartbeat makes the WebSocket reconnect. false: stop is closed*/
+func SendUserEvent(out chan<- *UserEvent, event *UserEvent, stop <-chan struct{}) bool {
+	select {
+	case out <- event:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+/*Poll the User Data by REST
+For the exchanges without a user stream. In each interval:
+Step 1: The open orders (ListOpenOrders), an order is sent when it is new or its status or deal changed.
+	An order which left the open orders is updated by OrderStatus and sent with its final status
+Step 2: The fills since the last poll (GetFills), each TradeID once
+Step 3: The balances (UpdateAllBalances), a coin is sent when its balance changed
+The first poll sends the open orders and the balances as they are, the fills start from now.
+The parts the exchange doesn't support (ErrUnsupported) are skipped.
+The channel is closed after stop is closed*/
+func PollUserData(ex Exchange, interval time.Duration, stop <-chan struct{}) <-chan *UserEvent {
+	out := make(chan *UserEvent, StreamBuffer)
+	go func() {
+		defer close(out)
+		poller := newUserPoller(ex, out, stop)
+		poller.run(interval, poller.pollOrders, poller.pollFills, poller.pollBalances)
+	}()
+	return out
+}
+
+/*Poll the Balances into the User Stream
+For the user streams without the balances (eg. Kraken). It returns after stop is closed,
+the caller closes out*/
+func PollBalances(ex Exchange, out chan<- *UserEvent, interval time.Duration, stop <-chan struct{}) {
+	poller := newUserPoller(ex, out, stop)
+	poller.run(interval, poller.pollBalances)
+}
+
+// the state of the polls, only used in the poll goroutine
+type userPoller struct {
+	ex   Exchange
+	out  chan<- *UserEvent
+	stop <-chan struct{}
+
+	created      time.Time
+	orders       map[string]*market.Order // order id: the last sent
+	skipOrders   bool
+	fillsSince   time.Time
+	fills        map[string]bool // trade ids of the last poll
+	skipFills    bool
+	balances     map[string]market.Balance // coin code: the last sent
+	skipBalances bool
+}
+
+func newUserPoller(ex Exchange, out chan<- *UserEvent, stop <-chan struct{}) *userPoller {
+	now := time.Now()
+	return &userPoller{
+		ex:         ex,
+		out:        out,
+		stop:       stop,
+		created:    now,
+		orders:     make(map[string]*market.Order),
+		fillsSince: now,
+		fills:      make(map[string]bool),
+		balances:   make(map[string]market.Balance),
+	}
+}
+
+// poll until stop is closed, a poll returns false if stop is closed while sending
+func (p *userPoller) run(interval time.Duration, polls ...func() bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, poll := range polls {
+			if !poll() {
+				return
+			}
+		}
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *userPoller) send(event *UserEvent) bool {
+	return SendUserEvent(p.out, event, p.stop)
+}
+
+func (p *userPoller) pollOrders() bool {
+	if p.skipOrders {
+		return true
+	}
+	open, err := p.ex.ListOpenOrders(nil)
+	if err != nil {
+		p.skipOrders = p.skip("ListOpenOrders", err)
+		return true
+	}
+
+	current := make(map[string]bool)
+	for _, order := range open {
+		current[order.OrderID] = true
+		last, ok := p.orders[order.OrderID]
+		if ok && last.Status == order.Status && last.DealQuantity == order.DealQuantity {
+			continue
+		}
+		o := *order
+		p.orders[order.OrderID] = &o
+		if !p.send(NewOrderEvent(order)) {
+			return false
+		}
+	}
+
+	for id, order := range p.orders {
+		if current[id] {
+			continue
+		}
+		if err := p.ex.OrderStatus(order); err != nil {
+			if IsKind(err, ErrNotFound) {
+				delete(p.orders, id)
+			}
+			log.Printf("%s PollUserData OrderStatus %s err: %v", p.ex.GetName(), id, err)
+			continue // try again in the next poll
+		}
+		if order.Status == market.New || order.Status == market.Partial {
+			continue // left the page of the open orders, still open
+		}
+		delete(p.orders, id)
+		if !p.send(NewOrderEvent(order)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *userPoller) pollFills() bool {
+	if p.skipFills {
+		return true
+	}
+	start := time.Now()
+	trades, err := p.ex.GetFills(nil, p.fillsSince)
+	if err != nil {
+		p.skipFills = p.skip("GetFills", err)
+		return true
+	}
+
+	// the next poll overlaps the last interval, for the trades which show up late
+	fills := make(map[string]bool)
+	for _, trade := range trades {
+		fills[trade.TradeID] = true
+		if p.fills[trade.TradeID] {
+			continue
+		}
+		if !p.send(NewFillEvent(trade)) {
+			return false
+		}
+	}
+	p.fills = fills
+	if since := start.Add(-time.Minute); since.After(p.created) {
+		p.fillsSince = since
+	}
+	return true
+}
+
+func (p *userPoller) pollBalances() bool {
+	if p.skipBalances {
+		return true
+	}
+	if err := p.ex.UpdateAllBalances(); err != nil {
+		p.skipBalances = p.skip("UpdateAllBalances", err) // the cached balances are not sent as new
+		return true
+	}
+	for _, balance := range p.ex.GetBalances() {
+		if balance == nil || balance.Coin == nil {
+			continue
+		}
+		last, ok := p.balances[balance.Coin.Code]
+		if ok && last.Available == balance.Available && last.Locked == balance.Locked && last.Total == balance.Total {
+			continue
+		}
+		p.balances[balance.Coin.Code] = *balance
+		if !p.send(NewBalanceEvent(balance)) {
+			return false
+		}
+	}
+	return true
+}
+
+// true: the exchange doesn't support the function, it is not polled again
+func (p *userPoller) skip(op string, err error) bool {
+	if IsKind(err, ErrUnsupported) {
+		return true
+	}
+	log.Printf("%s PollUserData %s err: %v", p.ex.GetName(), op, err)
+	return false
+}
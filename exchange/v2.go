@@ -0,0 +1,646 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"../coin"
+	"../market"
+	"../pair"
+)
+
+/*Exchange Interface v2
+Every network or Redis touching function takes a context and returns a typed *Error (see error.go),
+so the caller can tell "zero balance" from "API down".
+Exchanges which still implement the v1 Exchange interface are wrapped by Upgrade()*/
+type ExchangeV2 interface {
+	GetName() ExchangeName
+	GetTradingWebURL(pair *pair.Pair) string
+	GetClockSkew() time.Duration
+
+	GetCode(symbol string) string
+	GetSymbol(code string) string
+
+	GetCoins() []*coin.Coin
+	GetPairs() []*pair.Pair
+	GetPair(key string) *pair.Pair
+	GetPairCode(pair *pair.Pair) string
+	HasPair(ctx context.Context, pair *pair.Pair) (bool, error)
+
+	GetMaker(ctx context.Context, pair *pair.Pair) (*market.Maker, error)
+	UpdateMaker(ctx context.Context, pair *pair.Pair, maker *market.Maker) error
+
+	GetCapabilities() *Capabilities
+
+	GetLotSize(ctx context.Context, pair *pair.Pair) (float64, error)
+	GetPriceFilter(ctx context.Context, pair *pair.Pair) (float64, error)
+
+	GetFee(ctx context.Context, pair *pair.Pair) (float64, error)
+	GetTradeFee(ctx context.Context, pair *pair.Pair) (*TradeFee, error)
+	UpdateFees(ctx context.Context) error
+	GetTxFee(ctx context.Context, coin *coin.Coin) (float64, error)
+
+	CanWithdraw(ctx context.Context, coin *coin.Coin) (bool, error)
+	CanDeposit(ctx context.Context, coin *coin.Coin) (bool, error)
+
+	Withdraw(ctx context.Context, coin *coin.Coin, quantity float64, addr, tag string) (*WithdrawalResult, error)
+	WithdrawalStatus(ctx context.Context, withdrawal *WithdrawalResult) error
+	GetDepositAddress(ctx context.Context, coin *coin.Coin) (*DepositAddress, error)
+	GetTransfers(ctx context.Context, coin *coin.Coin, since time.Time) ([]*Transfer, error)
+
+	PlaceOrder(ctx context.Context, request *market.OrderRequest) (*market.Order, error)
+	LimitSell(ctx context.Context, pair *pair.Pair, quantity, rate float64) (*market.Order, error)
+	LimitBuy(ctx context.Context, pair *pair.Pair, quantity, rate float64) (*market.Order, error)
+
+	OrderStatus(ctx context.Context, order *market.Order) error
+	OrdersStatus(ctx context.Context, orders []*market.Order) error
+	OrderByClientID(ctx context.Context, pair *pair.Pair, clientOrderID string) (*market.Order, error)
+	CancelOrder(ctx context.Context, order *market.Order) error
+	CancelAllOrder(ctx context.Context) error
+	CancelAllOrders(ctx context.Context, pair *pair.Pair) (*CancelReport, error)
+	ListOrders(ctx context.Context) (*[]market.Order, error)
+	ListOpenOrders(ctx context.Context, query *market.OrderQuery) ([]*market.Order, error)
+	GetFills(ctx context.Context, pair *pair.Pair, since time.Time) ([]*market.Trade, error)
+
+	GetBalance(ctx context.Context, coin *coin.Coin) (float64, error)
+	GetBalances(ctx context.Context) ([]*market.Balance, error)
+	UpdateAllBalances(ctx context.Context) error
+	StreamUserData(ctx context.Context) (<-chan *UserEvent, error) // the stream stops when ctx is done
+
+	OrderBook(ctx context.Context, p *pair.Pair) (*market.Maker, error)
+	Ticker(ctx context.Context, pair *pair.Pair) (*market.Ticker, error)
+	Tickers(ctx context.Context) ([]*market.Ticker, error)
+	RecentTrades(ctx context.Context, pair *pair.Pair, since time.Time) ([]*market.Trade, error)
+	Candles(ctx context.Context, pair *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error)
+	StreamOrderBook(ctx context.Context, pairs []*pair.Pair) (<-chan *market.Maker, error) // the stream stops when ctx is done
+
+	UpdatePairConstrain(ctx context.Context) error
+	UpdateCoinConstrain(ctx context.Context) error
+}
+
+// magic values returned by v1 exchanges when the constrain is unknown
+const (
+	legacyUnknownTxFee = 100.001
+)
+
+/*Upgrade a v1 Exchange to ExchangeV2
+The v1 functions can't be cancelled, when the context is done the call keeps running in background
+and its result is dropped: a read returns ErrCanceled, an order or withdraw call returns ErrNetwork
+as it may have been executed (SubmitOrder looks it up by the client order id).
+The adapters return typed *Error, other errors are ErrUnknown.
+GetBalance and GetBalances return the error of the last UpdateAllBalances instead of the cached balances.*/
+func Upgrade(ex Exchange) ExchangeV2 {
+	if ex == nil {
+		return nil
+	}
+	return &legacyExchange{ex: ex}
+}
+
+type legacyExchange struct {
+	ex Exchange
+
+	balanceLock sync.Mutex
+	balancesAt  time.Time // the last successful UpdateAllBalances, zero: not fetched yet
+	balancesErr error     // the error of the last UpdateAllBalances, nil if it succeeded
+}
+
+// get the v1 Exchange behind an upgraded exchange, nil if it is a native ExchangeV2
+func Legacy(v2 ExchangeV2) Exchange {
+	if l, ok := v2.(*legacyExchange); ok {
+		return l.ex
+	}
+	return nil
+}
+
+// a read, it is abandoned with ErrCanceled when the context is done
+func (l *legacyExchange) call(ctx context.Context, op string, f func() error) error {
+	return l.run(ctx, op, ErrCanceled, f)
+}
+
+// an order or withdraw call, it is abandoned with ErrNetwork when the context is done as it may have been executed
+func (l *legacyExchange) mutate(ctx context.Context, op string, f func() error) error {
+	return l.run(ctx, op, ErrNetwork, f)
+}
+
+func (l *legacyExchange) run(ctx context.Context, op string, abandoned ErrorKind, f func() error) error {
+	if err := ctx.Err(); err != nil {
+		return NewError(l.ex.GetName(), op, ErrCanceled, err) // not started
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+
+	select {
+	case err := <-done:
+		return l.wrap(op, err)
+	case <-ctx.Done():
+		if abandoned == ErrCanceled {
+			return NewError(l.ex.GetName(), op, ErrCanceled, ctx.Err())
+		}
+		return Errorf(l.ex.GetName(), op, abandoned, "%v while the call is running, it may have been executed", ctx.Err())
+	}
+}
+
+func (l *legacyExchange) wrap(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) { // an *Error, maybe wrapped, keeps its kind
+		return err
+	}
+	return NewError(l.ex.GetName(), op, ErrUnknown, err)
+}
+
+func (l *legacyExchange) GetName() ExchangeName {
+	return l.ex.GetName()
+}
+
+func (l *legacyExchange) GetTradingWebURL(pair *pair.Pair) string {
+	return l.ex.GetTradingWebURL(pair)
+}
+
+func (l *legacyExchange) GetClockSkew() time.Duration {
+	return l.ex.GetClockSkew()
+}
+
+func (l *legacyExchange) GetCode(symbol string) string {
+	return l.ex.GetCode(symbol)
+}
+
+func (l *legacyExchange) GetSymbol(code string) string {
+	return l.ex.GetSymbol(code)
+}
+
+func (l *legacyExchange) GetCoins() []*coin.Coin {
+	return l.ex.GetCoins()
+}
+
+func (l *legacyExchange) GetPairs() []*pair.Pair {
+	return l.ex.GetPairs()
+}
+
+func (l *legacyExchange) GetPair(key string) *pair.Pair {
+	return l.ex.GetPair(key)
+}
+
+func (l *legacyExchange) GetPairCode(pair *pair.Pair) string {
+	return l.ex.GetPairCode(pair)
+}
+
+func (l *legacyExchange) HasPair(ctx context.Context, pair *pair.Pair) (bool, error) {
+	var has bool
+	err := l.call(ctx, "HasPair", func() error {
+		has = l.ex.HasPair(pair)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return has, nil
+}
+
+func (l *legacyExchange) GetMaker(ctx context.Context, pair *pair.Pair) (*market.Maker, error) {
+	var maker *market.Maker
+	err := l.call(ctx, "GetMaker", func() (err error) {
+		maker, err = l.ex.GetMaker(pair)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return maker, nil
+}
+
+func (l *legacyExchange) UpdateMaker(ctx context.Context, pair *pair.Pair, maker *market.Maker) error {
+	return l.call(ctx, "UpdateMaker", func() error {
+		return l.ex.UpdateMaker(pair, maker)
+	})
+}
+
+func (l *legacyExchange) GetCapabilities() *Capabilities {
+	return l.ex.GetCapabilities()
+}
+
+func (l *legacyExchange) GetLotSize(ctx context.Context, pair *pair.Pair) (float64, error) {
+	var lotSize float64
+	err := l.call(ctx, "GetLotSize", func() error {
+		lotSize = l.ex.GetLotSize(pair)
+		if lotSize <= 0 {
+			return NewError(l.ex.GetName(), "GetLotSize", ErrNotFound, errors.New("lot size is not available for "+pair.Name))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return lotSize, nil
+}
+
+func (l *legacyExchange) GetPriceFilter(ctx context.Context, pair *pair.Pair) (float64, error) {
+	var tickSize float64
+	err := l.call(ctx, "GetPriceFilter", func() error {
+		tickSize = l.ex.GetPriceFilter(pair)
+		if tickSize <= 0 {
+			return NewError(l.ex.GetName(), "GetPriceFilter", ErrNotFound, errors.New("price filter is not available for "+pair.Name))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return tickSize, nil
+}
+
+func (l *legacyExchange) GetFee(ctx context.Context, pair *pair.Pair) (float64, error) {
+	var fee float64
+	err := l.call(ctx, "GetFee", func() error {
+		fee = l.ex.GetFee(pair)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return fee, nil
+}
+
+func (l *legacyExchange) GetTradeFee(ctx context.Context, pair *pair.Pair) (*TradeFee, error) {
+	var fee *TradeFee
+	err := l.call(ctx, "GetTradeFee", func() error {
+		fee = l.ex.GetTradeFee(pair)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fee, nil
+}
+
+func (l *legacyExchange) UpdateFees(ctx context.Context) error {
+	return l.call(ctx, "UpdateFees", func() error {
+		return l.ex.UpdateFees()
+	})
+}
+
+func (l *legacyExchange) GetTxFee(ctx context.Context, coin *coin.Coin) (float64, error) {
+	var txFee float64
+	err := l.call(ctx, "GetTxFee", func() error {
+		txFee = l.ex.GetTxFee(coin)
+		if txFee == legacyUnknownTxFee || txFee < 0 {
+			return NewError(l.ex.GetName(), "GetTxFee", ErrNotFound, errors.New("tx fee is not available for "+coin.Code))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return txFee, nil
+}
+
+func (l *legacyExchange) CanWithdraw(ctx context.Context, coin *coin.Coin) (bool, error) {
+	var enable bool
+	err := l.call(ctx, "CanWithdraw", func() error {
+		enable = l.ex.CanWithdraw(coin)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return enable, nil
+}
+
+func (l *legacyExchange) CanDeposit(ctx context.Context, coin *coin.Coin) (bool, error) {
+	var enable bool
+	err := l.call(ctx, "CanDeposit", func() error {
+		enable = l.ex.CanDeposit(coin)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return enable, nil
+}
+
+func (l *legacyExchange) Withdraw(ctx context.Context, coin *coin.Coin, quantity float64, addr, tag string) (*WithdrawalResult, error) {
+	var withdrawal *WithdrawalResult
+	err := l.mutate(ctx, "Withdraw", func() (err error) {
+		withdrawal, err = l.ex.Withdraw(coin, quantity, addr, tag)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return withdrawal, nil
+}
+
+func (l *legacyExchange) WithdrawalStatus(ctx context.Context, withdrawal *WithdrawalResult) error {
+	return l.call(ctx, "WithdrawalStatus", func() error {
+		return l.ex.WithdrawalStatus(withdrawal)
+	})
+}
+
+func (l *legacyExchange) GetDepositAddress(ctx context.Context, coin *coin.Coin) (*DepositAddress, error) {
+	var address *DepositAddress
+	err := l.call(ctx, "GetDepositAddress", func() (err error) {
+		address, err = l.ex.GetDepositAddress(coin)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (l *legacyExchange) GetTransfers(ctx context.Context, coin *coin.Coin, since time.Time) ([]*Transfer, error) {
+	var transfers []*Transfer
+	err := l.call(ctx, "GetTransfers", func() (err error) {
+		transfers, err = l.ex.GetTransfers(coin, since)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+func (l *legacyExchange) PlaceOrder(ctx context.Context, request *market.OrderRequest) (*market.Order, error) {
+	var order *market.Order
+	err := l.mutate(ctx, "PlaceOrder", func() (err error) {
+		order, err = l.ex.PlaceOrder(request)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (l *legacyExchange) LimitSell(ctx context.Context, pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	var order *market.Order
+	err := l.mutate(ctx, "LimitSell", func() (err error) {
+		order, err = l.ex.LimitSell(pair, quantity, rate)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (l *legacyExchange) LimitBuy(ctx context.Context, pair *pair.Pair, quantity, rate float64) (*market.Order, error) {
+	var order *market.Order
+	err := l.mutate(ctx, "LimitBuy", func() (err error) {
+		order, err = l.ex.LimitBuy(pair, quantity, rate)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (l *legacyExchange) OrderStatus(ctx context.Context, order *market.Order) error {
+	return l.call(ctx, "OrderStatus", func() error {
+		return l.ex.OrderStatus(order)
+	})
+}
+
+func (l *legacyExchange) OrdersStatus(ctx context.Context, orders []*market.Order) error {
+	return l.call(ctx, "OrdersStatus", func() error {
+		return l.ex.OrdersStatus(orders)
+	})
+}
+
+func (l *legacyExchange) OrderByClientID(ctx context.Context, pair *pair.Pair, clientOrderID string) (*market.Order, error) {
+	var order *market.Order
+	err := l.call(ctx, "OrderByClientID", func() (err error) {
+		order, err = l.ex.OrderByClientID(pair, clientOrderID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (l *legacyExchange) CancelOrder(ctx context.Context, order *market.Order) error {
+	return l.mutate(ctx, "CancelOrder", func() error {
+		return l.ex.CancelOrder(order)
+	})
+}
+
+func (l *legacyExchange) CancelAllOrder(ctx context.Context) error {
+	return l.mutate(ctx, "CancelAllOrder", func() error {
+		return l.ex.CancelAllOrder()
+	})
+}
+
+func (l *legacyExchange) CancelAllOrders(ctx context.Context, pair *pair.Pair) (*CancelReport, error) {
+	var report *CancelReport
+	err := l.mutate(ctx, "CancelAllOrders", func() (err error) {
+		report, err = l.ex.CancelAllOrders(pair)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (l *legacyExchange) ListOrders(ctx context.Context) (*[]market.Order, error) {
+	var orders *[]market.Order
+	err := l.call(ctx, "ListOrders", func() (err error) {
+		orders, err = l.ex.ListOrders()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (l *legacyExchange) ListOpenOrders(ctx context.Context, query *market.OrderQuery) ([]*market.Order, error) {
+	var orders []*market.Order
+	err := l.call(ctx, "ListOpenOrders", func() (err error) {
+		orders, err = l.ex.ListOpenOrders(query)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (l *legacyExchange) GetFills(ctx context.Context, pair *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	var trades []*market.Trade
+	err := l.call(ctx, "GetFills", func() (err error) {
+		trades, err = l.ex.GetFills(pair, since)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+func (l *legacyExchange) GetBalance(ctx context.Context, coin *coin.Coin) (float64, error) {
+	if err := l.balancesFetched(ctx); err != nil {
+		return 0, err
+	}
+
+	var balance float64
+	err := l.call(ctx, "GetBalance", func() error {
+		balance = l.ex.GetBalance(coin)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+func (l *legacyExchange) GetBalances(ctx context.Context) ([]*market.Balance, error) {
+	if err := l.balancesFetched(ctx); err != nil {
+		return nil, err
+	}
+
+	var balances []*market.Balance
+	err := l.call(ctx, "GetBalances", func() error {
+		balances = l.ex.GetBalances()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (l *legacyExchange) UpdateAllBalances(ctx context.Context) error {
+	return l.call(ctx, "UpdateAllBalances", func() error {
+		err := l.ex.UpdateAllBalances()
+		l.balanceLock.Lock()
+		defer l.balanceLock.Unlock()
+		l.balancesErr = err
+		if err == nil {
+			l.balancesAt = time.Now()
+		}
+		return err
+	})
+}
+
+/*The Cached Balances are Usable
+The balances are fetched once if UpdateAllBalances has not been called,
+the error of the last UpdateAllBalances is returned until it succeeds again: a 0 balance is not "API down"*/
+func (l *legacyExchange) balancesFetched(ctx context.Context) error {
+	l.balanceLock.Lock()
+	fetchedAt, err := l.balancesAt, l.balancesErr
+	l.balanceLock.Unlock()
+
+	if err != nil {
+		return l.wrap("GetBalance", err)
+	}
+	if fetchedAt.IsZero() {
+		return l.UpdateAllBalances(ctx)
+	}
+	return nil
+}
+
+func (l *legacyExchange) StreamUserData(ctx context.Context) (<-chan *UserEvent, error) {
+	var events <-chan *UserEvent
+	err := l.call(ctx, "StreamUserData", func() (err error) {
+		events, err = l.ex.StreamUserData(ctx.Done())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (l *legacyExchange) OrderBook(ctx context.Context, p *pair.Pair) (*market.Maker, error) {
+	var maker *market.Maker
+	err := l.call(ctx, "OrderBook", func() (err error) {
+		maker, err = l.ex.OrderBook(p)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return maker, nil
+}
+
+func (l *legacyExchange) Ticker(ctx context.Context, pair *pair.Pair) (*market.Ticker, error) {
+	var ticker *market.Ticker
+	err := l.call(ctx, "Ticker", func() (err error) {
+		ticker, err = l.ex.Ticker(pair)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ticker, nil
+}
+
+func (l *legacyExchange) Tickers(ctx context.Context) ([]*market.Ticker, error) {
+	var tickers []*market.Ticker
+	err := l.call(ctx, "Tickers", func() (err error) {
+		tickers, err = l.ex.Tickers()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tickers, nil
+}
+
+func (l *legacyExchange) RecentTrades(ctx context.Context, pair *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	var trades []*market.Trade
+	err := l.call(ctx, "RecentTrades", func() (err error) {
+		trades, err = l.ex.RecentTrades(pair, since)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+func (l *legacyExchange) Candles(ctx context.Context, pair *pair.Pair, interval time.Duration, since time.Time) ([]*market.Candle, error) {
+	var candles []*market.Candle
+	err := l.call(ctx, "Candles", func() (err error) {
+		candles, err = l.ex.Candles(pair, interval, since)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return candles, nil
+}
+
+func (l *legacyExchange) StreamOrderBook(ctx context.Context, pairs []*pair.Pair) (<-chan *market.Maker, error) {
+	var makers <-chan *market.Maker
+	err := l.call(ctx, "StreamOrderBook", func() (err error) {
+		makers, err = l.ex.StreamOrderBook(pairs, ctx.Done())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return makers, nil
+}
+
+func (l *legacyExchange) UpdatePairConstrain(ctx context.Context) error {
+	return l.call(ctx, "UpdatePairConstrain", func() error {
+		return l.ex.UpdatePairConstrain()
+	})
+}
+
+func (l *legacyExchange) UpdateCoinConstrain(ctx context.Context) error {
+	return l.call(ctx, "UpdateCoinConstrain", func() error {
+		return l.ex.UpdateCoinConstrain()
+	})
+}
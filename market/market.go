@@ -3,6 +3,7 @@ package market
 import (
 	"sync"
 
+	"../coin"
 	"../pair"
 )
 
@@ -19,9 +20,54 @@ const (
 	Other     OrderStatus = "Other"
 )
 
+type OrderType string
+
+const (
+	LimitOrder     OrderType = "Limit"
+	MarketOrder    OrderType = "Market"
+	StopLimitOrder OrderType = "StopLimit"
+)
+
+type OrderSide string
+
+const (
+	Buy  OrderSide = "Buy"
+	Sell OrderSide = "Sell"
+)
+
+type TimeInForce string
+
+const (
+	GTC TimeInForce = "GTC" // good till cancel
+	IOC TimeInForce = "IOC" // immediate or cancel, the unfilled part is canceled
+	FOK TimeInForce = "FOK" // fill or kill, filled completely or canceled
+)
+
+// the order to be placed by Exchange PlaceOrder
+type OrderRequest struct {
+	Pair        *pair.Pair
+	Side        OrderSide
+	Type        OrderType   // default LimitOrder
+	TimeInForce TimeInForce // default GTC, only for LimitOrder and StopLimitOrder
+	PostOnly    bool        // maker only, rejected if it would take liquidity
+	Quantity    float64
+	Rate        float64 // limit price, ignored by MarketOrder
+	StopRate    float64 // trigger price of StopLimitOrder
+
+	ClientOrderID string // generated when empty, use it to find the order if the response is lost
+}
+
+// filter and page the orders, nil query means all the orders
+type OrderQuery struct {
+	Pair   *pair.Pair // nil: all pairs
+	Offset int
+	Limit  int // 0: no limit
+}
+
 type Order struct {
 	Pair          *pair.Pair
 	OrderID       string
+	ClientOrderID string
 	FilledOrders  []int64
 	Rate          float64 `bson:"Rate"`
 	Quantity      float64 `bson:"Quantity"`
@@ -33,6 +79,63 @@ type Order struct {
 	JsonResponse  string
 }
 
+type Liquidity string
+
+const (
+	LiquidityMaker   Liquidity = "Maker"
+	LiquidityTaker   Liquidity = "Taker"
+	LiquidityUnknown Liquidity = "" // the exchange doesn't tell
+)
+
+/*An Executed Fill of an Order
+The trades of the market (RecentTrades) have the aggressor (taker) Side, no OrderID and no Fee*/
+type Trade struct {
+	TradeID   string
+	OrderID   string
+	Pair      *pair.Pair
+	Side      OrderSide
+	Rate      float64
+	Quantity  float64
+	Fee       float64
+	FeeCoin   *coin.Coin
+	Timestamp int64 // in milliseconds
+	Liquidity Liquidity
+}
+
+// the balance of a coin in the account, Total = Available + Locked
+type Balance struct {
+	Coin      *coin.Coin
+	Available float64 // can be used by new orders and withdrawals
+	Locked    float64 // held by open orders or pending withdrawals
+	Total     float64
+	Timestamp int64 // in milliseconds, when the balance was refreshed
+}
+
+// the best prices and the 24h statistics of a pair
+type Ticker struct {
+	Pair        *pair.Pair
+	Bid         float64
+	Ask         float64
+	Last        float64
+	High        float64 // 24h
+	Low         float64 // 24h
+	Volume      float64 // 24h volume in pair.Target
+	QuoteVolume float64 // 24h volume in pair.Base, 0 if the exchange doesn't provide it
+	Timestamp   int64   // in milliseconds
+}
+
+// the OHLCV bar of an interval
+type Candle struct {
+	Pair        *pair.Pair
+	Timestamp   int64 // the open time in milliseconds
+	Open        float64
+	High        float64
+	Low         float64
+	Close       float64
+	Volume      float64 // in pair.Target
+	QuoteVolume float64 // in pair.Base, 0 if the exchange doesn't provide it
+}
+
 //Pair is the common name pairs across diff excahnges
 type Maker struct {
 	WorkerIP        string  `bson:"workerip"`
@@ -44,6 +147,8 @@ type Maker struct {
 	LastUpdateID    int     `json:"lastUpdateId"`
 	Bids            []Order `json:"bids"`
 	Asks            []Order `json:"asks"`
+
+	Pair *pair.Pair `json:"-" bson:"-"` // set by the streams, the Redis key has the pair
 }
 
 type Market struct{}
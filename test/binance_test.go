@@ -0,0 +1,280 @@
+package test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"../coin"
+	"../exchange"
+	"../exchange/binance"
+	"../market"
+	"../pair"
+	"../user"
+)
+
+const (
+	binanceKey    = "standInKey"
+	binanceSecret = "standInSecret"
+)
+
+// REST stand-in of Binance, the signed endpoints check the API Key header and the signature
+type binanceStandIn struct {
+	server *httptest.Server
+	lock   sync.Mutex
+	last   map[string]string // "METHOD path": the last query
+}
+
+func newBinanceStandIn() *binanceStandIn {
+	s := &binanceStandIn{last: make(map[string]string)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *binanceStandIn) query(method, path string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.last[method+" "+path]
+}
+
+func (s *binanceStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	s.last[r.Method+" "+r.URL.Path] = r.URL.RawQuery
+	s.lock.Unlock()
+
+	public := map[string]string{
+		"/api/v3/time": `{"serverTime":1499827319559}`,
+		"/api/v3/exchangeInfo": `{"timezone":"UTC","serverTime":1499827319559,"symbols":[
+			{"symbol":"ETHBTC","status":"TRADING","baseAsset":"ETH","quoteAsset":"BTC","filters":[
+				{"filterType":"PRICE_FILTER","minPrice":"0.00000100","maxPrice":"922327.00000000","tickSize":"0.00000100"},
+				{"filterType":"LOT_SIZE","minQty":"0.00010000","maxQty":"100000.00000000","stepSize":"0.00010000"}]},
+			{"symbol":"LTCBTC","status":"BREAK","baseAsset":"LTC","quoteAsset":"BTC","filters":[]}]}`,
+		"/api/v3/depth": `{"lastUpdateId":1027024,"bids":[["0.03500000","2.50000000"],["0.03490000","1.00000000"]],"asks":[["0.03510000","3.00000000"]]}`,
+	}
+	if body, ok := public[r.URL.Path]; ok {
+		fmt.Fprint(w, body)
+		return
+	}
+
+	query := r.URL.RawQuery
+	i := strings.LastIndex(query, "&signature=")
+	if r.Header.Get("X-MBX-APIKEY") != binanceKey || i < 0 || query[i+len("&signature="):] != binanceSignature(query[:i]) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"code":-1022,"msg":"Signature for this request is not valid."}`)
+		return
+	}
+	params := r.URL.Query()
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/v3/account":
+		fmt.Fprint(w, `{"makerCommission":10,"takerCommission":10,"canTrade":true,"balances":[
+			{"asset":"BTC","free":"1.50000000","locked":"0.50000000"},{"asset":"ETH","free":"10.00000000","locked":"0.00000000"}]}`)
+	case "POST /api/v3/order":
+		fmt.Fprintf(w, `{"symbol":"%s","orderId":28,"clientOrderId":"%s","transactTime":1507725176595,"price":"%s","origQty":"%s",
+			"executedQty":"0.00000000","cummulativeQuoteQty":"0.00000000","status":"NEW","timeInForce":"GTC","type":"%s","side":"%s"}`,
+			params.Get("symbol"), params.Get("newClientOrderId"), params.Get("price"), params.Get("quantity"), params.Get("type"), params.Get("side"))
+	case "GET /api/v3/order":
+		if params.Get("origClientOrderId") == "unknown" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":-2013,"msg":"Order does not exist."}`)
+			return
+		}
+		fmt.Fprint(w, `{"symbol":"ETHBTC","orderId":28,"clientOrderId":"c1","price":"0.03500000","origQty":"2.00000000",
+			"executedQty":"2.00000000","cummulativeQuoteQty":"0.06980000","status":"FILLED","timeInForce":"GTC","type":"LIMIT","side":"BUY"}`)
+	case "DELETE /api/v3/order":
+		fmt.Fprint(w, `{"symbol":"ETHBTC","orderId":28,"clientOrderId":"cancel1","origClientOrderId":"c1","price":"0.03500000","origQty":"2.00000000",
+			"executedQty":"0.00000000","cummulativeQuoteQty":"0.00000000","status":"CANCELED","timeInForce":"GTC","type":"LIMIT","side":"BUY"}`)
+	case "GET /sapi/v1/capital/config/getall":
+		fmt.Fprint(w, `[{"coin":"BTC","name":"Bitcoin","depositAllEnable":true,"withdrawAllEnable":true,"networkList":[
+			{"network":"BNB","coin":"BTC","isDefault":false,"depositEnable":true,"withdrawEnable":true,"withdrawFee":"0.0000026","withdrawMin":"0.0000052","minConfirm":1},
+			{"network":"BTC","coin":"BTC","isDefault":true,"depositEnable":true,"withdrawEnable":true,"withdrawFee":"0.0005","withdrawMin":"0.001","minConfirm":2}]},
+			{"coin":"ETH","name":"Ethereum","depositAllEnable":true,"withdrawAllEnable":false,"networkList":[
+			{"network":"ETH","coin":"ETH","isDefault":true,"depositEnable":true,"withdrawEnable":true,"withdrawFee":"0.005","withdrawMin":"0.01","minConfirm":12}]}]`)
+	case "POST /sapi/v1/capital/withdraw/apply":
+		fmt.Fprint(w, `{"id":"7213fea8e94b4a5593d507237e5a555b"}`)
+	case "GET /sapi/v1/capital/withdraw/history":
+		fmt.Fprint(w, `[{"id":"7213fea8e94b4a5593d507237e5a555b","amount":"0.1","transactionFee":"0.0004","coin":"BTC","status":6,
+			"address":"1FZdVHtiBqMrWdjPyRPULCUceZPJ2WLCsB","txId":"0xb5ef8c13b968a406cc62a93a8bd80f9e9a906ef1b3fcf20a2e48573c17659268","applyTime":"2019-10-12 11:12:02"}]`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":-1000,"msg":"Unknown endpoint."}`)
+	}
+}
+
+// HMAC-SHA256 of the query, hex encoded
+func binanceSignature(query string) string {
+	h := hmac.New(sha256.New, []byte(binanceSecret))
+	h.Write([]byte(query))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+/********************General********************/
+func Test_Binance_Signature(t *testing.T) {
+	// the example of the API document
+	query := "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559"
+	secret := "NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j"
+	if signature := binance.ComputeHmac256(query, secret); signature != "c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71" {
+		t.Fatalf("signature %s", signature)
+	}
+}
+
+func Test_Binance_Pairs(t *testing.T) {
+	e, _ := initBinance()
+
+	if len(e.GetPairs()) != 1 {
+		t.Fatalf("pairs %v, the BREAK symbol should be skipped", len(e.GetPairs()))
+	}
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+	if !e.HasPair(p) || e.GetPairCode(p) != "ETHBTC" {
+		t.Fatalf("pair %v code %s", p.Name, e.GetPairCode(p))
+	}
+	if e.GetLotSize(p) != 0.0001 || e.GetPriceFilter(p) != 0.000001 {
+		t.Fatalf("lot size %v tick size %v", e.GetLotSize(p), e.GetPriceFilter(p))
+	}
+}
+
+func Test_Binance_OrderBook(t *testing.T) {
+	e, s := initBinance()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	maker, err := e.OrderBook(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maker.LastUpdateID != 1027024 || len(maker.Bids) != 2 || len(maker.Asks) != 1 {
+		t.Fatalf("book %+v", maker)
+	}
+	if maker.Bids[0].Rate != 0.035 || maker.Bids[0].Quantity != 2.5 || maker.Asks[0].Rate != 0.0351 {
+		t.Fatalf("levels %+v %+v", maker.Bids[0], maker.Asks[0])
+	}
+	if !strings.Contains(s.query("GET", "/api/v3/depth"), "symbol=ETHBTC") {
+		t.Fatalf("depth query %s", s.query("GET", "/api/v3/depth"))
+	}
+}
+
+func Test_Binance_Balance(t *testing.T) {
+	e, _ := initBinance()
+
+	e.UpdateAllBalances()
+	if e.GetBalance(coin.GetCoin("BTC")) != 1.5 || e.GetBalance(coin.GetCoin("ETH")) != 10 {
+		t.Fatalf("balances BTC %v ETH %v", e.GetBalance(coin.GetCoin("BTC")), e.GetBalance(coin.GetCoin("ETH")))
+	}
+	for _, balance := range e.GetBalances() {
+		if balance.Coin.Code == "BTC" && (balance.Locked != 0.5 || balance.Total != 2) {
+			t.Fatalf("BTC balance %+v", balance)
+		}
+	}
+}
+
+func Test_Binance_Trade(t *testing.T) {
+	e, s := initBinance()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	order, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.LimitOrder, Quantity: 2, Rate: 0.035, PostOnly: true, ClientOrderID: "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderID != "28" || order.ClientOrderID != "c1" || order.Status != market.New || order.Rate != 0.035 || order.Quantity != 2 {
+		t.Fatalf("placed %+v", order)
+	}
+	if query := s.query("POST", "/api/v3/order"); !strings.Contains(query, "type=LIMIT_MAKER") || strings.Contains(query, "timeInForce") {
+		t.Fatalf("post only query %s", query)
+	}
+
+	if err := e.OrderStatus(order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != market.Filled || order.DealQuantity != 2 || order.DealRate != 0.0349 {
+		t.Fatalf("status %+v", order)
+	}
+
+	if err := e.CancelOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if query := s.query("DELETE", "/api/v3/order"); !strings.Contains(query, "orderId=28") || !strings.Contains(query, "symbol=ETHBTC") {
+		t.Fatalf("cancel query %s", query)
+	}
+}
+
+func Test_Binance_OrderByClientID(t *testing.T) {
+	e, _ := initBinance()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	order, err := e.OrderByClientID(p, "c1")
+	if err != nil || order.OrderID != "28" || order.Status != market.Filled {
+		t.Fatalf("order %+v err %v", order, err)
+	}
+	if _, err := e.OrderByClientID(p, "unknown"); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("unknown client order id err %v", err)
+	}
+}
+
+func Test_Binance_Auth(t *testing.T) {
+	e, _ := initBinance()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	u := &user.User{API_KEY: binanceKey, API_SECRET: "wrongSecret"}
+	if _, err := e.ForUser(u).OrderByClientID(p, "c1"); !exchange.IsKind(err, exchange.ErrAuth) {
+		t.Fatalf("wrong secret err %v", err)
+	}
+}
+
+func Test_Binance_CoinConstrain(t *testing.T) {
+	e, _ := initBinance()
+	btc, eth := coin.GetCoin("BTC"), coin.GetCoin("ETH")
+
+	e.UpdateCoinConstrain()
+	if e.GetTxFee(btc) != 0.0005 || e.GetConfirmation(btc) != 2 || !e.CanWithdraw(btc) || !e.CanDeposit(btc) {
+		t.Fatalf("BTC fee %v confirmation %v withdraw %v deposit %v", e.GetTxFee(btc), e.GetConfirmation(btc), e.CanWithdraw(btc), e.CanDeposit(btc))
+	}
+	if e.CanWithdraw(eth) || !e.CanDeposit(eth) {
+		t.Fatalf("ETH withdraw %v deposit %v", e.CanWithdraw(eth), e.CanDeposit(eth))
+	}
+}
+
+func Test_Binance_Withdraw(t *testing.T) {
+	e, s := initBinance()
+	c := coin.GetCoin("BTC")
+
+	withdrawal, err := e.Withdraw(c, 0.1, "1FZdVHtiBqMrWdjPyRPULCUceZPJ2WLCsB", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.ID != "7213fea8e94b4a5593d507237e5a555b" || withdrawal.Status != exchange.TransferPending {
+		t.Fatalf("withdrawal %+v", withdrawal)
+	}
+	if query := s.query("POST", "/sapi/v1/capital/withdraw/apply"); !strings.Contains(query, "amount=0.1") || strings.Contains(query, "addressTag") {
+		t.Fatalf("withdraw query %s", query)
+	}
+
+	if err := e.WithdrawalStatus(withdrawal); err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.Status != exchange.TransferCompleted || withdrawal.Fee != 0.0004 || withdrawal.TxHash == "" {
+		t.Fatalf("withdrawal status %+v", withdrawal)
+	}
+}
+
+var binanceOnce sync.Once
+var binanceInstance *binance.Binance
+var binanceServer *binanceStandIn
+
+// one stand-in and instance for all the tests, each instance starts the GC of a Redis Manager
+func initBinance() (*binance.Binance, *binanceStandIn) {
+	binanceOnce.Do(func() {
+		pair.Init()
+		binanceServer = newBinanceStandIn()
+		config := &exchange.Config{}
+		config.API_KEY = binanceKey
+		config.API_SECRET = binanceSecret
+		config.API_URL = binanceServer.server.URL
+		binanceInstance = binance.CreateBinance(config)
+	})
+	return binanceInstance, binanceServer
+}
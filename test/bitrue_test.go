@@ -1,19 +1,143 @@
 package test
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"../coin"
 	"../exchange"
 	"../exchange/bitrue"
+	"../market"
 	"../pair"
 	"github.com/davecgh/go-spew/spew"
 )
 
+const (
+	bitrueKey    = "standInKey"
+	bitrueSecret = "standInSecret"
+)
+
+// recorded responses of the Bitrue API, "METHOD path": body, "METHOD path?query": body if the public response depends on the query
+var bitrueResponses = map[string]string{
+	"GET /api/v1/time": `{"serverTime":1499827319559}`,
+	"GET /api/v1/exchangeInfo": `{"timezone":"UTC","serverTime":1499827319559,"rateLimits":[],"exchangeFilters":[],"symbols":[
+		{"symbol":"ETHBTC","status":"TRADING","baseAsset":"eth","baseAssetPrecision":8,"quoteAsset":"btc","quotePrecision":8,"orderTypes":["LIMIT","MARKET"],"icebergAllowed":false,"filters":[
+			{"filterType":"PRICE_FILTER","minPrice":"0.000001","maxPrice":"100000","priceScale":6},
+			{"filterType":"LOT_SIZE","minQty":"0.0001","maxQty":"100000","volumeScale":4}]}]}`,
+	"GET /api/v1/ticker/24hr?symbol=ETHBTC": `{"symbol":"ETHBTC","priceChange":"0.000281","priceChangePercent":"1.11","weightedAvgPrice":"0.025583","prevClosePrice":"0.025331",
+		"lastPrice":"0.025612","lastQty":"0.0412","bidPrice":"0.025608","askPrice":"0.025619","openPrice":"0.025331","highPrice":"0.025871","lowPrice":"0.025213",
+		"volume":"1834.2219","quoteVolume":"46.925611","openTime":1595249999871,"closeTime":1595336399871,"firstId":4410921,"lastId":4413188,"count":2268}`,
+	"GET /api/v1/ticker/24hr": `[
+		{"symbol":"ETHBTC","lastPrice":"0.025612","bidPrice":"0.025608","askPrice":"0.025619","highPrice":"0.025871","lowPrice":"0.025213","volume":"1834.2219","quoteVolume":"46.925611","closeTime":1595336399871},
+		{"symbol":"XRPBTC","lastPrice":"0.00002131","bidPrice":"0.00002130","askPrice":"0.00002133","highPrice":"0.00002170","lowPrice":"0.00002101","volume":"2410533","quoteVolume":"51.36","closeTime":1595336399012}]`,
+	"GET /api/v1/trades": `[
+		{"id":4413185,"price":"0.025601","qty":"0.8131","time":1595336398204,"isBuyerMaker":false,"isBestMatch":true},
+		{"id":4413186,"price":"0.025608","qty":"0.0412","time":1595336401377,"isBuyerMaker":true,"isBestMatch":true},
+		{"id":4413187,"price":"0.025608","qty":"1.2","time":1595336401377,"isBuyerMaker":true,"isBestMatch":true},
+		{"id":4413188,"price":"0.025619","qty":"0.3306","time":1595336415960,"isBuyerMaker":false,"isBestMatch":true}]`,
+	"GET /api/v1/klines": `[
+		[1595332800000,"0.025402","0.025588","0.025377","0.025531","301.4471",1595336399999,"7.682102",412,"160.2","4.083","0"],
+		[1595336400000,"0.025531","0.025619","0.025511","0.025612","88.0912",1595339999999,"2.250811",97,"41.6","1.063","0"]]`,
+	"GET /api/v1/account": `{"makerCommission":8,"takerCommission":10,"buyerCommission":0,"sellerCommission":0,"canTrade":true,"canWithdraw":true,"canDeposit":true,"updateTime":1595336400000,
+		"balances":[{"asset":"btc","free":"1.5","locked":"0"},{"asset":"eth","free":"8.5","locked":"1.5"}]}`,
+	"POST /api/v1/order": `{"symbol":"ETHBTC","orderId":28,"clientOrderId":"6gCrw2kRUAF9CvJDGP16IP","transactTime":1595336400000}`,
+	"GET /api/v1/order": `{"symbol":"ETHBTC","orderId":28,"clientOrderId":"6gCrw2kRUAF9CvJDGP16IP","price":"0.0713","origQty":"1.0","executedQty":"0.4","cummulativeQuoteQty":"0.02852",
+		"status":"PARTIALLY_FILLED","timeInForce":"GTC","type":"LIMIT","side":"BUY","stopPrice":"0.0","icebergQty":"0.0","time":1595336400000,"updateTime":1595336410000,"isWorking":true}`,
+	"DELETE /api/v1/order":         `{"symbol":"ETHBTC","origClientOrderId":"6gCrw2kRUAF9CvJDGP16IP","orderId":28,"clientOrderId":"cancelMyOrder1"}`,
+	"POST /api/v1/withdraw/commit": `{"code":200,"msg":"succ","data":{"msg":null,"amount":0.5,"fee":0.0005,"ctime":null,"coin":"btc","withdrawId":1156423,"addressTo":"Address"}}`,
+	"GET /api/v1/withdraw/history": `{"code":200,"msg":"succ","data":[
+		{"id":1156422,"symbol":"btc","amount":"1.0","fee":"0.0005","payAmount":"0","createdAt":1595336441000,"updatedAt":1595336576000,"addressFrom":"","addressTo":"Other","txid":"","confirmations":0,"status":3,"tagType":null},
+		{"id":1156423,"symbol":"btc","amount":"0.5","fee":"0.0004","payAmount":"0","createdAt":1595336441000,"updatedAt":1595336576000,"addressFrom":"","addressTo":"Address",
+			"txid":"ed03094b84eafbe4bc16e7ef766ee959885ee5bcb265872baaa9c64e1cf86c2b","confirmations":2,"status":6,"tagType":null}]}`,
+}
+
+// REST stand-in of Bitrue answering the recorded responses, the signed endpoints check the API Key header and the signature
+type bitrueStandIn struct {
+	server    *httptest.Server
+	lock      sync.Mutex
+	last      map[string]string // "METHOD path": the last query
+	responses map[string]string // "METHOD path": the body replacing the recorded response
+}
+
+func newBitrueStandIn() *bitrueStandIn {
+	s := &bitrueStandIn{last: make(map[string]string), responses: make(map[string]string)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *bitrueStandIn) query(method, path string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.last[method+" "+path]
+}
+
+// replace the response of the request until reset
+func (s *bitrueStandIn) set(method, path, body string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses[method+" "+path] = body
+}
+
+func (s *bitrueStandIn) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses = make(map[string]string)
+}
+
+func (s *bitrueStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	s.lock.Lock()
+	s.last[key] = r.URL.RawQuery
+	replaced, isReplaced := s.responses[key]
+	s.lock.Unlock()
+
+	public := map[string]bool{"/api/v1/time": true, "/api/v1/exchangeInfo": true, "/api/v1/ticker/24hr": true, "/api/v1/trades": true, "/api/v1/klines": true}
+	if !public[r.URL.Path] && !bitrueSigned(r) {
+		fmt.Fprint(w, `{"code":-1022,"msg":"Signature for this request is not valid."}`)
+		return
+	}
+	if isReplaced {
+		fmt.Fprint(w, replaced)
+		return
+	}
+	if response, ok := bitrueResponses[key+"?"+r.URL.RawQuery]; ok && public[r.URL.Path] {
+		fmt.Fprint(w, response)
+		return
+	}
+	if response, ok := bitrueResponses[key]; ok {
+		fmt.Fprint(w, response)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, `{"code":-1100,"msg":"invalid path"}`)
+}
+
+// the query before "&signature=" is signed by HMAC-SHA256 with the API Secret of the stand-in
+func bitrueSigned(r *http.Request) bool {
+	query := r.URL.RawQuery
+	i := strings.LastIndex(query, "&signature=")
+	if r.Header.Get("X-MBX-APIKEY") != bitrueKey || i < 0 {
+		return false
+	}
+	h := hmac.New(sha256.New, []byte(bitrueSecret))
+	h.Write([]byte(query[:i]))
+	return query[i+len("&signature="):] == hex.EncodeToString(h.Sum(nil))
+}
+
 /********************API********************/
 func Test_Bitrue_Balance(t *testing.T) {
-	e := initBitrue()
+	e, _ := initBitrue()
 	e.UpdateAllBalances()
 
 	for k, v := range e.GetPairs() { // pairs from binance
@@ -23,49 +147,257 @@ func Test_Bitrue_Balance(t *testing.T) {
 			log.Printf("%d  v:%v  #  %v:%v  %v:%v", k, v.Name, v.Base.Code, base, v.Target.Code, target)
 		}
 	}
-}
 
-func Test_Bitrue_Withdraw(t *testing.T) {
-	e := initBitrue()
-	c := coin.GetCoin("BTC")
-	amount := 0.0
-	addr := "Address"
-	tag := ""
-	if e.Withdraw(c, amount, addr, tag) {
-		log.Printf("Bitrue %s Withdraw Successful!", c.Code)
+	for _, balance := range e.GetBalances() {
+		log.Printf("%s Available: %v Locked: %v Total: %v", balance.Coin.Code, balance.Available, balance.Locked, balance.Total)
 	}
 }
 
 func Test_Bitrue_Trade(t *testing.T) {
-	e := initBitrue()
+	e, s := initBitrue()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	order, err := e.LimitBuy(p, 1, 0.0713)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderID != "28" || order.Status != market.New || order.Rate != 0.0713 || order.Quantity != 1 {
+		t.Fatalf("placed %+v", order)
+	}
+	if query := s.query("POST", "/api/v1/order"); !strings.Contains(query, "symbol=ETHBTC") || !strings.Contains(query, "side=BUY") || !strings.Contains(query, "type=LIMIT") {
+		t.Fatalf("place query %s", query)
+	}
+
+	if err := e.OrderStatus(order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != market.Partial || order.DealQuantity != 0.4 || order.DealRate != 0.0713 {
+		t.Fatalf("status %+v", order)
+	}
+
+	if err := e.CancelOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if query := s.query("DELETE", "/api/v1/order"); order.Status != market.Canceling || !strings.Contains(query, "orderId=28") {
+		t.Fatalf("cancel %+v query %s", order, query)
+	}
+}
+
+func Test_Bitrue_PlaceOrder(t *testing.T) {
+	e, s := initBitrue()
 	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
-	rate := 0.071300
-	quantity := 1.0
 
-	order, err := e.LimitBuy(p, quantity, rate)
-	if err == nil {
-		log.Printf("Bitrue Limit Buy: %v", order)
-	} else {
-		log.Printf("Bitrue Limit Buy Err: %s", err)
+	// the market order has no price and no time in force
+	if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Sell, Type: market.MarketOrder, Quantity: 2, ClientOrderID: "myOrder2"}); err != nil {
+		t.Fatal(err)
+	}
+	query, _ := url.ParseQuery(s.query("POST", "/api/v1/order"))
+	if query.Get("type") != "MARKET" || query.Get("side") != "SELL" || query.Get("quantity") != "2" || query.Get("newClientOrderId") != "myOrder2" || query.Get("price") != "" || query.Get("timeInForce") != "" {
+		t.Fatalf("market query %v", query)
 	}
 
-	err = e.OrderStatus(order)
-	if err == nil {
-		log.Printf("Bitrue Order Status: %v", order)
-	} else {
-		log.Printf("Bitrue Order Status Err: %s", err)
+	for _, timeInForce := range []market.TimeInForce{market.IOC, market.FOK} {
+		if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.LimitOrder, TimeInForce: timeInForce, Quantity: 1, Rate: 0.0713}); err != nil {
+			t.Fatal(err)
+		}
+		query, _ = url.ParseQuery(s.query("POST", "/api/v1/order"))
+		if query.Get("type") != "LIMIT" || query.Get("timeInForce") != string(timeInForce) || query.Get("price") != "0.0713" {
+			t.Fatalf("%s query %v", timeInForce, query)
+		}
 	}
 
-	err = e.CancelOrder(order)
-	if err == nil {
-		log.Printf("Bitrue Cancel Order: %v", order)
-	} else {
-		log.Printf("Bitrue Cancel Err: %s", err)
+	if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.LimitOrder, PostOnly: true, Quantity: 1, Rate: 0.0713}); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Fatalf("post only err %v", err)
+	}
+	if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.StopLimitOrder, Quantity: 1, Rate: 0.0713, StopRate: 0.072}); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Fatalf("stop limit err %v", err)
+	}
+}
+
+func Test_Bitrue_ListOpenOrders(t *testing.T) {
+	e, s := initBitrue()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// the orders are sorted by time, XRPBTC is not in the pair list
+	s.set("GET", "/api/v1/openOrders", `[
+		{"symbol":"ETHBTC","orderId":31,"clientOrderId":"myOrder31","price":"0.0715","origQty":"1.0","executedQty":"0.0","cummulativeQuoteQty":"0.0","status":"NEW","type":"LIMIT","side":"SELL","time":1595336430000},
+		{"symbol":"XRPBTC","orderId":12,"clientOrderId":"myOrder12","price":"0.000023","origQty":"100.0","executedQty":"0.0","cummulativeQuoteQty":"0.0","status":"NEW","type":"LIMIT","side":"BUY","time":1595336405000},
+		{"symbol":"ETHBTC","orderId":28,"clientOrderId":"myOrder28","price":"0.0713","origQty":"1.0","executedQty":"0.4","cummulativeQuoteQty":"0.02852","status":"PARTIALLY_FILLED","type":"LIMIT","side":"BUY","time":1595336400000},
+		{"symbol":"ETHBTC","orderId":30,"clientOrderId":"myOrder30","price":"0.0714","origQty":"2.0","executedQty":"0.0","cummulativeQuoteQty":"0.0","status":"NEW","type":"LIMIT","side":"BUY","time":1595336420000}]`)
+
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 3 || orders[0].OrderID != "28" || orders[1].OrderID != "30" || orders[2].OrderID != "31" {
+		t.Fatalf("open orders %+v", orders)
+	}
+	if first := orders[0]; first.Pair != p || first.ClientOrderID != "myOrder28" || first.Status != market.Partial || first.DealQuantity != 0.4 {
+		t.Fatalf("first order %+v", first)
+	}
+	if query, _ := url.ParseQuery(s.query("GET", "/api/v1/openOrders")); query.Get("symbol") != "" {
+		t.Fatalf("all pairs query %v", query)
+	}
+
+	page, err := e.ListOpenOrders(&market.OrderQuery{Pair: p, Offset: 1, Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].OrderID != "30" || page[1].OrderID != "31" {
+		t.Fatalf("page %+v", page)
+	}
+	if query, _ := url.ParseQuery(s.query("GET", "/api/v1/openOrders")); query.Get("symbol") != "ETHBTC" {
+		t.Fatalf("pair query %v", query)
+	}
+
+	s.set("GET", "/api/v1/openOrders", `{"code":-1121,"msg":"Invalid symbol."}`)
+	if _, err := e.ListOpenOrders(&market.OrderQuery{Pair: p}); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("invalid symbol err %v", err)
+	}
+}
+
+func Test_Bitrue_CancelAllOrders(t *testing.T) {
+	e, s := initBitrue()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// there is no cancel all API, the open orders are canceled one by one
+	s.set("GET", "/api/v1/openOrders", `[
+		{"symbol":"ETHBTC","orderId":28,"clientOrderId":"myOrder28","price":"0.0713","origQty":"1.0","executedQty":"0.4","cummulativeQuoteQty":"0.02852","status":"PARTIALLY_FILLED","type":"LIMIT","side":"BUY","time":1595336400000}]`)
+	report, err := e.CancelAllOrders(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Canceled) != 1 || report.Canceled[0].OrderID != "28" || report.Canceled[0].Status != market.Canceling || report.Err() != nil {
+		t.Fatalf("report %+v", report)
+	}
+	if query, _ := url.ParseQuery(s.query("DELETE", "/api/v1/order")); query.Get("orderId") != "28" || query.Get("symbol") != "ETHBTC" {
+		t.Fatalf("cancel query %v", query)
+	}
+
+	s.set("DELETE", "/api/v1/order", `{"code":-2011,"msg":"Unknown order sent."}`)
+	report, err = e.CancelAllOrders(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].Order.OrderID != "28" || report.Err() == nil {
+		t.Fatalf("failed report %+v", report)
+	}
+}
+
+func Test_Bitrue_GetFills(t *testing.T) {
+	e, s := initBitrue()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// the trade before since is dropped, the commission may be in either coin of the pair
+	s.set("GET", "/api/v1/myTrades", `[
+		{"symbol":"ETHBTC","id":28457,"orderId":28,"price":"0.0713","qty":"0.6","commission":"0.0000428","commissionAsset":"btc","time":1595336420000,"isBuyer":true,"isMaker":true},
+		{"symbol":"ETHBTC","id":28450,"orderId":27,"price":"0.0712","qty":"2.0","commission":"0.002","commissionAsset":"eth","time":1595336390000,"isBuyer":false,"isMaker":false},
+		{"symbol":"ETHBTC","id":28455,"orderId":28,"price":"0.0713","qty":"0.4","commission":"0.0000285","commissionAsset":"btc","time":1595336410000,"isBuyer":true,"isMaker":false}]`)
+	trades, err := e.GetFills(nil, time.Unix(0, 1595336400000*1e6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 || trades[0].TradeID != "28455" || trades[1].TradeID != "28457" {
+		t.Fatalf("fills %+v", trades)
+	}
+	taker, maker := trades[0], trades[1]
+	if taker.Pair != p || taker.OrderID != "28" || taker.Side != market.Buy || taker.Liquidity != market.LiquidityTaker || taker.Quantity != 0.4 || taker.Timestamp != 1595336410000 {
+		t.Fatalf("taker fill %+v", taker)
+	}
+	if maker.Liquidity != market.LiquidityMaker || maker.Fee != 0.0000428 || maker.FeeCoin != coin.GetCoin("BTC") || maker.Rate != 0.0713 {
+		t.Fatalf("maker fill %+v", maker)
+	}
+	if query, _ := url.ParseQuery(s.query("GET", "/api/v1/myTrades")); query.Get("symbol") != "ETHBTC" || query.Get("startTime") != "1595336400000" {
+		t.Fatalf("fills query %v", query)
+	}
+
+	s.set("GET", "/api/v1/myTrades", `{"code":-1121,"msg":"Invalid symbol."}`)
+	if _, err := e.GetFills(p, time.Time{}); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("GetFills err %v", err)
+	}
+}
+
+func Test_Bitrue_OrderStatus(t *testing.T) {
+	e, s := initBitrue()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	s.set("GET", "/api/v1/order", `{"code":-2013,"msg":"Order does not exist."}`)
+	order := &market.Order{Pair: p, OrderID: "27", Status: market.New}
+	if err := e.OrderStatus(order); !exchange.IsKind(err, exchange.ErrNotFound) || order.Status != market.New {
+		t.Fatalf("unknown order %+v err %v", order, err)
+	}
+
+	s.set("GET", "/api/v1/order", `{"code":-1100,"msg":"Illegal characters found in parameter 'orderId'."}`)
+	if err := e.OrderStatus(order); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("illegal order err %v", err)
+	}
+
+	s.set("GET", "/api/v1/order", `{"symbol":"ETHBTC","orderId":27,"clientOrderId":"myOrder27","price":"0.0713","origQty":"2.0","executedQty":"2.0","cummulativeQuoteQty":"0.1424",
+		"status":"FILLED","timeInForce":"GTC","type":"LIMIT","side":"SELL","time":1595336400000,"updateTime":1595336420000,"isWorking":false}`)
+	if err := e.OrderStatus(order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != market.Filled || order.DealQuantity != 2 || order.DealRate != 0.0712 {
+		t.Fatalf("filled %+v", order)
+	}
+}
+
+func Test_Bitrue_OrderByClientID(t *testing.T) {
+	e, s := initBitrue()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	order, err := e.OrderByClientID(p, "6gCrw2kRUAF9CvJDGP16IP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderID != "28" || order.ClientOrderID != "6gCrw2kRUAF9CvJDGP16IP" || order.Pair != p || order.Status != market.Partial || order.Side != string(market.Buy) {
+		t.Fatalf("client order %+v", order)
+	}
+	if query, _ := url.ParseQuery(s.query("GET", "/api/v1/order")); query.Get("origClientOrderId") != "6gCrw2kRUAF9CvJDGP16IP" || query.Get("orderId") != "" {
+		t.Fatalf("client order query %v", query)
+	}
+
+	// -2013: the order is surely not placed, it can be placed again
+	s.set("GET", "/api/v1/order", `{"code":-2013,"msg":"Order does not exist."}`)
+	if _, err := e.OrderByClientID(p, "myOrder404"); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("unknown client order err %v", err)
+	}
+	s.set("GET", "/api/v1/order", `{"code":-1003,"msg":"Too many requests."}`)
+	if _, err := e.OrderByClientID(p, "myOrder404"); !exchange.IsKind(err, exchange.ErrNetwork) {
+		t.Fatalf("rate limited err %v", err)
+	}
+}
+
+func Test_Bitrue_PlaceOrderErrors(t *testing.T) {
+	e, s := initBitrue()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	cases := []struct {
+		response string
+		kind     exchange.ErrorKind
+	}{
+		{`{"code":-1003,"msg":"Too many requests."}`, exchange.ErrNetwork},
+		{`{"code":-1007,"msg":"Timeout waiting for response from backend server. Send status unknown; execution status unknown."}`, exchange.ErrNetwork},
+		{`{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`, exchange.ErrAuth},
+		{`{"code":-1022,"msg":"Signature for this request is not valid."}`, exchange.ErrAuth},
+		{`{"code":-2010,"msg":"Account has insufficient balance for requested action."}`, exchange.ErrRejected},
+	}
+	for i, c := range cases {
+		s.set("POST", "/api/v1/order", c.response)
+		if _, err := e.LimitBuy(p, 1, 0.0713); !exchange.IsKind(err, c.kind) {
+			t.Errorf("case %d: expect %s got %v", i, c.kind, err)
+		}
 	}
 }
 
 func Test_Bitrue_OrderBook(t *testing.T) {
-	e := initBitrue()
+	e, _ := initBitrue()
 
 	for _, pair := range e.GetPairs() { // pairs from binance
 		if pair != nil {
@@ -78,19 +410,152 @@ func Test_Bitrue_OrderBook(t *testing.T) {
 	}
 }
 
-/********************General********************/
-func Test_Bitrue_ConstrainFetch(t *testing.T) {
-	e := initBitrue()
+func Test_Bitrue_Ticker(t *testing.T) {
+	e, s := initBitrue()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	ticker, err := e.Ticker(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Pair != p || ticker.Bid != 0.025608 || ticker.Ask != 0.025619 || ticker.Last != 0.025612 || ticker.High != 0.025871 || ticker.Low != 0.025213 {
+		t.Fatalf("ticker %+v", ticker)
+	}
+	// the closeTime of the 24 hours window is the time of the ticker
+	if ticker.Volume != 1834.2219 || ticker.QuoteVolume != 46.925611 || ticker.Timestamp != 1595336399871 {
+		t.Fatalf("ticker volume %+v", ticker)
+	}
+	if query := s.query("GET", "/api/v1/ticker/24hr"); query != "symbol=ETHBTC" {
+		t.Fatalf("ticker query %s", query)
+	}
+
+	// the symbols not on the pair list are skipped
+	tickers, err := e.Tickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickers) != 1 || tickers[0].Pair != p || tickers[0].Last != 0.025612 || tickers[0].Volume != 1834.2219 {
+		t.Fatalf("tickers %+v", tickers)
+	}
+	if query := s.query("GET", "/api/v1/ticker/24hr"); query != "" {
+		t.Fatalf("tickers query %s", query)
+	}
+
+	// the symbol without trades in the window has no closeTime, the time of the request is used
+	s.set("GET", "/api/v1/ticker/24hr", `[{"symbol":"ETHBTC","lastPrice":"0.025612","bidPrice":"0.025608","askPrice":"0.025619","highPrice":"0","lowPrice":"0","volume":"0","quoteVolume":"0"}]`)
+	before := time.Now().UnixNano() / 1e6
+	if tickers, err := e.Tickers(); err != nil || len(tickers) != 1 || tickers[0].Volume != 0 || tickers[0].Timestamp < before {
+		t.Fatalf("idle tickers %+v err %v", tickers, err)
+	}
+
+	s.set("GET", "/api/v1/ticker/24hr", `{"code":-1121,"msg":"Invalid symbol."}`)
+	if _, err := e.Ticker(p); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("invalid symbol err %v", err)
+	}
+}
+
+func Test_Bitrue_Fees(t *testing.T) {
+	e, _ := initBitrue()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	if fee := e.GetTradeFee(p); fee.Source != exchange.SourceStatic || fee.Taker != 0.00098 || fee.Maker != 0.00098 {
+		t.Fatalf("schedule fee %+v", fee)
+	}
+
+	// the commissions are in basis points
+	if err := e.UpdateFees(); err != nil {
+		t.Fatal(err)
+	}
+	if fee := e.GetTradeFee(p); fee.Source != exchange.SourceAPI || fee.Taker != 0.001 || fee.Maker != 0.0008 || fee.Timestamp == 0 {
+		t.Fatalf("account fee %+v", fee)
+	}
+}
+
+func Test_Bitrue_RecentTrades(t *testing.T) {
+	e, s := initBitrue()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// the trade before since is dropped, isBuyerMaker: the seller is the aggressor
+	trades, err := e.RecentTrades(p, time.Unix(0, 1595336400000*1e6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 3 {
+		t.Fatalf("trades %+v", trades)
+	}
+	// one sell order matched at two price levels has two trades at the same time, the ids keep the order
+	first, second := trades[0], trades[1]
+	if first.Side != market.Sell || first.Rate != 0.025608 || first.Quantity != 0.0412 || first.Timestamp != 1595336401377 || first.TradeID != "4413186" {
+		t.Fatalf("first sell trade %+v", first)
+	}
+	if second.Side != market.Sell || second.Quantity != 1.2 || second.Timestamp != first.Timestamp || second.TradeID != "4413187" {
+		t.Fatalf("second sell trade %+v", second)
+	}
+	if buy := trades[2]; buy.Side != market.Buy || buy.Rate != 0.025619 || buy.Quantity != 0.3306 || buy.Pair != p || buy.Liquidity != market.LiquidityTaker {
+		t.Fatalf("buy trade %+v", buy)
+	}
+	// only the latest 1000 trades are served
+	if query, _ := url.ParseQuery(s.query("GET", "/api/v1/trades")); query.Get("symbol") != "ETHBTC" || query.Get("limit") != "1000" {
+		t.Fatalf("trades query %v", query)
+	}
+}
+
+func Test_Bitrue_Candles(t *testing.T) {
+	e, s := initBitrue()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// kline: [open time, open, high, low, close, volume, close time, quote volume, trades, taker base volume, taker quote volume, ignore]
+	candles, err := e.Candles(p, time.Hour, time.Unix(1595333000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2 || candles[0].Timestamp != 1595332800000 || candles[1].Timestamp != 1595336400000 {
+		t.Fatalf("candles %+v", candles)
+	}
+	candle := candles[0]
+	if candle.Pair != p || candle.Open != 0.025402 || candle.High != 0.025588 || candle.Low != 0.025377 || candle.Close != 0.025531 || candle.Volume != 301.4471 || candle.QuoteVolume != 7.682102 {
+		t.Fatalf("candle %+v", candle)
+	}
+	// startTime is the open time of the first candle in milliseconds
+	if query, _ := url.ParseQuery(s.query("GET", "/api/v1/klines")); query.Get("symbol") != "ETHBTC" || query.Get("interval") != "1h" || query.Get("startTime") != "1595332800000" || query.Get("limit") != "1000" {
+		t.Fatalf("candles query %v", query)
+	}
+
+	// 2 hours is a kline interval
+	if _, err := e.Candles(p, 2*time.Hour, time.Unix(1595333000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if query, _ := url.ParseQuery(s.query("GET", "/api/v1/klines")); query.Get("interval") != "2h" || query.Get("startTime") != "1595332800000" {
+		t.Fatalf("2h candles query %v", query)
+	}
+
+	// 10 minutes is not a kline interval, the candle is built from the recent trades since the start of the candle
+	candles, err = e.Candles(p, 10*time.Minute, time.Unix(1595336405, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 1 || candles[0].Timestamp != 1595336400000 || candles[0].Open != 0.025608 || candles[0].Close != 0.025619 || candles[0].Low != 0.025608 {
+		t.Fatalf("candles from trades %+v", candles)
+	}
+	if math.Abs(candles[0].Volume-1.5718) > 1e-9 {
+		t.Fatalf("candle volume from trades %v", candles[0].Volume)
+	}
+}
 
-	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("BCH"))
+/********************General********************/
+func Test_Bitrue_Capabilities(t *testing.T) {
+	e, _ := initBitrue()
 
-	status := e.GetConstrainFetchMethod(p)
-	// "Binance ConstrainFetchMethod: %v",
+	status := e.GetCapabilities()
+	if err := exchange.Require(e, exchange.FeatureLimitOrder); err != nil {
+		t.Error(err)
+	}
 	spew.Dump(status)
 }
 
 func Test_Bitrue_Constrain(t *testing.T) {
-	e := initBitrue()
+	e, _ := initBitrue()
 
 	pair := pair.GetPairByKey("BTC|ETH")
 	coinName := coin.GetCoin(pair.Target.Code)
@@ -102,8 +567,54 @@ func Test_Bitrue_Constrain(t *testing.T) {
 	log.Printf("Deposit: %v", e.CanDeposit(coinName))
 }
 
+func Test_Bitrue_Withdraw(t *testing.T) {
+	e, s := initBitrue()
+	btc := coin.GetCoin("BTC")
+
+	withdrawal, err := e.Withdraw(btc, 0.5, "Address", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.ID != "1156423" || withdrawal.Fee != 0.0005 || withdrawal.Status != exchange.TransferPending || withdrawal.Sent() {
+		t.Fatalf("withdrawal %+v", withdrawal)
+	}
+	if query := s.query("POST", "/api/v1/withdraw/commit"); !strings.Contains(query, "addressTo=Address") || !strings.Contains(query, "amount=0.5") ||
+		!strings.Contains(query, "chainName=BTC") || strings.Contains(query, "tag=") {
+		t.Fatalf("withdraw query %s", query)
+	}
+
+	if err := e.WithdrawalStatus(withdrawal); err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.Status != exchange.TransferCompleted || withdrawal.Fee != 0.0004 || !withdrawal.Sent() || withdrawal.TxHash == "" {
+		t.Fatalf("status %+v", withdrawal)
+	}
+
+	// the chain of ETH is not configured, it may be ERC20 or another chain
+	if _, err := e.Withdraw(coin.GetCoin("ETH"), 1, "Address", ""); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("withdraw without a chain err %v", err)
+	}
+
+	unknown := &exchange.WithdrawalResult{ID: "1", Coin: btc}
+	if err := e.WithdrawalStatus(unknown); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("unknown withdrawal err %v", err)
+	}
+}
+
+func Test_Bitrue_GetDepositAddress(t *testing.T) {
+	e, _ := initBitrue()
+
+	// the API has no deposit address, the capability tells it before the call
+	if err := exchange.Require(e, exchange.FeatureDepositAddress); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Fatalf("Require err %v", err)
+	}
+	if _, err := e.GetDepositAddress(coin.GetCoin("BTC")); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Fatalf("GetDepositAddress err %v", err)
+	}
+}
+
 func Test_Bitrue_GetMaker(t *testing.T) {
-	e := initBitrue()
+	e, _ := initBitrue()
 
 	pair := pair.GetPairByKey("BTC|ETH")
 	maker, _ := e.GetMaker(pair)
@@ -111,20 +622,22 @@ func Test_Bitrue_GetMaker(t *testing.T) {
 	log.Printf("Maker: %v", maker)
 }
 
-/* Modify Config
-Redis Server: xx.xx.xx.xx:xxxx
-Redis DB: DB Number
-API Key: Exchange API Key
-API Secret: Exchange API Secret Key */
-func initBitrue() exchange.Exchange {
-	pair.Init()
-	config := &exchange.Config{}
-	config.RedisServer = "RedisAddr:Port"
-	config.RedisDB = 0
-	config.API_KEY = "aa4fd929d856095d8e5da4a9e9e67d1e6445a66c46f73feb689227066210ffc9"
-	config.API_SECRET = "eae894d2c61c8ecdda593d9d637f5edb4b79da8ab369831741222a2a9288cc09"
-	ex := bitrue.CreateBitrue(config)
-	log.Printf("Initial [ %v ]", ex.GetName())
-	config = nil
-	return ex
+var bitrueOnce sync.Once
+var bitrueInstance *bitrue.Bitrue
+var bitrueServer *bitrueStandIn
+
+// one stand-in and instance for all the tests
+func initBitrue() (*bitrue.Bitrue, *bitrueStandIn) {
+	bitrueOnce.Do(func() {
+		pair.Init()
+		bitrueServer = newBitrueStandIn()
+		config := &exchange.Config{}
+		config.API_KEY = bitrueKey
+		config.API_SECRET = bitrueSecret
+		config.API_URL = bitrueServer.server.URL
+		config.WalletStatus = []exchange.Wallet_Stat{{Currency: "BTC", Withdraw: true, Deposit: true, TxFee: 0.0005, Chain: "BTC"}}
+		bitrueInstance = bitrue.CreateBitrue(config)
+		log.Printf("Initial [ %v ]", bitrueInstance.GetName())
+	})
+	return bitrueInstance, bitrueServer
 }
@@ -3,6 +3,7 @@ package test
 import (
 	"log"
 	"testing"
+	"time"
 
 	"../coin"
 	"../exchange"
@@ -23,6 +24,10 @@ func Test_Blank_Balance(t *testing.T) {
 			log.Printf("%d  v:%v  #  %v:%v  %v:%v", k, v.Name, v.Base.Code, base, v.Target.Code, target)
 		}
 	}
+
+	for _, balance := range e.GetBalances() {
+		log.Printf("%s Available: %v Locked: %v Total: %v", balance.Coin.Code, balance.Available, balance.Locked, balance.Total)
+	}
 }
 
 func Test_Blank_Withdraw(t *testing.T) {
@@ -31,9 +36,17 @@ func Test_Blank_Withdraw(t *testing.T) {
 	amount := 0.0
 	addr := "Address"
 	tag := ""
-	if e.Withdraw(c, amount, addr, tag) {
-		log.Printf("Blank %s Withdraw Successful!", c.Code)
+	withdrawal, err := e.Withdraw(c, amount, addr, tag)
+	if err != nil {
+		log.Printf("Blank %s Withdraw Err: %v", c.Code, err)
+		return
+	}
+	log.Printf("Blank %s Withdraw Successful! ID: %s", c.Code, withdrawal.ID)
+
+	if err := e.WithdrawalStatus(withdrawal); err != nil {
+		log.Printf("Blank %s WithdrawalStatus Err: %v", c.Code, err)
 	}
+	log.Printf("Status: %s Sent: %v", withdrawal.Status, withdrawal.Sent())
 }
 
 func Test_Blank_Trade(t *testing.T) {
@@ -78,14 +91,52 @@ func Test_Blank_OrderBook(t *testing.T) {
 	}
 }
 
-/********************General********************/
-func Test_Blank_ConstrainFetch(t *testing.T) {
+func Test_Blank_Ticker(t *testing.T) {
+	e := initBlank()
+
+	tickers, err := e.Tickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ticker := range tickers {
+		log.Printf("%s: %+v", ticker.Pair.Name, ticker)
+	}
+}
+
+func Test_Blank_RecentTrades(t *testing.T) {
+	e := initBlank()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	trades, err := e.RecentTrades(p, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, trade := range trades {
+		log.Printf("%s %v@%v %d", trade.Side, trade.Quantity, trade.Rate, trade.Timestamp)
+	}
+}
+
+func Test_Blank_Candles(t *testing.T) {
 	e := initBlank()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	candles, err := e.Candles(p, time.Hour, time.Now().AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, candle := range candles {
+		log.Printf("%d O:%v H:%v L:%v C:%v V:%v", candle.Timestamp, candle.Open, candle.High, candle.Low, candle.Close, candle.Volume)
+	}
+}
 
-	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("BCH"))
+/********************General********************/
+func Test_Blank_Capabilities(t *testing.T) {
+	e := initBlank()
 
-	status := e.GetConstrainFetchMethod(p)
-	// "Binance ConstrainFetchMethod: %v",
+	status := e.GetCapabilities()
+	if err := exchange.Require(e, exchange.FeatureLimitOrder); err != nil {
+		t.Error(err)
+	}
 	spew.Dump(status)
 }
 
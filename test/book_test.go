@@ -0,0 +1,111 @@
+package test
+
+import (
+	"testing"
+
+	"../exchange"
+	"../exchange/blank"
+	"../market"
+	"../pair"
+)
+
+// REST snapshot stand-in, the book is at lastID
+type snapshotStandIn struct {
+	lastID int
+	calls  int
+}
+
+func (s *snapshotStandIn) OrderBook(p *pair.Pair) (*market.Maker, error) {
+	s.calls++
+	return &market.Maker{
+		LastUpdateID: s.lastID,
+		Bids:         []market.Order{{Rate: 100, Quantity: 1}, {Rate: 99, Quantity: 2}},
+		Asks:         []market.Order{{Rate: 101, Quantity: 1}},
+	}, nil
+}
+
+/********************General********************/
+func Test_Book_Sequence(t *testing.T) {
+	p := &pair.Pair{Name: "BTC|ETH"}
+	snapshot := &snapshotStandIn{lastID: 10}
+	book := exchange.NewOrderBookEngine(blank.NAME, p, 0, snapshot.OrderBook)
+
+	// not synced: the snapshot is fetched before the first delta
+	if err := book.Apply(&exchange.BookUpdate{FirstID: 11, LastID: 12, Bids: []market.Order{{Rate: 100, Quantity: 3}}}); err != nil {
+		t.Fatalf("Apply err: %v", err)
+	}
+	maker := book.Maker()
+	if maker.LastUpdateID != 12 || maker.Bids[0].Quantity != 3 || maker.Pair != p {
+		t.Errorf("the delta is not applied: %+v", maker)
+	}
+
+	// stale: the book has it already
+	book.Apply(&exchange.BookUpdate{FirstID: 5, LastID: 8, Bids: []market.Order{{Rate: 100, Quantity: 9}}})
+	// overlapping: follows the book
+	book.Apply(&exchange.BookUpdate{FirstID: 12, LastID: 13, Asks: []market.Order{{Rate: 101, Quantity: 0}, {Rate: 102, Quantity: 4}}})
+	maker = book.Maker()
+	if maker.LastUpdateID != 13 || maker.Bids[0].Quantity != 3 || len(maker.Asks) != 1 || maker.Asks[0].Rate != 102 {
+		t.Errorf("unexpected book after the stale and overlapping updates: %+v", maker)
+	}
+	if book.Resyncs() != 1 {
+		t.Errorf("expect 1 resync, got %d", book.Resyncs())
+	}
+
+	// gap: resync, the snapshot has the update
+	snapshot.lastID = 21
+	if err := book.Apply(&exchange.BookUpdate{FirstID: 20, LastID: 21, Bids: []market.Order{{Rate: 98, Quantity: 1}}}); err != nil {
+		t.Errorf("Apply err: %v", err)
+	}
+	maker = book.Maker()
+	if book.Resyncs() != 2 || maker.LastUpdateID != 21 || len(maker.Bids) != 2 || maker.Asks[0].Rate != 101 {
+		t.Errorf("the gap is not resynced from the snapshot: %+v", maker)
+	}
+
+	// gap: the snapshot is behind the stream
+	err := book.Apply(&exchange.BookUpdate{FirstID: 30, LastID: 31})
+	if !exchange.IsKind(err, exchange.ErrNetwork) {
+		t.Errorf("expect ErrNetwork, got %v", err)
+	}
+	snapshot.lastID = 31
+	book.Apply(&exchange.BookUpdate{FirstID: 32, LastID: 32})
+	if maker = book.Maker(); maker.LastUpdateID != 32 || book.Resyncs() != 4 {
+		t.Errorf("the book is not resynced after the gap: %d %d", maker.LastUpdateID, book.Resyncs())
+	}
+}
+
+func Test_Book_Snapshot(t *testing.T) {
+	p := &pair.Pair{Name: "BTC|ETH"}
+	book := exchange.NewOrderBookEngine(blank.NAME, p, 2, nil)
+
+	// no REST snapshot: the stream sends it
+	err := book.Apply(&exchange.BookUpdate{Bids: []market.Order{{Rate: 1, Quantity: 1}}})
+	if !exchange.IsKind(err, exchange.ErrNetwork) {
+		t.Errorf("expect ErrNetwork before the snapshot, got %v", err)
+	}
+
+	book.Apply(&exchange.BookUpdate{
+		Snapshot: true,
+		LastID:   5,
+		Bids:     []market.Order{{Rate: 1, Quantity: 1}, {Rate: 3, Quantity: 1}, {Rate: 2, Quantity: 1}},
+		Asks:     []market.Order{{Rate: 6, Quantity: 1}, {Rate: 4, Quantity: 1}, {Rate: 5, Quantity: 1}},
+	})
+	maker := book.Maker()
+	if len(maker.Bids) != 2 || maker.Bids[0].Rate != 3 || maker.Bids[1].Rate != 2 {
+		t.Errorf("bids are not sorted and trimmed: %+v", maker.Bids)
+	}
+	if len(maker.Asks) != 2 || maker.Asks[0].Rate != 4 || maker.Asks[1].Rate != 5 {
+		t.Errorf("asks are not sorted and trimmed: %+v", maker.Asks)
+	}
+
+	// older snapshot
+	book.Apply(&exchange.BookUpdate{Snapshot: true, LastID: 4})
+	if maker = book.Maker(); maker.LastUpdateID != 5 || len(maker.Bids) != 2 {
+		t.Errorf("the older snapshot replaced the book: %+v", maker)
+	}
+
+	// no sequence: applied as it is
+	book.Apply(&exchange.BookUpdate{Bids: []market.Order{{Rate: 3, Quantity: 0}}})
+	if maker = book.Maker(); len(maker.Bids) != 1 || maker.Bids[0].Rate != 2 || maker.LastUpdateID != 5 {
+		t.Errorf("the update without sequence is not applied: %+v", maker)
+	}
+}
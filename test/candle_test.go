@@ -0,0 +1,77 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"../exchange"
+	"../exchange/blank"
+	"../market"
+	"../pair"
+)
+
+// v1 exchange stand-in returning the trades in memory as the recent trades
+type recentTradesStandIn struct {
+	exchange.Exchange
+	trades []*market.Trade
+}
+
+func (e *recentTradesStandIn) GetName() exchange.ExchangeName {
+	return blank.NAME
+}
+
+func (e *recentTradesStandIn) RecentTrades(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	trades := []*market.Trade{}
+	for _, trade := range e.trades {
+		if trade.Timestamp >= since.UnixNano()/1e6 {
+			trades = append(trades, trade)
+		}
+	}
+	return trades, nil
+}
+
+/********************General********************/
+func Test_Candle_BuildCandles(t *testing.T) {
+	p := &pair.Pair{Name: "BTC|ETH"}
+	minute := int64(60 * 1000)
+	trades := []*market.Trade{
+		{Rate: 0.031, Quantity: 2, Timestamp: 10*minute + 5000},
+		{Rate: 0.030, Quantity: 1, Timestamp: 10*minute + 1000},
+		{Rate: 0.033, Quantity: 1, Timestamp: 10*minute + 30000},
+		{Rate: 0.032, Quantity: 4, Timestamp: 12*minute + 1000},
+	}
+
+	candles := exchange.BuildCandles(p, trades, time.Minute)
+	if len(candles) != 2 {
+		t.Fatalf("expect 2 candles, the minute without trade has no candle, got %d", len(candles))
+	}
+	first := candles[0]
+	if first.Timestamp != 10*minute || first.Open != 0.030 || first.High != 0.033 || first.Low != 0.030 || first.Close != 0.033 || first.Volume != 4 {
+		t.Errorf("wrong first candle: %+v", first)
+	}
+	if candles[1].Timestamp != 12*minute || candles[1].Open != 0.032 || candles[1].Close != 0.032 {
+		t.Errorf("wrong second candle: %+v", candles[1])
+	}
+}
+
+func Test_Candle_CandlesFromTrades(t *testing.T) {
+	p := &pair.Pair{Name: "BTC|ETH"}
+	standIn := &recentTradesStandIn{trades: []*market.Trade{
+		{Rate: 0.030, Quantity: 1, Timestamp: 0},
+		{Rate: 0.031, Quantity: 1, Timestamp: int64(90 * time.Minute / time.Millisecond)},
+		{Rate: 0.032, Quantity: 1, Timestamp: int64(150 * time.Minute / time.Millisecond)},
+	}}
+
+	// since 1:30 in the 1h interval, the candle from 1:00 is complete
+	candles, err := exchange.CandlesFromTrades(standIn, p, time.Hour, time.Unix(0, 0).Add(90*time.Minute))
+	if err != nil {
+		t.Fatalf("CandlesFromTrades Err: %v", err)
+	}
+	if len(candles) != 2 || candles[0].Timestamp != int64(time.Hour/time.Millisecond) {
+		t.Errorf("expect the candles of 1:00 and 2:00, got %+v", candles)
+	}
+
+	if _, err := exchange.CandlesFromTrades(standIn, p, 0, time.Now()); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Errorf("zero interval should be rejected: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"../exchange"
+	"../exchange/blank"
+)
+
+/********************General********************/
+func Test_Clock_Skew(t *testing.T) {
+	calls := 0
+	clock := exchange.NewClock(blank.NAME, func() (time.Time, error) {
+		calls++
+		return time.Now().Add(-3 * time.Second), nil
+	})
+
+	now := clock.Now()
+	if skew := clock.Skew(); skew > -2900*time.Millisecond || skew < -3100*time.Millisecond {
+		t.Errorf("expect skew about -3s, got %v", skew)
+	}
+	if diff := time.Since(now); diff < 2900*time.Millisecond {
+		t.Errorf("server time is not corrected: %v behind", diff)
+	}
+	clock.Now()
+	if calls != 1 {
+		t.Errorf("clock should sync once in the interval, synced %d times", calls)
+	}
+
+	last := clock.Nonce()
+	for i := 0; i < 100; i++ {
+		nonce := clock.Nonce()
+		if nonce <= last {
+			t.Fatalf("nonce %d is not larger than %d", nonce, last)
+		}
+		last = nonce
+	}
+}
+
+func Test_Clock_SyncFailed(t *testing.T) {
+	clock := exchange.NewClock(blank.NAME, func() (time.Time, error) {
+		return time.Time{}, errors.New("connection refused")
+	})
+
+	if err := clock.Sync(); !exchange.IsKind(err, exchange.ErrNetwork) {
+		t.Errorf("Sync err should be %s: %v", exchange.ErrNetwork, err)
+	}
+	if diff := time.Since(clock.Now()); diff > time.Second || diff < -time.Second {
+		t.Errorf("clock should fall back to the local time: %v", diff)
+	}
+	if clock.Skew() != 0 {
+		t.Errorf("skew should be 0 without a sync: %v", clock.Skew())
+	}
+}
+
+func Test_Clock_SyncTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	clock := exchange.NewClock(blank.NAME, func() (time.Time, error) {
+		<-release // the server time API never answers
+		return time.Now(), nil
+	})
+
+	start := time.Now()
+	if err := clock.Sync(); !exchange.IsKind(err, exchange.ErrNetwork) {
+		t.Errorf("Sync err should be %s: %v", exchange.ErrNetwork, err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Sync should time out, waited %v", elapsed)
+	}
+
+	// the first Now waits the sync until the timeout, the failed sync is not retried in the interval
+	start = time.Now()
+	clock.Now()
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Now should time out, waited %v", elapsed)
+	}
+	start = time.Now()
+	clock.Now()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Now after the failed sync waited %v", elapsed)
+	}
+}
@@ -1,23 +1,161 @@
 package test
 
 import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"../coin"
 	"../exchange"
 	"../exchange/cryptopia"
+	"../market"
 	"../pair"
 	"github.com/davecgh/go-spew/spew"
 )
 
+const (
+	cryptopiaKey    = "standInKey"
+	cryptopiaSecret = "c3RhbmRJblNlY3JldA==" // base64 of standInSecret
+)
+
+// recorded responses of the Cryptopia API, "METHOD path": body, the last segment of the request path is a parameter if its parent is recorded
+var cryptopiaResponses = map[string]string{
+	"GET /api/GetCurrencies": `{"Success":true,"Message":null,"Data":[
+		{"Id":1,"Name":"Bitcoin","Symbol":"BTC","Algorithm":"sha256","WithdrawFee":0.001,"MinWithdraw":0.002,"MaxWithdraw":1000,"MinBaseTrade":0.00005,"IsTipEnabled":false,"MinTip":0,"DepositConfirmations":3,"Status":"OK","StatusMessage":null,"ListingStatus":"Active"},
+		{"Id":2,"Name":"Ethereum","Symbol":"ETH","Algorithm":"ethash","WithdrawFee":0.01,"MinWithdraw":0.02,"MaxWithdraw":1000,"MinBaseTrade":0.00005,"IsTipEnabled":false,"MinTip":0,"DepositConfirmations":12,"Status":"OK","StatusMessage":null,"ListingStatus":"Active"}],"Error":null}`,
+	"GET /api/GetTradePairs": `{"Success":true,"Message":null,"Data":[
+		{"Id":5203,"Label":"ETH/BTC","Currency":"Ethereum","Symbol":"ETH","BaseCurrency":"Bitcoin","BaseSymbol":"BTC","Status":"OK","StatusMessage":null,"TradeFee":0.2,
+			"MinimumTrade":0.0001,"MaximumTrade":100000000,"MinimumBaseTrade":0.00005,"MaximumBaseTrade":100000000,"MinimumPrice":0.00000001,"MaximumPrice":100000000}],"Error":null}`,
+	"GET /api/GetMarket/ETH_BTC": `{"Success":true,"Message":null,"Data":{"TradePairId":5203,"Label":"ETH/BTC","AskPrice":0.02551003,"BidPrice":0.02543127,"Low":0.02498,"High":0.02589999,
+		"Volume":143.77431205,"LastPrice":0.02547,"BuyVolume":2210.40915624,"SellVolume":318.00441873,"Change":1.31,"Open":0.02514,"Close":0.02547,"BaseVolume":3.66352119,
+		"BuyBaseVolume":41.75098112,"SellBaseVolume":2290.0113117},"Error":null}`,
+	"GET /api/GetMarkets": `{"Success":true,"Message":null,"Data":[
+		{"TradePairId":5203,"Label":"ETH/BTC","AskPrice":0.02551003,"BidPrice":0.02543127,"Low":0.02498,"High":0.02589999,"Volume":143.77431205,"LastPrice":0.02547,"Open":0.02514,"Close":0.02547,"BaseVolume":3.66352119},
+		{"TradePairId":101,"Label":"DOT/BTC","AskPrice":0.00000015,"BidPrice":0.00000013,"Low":0,"High":0,"Volume":0,"LastPrice":0.00000014,"Open":0,"Close":0,"BaseVolume":0}],"Error":null}`,
+	"GET /api/GetMarketHistory/ETH_BTC": `{"Success":true,"Message":null,"Data":[
+		{"TradePairId":5203,"Label":"ETH/BTC","Type":"Buy","Price":0.02551003,"Amount":0.31,"Total":0.00790811,"Timestamp":1595336452},
+		{"TradePairId":5203,"Label":"ETH/BTC","Type":"Sell","Price":0.02543127,"Amount":1.0481,"Total":0.02665451,"Timestamp":1595336431},
+		{"TradePairId":5203,"Label":"ETH/BTC","Type":"Sell","Price":0.02543127,"Amount":0.2,"Total":0.00508625,"Timestamp":1595336431},
+		{"TradePairId":5203,"Label":"ETH/BTC","Type":"Buy","Price":0.0255,"Amount":4,"Total":0.102,"Timestamp":1595336388}],"Error":null}`,
+	"POST /api/GetDepositAddress": `{"Success":true,"Error":null,"Data":{"Currency":"BTC","Address":"1FZdVHtiBqMrWdjPyRPULCUceZPJ2WLCsB","BaseAddress":""}}`,
+	"POST /api/SubmitTrade":       `{"Success":true,"Error":null,"Data":{"OrderId":23467,"FilledOrders":[]}}`,
+	"POST /api/GetOpenOrders": `{"Success":true,"Error":null,"Data":[
+		{"OrderId":23467,"TradePairId":5203,"Market":"ETH/BTC","Type":"Buy","Rate":0.5,"Amount":1,"Total":0.5,"Remaining":0.6,"TimeStamp":"2020-07-21T13:00:00"}]}`,
+	"POST /api/CancelTrade": `{"Success":true,"Error":null,"Data":[23467]}`,
+}
+
+// the deposits and withdrawals of GetTransactions, Type: body
+var cryptopiaTransactions = map[string]string{
+	"Deposit": `[
+		{"Id":4002,"Currency":"ETH","TxId":"0x5e1c1e5f2c3b4a6d","Type":"Deposit","Amount":2.5,"Fee":0,"Status":"UnConfirmed","Confirmations":3,"Timestamp":"2020-07-21T13:10:00","Address":""},
+		{"Id":4000,"Currency":"BTC","TxId":"9281d8b1bc4bda6e8c62ecb04b2e41b4c7f5a0e4bd4a7f8e3d2f8a9b2c1d0e7f","Type":"Deposit","Amount":0.78125,"Fee":0,"Status":"Confirmed","Confirmations":20,"Timestamp":"2020-07-21T13:00:00","Address":""},
+		{"Id":3990,"Currency":"BTC","TxId":"older","Type":"Deposit","Amount":1,"Fee":0,"Status":"Confirmed","Confirmations":120,"Timestamp":"2020-07-20T09:00:00","Address":""}]`,
+	"Withdraw": `[
+		{"Id":4001,"Currency":"BTC","TxId":"","Type":"Withdraw","Amount":0.5,"Fee":0.001,"Status":"Pending","Confirmations":0,"Timestamp":"2020-07-21T14:30:00.123","Address":"1FZdVHtiBqMrWdjPyRPULCUceZPJ2WLCsB"}]`,
+}
+
+// REST stand-in of Cryptopia answering the recorded responses, the private requests are verified by the amx Authorization
+type cryptopiaStandIn struct {
+	server    *httptest.Server
+	lock      sync.Mutex
+	last      map[string]string // "METHOD path": the last path or body
+	responses map[string]string // "METHOD path": the body replacing the recorded response
+}
+
+func newCryptopiaStandIn() *cryptopiaStandIn {
+	s := &cryptopiaStandIn{last: make(map[string]string), responses: make(map[string]string)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *cryptopiaStandIn) query(method, path string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.last[method+" "+path]
+}
+
+// replace the response of the request until reset
+func (s *cryptopiaStandIn) set(method, path, body string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses[method+" "+path] = body
+}
+
+func (s *cryptopiaStandIn) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses = make(map[string]string)
+}
+
+func (s *cryptopiaStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	body, _ := ioutil.ReadAll(r.Body)
+	if _, ok := cryptopiaResponses[r.Method+" "+path.Dir(r.URL.Path)]; ok {
+		key = r.Method + " " + path.Dir(r.URL.Path)
+	}
+	s.lock.Lock()
+	s.last[key] = r.URL.Path + string(body)
+	replaced, isReplaced := s.responses[key]
+	s.lock.Unlock()
+
+	if r.Method == "POST" && !cryptopiaSigned(r, body) {
+		fmt.Fprint(w, `{"Success":false,"Error":"Signature does not match request parameters."}`)
+		return
+	}
+	if isReplaced {
+		fmt.Fprint(w, replaced)
+		return
+	}
+	if key == "POST /api/GetTransactions" {
+		params := struct{ Type string }{}
+		json.Unmarshal(body, &params)
+		fmt.Fprintf(w, `{"Success":true,"Message":null,"Data":%s,"Error":null}`, cryptopiaTransactions[params.Type])
+		return
+	}
+	if response, ok := cryptopiaResponses[key]; ok {
+		fmt.Fprint(w, response)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, `{"Success":false,"Error":"invalid path"}`)
+}
+
+// Authorization: amx API Key:HMAC-SHA256(API Key + POST + url + nonce + base64(MD5(body))):nonce
+func cryptopiaSigned(r *http.Request, body []byte) bool {
+	auth := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "amx "), ":")
+	if len(auth) != 3 || auth[0] != cryptopiaKey {
+		return false
+	}
+	hash := md5.Sum(body)
+	message := auth[0] + r.Method + strings.ToLower(url.QueryEscape("http://"+r.Host+r.URL.Path)) + auth[2] + base64.StdEncoding.EncodeToString(hash[:])
+
+	secret, _ := base64.StdEncoding.DecodeString(cryptopiaSecret)
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(message))
+	return auth[1] == base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
 /********************API********************/
 func Test_Cryptopia_Balance(t *testing.T) {
 	// log.SetFlags(log.LstdFlags | log.Lshortfile)
 	time.LoadLocation("America/Vancouver")
 
-	e := initCryptopia()
+	e, _ := initCryptopia()
 	e.UpdateAllBalances()
 
 	for k, v := range e.GetPairs() { // pairs from binance
@@ -27,50 +165,298 @@ func Test_Cryptopia_Balance(t *testing.T) {
 			log.Printf("%d  v:%v  #  %v:%v  %v:%v", k, v.Name, v.Base.Code, base, v.Target.Code, target)
 		}
 	}
+
+	for _, balance := range e.GetBalances() {
+		log.Printf("%s Available: %v Locked: %v Total: %v", balance.Coin.Code, balance.Available, balance.Locked, balance.Total)
+	}
 }
 
 func Test_Cryptopia_Withdraw(t *testing.T) {
-	e := initCryptopia()
+	e, _ := initCryptopia()
 	c := coin.GetCoin("BTC")
 	amount := 0.0
 	addr := "Address"
 	tag := ""
-	if e.Withdraw(c, amount, addr, tag) {
-		log.Printf("Cryptopia %s Withdraw Successful!", c.Code)
+	withdrawal, err := e.Withdraw(c, amount, addr, tag)
+	if err != nil {
+		log.Printf("Cryptopia %s Withdraw Err: %v", c.Code, err)
+		return
+	}
+	log.Printf("Cryptopia %s Withdraw Successful! ID: %s", c.Code, withdrawal.ID)
+
+	if err := e.WithdrawalStatus(withdrawal); err != nil {
+		log.Printf("Cryptopia %s WithdrawalStatus Err: %v", c.Code, err)
+	}
+	log.Printf("Status: %s Sent: %v", withdrawal.Status, withdrawal.Sent())
+}
+
+func Test_Cryptopia_Transfers(t *testing.T) {
+	e, s := initCryptopia()
+	btc, eth := coin.GetCoin("BTC"), coin.GetCoin("ETH")
+	since := time.Date(2020, 7, 21, 12, 0, 0, 0, time.UTC)
+
+	// the other coins and the deposit before since are dropped, the deposits and withdrawals are sorted by time
+	transfers, err := e.GetTransfers(btc, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 2 {
+		t.Fatalf("transfers %+v", transfers)
+	}
+	deposit, withdrawal := transfers[0], transfers[1]
+	if deposit.Type != exchange.Deposit || deposit.ID != "4000" || deposit.Coin != btc || deposit.Quantity != 0.78125 || deposit.Status != exchange.TransferCompleted {
+		t.Fatalf("deposit %+v", deposit)
+	}
+	if deposit.TxHash == "" || deposit.Timestamp != 1595336400000 {
+		t.Fatalf("deposit %+v", deposit)
+	}
+	if withdrawal.Type != exchange.Withdrawal || withdrawal.Quantity != 0.5 || withdrawal.Fee != 0.001 || withdrawal.Status != exchange.TransferPending {
+		t.Fatalf("withdrawal %+v", withdrawal)
+	}
+	if withdrawal.Address != "1FZdVHtiBqMrWdjPyRPULCUceZPJ2WLCsB" || withdrawal.Timestamp != 1595341800123 {
+		t.Fatalf("withdrawal %+v", withdrawal)
+	}
+	if body := s.query("POST", "/api/GetTransactions"); !strings.Contains(body, `"Count":1000`) {
+		t.Fatalf("transactions body %s", body)
+	}
+
+	// nil coin: the coins of all the transfers
+	transfers, err = e.GetTransfers(nil, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 3 || transfers[1].Coin != eth || transfers[1].Status != exchange.TransferPending {
+		t.Fatalf("all transfers %+v", transfers)
+	}
+}
+
+func Test_Cryptopia_GetDepositAddress(t *testing.T) {
+	e, s := initCryptopia()
+	defer s.reset()
+	btc := coin.GetCoin("BTC")
+
+	address, err := e.GetDepositAddress(btc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.Coin != btc || address.Address != "1FZdVHtiBqMrWdjPyRPULCUceZPJ2WLCsB" || address.Tag != "" {
+		t.Fatalf("address %+v", address)
+	}
+	if body := s.query("POST", "/api/GetDepositAddress"); !strings.Contains(body, `"Currency":"BTC"`) {
+		t.Fatalf("address body %s", body)
+	}
+
+	// the coins with a payment id share BaseAddress, Address is the payment id
+	s.set("POST", "/api/GetDepositAddress", `{"Success":true,"Error":null,"Data":{"Currency":"ETH","Address":"8c4a3f1e2d","BaseAddress":"0x4bbeeb066ed09b7aed07bf39eee0460dfa261520"}}`)
+	if address, err = e.GetDepositAddress(coin.GetCoin("ETH")); err != nil || address.Address != "0x4bbeeb066ed09b7aed07bf39eee0460dfa261520" || address.Tag != "8c4a3f1e2d" {
+		t.Fatalf("payment id address %+v err %v", address, err)
+	}
+
+	s.set("POST", "/api/GetDepositAddress", `{"Success":false,"Error":"Currency is not enabled for deposits."}`)
+	if _, err := e.GetDepositAddress(btc); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("disabled deposit err %v", err)
 	}
 }
 
 func Test_Cryptopia_Trade(t *testing.T) {
-	e := initCryptopia()
+	e, s := initCryptopia()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	order, err := e.LimitBuy(p, 1, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderID != "23467" || order.Status != market.New || order.Rate != 0.5 || order.Quantity != 1 {
+		t.Fatalf("placed %+v", order)
+	}
+	if body := s.query("POST", "/api/SubmitTrade"); !strings.Contains(body, `"Market":"ETH/BTC"`) || !strings.Contains(body, `"Amount":1`) {
+		t.Fatalf("place body %s", body)
+	}
+
+	// the remaining is less than the amount
+	if err := e.OrderStatus(order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != market.Partial {
+		t.Fatalf("status %+v", order)
+	}
+
+	if err := e.CancelOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if body := s.query("POST", "/api/CancelTrade"); order.Status != market.Canceling || !strings.Contains(body, `"OrderId":23467`) {
+		t.Fatalf("cancel %+v body %s", order, body)
+	}
+}
+
+func Test_Cryptopia_PlaceOrder(t *testing.T) {
+	e, s := initCryptopia()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// SubmitTrade only places limit orders good till cancel
+	unsupported := []*market.OrderRequest{
+		{Pair: p, Side: market.Buy, Type: market.MarketOrder, Quantity: 1},
+		{Pair: p, Side: market.Buy, Type: market.LimitOrder, TimeInForce: market.IOC, Quantity: 1, Rate: 0.5},
+		{Pair: p, Side: market.Buy, Type: market.LimitOrder, PostOnly: true, Quantity: 1, Rate: 0.5},
+	}
+	for i, request := range unsupported {
+		if _, err := e.PlaceOrder(request); !exchange.IsKind(err, exchange.ErrUnsupported) {
+			t.Errorf("case %d: err %v", i, err)
+		}
+	}
+
+	// the order filled on placing has no OrderId, only the ids of the orders it filled
+	s.set("POST", "/api/SubmitTrade", `{"Success":true,"Error":null,"Data":{"OrderId":null,"FilledOrders":[23465,23466]}}`)
+	order, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Sell, Quantity: 1, Rate: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != market.Filled || len(order.FilledOrders) != 2 || order.Side != "Sell" {
+		t.Fatalf("filled %+v", order)
+	}
+	if body := s.query("POST", "/api/SubmitTrade"); !strings.Contains(body, `"Type":"Sell"`) || !strings.Contains(body, `"Rate":0.5`) {
+		t.Fatalf("place body %s", body)
+	}
+}
+
+func Test_Cryptopia_ListOpenOrders(t *testing.T) {
+	e, s := initCryptopia()
+	defer s.reset()
 	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
-	rate := 0.5
-	quantity := 1.0
 
-	// Place Order in 2 Second
-	order, err := e.LimitBuy(p, quantity, rate)
-	if err == nil {
-		log.Printf("Cryptopia Limit Buy: %v", order)
-	} else {
-		log.Printf("Cryptopia Limit Buy Err: %s", err)
+	// the orders are sorted by OrderId, LTC/BTC is not in the pair list
+	s.set("POST", "/api/GetOpenOrders", `{"Success":true,"Error":null,"Data":[
+		{"OrderId":23470,"TradePairId":5203,"Market":"ETH/BTC","Type":"Sell","Rate":0.036,"Amount":2,"Total":0.072,"Remaining":2,"TimeStamp":"2020-07-21T13:20:00"},
+		{"OrderId":23468,"TradePairId":5204,"Market":"LTC/BTC","Type":"Buy","Rate":0.006,"Amount":10,"Total":0.06,"Remaining":10,"TimeStamp":"2020-07-21T13:10:00"},
+		{"OrderId":23467,"TradePairId":5203,"Market":"ETH/BTC","Type":"Buy","Rate":0.035,"Amount":1,"Total":0.035,"Remaining":0.6,"TimeStamp":"2020-07-21T13:00:00"},
+		{"OrderId":23469,"TradePairId":5203,"Market":"ETH/BTC","Type":"Buy","Rate":0.0345,"Amount":1.5,"Total":0.05175,"Remaining":1.5,"TimeStamp":"2020-07-21T13:15:00"}]}`)
+
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 3 || orders[0].OrderID != "23467" || orders[1].OrderID != "23469" || orders[2].OrderID != "23470" {
+		t.Fatalf("open orders %+v", orders)
+	}
+	// the deal rate is the order rate, the API doesn't have the average
+	if first := orders[0]; first.Pair != p || first.Status != market.Partial || first.DealQuantity != 0.4 || first.DealRate != 0.035 {
+		t.Fatalf("first order %+v", first)
+	}
+	if orders[1].Status != market.New || orders[1].DealQuantity != 0 {
+		t.Fatalf("new order %+v", orders[1])
+	}
+	if body := s.query("POST", "/api/GetOpenOrders"); strings.Contains(body, "Market") {
+		t.Fatalf("all markets body %s", body)
+	}
+
+	page, err := e.ListOpenOrders(&market.OrderQuery{Pair: p, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].OrderID != "23467" || page[1].OrderID != "23469" {
+		t.Fatalf("page %+v", page)
+	}
+	if body := s.query("POST", "/api/GetOpenOrders"); !strings.Contains(body, `"Market":"ETH/BTC"`) {
+		t.Fatalf("market body %s", body)
+	}
+	if page, err := e.ListOpenOrders(&market.OrderQuery{Pair: p, Offset: 3}); err != nil || len(page) != 0 {
+		t.Fatalf("page after the end %+v err %v", page, err)
+	}
+}
+
+func Test_Cryptopia_GetFills(t *testing.T) {
+	e, s := initCryptopia()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// the history has no order id and no liquidity, the fee is in BTC, the time is UTC without a zone
+	s.set("POST", "/api/GetTradeHistory", `{"Success":true,"Error":null,"Data":[
+		{"TradeId":23471,"TradePairId":5203,"Market":"ETH/BTC","Type":"Sell","Rate":0.036,"Amount":0.5,"Total":0.018,"Fee":0.000036,"TimeStamp":"2020-07-21T13:30:00.5"},
+		{"TradeId":23460,"TradePairId":5204,"Market":"LTC/BTC","Type":"Buy","Rate":0.006,"Amount":10,"Total":0.06,"Fee":0.00012,"TimeStamp":"2020-07-21T13:20:00"},
+		{"TradeId":23455,"TradePairId":5203,"Market":"ETH/BTC","Type":"Buy","Rate":0.035,"Amount":0.4,"Total":0.014,"Fee":0.000028,"TimeStamp":"2020-07-21T13:05:00"},
+		{"TradeId":23401,"TradePairId":5203,"Market":"ETH/BTC","Type":"Buy","Rate":0.034,"Amount":1,"Total":0.034,"Fee":0.000068,"TimeStamp":"2020-07-20T09:00:00"}]}`)
+	trades, err := e.GetFills(p, time.Date(2020, 7, 21, 13, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 || trades[0].TradeID != "23455" || trades[1].TradeID != "23471" {
+		t.Fatalf("fills %+v", trades)
+	}
+	buy, sell := trades[0], trades[1]
+	if buy.Pair != p || buy.Side != market.Buy || buy.OrderID != "" || buy.Liquidity != market.LiquidityUnknown || buy.FeeCoin != p.Base || buy.Fee != 0.000028 {
+		t.Fatalf("buy fill %+v", buy)
+	}
+	if sell.Side != market.Sell || sell.Rate != 0.036 || sell.Quantity != 0.5 || sell.Timestamp != time.Date(2020, 7, 21, 13, 30, 0, 5e8, time.UTC).UnixNano()/1e6 {
+		t.Fatalf("sell fill %+v", sell)
+	}
+	if body := s.query("POST", "/api/GetTradeHistory"); !strings.Contains(body, `"Market":"ETH/BTC"`) || !strings.Contains(body, `"Count":1000`) {
+		t.Fatalf("fills body %s", body)
+	}
+}
+
+func Test_Cryptopia_OrderByClientID(t *testing.T) {
+	e, _ := initCryptopia()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	placed, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Quantity: 1, Rate: 0.5, ClientOrderID: "myOrder1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order, err := e.OrderByClientID(p, "myOrder1"); err != nil || order.OrderID != placed.OrderID {
+		t.Fatalf("client order %+v err %v", order, err)
+	}
+
+	// Cryptopia doesn't keep the client order id, an order of another instance may exist
+	if _, err := e.OrderByClientID(p, "myOrder404"); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Fatalf("unknown client order err %v", err)
+	}
+}
+
+func Test_Cryptopia_CancelAllOrders(t *testing.T) {
+	e, s := initCryptopia()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// 23470 is placed after the open orders are listed, only its id is known
+	s.set("POST", "/api/CancelTrade", `{"Success":true,"Error":null,"Data":[23467,23470]}`)
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := s.query("POST", "/api/CancelTrade"); !strings.Contains(body, `"Type":"All"`) || strings.Contains(body, "TradePairId") {
+		t.Fatalf("cancel all body %s", body)
+	}
+	if len(report.Canceled) != 2 || len(report.Failed) != 0 || report.Err() != nil {
+		t.Fatalf("report %+v", report)
+	}
+	listed, placed := report.Canceled[0], report.Canceled[1]
+	if listed.OrderID != "23467" || listed.Pair != p || listed.Rate != 0.5 || listed.Quantity != 1 || listed.Side != "Buy" || listed.Status != market.Canceling {
+		t.Fatalf("listed order %+v", listed)
+	}
+	if placed.OrderID != "23470" || placed.Pair != nil || placed.Status != market.Canceling {
+		t.Fatalf("order placed in between %+v", placed)
 	}
 
-	err = e.OrderStatus(order)
-	if err == nil {
-		log.Printf("Cryptopia Order Status: %v", order)
-	} else {
-		log.Printf("Cryptopia Order Status Err: %s", err)
+	s.set("POST", "/api/CancelTrade", `{"Success":true,"Error":null,"Data":[23467]}`)
+	if report, err = e.CancelAllOrders(p); err != nil || len(report.Canceled) != 1 || report.Canceled[0].Pair != p {
+		t.Fatalf("report %+v err %v", report, err)
+	}
+	if body := s.query("POST", "/api/CancelTrade"); !strings.Contains(body, `"Type":"TradePair"`) || !strings.Contains(body, `"TradePairId":5203`) {
+		t.Fatalf("cancel pair body %s", body)
+	}
+	if body := s.query("POST", "/api/GetOpenOrders"); !strings.Contains(body, `"Market":"ETH/BTC"`) {
+		t.Fatalf("open orders body %s", body)
 	}
 
-	err = e.CancelOrder(order)
-	if err == nil {
-		log.Printf("Cryptopia Cancel Order: %v", order)
-	} else {
-		log.Printf("Cryptopia Cancel Err: %s", err)
+	s.set("POST", "/api/CancelTrade", `{"Success":false,"Error":"No open orders found."}`)
+	if _, err := e.CancelAllOrders(p); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("cancel err %v", err)
 	}
 }
 
 func Test_Cryptopia_OrderBook(t *testing.T) {
-	e := initCryptopia()
+	e, _ := initCryptopia()
 
 	for _, pair := range e.GetPairs() { // pairs from binance
 		if pair != nil {
@@ -83,19 +469,128 @@ func Test_Cryptopia_OrderBook(t *testing.T) {
 	}
 }
 
-/********************General********************/
-func Test_Cryptopia_ConstrainFetch(t *testing.T) {
-	e := initCryptopia()
+func Test_Cryptopia_Ticker(t *testing.T) {
+	e, s := initCryptopia()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
 
-	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("BCH"))
+	ticker, err := e.Ticker(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Pair != p || ticker.Bid != 0.02543127 || ticker.Ask != 0.02551003 || ticker.Last != 0.02547 || ticker.High != 0.02589999 || ticker.Low != 0.02498 {
+		t.Fatalf("ticker %+v", ticker)
+	}
+	// Volume is in the coin, BaseVolume is in the base currency, BuyBaseVolume and SellBaseVolume are the order book totals
+	if ticker.Volume != 143.77431205 || ticker.QuoteVolume != 3.66352119 || ticker.Timestamp == 0 {
+		t.Fatalf("ticker volume %+v", ticker)
+	}
+	if path := s.query("GET", "/api/GetMarket/ETH_BTC"); path != "/api/GetMarket/ETH_BTC" {
+		t.Fatalf("ticker path %s", path)
+	}
+
+	// the markets not on the pair list are skipped
+	tickers, err := e.Tickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickers) != 1 || tickers[0].Pair != p || tickers[0].Last != 0.02547 || tickers[0].QuoteVolume != 3.66352119 {
+		t.Fatalf("tickers %+v", tickers)
+	}
+
+	// a market closed by Cryptopia
+	s.set("GET", "/api/GetMarket/ETH_BTC", `{"Success":false,"Message":"Market ETH_BTC not found","Data":null,"Error":null}`)
+	if _, err := e.Ticker(p); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("closed market err %v", err)
+	}
+}
 
-	status := e.GetConstrainFetchMethod(p)
-	// "Binance ConstrainFetchMethod: %v",
+func Test_Cryptopia_RecentTrades(t *testing.T) {
+	e, s := initCryptopia()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+	since := time.Unix(1595336400, 0)
+
+	// the trade before since is dropped, the hours cover since
+	trades, err := e.RecentTrades(p, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 3 {
+		t.Fatalf("trades %+v", trades)
+	}
+	// the history has no trade ids and the time is in seconds, the trades of a second keep the order of the response
+	for _, trade := range trades {
+		if trade.TradeID != "" || trade.Pair != p || trade.Liquidity != market.LiquidityTaker {
+			t.Fatalf("trade %+v", trade)
+		}
+	}
+	first, second, buy := trades[0], trades[1], trades[2]
+	if first.Side != market.Sell || first.Rate != 0.02543127 || first.Quantity != 1.0481 || first.Timestamp != 1595336431000 {
+		t.Fatalf("first sell trade %+v", first)
+	}
+	if second.Side != market.Sell || second.Quantity != 0.2 || second.Timestamp != first.Timestamp {
+		t.Fatalf("second sell trade %+v", second)
+	}
+	if buy.Side != market.Buy || buy.Rate != 0.02551003 || buy.Quantity != 0.31 || buy.Timestamp != 1595336452000 {
+		t.Fatalf("buy trade %+v", buy)
+	}
+	path := s.query("GET", "/api/GetMarketHistory/ETH_BTC")
+	if hours, _ := strconv.ParseFloat(strings.TrimPrefix(path, "/api/GetMarketHistory/ETH_BTC/"), 64); hours < time.Since(since).Hours() {
+		t.Fatalf("trades path %s", path)
+	}
+}
+
+func Test_Cryptopia_Candles(t *testing.T) {
+	e, s := initCryptopia()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// Cryptopia has no candle API, the candle is built from the trades since the start of the minute
+	candles, err := e.Candles(p, time.Minute, time.Unix(1595336405, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("candles %+v", candles)
+	}
+	candle := candles[0]
+	if candle.Pair != p || candle.Timestamp != 1595336400000 || candle.Open != 0.02543127 || candle.High != 0.02551003 || candle.Low != 0.02543127 || candle.Close != 0.02551003 || math.Abs(candle.Volume-1.5581) > 1e-9 {
+		t.Fatalf("candle %+v", candle)
+	}
+	if math.Abs(candle.QuoteVolume-(1.2481*0.02543127+0.31*0.02551003)) > 1e-12 {
+		t.Fatalf("candle quote volume %v", candle.QuoteVolume)
+	}
+	// the history is requested by the hours since the start of the candle
+	path := s.query("GET", "/api/GetMarketHistory/ETH_BTC")
+	if hours, _ := strconv.ParseFloat(strings.TrimPrefix(path, "/api/GetMarketHistory/ETH_BTC/"), 64); hours < time.Since(time.Unix(1595336400, 0)).Hours() {
+		t.Fatalf("history path %s", path)
+	}
+
+	// the history is in seconds, any interval is built, the intervals without trades have no candle
+	candles, err = e.Candles(p, 10*time.Second, time.Unix(1595336405, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2 || candles[0].Timestamp != 1595336430000 || candles[1].Timestamp != 1595336450000 {
+		t.Fatalf("10s candles %+v", candles)
+	}
+	if candles[0].Open != 0.02543127 || candles[0].Close != 0.02543127 || math.Abs(candles[0].Volume-1.2481) > 1e-9 || candles[1].Volume != 0.31 {
+		t.Fatalf("10s candles %+v %+v", candles[0], candles[1])
+	}
+}
+
+/********************General********************/
+func Test_Cryptopia_Capabilities(t *testing.T) {
+	e, _ := initCryptopia()
+
+	status := e.GetCapabilities()
+	if err := exchange.Require(e, exchange.FeatureLimitOrder); err != nil {
+		t.Error(err)
+	}
 	spew.Dump(status)
 }
 
 func Test_Cryptopia_Constrain(t *testing.T) {
-	e := initCryptopia()
+	e, _ := initCryptopia()
 
 	pair := pair.GetPairByKey("BTC|ETH")
 	coinName := coin.GetCoin(pair.Target.Code)
@@ -108,7 +603,7 @@ func Test_Cryptopia_Constrain(t *testing.T) {
 }
 
 func Test_Cryptopia_GetMaker(t *testing.T) {
-	e := initCryptopia()
+	e, _ := initCryptopia()
 
 	pair := pair.GetPairByKey("BTC|ETH")
 	maker, _ := e.GetMaker(pair)
@@ -116,15 +611,21 @@ func Test_Cryptopia_GetMaker(t *testing.T) {
 	log.Printf("Maker: %v", maker)
 }
 
-func initCryptopia() exchange.Exchange {
-	pair.Init()
-	config := &exchange.Config{}
-	config.RedisServer = ""
-	config.RedisDB = 9
-	config.API_KEY = ""
-	config.API_SECRET = ""
-	ex := cryptopia.CreateCryptopia(config)
-	log.Printf("Initial [ %v ]", ex.GetName())
-	config = nil
-	return ex
+var cryptopiaOnce sync.Once
+var cryptopiaInstance *cryptopia.Cryptopia
+var cryptopiaServer *cryptopiaStandIn
+
+// one stand-in and instance for all the tests
+func initCryptopia() (*cryptopia.Cryptopia, *cryptopiaStandIn) {
+	cryptopiaOnce.Do(func() {
+		pair.Init()
+		cryptopiaServer = newCryptopiaStandIn()
+		config := &exchange.Config{}
+		config.API_KEY = cryptopiaKey
+		config.API_SECRET = cryptopiaSecret
+		config.API_URL = cryptopiaServer.server.URL
+		cryptopiaInstance = cryptopia.CreateCryptopia(config)
+		log.Printf("Initial [ %v ]", cryptopiaInstance.GetName())
+	})
+	return cryptopiaInstance, cryptopiaServer
 }
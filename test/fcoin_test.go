@@ -1,19 +1,189 @@
 package test
 
 import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"../coin"
 	"../exchange"
 	"../exchange/fcoin"
+	"../market"
 	"../pair"
 	"github.com/davecgh/go-spew/spew"
 )
 
+const (
+	fcoinKey    = "standInKey"
+	fcoinSecret = "standInSecret"
+)
+
+// recorded responses of the Fcoin API, "METHOD path": body
+var fcoinResponses = map[string]string{
+	"GET /v2/public/server-time": `{"status":0,"data":1531917930036}`,
+	"GET /v2/public/currencies":  `{"status":0,"data":["btc","eth"]}`,
+	"GET /v2/public/symbols": `{"status":0,"data":[
+		{"name":"ethbtc","base_currency":"eth","quote_currency":"btc","price_decimal":6,"amount_decimal":4}]}`,
+	"GET /v2/market/ticker/ethbtc": `{"status":0,"data":{"type":"ticker.ethbtc","seq":183042517,
+		"ticker":[0.062441,0.0385,0.062437,1.9207,0.062455,0.4112,0.062891,0.063514,0.061902,51428.317,3221.04288]}}`,
+	"GET /v2/market/trades/ethbtc": `{"status":0,"data":[
+		{"amount":0.0385,"ts":1531917935512,"id":74224301020001,"side":"buy","price":0.062441},
+		{"amount":3.2107,"ts":1531917931190,"id":74224301010000,"side":"sell","price":0.062430},
+		{"amount":0.5,"ts":1531917928004,"id":74224300980000,"side":"sell","price":0.062452}]}`,
+	"GET /v2/market/candles/H1/ethbtc": `{"status":0,"data":[
+		{"open":0.062401,"close":0.062441,"high":0.062519,"quote_vol":41.87741,"id":1531915200,"count":903,"low":0.06238,"seq":18304251700000,"base_vol":670.7311},
+		{"open":0.062758,"close":0.062401,"high":0.062891,"quote_vol":133.6001862,"id":1531911600,"count":2671,"low":0.062177,"seq":18303119800000,"base_vol":2139.11},
+		{"open":0.062512,"close":0.062758,"high":0.062804,"quote_vol":98.214155,"id":1531908000,"count":1840,"low":0.062407,"seq":18301846200000,"base_vol":1568.7}]}`,
+	"POST /v2/orders": `{"status":0,"data":"9d17a03b852e48c0b3920c7412867623"}`,
+	"GET /v2/orders/9d17a03b852e48c0b3920c7412867623": `{"status":0,"data":{"id":"9d17a03b852e48c0b3920c7412867623","symbol":"ethbtc","type":"limit","side":"buy",
+		"price":"0.00001","amount":"1.0000","state":"partial_filled","executed_value":"0.000004","fill_fees":"0.0004","filled_amount":"0.4000","created_at":1531917930036,"source":"api"}}`,
+	"POST /v2/orders/9d17a03b852e48c0b3920c7412867623/submit-cancel": `{"status":0}`,
+	"GET /v2/orders/f3/match-results": `{"status":0,"data":[
+		{"price":"0.035","fill_fees":"0.001","filled_amount":"1.0","side":"buy","type":"limit","created_at":1531917932100}]}`,
+	"GET /v2/orders/f2/match-results": `{"status":0,"data":[
+		{"price":"0.036","fill_fees":"0.00001","filled_amount":"0.5","side":"sell","type":"limit","created_at":1531917930600},
+		{"price":"0.036","fill_fees":"0.00001","filled_amount":"0.5","side":"sell","type":"limit","created_at":1531917931600}]}`,
+}
+
+// REST stand-in of Fcoin answering the recorded responses, the signed requests are verified
+type fcoinStandIn struct {
+	server    *httptest.Server
+	lock      sync.Mutex
+	last      map[string]string // "METHOD path": the last query
+	responses map[string]string // "METHOD path": the body replacing the recorded response
+	orders    []fcoin.OrderData // the orders of /orders, newest first
+	pages     int               // the number of /orders requests
+}
+
+func newFcoinStandIn() *fcoinStandIn {
+	s := &fcoinStandIn{last: make(map[string]string), responses: make(map[string]string)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *fcoinStandIn) query(method, path string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.last[method+" "+path]
+}
+
+// replace the response of the request until reset
+func (s *fcoinStandIn) set(method, path, body string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses[method+" "+path] = body
+}
+
+func (s *fcoinStandIn) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses = make(map[string]string)
+}
+
+// replace the orders of /orders, created_at is descending
+func (s *fcoinStandIn) setOrders(orders []fcoin.OrderData) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.orders = orders
+	s.pages = 0
+}
+
+func (s *fcoinStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	body, _ := ioutil.ReadAll(r.Body)
+	s.lock.Lock()
+	s.last[key] = r.URL.RawQuery
+	replaced, isReplaced := s.responses[key]
+	s.lock.Unlock()
+
+	if !strings.HasPrefix(r.URL.Path, "/v2/public/") && !strings.HasPrefix(r.URL.Path, "/v2/market/") && !fcoinSigned(r, body) {
+		fmt.Fprint(w, `{"status":6005,"msg":"api key check fail"}`)
+		return
+	}
+
+	if isReplaced {
+		fmt.Fprint(w, replaced)
+		return
+	}
+	if key == "GET /v2/orders" {
+		s.serveOrders(w, r)
+		return
+	}
+	if response, ok := fcoinResponses[key]; ok {
+		fmt.Fprint(w, response)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, `{"status":404,"msg":"invalid path"}`)
+}
+
+// a page of the orders created before the "before" cursor, newest first
+func (s *fcoinStandIn) serveOrders(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pages++
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	before, _ := strconv.Atoi(r.URL.Query().Get("before"))
+
+	states := "," + r.URL.Query().Get("states") + ","
+	page := []fcoin.OrderData{}
+	for _, o := range s.orders {
+		if strings.Contains(states, ","+o.State+",") && (before == 0 || o.CreatedAt < before) && len(page) < limit {
+			page = append(page, o)
+		}
+	}
+	data, _ := json.Marshal(page)
+	fmt.Fprintf(w, `{"status":0,"data":%s}`, data)
+}
+
+// HMAC-SHA1 of base64("METHOD" + url + timestamp + sorted body params) with the API Secret of the stand-in
+func fcoinSigned(r *http.Request, body []byte) bool {
+	if r.Header.Get("FC-ACCESS-KEY") != fcoinKey {
+		return false
+	}
+	message := r.Method + "http://" + r.Host + r.URL.RequestURI() + r.Header.Get("FC-ACCESS-TIMESTAMP")
+	if len(body) > 0 {
+		params := map[string]string{}
+		if err := json.Unmarshal(body, &params); err != nil {
+			return false
+		}
+		keys := []string{}
+		for k := range params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		query := []string{}
+		for _, k := range keys {
+			query = append(query, k+"="+params[k])
+		}
+		message += strings.Join(query, "&")
+	}
+
+	h := hmac.New(sha1.New, []byte(fcoinSecret))
+	h.Write([]byte(base64.StdEncoding.EncodeToString([]byte(message))))
+	return r.Header.Get("FC-ACCESS-SIGNATURE") == base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
 /********************API********************/
 func Test_Fcoin_Balance(t *testing.T) {
-	e := initFcoin()
+	e, _ := initFcoin()
 	e.UpdateAllBalances()
 
 	for k, v := range e.GetPairs() { // pairs from binance
@@ -23,51 +193,75 @@ func Test_Fcoin_Balance(t *testing.T) {
 			log.Printf("%d  v:%v  #  %v:%v  %v:%v", k, v.Name, v.Base.Code, base, v.Target.Code, target)
 		}
 	}
-}
 
-func Test_Fcoin_Withdraw(t *testing.T) {
-	e := initFcoin()
-	c := coin.GetCoin("BTC")
-	amount := 0.0
-	addr := "Address"
-	tag := ""
-	if e.Withdraw(c, amount, addr, tag) {
-		log.Printf("Fcoin %s Withdraw Successful!", c.Code)
+	for _, balance := range e.GetBalances() {
+		log.Printf("%s Available: %v Locked: %v Total: %v", balance.Coin.Code, balance.Available, balance.Locked, balance.Total)
 	}
 }
 
-// no blance, cannot test this 
+// no blance, cannot test this
 func Test_Fcoin_Trade(t *testing.T) {
-	e := initFcoin()
+	e, _ := initFcoin()
 	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
 
-	rate := 0.00001
-	quantity := 1.0
+	order, err := e.LimitBuy(p, 1, 0.00001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderID != "9d17a03b852e48c0b3920c7412867623" || order.Status != market.New || order.Rate != 0.00001 || order.Quantity != 1 {
+		t.Fatalf("placed %+v", order)
+	}
+
+	if err := e.OrderStatus(order); err != nil {
+		t.Fatal(err)
+	}
+	// executed_value is the quote value of the filled_amount
+	if order.Status != market.Partial || order.DealQuantity != 0.4 || math.Abs(order.DealRate-0.00001) > 1e-12 {
+		t.Fatalf("status %+v", order)
+	}
+
+	if err := e.CancelOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != market.Canceling {
+		t.Fatalf("cancel %+v", order)
+	}
+}
+
+func Test_Fcoin_OrderByClientID(t *testing.T) {
+	e, s := initFcoin()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
 
-	order, err := e.LimitBuy(p, quantity, rate)
-	if err == nil {
-		log.Printf("Fcoin Limit Buy: %v", order)
-	} else {
-		log.Printf("Fcoin Limit Buy Err: %s", err)
+	placed, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.LimitOrder, Quantity: 1, Rate: 0.00001, ClientOrderID: "myOrder1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order, err := e.OrderByClientID(p, "myOrder1"); err != nil || order.OrderID != placed.OrderID {
+		t.Fatalf("client order %+v err %v", order, err)
 	}
 
-	err = e.OrderStatus(order)
-	if err == nil {
-		log.Printf("Fcoin Order Status: %v", order)
-	} else {
-		log.Printf("Fcoin Order Status Err: %s", err)
+	// the order is still open
+	if err := e.OrderStatus(placed); err != nil || placed.Status != market.Partial {
+		t.Fatalf("status %+v err %v", placed, err)
+	}
+	if _, err := e.OrderByClientID(p, "myOrder1"); err != nil {
+		t.Fatalf("the open order is forgotten: %v", err)
 	}
 
-	err = e.CancelOrder(order)
-	if err == nil {
-		log.Printf("Fcoin Cancel Order: %v", order)
-	} else {
-		log.Printf("Fcoin Cancel Err: %s", err)
+	// the closed order is dropped from the map, it is looked up like an order placed before a restart
+	s.set("GET", "/v2/orders/9d17a03b852e48c0b3920c7412867623", `{"status":0,"data":{"id":"9d17a03b852e48c0b3920c7412867623","symbol":"ethbtc","type":"limit","side":"buy",
+		"price":"0.00001","amount":"1.0000","state":"filled","executed_value":"0.00001","fill_fees":"0.001","filled_amount":"1.0000","created_at":1531917930036,"source":"api"}}`)
+	if err := e.OrderStatus(placed); err != nil || placed.Status != market.Filled {
+		t.Fatalf("status %+v err %v", placed, err)
+	}
+	if _, err := e.OrderByClientID(p, "myOrder1"); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Fatalf("closed client order err %v", err)
 	}
 }
 
 func Test_Fcoin_OrderBook(t *testing.T) {
-	e := initFcoin()
+	e, _ := initFcoin()
 
 	for _, pair := range e.GetPairs() { // pairs from binance
 		if pair != nil {
@@ -81,19 +275,119 @@ func Test_Fcoin_OrderBook(t *testing.T) {
 	}
 }
 
-/********************General********************/
-func Test_Fcoin_ConstrainFetch(t *testing.T) {
-	e := initFcoin()
+func Test_Fcoin_Ticker(t *testing.T) {
+	e, s := initFcoin()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
 
-	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("BCH"))
+	// ticker: [last, last volume, bid, bid volume, ask, ask volume, open, high, low, base volume, quote volume]
+	// the symbol of /market/ticker/{symbol} is lower case
+	ticker, err := e.Ticker(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticker.Pair != p || ticker.Last != 0.062441 || ticker.Bid != 0.062437 || ticker.Ask != 0.062455 || ticker.High != 0.063514 || ticker.Low != 0.061902 {
+		t.Fatalf("ticker %+v", ticker)
+	}
+	if ticker.Volume != 51428.317 || ticker.QuoteVolume != 3221.04288 || ticker.Timestamp == 0 {
+		t.Fatalf("ticker volume %+v", ticker)
+	}
 
-	status := e.GetConstrainFetchMethod(p)
-	// "Binance ConstrainFetchMethod: %v",
+	// no batch ticker, Tickers requests the pairs one by one
+	tickers, err := e.Tickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickers) != 1 || tickers[0].Pair != p || tickers[0].Last != 0.062441 {
+		t.Fatalf("tickers %+v", tickers)
+	}
+
+	// the ticker of a symbol without trades is cut short
+	s.set("GET", "/v2/market/ticker/ethbtc", `{"status":0,"data":{"type":"ticker.ethbtc","seq":0,"ticker":[0.062441,0.0385]}}`)
+	if _, err := e.Ticker(p); !exchange.IsKind(err, exchange.ErrUnknown) {
+		t.Fatalf("short ticker err %v", err)
+	}
+	s.set("GET", "/v2/market/ticker/ethbtc", `{"status":40003,"msg":"symbol not found"}`)
+	if _, err := e.Tickers(); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("unknown symbol err %v", err)
+	}
+}
+
+func Test_Fcoin_RecentTrades(t *testing.T) {
+	e, s := initFcoin()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// the trade before since is dropped, newest first is sorted to oldest first
+	trades, err := e.RecentTrades(p, time.Unix(0, 1531917930000*1e6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 {
+		t.Fatalf("trades %+v", trades)
+	}
+	// side is the aggressor, the ids are beyond 32 bits
+	sell, buy := trades[0], trades[1]
+	if sell.Side != market.Sell || sell.Rate != 0.06243 || sell.Quantity != 3.2107 || sell.Timestamp != 1531917931190 || sell.TradeID != "74224301010000" {
+		t.Fatalf("sell trade %+v", sell)
+	}
+	if buy.Side != market.Buy || buy.Rate != 0.062441 || buy.Quantity != 0.0385 || buy.TradeID != "74224301020001" || buy.Pair != p || buy.Liquidity != market.LiquidityTaker {
+		t.Fatalf("buy trade %+v", buy)
+	}
+	if query := s.query("GET", "/v2/market/trades/ethbtc"); query != "limit=100" {
+		t.Fatalf("trades query %s", query)
+	}
+}
+
+func Test_Fcoin_Candles(t *testing.T) {
+	e, s := initFcoin()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// the id of a candle is its open time in seconds, the candle before since is dropped, newest first is sorted to oldest first
+	candles, err := e.Candles(p, time.Hour, time.Unix(1531911700, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2 || candles[0].Timestamp != 1531911600000 || candles[1].Timestamp != 1531915200000 {
+		t.Fatalf("candles %+v", candles)
+	}
+	candle := candles[0]
+	if candle.Pair != p || candle.Open != 0.062758 || candle.High != 0.062891 || candle.Low != 0.062177 || candle.Close != 0.062401 || candle.Volume != 2139.11 || candle.QuoteVolume != 133.6001862 {
+		t.Fatalf("candle %+v", candle)
+	}
+	// the current candle is still open
+	if candles[1].Close != 0.062441 || candles[1].Volume != 670.7311 {
+		t.Fatalf("current candle %+v", candles[1])
+	}
+	if query := s.query("GET", "/v2/market/candles/H1/ethbtc"); query != "limit=100" {
+		t.Fatalf("candles query %s", query)
+	}
+
+	// 2 hours is not a resolution, the candle is built from the recent trades since the start of the candle
+	candles, err = e.Candles(p, 2*time.Hour, time.Unix(1531917930, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 1 || candles[0].Timestamp != 1531915200000 || candles[0].Open != 0.062452 || candles[0].Close != 0.062441 || candles[0].High != 0.062452 || candles[0].Low != 0.06243 {
+		t.Fatalf("candles from trades %+v", candles)
+	}
+	if math.Abs(candles[0].Volume-3.7492) > 1e-9 {
+		t.Fatalf("candle volume from trades %v", candles[0].Volume)
+	}
+}
+
+/********************General********************/
+func Test_Fcoin_Capabilities(t *testing.T) {
+	e, _ := initFcoin()
+
+	status := e.GetCapabilities()
+	if err := exchange.Require(e, exchange.FeatureLimitOrder); err != nil {
+		t.Error(err)
+	}
 	spew.Dump(status)
 }
 
 func Test_Fcoin_Constrain(t *testing.T) {
-	e := initFcoin()
+	e, _ := initFcoin()
 
 	pair := pair.GetPairByKey("BTC|ETH")
 	coinName := coin.GetCoin(pair.Target.Code)
@@ -105,8 +399,84 @@ func Test_Fcoin_Constrain(t *testing.T) {
 	log.Printf("Deposit: %v", e.CanDeposit(coinName))
 }
 
+func Test_Fcoin_ListOpenOrders(t *testing.T) {
+	e, s := initFcoin()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// 250 orders, two of them at each ms so a page can end between the orders of the same time
+	orders := []fcoin.OrderData{}
+	for i := 250; i > 0; i-- {
+		orders = append(orders, fcoin.OrderData{ID: fmt.Sprintf("o%d", i), Symbol: "ethbtc", Type: "limit", Side: "buy",
+			Price: "0.035", Amount: "1", State: "submitted", FilledAmount: "0", CreatedAt: 1531917930000 + (i+1)/2})
+	}
+	s.setOrders(orders)
+
+	open, err := e.ListOpenOrders(&market.OrderQuery{Pair: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 250 || open[0].OrderID != "o1" && open[0].OrderID != "o2" {
+		t.Fatalf("open orders %d, the first %+v", len(open), open[0])
+	}
+	seen := map[string]bool{}
+	for _, o := range open {
+		if seen[o.OrderID] {
+			t.Fatalf("order %s is listed twice", o.OrderID)
+		}
+		seen[o.OrderID] = true
+	}
+	if query := s.query("GET", "/v2/orders"); !strings.Contains(query, "before=") || !strings.Contains(query, "limit=100") {
+		t.Fatalf("the last page query %s", query)
+	}
+
+	// more orders at the same ms than a page, they can't be paged by time
+	orders = []fcoin.OrderData{}
+	for i := 0; i < 150; i++ {
+		orders = append(orders, fcoin.OrderData{ID: fmt.Sprintf("s%d", i), Symbol: "ethbtc", State: "submitted", CreatedAt: 1531917930000})
+	}
+	s.setOrders(orders)
+	if _, err := e.ListOpenOrders(&market.OrderQuery{Pair: p}); err == nil {
+		t.Fatalf("the truncated orders should be an error")
+	}
+}
+
+func Test_Fcoin_GetFills(t *testing.T) {
+	e, s := initFcoin()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// f1 is closed before since, its match results are not requested (404 in the stand-in)
+	s.setOrders([]fcoin.OrderData{
+		{ID: "f3", Symbol: "ethbtc", Side: "buy", State: "filled", CreatedAt: 1531917932000},
+		{ID: "f2", Symbol: "ethbtc", Side: "sell", State: "partial_filled", CreatedAt: 1531917930500},
+		{ID: "f1", Symbol: "ethbtc", Side: "buy", State: "filled", CreatedAt: 1531917930400},
+	})
+
+	fills, err := e.GetFills(p, time.Unix(0, 1531917931500*1e6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 2 {
+		t.Fatalf("fills %d", len(fills))
+	}
+	if fills[0].OrderID != "f2" || fills[0].Side != market.Sell || fills[0].Quantity != 0.5 || fills[0].Rate != 0.036 || fills[0].FeeCoin != p.Base {
+		t.Fatalf("the fill of the open order %+v", fills[0])
+	}
+	if fills[1].OrderID != "f3" || fills[1].Side != market.Buy || fills[1].Fee != 0.001 || fills[1].FeeCoin != p.Target || fills[1].Timestamp != 1531917932100 {
+		t.Fatalf("the fill of the closed order %+v", fills[1])
+	}
+}
+
+func Test_Fcoin_Withdraw(t *testing.T) {
+	e, _ := initFcoin()
+
+	// transfer/out only moves the assets between the Fcoin accounts, it is not a withdrawal
+	if _, err := e.Withdraw(coin.GetCoin("BTC"), 0.5, "Address", ""); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Errorf("withdraw err %v", err)
+	}
+}
+
 func Test_Fcoin_GetMaker(t *testing.T) {
-	e := initFcoin()
+	e, _ := initFcoin()
 
 	pair := pair.GetPairByKey("BTC|ETH")
 	maker, _ := e.GetMaker(pair)
@@ -114,21 +484,21 @@ func Test_Fcoin_GetMaker(t *testing.T) {
 	log.Printf("Maker: %v", maker)
 }
 
-/* Modify Config
-Redis Server: xx.xx.xx.xx:xxxx
-Redis DB: DB Number
-API Key: Exchange API Key
-API Secret: Exchange API Secret Key */
-
-func initFcoin() exchange.Exchange {
-	pair.Init()
-	config := &exchange.Config{}
-	config.RedisServer = "RedisAddr:Port"
-	config.RedisDB = 0
-	config.API_KEY = "7eb8721a8fb341718035abc2335d8545"
-	config.API_SECRET = "523d79a231ed44a981e1056151861b09"
-	ex := fcoin.CreateFcoin(config)
-	log.Printf("Initial [ %v ]", ex.GetName())
-	config = nil
-	return ex
+var fcoinOnce sync.Once
+var fcoinInstance *fcoin.Fcoin
+var fcoinServer *fcoinStandIn
+
+// one stand-in and instance for all the tests
+func initFcoin() (*fcoin.Fcoin, *fcoinStandIn) {
+	fcoinOnce.Do(func() {
+		pair.Init()
+		fcoinServer = newFcoinStandIn()
+		config := &exchange.Config{}
+		config.API_KEY = fcoinKey
+		config.API_SECRET = fcoinSecret
+		config.API_URL = fcoinServer.server.URL + "/v2"
+		fcoinInstance = fcoin.CreateFcoin(config)
+		log.Printf("Initial [ %v ]", fcoinInstance.GetName())
+	})
+	return fcoinInstance, fcoinServer
 }
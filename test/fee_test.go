@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+
+	"../exchange"
+)
+
+/********************General********************/
+func Test_Fee_TierRate(t *testing.T) {
+	tiers := []exchange.FeeTier{
+		{Volume: 50000, Rate: 0.0024},
+		{Volume: 0, Rate: 0.0026},
+		{Volume: 100000, Rate: 0.0022},
+	}
+
+	cases := []struct {
+		volume float64
+		rate   float64
+	}{
+		{0, 0.0026},
+		{49999, 0.0026},
+		{50000, 0.0024},
+		{99999.99, 0.0024},
+		{1000000, 0.0022},
+		{-1, 0.0026},
+	}
+	for _, c := range cases {
+		if rate := exchange.TierRate(tiers, c.volume); rate != c.rate {
+			t.Errorf("volume %v: expect rate %v, got %v", c.volume, c.rate, rate)
+		}
+	}
+
+	if tiers[0].Volume != 50000 {
+		t.Errorf("TierRate should not sort the tiers of the caller: %+v", tiers)
+	}
+	if rate := exchange.TierRate(nil, 100); rate != -1 {
+		t.Errorf("expect -1 without tiers, got %v", rate)
+	}
+}
@@ -0,0 +1,366 @@
+package test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"../coin"
+	"../exchange"
+	"../exchange/huobi"
+	"../market"
+	"../pair"
+	"../user"
+)
+
+const (
+	huobiKey    = "standInKey"
+	huobiSecret = "standInSecret"
+)
+
+// recorded responses of the Huobi API, "METHOD path": body
+var huobiResponses = map[string]string{
+	"GET /v1/common/timestamp": `{"status":"ok","data":1569829440004}`,
+	"GET /v1/common/symbols": `{"status":"ok","data":[
+		{"base-currency":"eth","quote-currency":"btc","price-precision":6,"amount-precision":4,"symbol-partition":"main","symbol":"ethbtc","state":"online","value-precision":8,"min-order-amt":0.001,"max-order-amt":10000,"min-order-value":0.0001},
+		{"base-currency":"ltc","quote-currency":"btc","price-precision":6,"amount-precision":4,"symbol-partition":"main","symbol":"ltcbtc","state":"offline","value-precision":8,"min-order-amt":0.01,"max-order-amt":10000,"min-order-value":0.0001}]}`,
+	"GET /market/depth": `{"ch":"market.ethbtc.depth.step0","status":"ok","ts":1569829440004,
+		"tick":{"bids":[[0.035,2.5],[0.0349,1.0]],"asks":[[0.0351,3.0]],"version":100434317651,"ts":1569829439991}}`,
+	"GET /v2/reference/currencies": `{"code":200,"data":[
+		{"currency":"btc","instStatus":"normal","chains":[
+			{"chain":"hbtc","displayName":"HBTC","numOfConfirmations":15,"depositStatus":"allowed","minDepositAmt":"0.001","withdrawStatus":"allowed","minWithdrawAmt":"0.001","withdrawFeeType":"fixed","transactFeeWithdraw":"0.0001"},
+			{"chain":"btc","displayName":"BTC","numOfConfirmations":2,"depositStatus":"allowed","minDepositAmt":"0.0001","withdrawStatus":"allowed","minWithdrawAmt":"0.001","withdrawFeeType":"fixed","transactFeeWithdraw":"0.0005"}]},
+		{"currency":"eth","instStatus":"normal","chains":[
+			{"chain":"eth","displayName":"ERC20","numOfConfirmations":12,"depositStatus":"allowed","minDepositAmt":"0.01","withdrawStatus":"prohibited","minWithdrawAmt":"0.01","withdrawFeeType":"fixed","transactFeeWithdraw":"0.005"}]}]}`,
+	"GET /v1/account/accounts": `{"status":"ok","data":[{"id":100010,"type":"otc","subtype":"","state":"working"},{"id":100009,"type":"spot","subtype":"","state":"working"}]}`,
+	"GET /v1/account/accounts/100009/balance": `{"status":"ok","data":{"id":100009,"type":"spot","state":"working","list":[
+		{"currency":"btc","type":"trade","balance":"1.500000000000000000"},{"currency":"btc","type":"frozen","balance":"0.500000000000000000"},
+		{"currency":"eth","type":"trade","balance":"10.000000000000000000"},{"currency":"eth","type":"frozen","balance":"0.000000000000000000"}]}}`,
+	"POST /v1/order/orders/place": `{"status":"ok","data":"59378"}`,
+	"GET /v1/order/orders/59378": `{"status":"ok","data":{"id":59378,"symbol":"ethbtc","account-id":100009,"client-order-id":"c1","amount":"2.000000000000000000",
+		"price":"0.035000000000000000","created-at":1494901162595,"type":"buy-limit-maker","field-amount":"2.000000000000000000",
+		"field-cash-amount":"0.069800000000000000","field-fees":"0.004000000000000000","finished-at":1494901400468,"source":"spot-api","state":"filled","canceled-at":0}}`,
+	"POST /v1/order/orders/59378/submitcancel": `{"status":"ok","data":"59378"}`,
+	"GET /v1/order/openOrders": `{"status":"ok","data":[
+		{"id":59379,"symbol":"ethbtc","account-id":100009,"client-order-id":"c2","amount":"1.000000000000000000","price":"0.036000000000000000","created-at":1494901162600,
+			"type":"sell-limit","filled-amount":"0.0","filled-cash-amount":"0.0","filled-fees":"0.0","source":"spot-api","state":"submitted"},
+		{"id":59378,"symbol":"ethbtc","account-id":100009,"client-order-id":"c1","amount":"2.000000000000000000","price":"0.035000000000000000","created-at":1494901162595,
+			"type":"buy-limit","filled-amount":"0.5","filled-cash-amount":"0.0175","filled-fees":"0.001","source":"spot-api","state":"partial-filled"}]}`,
+	"GET /v1/order/history": `{"status":"ok","data":[
+		{"id":59380,"symbol":"ethbtc","account-id":100009,"client-order-id":"c3","amount":"1.000000000000000000","price":"0.034000000000000000","created-at":1494901162700,
+			"type":"sell-limit","field-amount":"1.000000000000000000","field-cash-amount":"0.034000000000000000","field-fees":"0.000068000000000000",
+			"finished-at":1494901163000,"source":"spot-api","state":"filled","canceled-at":0}]}`,
+	"GET /v1/order/matchresults": `{"status":"ok","data":[
+		{"id":29555,"order-id":59380,"match-id":100047251,"trade-id":100282,"symbol":"ethbtc","type":"sell-limit","price":"0.034000000000000000",
+			"filled-amount":"1.000000000000000000","filled-fees":"0.000068000000000000","fee-currency":"btc","role":"taker","created-at":1494901163000},
+		{"id":29554,"order-id":59378,"match-id":100047250,"trade-id":100281,"symbol":"ethbtc","type":"buy-limit","price":"0.035000000000000000",
+			"filled-amount":"0.500000000000000000","filled-fees":"0.001000000000000000","fee-currency":"eth","role":"maker","created-at":1494901162900}]}`,
+	"POST /v1/order/orders/batchcancel": `{"status":"ok","data":{"success":["59378"],
+		"failed":[{"err-msg":"Incorrect order state","order-state":7,"order-id":"59379","err-code":"order-orderstate-error"}]}}`,
+	"POST /v1/dw/withdraw/api/create": `{"status":"ok","data":700}`,
+	"GET /v1/query/deposit-withdraw": `{"status":"ok","data":[{"id":700,"type":"withdraw","currency":"btc","chain":"btc",
+		"tx-hash":"ed03094b84eafbe4bc16e7ef766ee959885ee5bcb265872baaa9c64e1cf86c2b","amount":0.1,"address":"1PSRjPg53cX7hMRYAXGJnL8mqHtzmQgPUs",
+		"address-tag":"","fee":0.0004,"state":"confirmed","created-at":1510912472199,"updated-at":1511145876575}]}`,
+}
+
+// REST stand-in of Huobi answering the recorded responses, the signed requests are verified by signature v2
+type huobiStandIn struct {
+	server *httptest.Server
+	lock   sync.Mutex
+	last   map[string]string // "METHOD path": the last query
+	bodies map[string]string // "METHOD path": the last body
+}
+
+func newHuobiStandIn() *huobiStandIn {
+	s := &huobiStandIn{last: make(map[string]string), bodies: make(map[string]string)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *huobiStandIn) request(method, path string) (string, string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.last[method+" "+path], s.bodies[method+" "+path]
+}
+
+func (s *huobiStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	body, _ := ioutil.ReadAll(r.Body)
+	s.lock.Lock()
+	s.last[key] = r.URL.RawQuery
+	s.bodies[key] = string(body)
+	s.lock.Unlock()
+
+	public := strings.HasPrefix(r.URL.Path, "/market/") || strings.HasPrefix(r.URL.Path, "/v1/common/") || r.URL.Path == "/v2/reference/currencies"
+	if !public && !huobiSigned(r) {
+		fmt.Fprint(w, `{"status":"error","err-code":"api-signature-not-valid","err-msg":"Signature not valid: Verification failure [校验失败]","data":null}`)
+		return
+	}
+
+	if r.URL.Path == "/v1/order/orders/getClientOrder" {
+		if r.URL.Query().Get("clientOrderId") != "c1" {
+			fmt.Fprint(w, `{"status":"error","err-code":"base-record-invalid","err-msg":"record invalid","data":null}`)
+			return
+		}
+		key = "GET /v1/order/orders/59378"
+	}
+	if response, ok := huobiResponses[key]; ok {
+		fmt.Fprint(w, response)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, `{"status":"error","err-code":"invalid-parameter","err-msg":"invalid path","data":null}`)
+}
+
+// the query is sorted and signed by "METHOD\nhost\npath\nquery" with the API Key of the stand-in
+func huobiSigned(r *http.Request) bool {
+	query := r.URL.RawQuery
+	i := strings.LastIndex(query, "&Signature=")
+	if i < 0 {
+		return false
+	}
+	signature, err := url.QueryUnescape(query[i+len("&Signature="):])
+	if err != nil {
+		return false
+	}
+	params, err := url.ParseQuery(query[:i])
+	if err != nil || params.Encode() != query[:i] || params.Get("AccessKeyId") != huobiKey ||
+		params.Get("SignatureMethod") != "HmacSHA256" || params.Get("SignatureVersion") != "2" || params.Get("Timestamp") == "" {
+		return false
+	}
+
+	h := hmac.New(sha256.New, []byte(huobiSecret))
+	h.Write([]byte(r.Method + "\n" + strings.ToLower(r.Host) + "\n" + r.URL.Path + "\n" + query[:i]))
+	return signature == base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+/********************General********************/
+func Test_Huobi_Signature(t *testing.T) {
+	params := map[string]string{
+		"order-id":         "1234567890",
+		"Timestamp":        "2017-05-11T15:19:30",
+		"SignatureVersion": "2",
+		"SignatureMethod":  "HmacSHA256",
+		"AccessKeyId":      "e2xxxxxx-99xxxxxx-84xxxxxx-7xxxx",
+	}
+	query := huobi.Map2UrlQuery(params)
+	if query != "AccessKeyId=e2xxxxxx-99xxxxxx-84xxxxxx-7xxxx&SignatureMethod=HmacSHA256&SignatureVersion=2&Timestamp=2017-05-11T15%3A19%3A30&order-id=1234567890" {
+		t.Fatalf("query %s", query)
+	}
+
+	signature := huobi.ComputeHmac256("GET\napi.huobi.pro\n/v1/order/orders\n"+query, "b0xxxxxx-c6xxxxxx-94xxxxxx-dxxxx")
+	if signature != "Nmd8AU8uAe0mkFpxNbiava0aeZzBEtYjCdie1ZYZjoM=" {
+		t.Fatalf("signature %s", signature)
+	}
+}
+
+func Test_Huobi_Pairs(t *testing.T) {
+	e, _ := initHuobi()
+
+	if len(e.GetPairs()) != 1 {
+		t.Fatalf("pairs %v, the offline symbol should be skipped", len(e.GetPairs()))
+	}
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+	if !e.HasPair(p) || e.GetPairCode(p) != "ethbtc" {
+		t.Fatalf("pair %v code %s", p.Name, e.GetPairCode(p))
+	}
+	if e.GetLotSize(p) != 0.0001 || e.GetPriceFilter(p) != 0.000001 {
+		t.Fatalf("lot size %v tick size %v", e.GetLotSize(p), e.GetPriceFilter(p))
+	}
+}
+
+func Test_Huobi_OrderBook(t *testing.T) {
+	e, s := initHuobi()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	maker, err := e.OrderBook(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maker.LastUpdateID != 100434317651 || len(maker.Bids) != 2 || len(maker.Asks) != 1 {
+		t.Fatalf("book %+v", maker)
+	}
+	if maker.Bids[0].Rate != 0.035 || maker.Bids[0].Quantity != 2.5 || maker.Asks[0].Rate != 0.0351 {
+		t.Fatalf("levels %+v %+v", maker.Bids[0], maker.Asks[0])
+	}
+	if query, _ := s.request("GET", "/market/depth"); !strings.Contains(query, "symbol=ethbtc") {
+		t.Fatalf("depth query %s", query)
+	}
+}
+
+func Test_Huobi_Balance(t *testing.T) {
+	e, _ := initHuobi()
+
+	e.UpdateAllBalances()
+	if e.GetBalance(coin.GetCoin("BTC")) != 1.5 || e.GetBalance(coin.GetCoin("ETH")) != 10 {
+		t.Fatalf("balances BTC %v ETH %v", e.GetBalance(coin.GetCoin("BTC")), e.GetBalance(coin.GetCoin("ETH")))
+	}
+	for _, balance := range e.GetBalances() {
+		if balance.Coin.Code == "BTC" && (balance.Locked != 0.5 || balance.Total != 2) {
+			t.Fatalf("BTC balance %+v", balance)
+		}
+	}
+}
+
+func Test_Huobi_Trade(t *testing.T) {
+	e, s := initHuobi()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	order, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.LimitOrder, Quantity: 2, Rate: 0.035, PostOnly: true, ClientOrderID: "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderID != "59378" || order.ClientOrderID != "c1" || order.Status != market.New {
+		t.Fatalf("placed %+v", order)
+	}
+	if _, body := s.request("POST", "/v1/order/orders/place"); !strings.Contains(body, `"type":"buy-limit-maker"`) || !strings.Contains(body, `"account-id":"100009"`) {
+		t.Fatalf("place body %s", body)
+	}
+
+	if err := e.OrderStatus(order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != market.Filled || order.DealQuantity != 2 || order.DealRate != 0.0349 {
+		t.Fatalf("status %+v", order)
+	}
+
+	if err := e.CancelOrder(order); err != nil || order.Status != market.Canceling {
+		t.Fatalf("cancel %+v err %v", order, err)
+	}
+
+	if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.MarketOrder, Quantity: 2}); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Fatalf("market buy err %v", err)
+	}
+}
+
+func Test_Huobi_Fills(t *testing.T) {
+	e, s := initHuobi()
+	since := time.Now().Add(-time.Hour)
+
+	// nil pair: the fills of the pairs of the open orders and the order history
+	trades, err := e.GetFills(nil, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 || trades[0].TradeID != "100281" || trades[1].TradeID != "100282" {
+		t.Fatalf("fills %+v", trades)
+	}
+	if trades[0].Side != market.Buy || trades[0].Liquidity != market.LiquidityMaker || trades[1].Side != market.Sell || trades[1].Quantity != 1 {
+		t.Fatalf("fills %+v %+v", trades[0], trades[1])
+	}
+	if query, _ := s.request("GET", "/v1/order/history"); !strings.Contains(query, fmt.Sprintf("start-time=%d", since.UnixNano()/1e6)) {
+		t.Fatalf("history query %s", query)
+	}
+	if query, _ := s.request("GET", "/v1/order/matchresults"); !strings.Contains(query, "symbol=ethbtc") {
+		t.Fatalf("fills query %s", query)
+	}
+}
+
+func Test_Huobi_OrderByClientID(t *testing.T) {
+	e, _ := initHuobi()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	order, err := e.OrderByClientID(p, "c1")
+	if err != nil || order.OrderID != "59378" || order.Status != market.Filled {
+		t.Fatalf("order %+v err %v", order, err)
+	}
+	if _, err := e.OrderByClientID(p, "unknown"); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("unknown client order id err %v", err)
+	}
+}
+
+func Test_Huobi_CancelAllOrders(t *testing.T) {
+	e, s := initHuobi()
+
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 || orders[0].OrderID != "59378" || orders[0].Status != market.Partial || orders[0].DealQuantity != 0.5 || orders[1].Side != string(market.Sell) {
+		t.Fatalf("open orders %+v", orders)
+	}
+
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Canceled) != 1 || report.Canceled[0].OrderID != "59378" || len(report.Failed) != 1 || !exchange.IsKind(report.Failed[0].Err, exchange.ErrNotFound) {
+		t.Fatalf("report %+v", report)
+	}
+	if _, body := s.request("POST", "/v1/order/orders/batchcancel"); body != `{"order-ids":["59378","59379"]}` {
+		t.Fatalf("batchcancel body %s", body)
+	}
+}
+
+func Test_Huobi_Auth(t *testing.T) {
+	e, _ := initHuobi()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	u := &user.User{API_KEY: huobiKey, API_SECRET: "wrongSecret"}
+	if _, err := e.ForUser(u).OrderByClientID(p, "c1"); !exchange.IsKind(err, exchange.ErrAuth) {
+		t.Fatalf("wrong secret err %v", err)
+	}
+}
+
+func Test_Huobi_CoinConstrain(t *testing.T) {
+	e, _ := initHuobi()
+	btc, eth := coin.GetCoin("BTC"), coin.GetCoin("ETH")
+
+	e.UpdateCoinConstrain()
+	if e.GetTxFee(btc) != 0.0005 || e.GetConfirmation(btc) != 2 || !e.CanWithdraw(btc) || !e.CanDeposit(btc) {
+		t.Fatalf("BTC fee %v confirmation %v withdraw %v deposit %v", e.GetTxFee(btc), e.GetConfirmation(btc), e.CanWithdraw(btc), e.CanDeposit(btc))
+	}
+	if e.CanWithdraw(eth) || !e.CanDeposit(eth) {
+		t.Fatalf("ETH withdraw %v deposit %v", e.CanWithdraw(eth), e.CanDeposit(eth))
+	}
+}
+
+func Test_Huobi_Withdraw(t *testing.T) {
+	e, s := initHuobi()
+	c := coin.GetCoin("BTC")
+
+	withdrawal, err := e.Withdraw(c, 0.1, "1PSRjPg53cX7hMRYAXGJnL8mqHtzmQgPUs", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.ID != "700" || withdrawal.Status != exchange.TransferPending {
+		t.Fatalf("withdrawal %+v", withdrawal)
+	}
+	if _, body := s.request("POST", "/v1/dw/withdraw/api/create"); !strings.Contains(body, `"amount":"0.1"`) || !strings.Contains(body, `"currency":"btc"`) || strings.Contains(body, "addr-tag") {
+		t.Fatalf("withdraw body %s", body)
+	}
+
+	if err := e.WithdrawalStatus(withdrawal); err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.Status != exchange.TransferCompleted || withdrawal.Fee != 0.0004 || withdrawal.TxHash == "" {
+		t.Fatalf("withdrawal status %+v", withdrawal)
+	}
+}
+
+var huobiOnce sync.Once
+var huobiInstance *huobi.Huobi
+var huobiServer *huobiStandIn
+
+// one stand-in and instance for all the tests, each instance starts the GC of a Redis Manager
+func initHuobi() (*huobi.Huobi, *huobiStandIn) {
+	huobiOnce.Do(func() {
+		pair.Init()
+		huobiServer = newHuobiStandIn()
+		config := &exchange.Config{}
+		config.API_KEY = huobiKey
+		config.API_SECRET = huobiSecret
+		config.API_URL = huobiServer.server.URL
+		huobiInstance = huobi.CreateHuobi(config)
+	})
+	return huobiInstance, huobiServer
+}
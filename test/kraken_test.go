@@ -1,71 +1,605 @@
 package test
 
 import (
+	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"../coin"
 	"../exchange"
 	"../exchange/kraken"
+	"../market"
 	"../pair"
+	"../user"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/gorilla/websocket"
 )
 
+const (
+	krakenKey    = "standInKey"
+	krakenSecret = "kQH5HW/8p1uGOVjbgWA7FunAmGO8lsSUXNsu3eow76sz84Q18fWxnyRzBHCd3pd5nE9qa99HAZtuZuj6F1huXg=="
+)
+
+// recorded responses of the Kraken API, "METHOD path": body
+var krakenResponses = map[string]string{
+	"GET /0/public/Time": `{"error":[],"result":{"unixtime":1616492376,"rfc1123":"Tue, 23 Mar 21 09:39:36 +0000"}}`,
+	"GET /0/public/Assets": `{"error":[],"result":{
+		"XXBT":{"aclass":"currency","altname":"XBT","decimals":10,"display_decimals":5},
+		"XETH":{"aclass":"currency","altname":"ETH","decimals":10,"display_decimals":5},
+		"ZUSD":{"aclass":"currency","altname":"USD","decimals":4,"display_decimals":2}}}`,
+	"GET /0/public/AssetPairs": `{"error":[],"result":{
+		"XETHXXBT":{"altname":"ETHXBT","wsname":"ETH/XBT","aclass_base":"currency","base":"XETH","aclass_quote":"currency","quote":"XXBT","lot":"unit",
+			"pair_decimals":5,"lot_decimals":8,"lot_multiplier":1,"leverage_buy":[],"leverage_sell":[],
+			"fees":[[0,0.26],[50000,0.24]],"fees_maker":[[0,0.16],[50000,0.14]],"fee_volume_currency":"ZUSD","margin_call":80,"margin_stop":40}}}`,
+	"GET /0/public/Ticker": `{"error":[],"result":{
+		"XETHXXBT":{"a":["0.031240","9","9.000"],"b":["0.031230","3","3.000"],"c":["0.031236","0.52000000"],"v":["2104.10000000","6389.72000000"],
+			"p":["0.031180","0.031052"],"t":[1900,5210],"l":["0.031010","0.030640"],"h":["0.031420","0.031775"],"o":"0.031101"},
+		"XXBTZUSD":{"a":["52130.00000","1","1.000"],"b":["52129.90000","2","2.000"],"c":["52130.00000","0.00150000"],"v":["1503.2","4120.9"],
+			"p":["51920.1","51634.7"],"t":[12040,35311],"l":["51230.0","50410.0"],"h":["52410.0","53050.0"],"o":"51720.0"}}}`,
+	"GET /0/public/Trades": `{"error":[],"result":{"XETHXXBT":[
+		["0.031236","0.52000000",1616663618.8127,"b","l",""],
+		["0.031190","2.04000000",1616663605.4459,"s","m",""]],"last":"1616663618812714451"}}`,
+	"GET /0/public/OHLC": `{"error":[],"result":{"XETHXXBT":[
+		[1616659200,"0.031050","0.031190","0.030980","0.031120","0.031088","412.73218400",611],
+		[1616662800,"0.031120","0.031420","0.031010","0.031236","0.031201","286.10452100",438]],"last":1616659200}}`,
+	"POST /0/private/Balance": `{"error":[],"result":{"XXBT":"1.5000000000","XETH":"10.0000000000","ZUSD":"5.0000"}}`,
+	"POST /0/private/DepositStatus": `{"error":[],"result":[
+		{"method":"Bitcoin","aclass":"currency","asset":"XXBT","refid":"QSKDTRG-AHJ2SJ-ECJ4LD","txid":"6544b41b607d8b2512baf801755a3a87b6890eacdb451be8a94059fb11f0a8d9",
+			"info":"2Myd4eaAW96ojk38A2uDK4FbioCayvkEgVq","amount":"0.78125000","fee":"0.0000000000","time":1616663600,"status":"Success"},
+		{"method":"Bitcoin","aclass":"currency","asset":"XXBT","refid":"QSKDTRG-OLDER","txid":"","info":"2Myd4eaAW96ojk38A2uDK4FbioCayvkEgVq",
+			"amount":"1.00000000","fee":"0.0000000000","time":1616000000,"status":"Success"}]}`,
+	"POST /0/private/DepositMethods": `{"error":[],"result":[{"method":"Bitcoin","limit":false,"fee":"0.0000000000","gen-address":true}]}`,
+	"POST /0/private/DepositAddresses": `{"error":[],"result":[
+		{"address":"2N9fRkx5JTWXWHmXzZtvhQsufvoYRMq9ExV","expiretm":"0","new":true},
+		{"address":"2NCpXUCEYr8ur9WXM1tAjZSem2w3aQeTcAo","expiretm":"0","new":true}]}`,
+	"POST /0/private/WithdrawInfo": `{"error":[],"result":{"method":"Bitcoin","limit":"332.00956139","amount":"0.72485000","fee":"0.00015000"}}`,
+	"POST /0/private/Withdraw":     `{"error":[],"result":{"refid":"AGBSO6T-UFMTTQ-I7KGS6"}}`,
+	"POST /0/private/WithdrawStatus": `{"error":[],"result":[
+		{"method":"Bitcoin","aclass":"currency","asset":"XXBT","refid":"AGBSO6T-UFMTTQ-I7KGS6","txid":"","info":"mzp6yUVMRxfasyfwzTZjjy38dHqMX7Z3GR",
+			"amount":"0.72485000","fee":"0.00015000","time":1616664000,"status":"Pending","status-prop":"onhold"},
+		{"method":"Bitcoin","aclass":"currency","asset":"XXBT","refid":"AGBZNBO-5P2XSB-RFVF6J","txid":"","info":"mzp6yUVMRxfasyfwzTZjjy38dHqMX7Z3GR",
+			"amount":"0.50000000","fee":"0.00015000","time":1616664500,"status":"Pending","status-prop":"canceled"}]}`,
+	"POST /0/private/TradeVolume": `{"error":[],"result":{"currency":"ZUSD","volume":"60000.0000",
+		"fees":{"XETHXXBT":{"fee":"0.2400","minfee":"0.1000","maxfee":"0.2600","nextfee":"0.2200","nextvolume":"100000.0000","tiervolume":"50000.0000"}},
+		"fees_maker":{"XETHXXBT":{"fee":"0.1400","minfee":"0.0000","maxfee":"0.1600","nextfee":"0.1200","nextvolume":"100000.0000","tiervolume":"50000.0000"}}}}`,
+	"POST /0/private/AddOrder": `{"error":[],"result":{"descr":{"order":"buy 1.00000000 ETHXBT @ limit 0.0349"},"txid":["OUF4EM-FRGI2-MQMWZD"]}}`,
+	"POST /0/private/QueryOrders": `{"error":[],"result":{"OUF4EM-FRGI2-MQMWZD":{"refid":null,"userref":0,"status":"open","opentm":1616665496.7808,"starttm":0,"expiretm":0,
+		"descr":{"pair":"ETHXBT","type":"buy","ordertype":"limit","price":"0.0349","price2":"0","leverage":"none","order":"buy 1.00000000 ETHXBT @ limit 0.0349","close":""},
+		"vol":"1.00000000","vol_exec":"0.40000000","cost":"0.01396","fee":"0.00003","price":"0.0349","stopprice":"0","limitprice":"0","misc":"","oflags":"fciq"}}}`,
+	"POST /0/private/CancelOrder":        `{"error":[],"result":{"count":1}}`,
+	"POST /0/private/CancelAll":          `{"error":[],"result":{"count":2}}`,
+	"POST /0/private/GetWebSocketsToken": `{"error":[],"result":{"token":"1Dwc4lzSwNWOAwkMdqhssNNFhs1ed606d1WcF3XfEMw","expires":900}}`,
+	"POST /0/private/TradesHistory": `{"error":[],"result":{"count":2,"trades":{
+		"TCWJEG-FL4SZ-3FKGH6":{"ordertxid":"OUF4EM-FRGI2-MQMWZD","pair":"XETHXXBT","time":1616667796.8802,"type":"buy","ordertype":"limit",
+			"price":"0.0349","cost":"0.01396","fee":"0.00003","vol":"0.40000000","margin":"0","misc":"","maker":true},
+		"TJKLXX-PSLSH-KGNGVO":{"ordertxid":"OUF4EM-FRGI2-MQMWZD","pair":"XETHXXBT","time":1616667800.1021,"type":"buy","ordertype":"limit",
+			"price":"0.0349","cost":"0.02094","fee":"0.00004","vol":"0.60000000","margin":"0","misc":"","maker":true}}}}`,
+	"POST /0/private/OpenOrders": `{"error":[],"result":{"open":{
+		"OQCLML-BW3P3-BUCMWZ":{"refid":null,"userref":0,"status":"open","opentm":1616666559.8974,"starttm":0,"expiretm":0,
+			"descr":{"pair":"ETHXBT","type":"sell","ordertype":"limit","price":"0.035","price2":"0","leverage":"none","order":"sell 2.00000000 ETHXBT @ limit 0.035","close":""},
+			"vol":"2.00000000","vol_exec":"0.50000000","cost":"0.0175","fee":"0.00003","price":"0.035","stopprice":"0","limitprice":"0","misc":"","oflags":"fciq"}}}}`,
+}
+
+// REST stand-in of Kraken answering the recorded responses, the private requests are verified by API-Sign
+type krakenStandIn struct {
+	server    *httptest.Server
+	lock      sync.Mutex
+	last      map[string]string   // "METHOD path": the last query or form
+	responses map[string]string   // "METHOD path": the body replacing the recorded response
+	next      map[string][]string // "METHOD path": the bodies answered one by one before the response
+}
+
+func newKrakenStandIn() *krakenStandIn {
+	s := &krakenStandIn{last: make(map[string]string), responses: make(map[string]string), next: make(map[string][]string)}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *krakenStandIn) query(method, path string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.last[method+" "+path]
+}
+
+// replace the response of the request until reset
+func (s *krakenStandIn) set(method, path, body string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses[method+" "+path] = body
+}
+
+// answer the bodies to the next requests one by one, then the response before
+func (s *krakenStandIn) setNext(method, path string, bodies ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.next[method+" "+path] = bodies
+}
+
+func (s *krakenStandIn) reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses = make(map[string]string)
+	s.next = make(map[string][]string)
+}
+
+func (s *krakenStandIn) serve(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	body, _ := ioutil.ReadAll(r.Body)
+	s.lock.Lock()
+	s.last[key] = r.URL.RawQuery + string(body)
+	response, replaced := s.responses[key]
+	if next := s.next[key]; len(next) > 0 {
+		response, replaced = next[0], true
+		s.next[key] = next[1:]
+	}
+	s.lock.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/0/private/") && !krakenSigned(r, string(body)) {
+		fmt.Fprint(w, `{"error":["EAPI:Invalid signature"]}`)
+		return
+	}
+	if !replaced {
+		response, replaced = krakenResponses[key]
+	}
+	if replaced {
+		fmt.Fprint(w, response)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, `{"error":["EGeneral:Unknown method"]}`)
+}
+
+// API-Sign is HMAC-SHA512 of (URI path + SHA256(nonce + POST data)) with the API Secret of the stand-in
+func krakenSigned(r *http.Request, body string) bool {
+	form, err := url.ParseQuery(body)
+	if err != nil || r.Header.Get("API-Key") != krakenKey {
+		return false
+	}
+	params := map[string]string{}
+	for k := range form {
+		params[k] = form.Get(k)
+	}
+	return r.Header.Get("API-Sign") == kraken.ComputeHmac512(r.URL.Path, params, krakenSecret)
+}
+
 /********************API********************/
-func Test_Kraken_Balance(t *testing.T) {
-	e := initKraken()
-	e.UpdateAllBalances()
-
-	for k, v := range e.GetPairs() { // pairs from binance
-		if v != nil {
-			base := e.GetBalance(v.Base)
-			target := e.GetBalance(v.Target)
-			log.Printf("%d  v:%v  #  %v:%v  %v:%v", k, v.Name, v.Base.Code, base, v.Target.Code, target)
+func Test_Kraken_Withdraw(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	btc := coin.GetCoin("BTC")
+
+	// the address is the withdrawal key name set up on the account
+	withdrawal, err := e.Withdraw(btc, 0.72485, "btc-cold", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.ID != "AGBSO6T-UFMTTQ-I7KGS6" || withdrawal.Fee != 0.00015 || withdrawal.Status != exchange.TransferPending || withdrawal.Sent() {
+		t.Fatalf("withdrawal %+v", withdrawal)
+	}
+	for _, path := range []string{"/0/private/WithdrawInfo", "/0/private/Withdraw"} {
+		if query, _ := url.ParseQuery(s.query("POST", path)); query.Get("key") != "btc-cold" || query.Get("asset") != "XXBT" || query.Get("amount") != "0.72485" {
+			t.Fatalf("%s query %v", path, query)
 		}
 	}
+
+	// on hold: still pending
+	if err := e.WithdrawalStatus(withdrawal); err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.Status != exchange.TransferPending || withdrawal.Sent() {
+		t.Fatalf("on hold %+v", withdrawal)
+	}
+
+	s.set("POST", "/0/private/WithdrawStatus", `{"error":[],"result":[
+		{"method":"Bitcoin","aclass":"currency","asset":"XXBT","refid":"AGBSO6T-UFMTTQ-I7KGS6","txid":"a3b4cb4ecd2ac8c4e5bb1a9c35a9a3e6d8a6e5d1b4b0f3f7e1b0d2c6b7a3e9f1",
+			"info":"mzp6yUVMRxfasyfwzTZjjy38dHqMX7Z3GR","amount":"0.72485000","fee":"0.00020000","time":1616664900,"status":"Success"},
+		{"method":"Bitcoin","aclass":"currency","asset":"XXBT","refid":"AGBZNBO-5P2XSB-RFVF6J","txid":"","info":"mzp6yUVMRxfasyfwzTZjjy38dHqMX7Z3GR",
+			"amount":"0.50000000","fee":"0.00015000","time":1616664500,"status":"Failure"}]}`)
+	if err := e.WithdrawalStatus(withdrawal); err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.Status != exchange.TransferCompleted || !withdrawal.Sent() || withdrawal.Fee != 0.0002 || withdrawal.TxHash == "" {
+		t.Fatalf("completed %+v", withdrawal)
+	}
+
+	failed := &exchange.WithdrawalResult{ID: "AGBZNBO-5P2XSB-RFVF6J", Coin: btc, Status: exchange.TransferPending}
+	if err := e.WithdrawalStatus(failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed.Status != exchange.TransferFailed || failed.Sent() {
+		t.Fatalf("failed %+v", failed)
+	}
+
+	unknown := &exchange.WithdrawalResult{ID: "AUNKNWN-000000-000000", Coin: btc}
+	if err := e.WithdrawalStatus(unknown); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("unknown withdrawal err %v", err)
+	}
 }
 
-func Test_Kraken_Withdraw(t *testing.T) {
-	e := initKraken()
-	c := coin.GetCoin("BTC")
-	amount := 0.0
-	addr := "Address"
-	tag := ""
-	if e.Withdraw(c, amount, addr, tag) {
-		log.Printf("Kraken %s Withdraw Successful!", c.Code)
+func Test_Kraken_GetDepositAddress(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	btc := coin.GetCoin("BTC")
+
+	// the first address of the first method
+	address, err := e.GetDepositAddress(btc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.Coin != btc || address.Address != "2N9fRkx5JTWXWHmXzZtvhQsufvoYRMq9ExV" || address.Tag != "" {
+		t.Fatalf("address %+v", address)
+	}
+	if form, _ := url.ParseQuery(s.query("POST", "/0/private/DepositAddresses")); form.Get("asset") != "XXBT" || form.Get("method") != "Bitcoin" || form.Get("new") != "" {
+		t.Fatalf("addresses form %v", form)
+	}
+
+	// no address yet, gen-address: a new one is generated
+	s.setNext("POST", "/0/private/DepositAddresses", `{"error":[],"result":[]}`,
+		`{"error":[],"result":[{"address":"2MvXfV2bjmKMkSJVG3ydFUfXrKnS6fQzCjT","expiretm":"0","new":true}]}`)
+	if address, err = e.GetDepositAddress(btc); err != nil || address.Address != "2MvXfV2bjmKMkSJVG3ydFUfXrKnS6fQzCjT" {
+		t.Fatalf("generated address %+v err %v", address, err)
+	}
+	if form, _ := url.ParseQuery(s.query("POST", "/0/private/DepositAddresses")); form.Get("new") != "true" {
+		t.Fatalf("generate form %v", form)
+	}
+
+	// the method can't generate an address
+	s.set("POST", "/0/private/DepositMethods", `{"error":[],"result":[{"method":"Bitcoin","limit":false,"fee":"0.0000000000","gen-address":false}]}`)
+	s.set("POST", "/0/private/DepositAddresses", `{"error":[],"result":[]}`)
+	if _, err := e.GetDepositAddress(btc); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("no address err %v", err)
+	}
+
+	s.set("POST", "/0/private/DepositMethods", `{"error":[],"result":[]}`)
+	if _, err := e.GetDepositAddress(btc); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("no method err %v", err)
+	}
+}
+
+func Test_Kraken_Transfers(t *testing.T) {
+	e, s := initKraken()
+	btc := coin.GetCoin("BTC")
+
+	// the deposit before since is dropped, the deposits and withdrawals are sorted by time
+	transfers, err := e.GetTransfers(btc, time.Unix(1616663000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 3 {
+		t.Fatalf("transfers %+v", transfers)
+	}
+	deposit, withdrawal, canceled := transfers[0], transfers[1], transfers[2]
+	if deposit.Type != exchange.Deposit || deposit.ID != "QSKDTRG-AHJ2SJ-ECJ4LD" || deposit.Coin != btc || deposit.Quantity != 0.78125 || deposit.Status != exchange.TransferCompleted {
+		t.Fatalf("deposit %+v", deposit)
+	}
+	if deposit.TxHash == "" || deposit.Address != "2Myd4eaAW96ojk38A2uDK4FbioCayvkEgVq" || deposit.Timestamp != 1616663600000 {
+		t.Fatalf("deposit %+v", deposit)
+	}
+	if withdrawal.Type != exchange.Withdrawal || withdrawal.Quantity != 0.72485 || withdrawal.Fee != 0.00015 || withdrawal.Status != exchange.TransferPending {
+		t.Fatalf("withdrawal %+v", withdrawal)
+	}
+	if canceled.Type != exchange.Withdrawal || canceled.Status != exchange.TransferCanceled {
+		t.Fatalf("canceled withdrawal %+v", canceled)
+	}
+	if query, _ := url.ParseQuery(s.query("POST", "/0/private/WithdrawStatus")); query.Get("asset") != "XXBT" {
+		t.Fatalf("withdraw status query %v", query)
+	}
+
+	if _, err := e.GetTransfers(nil, time.Time{}); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Fatalf("nil coin err %v", err)
 	}
 }
 
 func Test_Kraken_Trade(t *testing.T) {
-	e := initKraken()
-	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ZCL"))
-	rate := 0.00071300
-	quantity := 1.0
+	e, s := initKraken()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	order, err := e.LimitBuy(p, 1, 0.0349)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderID != "OUF4EM-FRGI2-MQMWZD" || order.Status != market.New || order.Rate != 0.0349 || order.Quantity != 1 {
+		t.Fatalf("placed %+v", order)
+	}
+	if query, _ := url.ParseQuery(s.query("POST", "/0/private/AddOrder")); query.Get("pair") != "ethxbt" || query.Get("type") != "buy" || query.Get("ordertype") != "limit" || query.Get("price") != "0.0349" {
+		t.Fatalf("place query %v", query)
+	}
+
+	if err := e.OrderStatus(order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != market.Partial || order.DealQuantity != 0.4 || order.DealRate != 0.0349 {
+		t.Fatalf("status %+v", order)
+	}
+
+	if err := e.CancelOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if query, _ := url.ParseQuery(s.query("POST", "/0/private/CancelOrder")); order.Status != market.Canceling || query.Get("txid") != order.OrderID {
+		t.Fatalf("cancel %+v query %v", order, query)
+	}
+}
+
+func Test_Kraken_PlaceOrder(t *testing.T) {
+	e, s := initKraken()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
 
-	order, err := e.LimitBuy(p, quantity, rate)
-	if err == nil {
-		log.Printf("Kraken Limit Buy: %v", order)
-	} else {
-		log.Printf("Kraken Limit Buy Err: %s", err)
+	// the market order has no price and no time in force
+	if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Sell, Type: market.MarketOrder, Quantity: 2, ClientOrderID: "1001"}); err != nil {
+		t.Fatal(err)
+	}
+	form, _ := url.ParseQuery(s.query("POST", "/0/private/AddOrder"))
+	if form.Get("ordertype") != "market" || form.Get("type") != "sell" || form.Get("volume") != "2" || form.Get("userref") != "1001" || form.Get("price") != "" || form.Get("timeinforce") != "" {
+		t.Fatalf("market form %v", form)
 	}
 
-	err = e.OrderStatus(order)
-	if err == nil {
-		log.Printf("Kraken Order Status: %v", order)
-	} else {
-		log.Printf("Kraken Order Status Err: %s", err)
+	if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.LimitOrder, TimeInForce: market.IOC, Quantity: 1, Rate: 0.0349}); err != nil {
+		t.Fatal(err)
+	}
+	form, _ = url.ParseQuery(s.query("POST", "/0/private/AddOrder"))
+	if form.Get("ordertype") != "limit" || form.Get("timeinforce") != "IOC" || form.Get("price") != "0.0349" || form.Get("oflags") != "" {
+		t.Fatalf("IOC form %v", form)
+	}
+
+	if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.LimitOrder, PostOnly: true, Quantity: 1, Rate: 0.0349}); err != nil {
+		t.Fatal(err)
+	}
+	form, _ = url.ParseQuery(s.query("POST", "/0/private/AddOrder"))
+	if form.Get("oflags") != "post" || form.Get("timeinforce") != "GTC" {
+		t.Fatalf("post only form %v", form)
 	}
 
-	err = e.CancelOrder(order)
-	if err == nil {
-		log.Printf("Kraken Cancel Order: %v", order)
-	} else {
-		log.Printf("Kraken Cancel Err: %s", err)
+	// price is the trigger, price2 is the limit
+	if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Sell, Type: market.StopLimitOrder, Quantity: 1, Rate: 0.0339, StopRate: 0.034}); err != nil {
+		t.Fatal(err)
+	}
+	form, _ = url.ParseQuery(s.query("POST", "/0/private/AddOrder"))
+	if form.Get("ordertype") != "stop-loss-limit" || form.Get("price") != "0.034" || form.Get("price2") != "0.0339" {
+		t.Fatalf("stop limit form %v", form)
+	}
+
+	if _, err := e.PlaceOrder(&market.OrderRequest{Pair: p, Side: market.Buy, Type: market.LimitOrder, TimeInForce: market.FOK, Quantity: 1, Rate: 0.0349}); !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Fatalf("FOK err %v", err)
+	}
+}
+
+func Test_Kraken_ListOpenOrders(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	open := func(txid string, opentm float64, pairCode, side string) string {
+		return fmt.Sprintf(`"%s":{"refid":null,"userref":0,"status":"open","opentm":%v,"starttm":0,"expiretm":0,`+
+			`"descr":{"pair":"%s","type":"%s","ordertype":"limit","price":"0.035","order":"%s 2.00000000 %s @ limit 0.035"},`+
+			`"vol":"2.00000000","vol_exec":"0.00000000","cost":"0","fee":"0","price":"0","misc":"","oflags":"fciq"}`, txid, opentm, pairCode, side, side, pairCode)
+	}
+	// the API answers a map, the orders are sorted by opentm
+	s.set("POST", "/0/private/OpenOrders", `{"error":[],"result":{"open":{`+strings.Join([]string{
+		open("OTHIRD-AAAAA-000003", 1616666700.1, "ETHXBT", "sell"),
+		open("OFIRST-AAAAA-000001", 1616666500.1, "ETHXBT", "buy"),
+		open("OOTHER-AAAAA-000009", 1616666600.1, "XBTUSD", "buy"),
+		open("OSECND-AAAAA-000002", 1616666600.1, "XETHXXBT", "sell"),
+	}, ",")+`}}}`)
+
+	orders, err := e.ListOpenOrders(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// XBTUSD is not in the pair list, it is skipped
+	if len(orders) != 3 || orders[0].OrderID != "OFIRST-AAAAA-000001" || orders[1].OrderID != "OSECND-AAAAA-000002" || orders[2].OrderID != "OTHIRD-AAAAA-000003" {
+		t.Fatalf("open orders %+v", orders)
+	}
+	if orders[0].Pair != p || orders[0].Side != string(market.Buy) || orders[1].Pair != p || orders[2].Side != string(market.Sell) {
+		t.Fatalf("open orders %+v", orders)
+	}
+
+	page, err := e.ListOpenOrders(&market.OrderQuery{Pair: p, Offset: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].OrderID != "OSECND-AAAAA-000002" {
+		t.Fatalf("page %+v", page)
+	}
+
+	other := pair.GetPair(coin.GetCoin("USD"), coin.GetCoin("BTC"))
+	if orders, err := e.ListOpenOrders(&market.OrderQuery{Pair: other}); err != nil || len(orders) != 0 {
+		t.Fatalf("orders of %s %+v err %v", other.Name, orders, err)
+	}
+}
+
+func Test_Kraken_CancelAllOrders(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	open := func(txid string, opentm float64) string {
+		return fmt.Sprintf(`"%s":{"refid":null,"userref":0,"status":"open","opentm":%v,"descr":{"pair":"ETHXBT","type":"buy","ordertype":"limit","price":"0.0349"},`+
+			`"vol":"1.00000000","vol_exec":"0.00000000","cost":"0","fee":"0","price":"0","misc":"","oflags":"fciq"}`, txid, opentm)
+	}
+	// CancelAll only answers the count, the order still open after it is failed
+	s.setNext("POST", "/0/private/OpenOrders",
+		`{"error":[],"result":{"open":{`+open("OFIRST-AAAAA-000001", 1616666500.1)+","+open("OSECND-AAAAA-000002", 1616666600.1)+`}}}`,
+		`{"error":[],"result":{"open":{`+open("OSECND-AAAAA-000002", 1616666600.1)+`}}}`)
+	report, err := e.CancelAllOrders(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Canceled) != 1 || report.Canceled[0].OrderID != "OFIRST-AAAAA-000001" || report.Canceled[0].Status != market.Canceling {
+		t.Fatalf("canceled %+v", report.Canceled)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].Order.OrderID != "OSECND-AAAAA-000002" || !exchange.IsKind(report.Failed[0].Err, exchange.ErrRejected) {
+		t.Fatalf("failed %+v", report.Failed)
+	}
+
+	// the pair is canceled order by order, CancelAll would cancel the other pairs
+	report, err = e.CancelAllOrders(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Canceled) != 1 || report.Canceled[0].OrderID != "OQCLML-BW3P3-BUCMWZ" || report.Err() != nil {
+		t.Fatalf("pair report %+v", report)
+	}
+	if form, _ := url.ParseQuery(s.query("POST", "/0/private/CancelOrder")); form.Get("txid") != "OQCLML-BW3P3-BUCMWZ" {
+		t.Fatalf("cancel form %v", form)
+	}
+
+	s.set("POST", "/0/private/CancelAll", `{"error":["EService:Unavailable"]}`)
+	if _, err := e.CancelAllOrders(nil); !exchange.IsKind(err, exchange.ErrNetwork) {
+		t.Fatalf("CancelAll err %v", err)
+	}
+}
+
+func Test_Kraken_GetFills(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// count is the total of the trades since start, the next page is requested by ofs
+	s.setNext("POST", "/0/private/TradesHistory", `{"error":[],"result":{"count":3,"trades":{
+		"TJKLXX-PSLSH-KGNGVO":{"ordertxid":"OUF4EM-FRGI2-MQMWZD","pair":"XETHXXBT","time":1616667800.1021,"type":"buy","ordertype":"limit",
+			"price":"0.0349","cost":"0.02094","fee":"0.00004","vol":"0.60000000","margin":"0","misc":"","maker":true},
+		"TZX2WP-XSEOP-FP7WYR":{"ordertxid":"OQCLML-BW3P3-BUCMWZ","pair":"XXBTZUSD","time":1616667790.5,"type":"sell","ordertype":"market",
+			"price":"54000.0","cost":"540.0","fee":"1.404","vol":"0.01000000","margin":"0","misc":"","maker":false}}}}`,
+		`{"error":[],"result":{"count":3,"trades":{
+		"TCWJEG-FL4SZ-3FKGH6":{"ordertxid":"OQCLML-BW3P3-BUCMWZ","pair":"XETHXXBT","time":1616667796.8802,"type":"sell","ordertype":"limit",
+			"price":"0.035","cost":"0.0175","fee":"0.00005","vol":"0.50000000","margin":"0","misc":"","maker":false}}}}`)
+	since := time.Unix(1616667700, 0)
+	trades, err := e.GetFills(p, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 || trades[0].TradeID != "TCWJEG-FL4SZ-3FKGH6" || trades[1].TradeID != "TJKLXX-PSLSH-KGNGVO" {
+		t.Fatalf("fills %+v", trades)
+	}
+	sell, buy := trades[0], trades[1]
+	if sell.Side != market.Sell || sell.Liquidity != market.LiquidityTaker || sell.OrderID != "OQCLML-BW3P3-BUCMWZ" || sell.Rate != 0.035 || sell.Quantity != 0.5 || sell.Timestamp != 1616667796880 {
+		t.Fatalf("sell fill %+v", sell)
+	}
+	if buy.Side != market.Buy || buy.Liquidity != market.LiquidityMaker || buy.Fee != 0.00004 || buy.FeeCoin != p.Base || buy.Pair != p {
+		t.Fatalf("buy fill %+v", buy)
+	}
+	if form, _ := url.ParseQuery(s.query("POST", "/0/private/TradesHistory")); form.Get("ofs") != "2" || form.Get("start") != "1616667700" {
+		t.Fatalf("second page form %v", form)
+	}
+
+	s.set("POST", "/0/private/TradesHistory", `{"error":["EGeneral:Permission denied"]}`)
+	if _, err := e.GetFills(nil, since); !exchange.IsKind(err, exchange.ErrAuth) {
+		t.Fatalf("GetFills err %v", err)
+	}
+}
+
+func Test_Kraken_OrdersStatus(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	queried := func(txid, status, volExec string) string {
+		return fmt.Sprintf(`"%s":{"status":"%s","reason":null,"descr":{"pair":"ETHXBT","type":"sell","ordertype":"limit","price":"0.035"},`+
+			`"vol":"2.00000000","vol_exec":"%s","price":"0.0351"}`, txid, status, volExec)
+	}
+	s.set("POST", "/0/private/QueryOrders", `{"error":[],"result":{`+strings.Join([]string{
+		queried("OPEND0-AAAAA-000000", "pending", "0.00000000"),
+		queried("OOPEN0-AAAAA-000000", "open", "0.00000000"),
+		queried("OOPEN1-AAAAA-000000", "open", "0.50000000"),
+		queried("OCLOSE-AAAAA-000000", "closed", "2.00000000"),
+		queried("OCANCL-AAAAA-000000", "canceled", "0.50000000"),
+		queried("OEXPIR-AAAAA-000000", "expired", "0.00000000"),
+	}, ",")+`}}`)
+
+	expected := []market.OrderStatus{market.New, market.New, market.Partial, market.Filled, market.Canceled, market.Expired}
+	txids := []string{"OPEND0-AAAAA-000000", "OOPEN0-AAAAA-000000", "OOPEN1-AAAAA-000000", "OCLOSE-AAAAA-000000", "OCANCL-AAAAA-000000", "OEXPIR-AAAAA-000000"}
+	orders := []*market.Order{}
+	for _, txid := range txids {
+		orders = append(orders, &market.Order{Pair: p, OrderID: txid, Status: market.Other})
+	}
+	for i := len(orders); i < 51; i++ { // the 51st is in the second batch
+		orders = append(orders, &market.Order{Pair: p, OrderID: fmt.Sprintf("OMISS%d-AAAAA-000000", i), Status: market.Other})
+	}
+
+	err := e.OrdersStatus(orders)
+	if !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("the orders not returned should be %s: %v", exchange.ErrNotFound, err)
+	}
+	for i, status := range expected {
+		if orders[i].Status != status {
+			t.Errorf("%s status should be %s: %+v", txids[i], status, orders[i])
+		}
+	}
+	if orders[2].DealQuantity != 0.5 || orders[2].DealRate != 0.0351 {
+		t.Errorf("partial %+v", orders[2])
+	}
+	for _, order := range orders[len(expected):] {
+		if order.Status != market.Other {
+			t.Errorf("the order not returned is changed: %+v", order)
+		}
+	}
+	if query, _ := url.ParseQuery(s.query("POST", "/0/private/QueryOrders")); query.Get("txid") != "OMISS50-AAAAA-000000" {
+		t.Errorf("the second batch query %v", query)
+	}
+
+	single := &market.Order{Pair: p, OrderID: "OMISS7-AAAAA-000000", Status: market.Other}
+	if err := e.OrderStatus(single); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Errorf("OrderStatus of the order not returned should be %s: %v", exchange.ErrNotFound, err)
+	}
+}
+
+func Test_Kraken_OrderByClientID(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	closed := func(txid string, userref int, pairCode, status string) string {
+		return fmt.Sprintf(`"%s":{"refid":null,"userref":%d,"status":"%s","opentm":1616665496.7808,"closetm":1616665596.1021,`+
+			`"descr":{"pair":"%s","type":"buy","ordertype":"limit","price":"0.0349","order":"buy 1.00000000 %s @ limit 0.0349"},`+
+			`"vol":"1.00000000","vol_exec":"1.00000000","cost":"0.0349","fee":"0.00006","price":"0.0349","misc":"","oflags":"fciq"}`, txid, userref, status, pairCode, pairCode)
+	}
+	s.set("POST", "/0/private/ClosedOrders", `{"error":[],"result":{"closed":{`+strings.Join([]string{
+		closed("OFILLD-AAAAA-001001", 1001, "ETHXBT", "closed"),
+		closed("OFILLD-AAAAA-001002", 1002, "ETHXBT", "closed"),
+		closed("OOTHER-AAAAA-001002", 1002, "XBTUSD", "closed"),
+		closed("OFIRST-AAAAA-001003", 1003, "ETHXBT", "closed"),
+		closed("OSECND-AAAAA-001003", 1003, "ETHXBT", "canceled"),
+	}, ",")+`},"count":5}}`)
+
+	order, err := e.OrderByClientID(p, "1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.OrderID != "OFILLD-AAAAA-001001" || order.ClientOrderID != "1001" || order.Pair != p || order.Status != market.Filled {
+		t.Fatalf("client order %+v", order)
+	}
+	if query, _ := url.ParseQuery(s.query("POST", "/0/private/ClosedOrders")); query.Get("userref") != "1001" {
+		t.Fatalf("closed orders query %v", query)
+	}
+
+	// the userref is shared with an order of another pair, the description tells them apart
+	if order, err := e.OrderByClientID(p, "1002"); err != nil || order.OrderID != "OFILLD-AAAAA-001002" {
+		t.Fatalf("client order %+v err %v", order, err)
+	}
+
+	// two orders of the pair, it is not known which one is placed, so it is not placed again
+	if _, err := e.OrderByClientID(p, "1003"); !exchange.IsKind(err, exchange.ErrUnknown) {
+		t.Fatalf("ambiguous client order err %v", err)
+	}
+
+	if _, err := e.OrderByClientID(p, "6gCrw2kRUAF9CvJDGP16IP"); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("unknown client order err %v", err)
+	}
+	if query, _ := url.ParseQuery(s.query("POST", "/0/private/OpenOrders")); query.Get("userref") == "" || query.Get("userref") == "6gCrw2kRUAF9CvJDGP16IP" {
+		t.Fatalf("the client order id is not hashed to the userref %v", query)
 	}
 }
 
 func Test_Kraken_OrderBook(t *testing.T) {
-	e := initKraken()
+	e, _ := initKraken()
 
 	for _, pair := range e.GetPairs() { // pairs from binance
 		if pair != nil {
@@ -78,19 +612,302 @@ func Test_Kraken_OrderBook(t *testing.T) {
 	}
 }
 
-/********************General********************/
-func Test_Kraken_ConstrainFetch(t *testing.T) {
-	e := initKraken()
+func Test_Kraken_Checksum(t *testing.T) {
+	asks := [][]string{{"0.05005", "0.00000500"}, {"0.05010", "0.00000500"}}
+	bids := [][]string{{"0.05000", "0.00000500"}, {"0.04995", "0.10000000"}}
+	// CRC32 of "5005500" + "5010500" + "5000500" + "499510000000"
+	if checksum := kraken.Checksum(asks, bids); checksum != 1850802939 {
+		t.Errorf("checksum %d", checksum)
+	}
+}
+
+func Test_Kraken_StreamOrderBook(t *testing.T) {
+	e, _ := initKraken()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	asks := [][]string{{"0.05005", "0.00000500"}, {"0.05010", "0.00000500"}}
+	bids := [][]string{{"0.05000", "0.00000600"}, {"0.04995", "0.10000000"}}
+	standIn := newWsStandIn(func(s *wsStandIn, conn *websocket.Conn, connection int) {
+		if s.read(conn) != nil { // subscribe
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`[336,{"as":[["0.05005","0.00000500","1582905487.684110"],["0.05010","0.00000500","1582905486.187983"]],`+
+			`"bs":[["0.05000","0.00000500","1582905487.439814"],["0.04995","0.10000000","1582905487.439814"]]},"book-10","ETH/XBT"]`))
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`[336,{"b":[["0.05000","0.00000600","1582905488.439814"]],"c":"%d"},"book-10","ETH/XBT"]`,
+			kraken.Checksum(asks, bids))))
+		conn.WriteMessage(websocket.TextMessage, []byte(`[336,{"a":[["0.05005","0.00000700","1582905489.684110"]],"c":"1"},"book-10","ETH/XBT"]`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`[336,{"b":[["0.04990","0.30000000","1582905489.784110"]]},"book-10","ETH/XBT"]`))
+		if s.read(conn) != nil || s.read(conn) != nil { // unsubscribe and subscribe
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`[336,{"as":[["0.06000","1.00000000","1582905490.684110"]],`+
+			`"bs":[["0.05900","2.00000000","1582905490.439814"]]},"book-10","ETH/XBT"]`))
+		for s.read(conn) == nil {
+		}
+	})
+	defer standIn.server.Close()
+	e.WS_URL = standIn.url()
+	defer func() { e.WS_URL = kraken.WS_URL }()
+
+	stop := make(chan struct{})
+	makers, err := e.StreamOrderBook([]*pair.Pair{p}, stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := []*market.Maker{}
+	for len(received) < 3 {
+		select {
+		case maker := <-makers:
+			received = append(received, maker)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d books", len(received))
+		}
+	}
+	close(stop)
+	for range makers {
+	}
+
+	if received[0].Pair != p || len(received[0].Bids) != 2 || received[0].Bids[0].Quantity != 0.000005 {
+		t.Errorf("snapshot %+v", received[0])
+	}
+	if received[1].Bids[0].Quantity != 0.000006 {
+		t.Errorf("verified update %+v", received[1])
+	}
+	// the update with the wrong checksum and the one after it are dropped until the new snapshot
+	if len(received[2].Asks) != 1 || received[2].Asks[0].Rate != 0.06 || len(received[2].Bids) != 1 || received[2].Bids[0].Rate != 0.059 {
+		t.Errorf("the book after the mismatch should be the new snapshot %+v", received[2])
+	}
+	if standIn.count(`{"event":"unsubscribe","pair":["ETH/XBT"],"subscription":{"depth":10,"name":"book"}}`) != 1 ||
+		standIn.count(`{"event":"subscribe","pair":["ETH/XBT"],"subscription":{"depth":10,"name":"book"}}`) != 2 {
+		t.Errorf("the book is not subscribed again")
+	}
+}
 
-	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("BCH"))
+func Test_Kraken_StreamUserData(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	s.set("POST", "/0/private/QueryOrders", `{"error":[],"result":{"OUF4EM-FRGI2-MQMWZD":{"status":"closed","reason":null,
+		"descr":{"pair":"ETHXBT","type":"buy","ordertype":"limit","price":"0.0349"},"vol":"1.00000000","vol_exec":"1.00000000","price":"0.0349"}}}`)
 
-	status := e.GetConstrainFetchMethod(p)
-	// "Binance ConstrainFetchMethod: %v",
+	standIn := newWsStandIn(func(s *wsStandIn, conn *websocket.Conn, connection int) {
+		if s.read(conn) != nil || s.read(conn) != nil { // openOrders and ownTrades
+			return
+		}
+		if connection == 1 {
+			conn.WriteMessage(websocket.TextMessage, []byte(`[[{"OUF4EM-FRGI2-MQMWZD":{"status":"open","vol":"1.00000000","vol_exec":"0.00000000",`+
+				`"descr":{"pair":"ETH/XBT","type":"buy","ordertype":"limit","price":"0.03490"}}}],"openOrders",{"sequence":1}]`))
+			conn.WriteMessage(websocket.TextMessage, []byte(`[[{"TCWJEG-FL4SZ-3FKGH6":{"ordertxid":"OUF4EM-FRGI2-MQMWZD","pair":"ETH/XBT",`+
+				`"time":"1616667796.880200","type":"buy","ordertype":"limit","price":"0.03490","cost":"0.01396","fee":"0.00003","vol":"0.40000000"}}],"ownTrades",{"sequence":1}]`))
+			return // dropped, the second trade and the close of the order are missed
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`[[],"openOrders",{"sequence":1}]`))
+		for s.read(conn) == nil {
+		}
+	})
+	defer standIn.server.Close()
+	e.WS_AUTH_URL = standIn.url()
+	defer func() { e.WS_AUTH_URL = kraken.WS_AUTH_URL }()
+
+	stop := make(chan struct{})
+	events, err := e.StreamUserData(stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := []*exchange.UserEvent{}
+	for len(received) < 4 {
+		select {
+		case event := <-events:
+			if event.Type != exchange.UserBalance {
+				received = append(received, event)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("received %d events", len(received))
+		}
+	}
+	close(stop)
+	for range events {
+	}
+
+	if received[0].Type != exchange.UserOrder || received[0].Order.Status != market.New {
+		t.Errorf("open order %+v", received[0])
+	}
+	if received[1].Type != exchange.UserFill || received[1].Trade.TradeID != "TCWJEG-FL4SZ-3FKGH6" {
+		t.Errorf("fill %+v", received[1])
+	}
+	// after the reconnect: the missed fill by TradesHistory without the first one again, then the order closed while disconnected
+	if received[2].Type != exchange.UserFill || received[2].Trade.TradeID != "TJKLXX-PSLSH-KGNGVO" || received[2].Trade.Quantity != 0.6 {
+		t.Errorf("backfilled fill %+v", received[2])
+	}
+	if received[3].Type != exchange.UserOrder || received[3].Order.OrderID != "OUF4EM-FRGI2-MQMWZD" || received[3].Order.Status != market.Filled {
+		t.Errorf("reconciled order %+v", received[3])
+	}
+}
+
+func Test_Kraken_Ticker(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// XXBTZUSD is not a pair of the stand-in, it is skipped
+	tickers, err := e.Tickers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickers) != 1 || tickers[0].Pair != p {
+		t.Fatalf("tickers %+v", tickers)
+	}
+	// the index 1 of high, low, volume and vwap is the last 24 hours, c is the last trade
+	ticker := tickers[0]
+	if ticker.Bid != 0.03123 || ticker.Ask != 0.03124 || ticker.Last != 0.031236 || ticker.High != 0.031775 || ticker.Low != 0.03064 || ticker.Volume != 6389.72 {
+		t.Fatalf("ticker %+v", ticker)
+	}
+	if math.Abs(ticker.QuoteVolume-6389.72*0.031052) > 1e-9 || ticker.Timestamp == 0 {
+		t.Fatalf("ticker %+v", ticker)
+	}
+	if query := s.query("GET", "/0/public/Ticker"); query != "pair=ETHXBT" {
+		t.Fatalf("ticker query %s", query)
+	}
+
+	if ticker, err := e.Ticker(p); err != nil || ticker.Pair != p || ticker.Last != 0.031236 {
+		t.Fatalf("ticker %+v err %v", ticker, err)
+	}
+
+	// a pair delisted by Kraken
+	s.set("GET", "/0/public/Ticker", `{"error":["EQuery:Unknown asset pair"]}`)
+	if _, err := e.Ticker(p); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Fatalf("unknown pair err %v", err)
+	}
+	// the result without the pair
+	s.set("GET", "/0/public/Ticker", `{"error":[],"result":{}}`)
+	if _, err := e.Ticker(p); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Fatalf("missing pair err %v", err)
+	}
+}
+
+func Test_Kraken_Fees(t *testing.T) {
+	e, s := initKraken()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// the lowest tier of AssetPairs before UpdateFees
+	if fee := e.GetTradeFee(p); fee.Source != exchange.SourceStatic || math.Abs(fee.Taker-0.0026) > 1e-12 || math.Abs(fee.Maker-0.0016) > 1e-12 {
+		t.Fatalf("schedule fee %+v", fee)
+	}
+
+	if err := e.UpdateFees(); err != nil {
+		t.Fatal(err)
+	}
+	if fee := e.GetTradeFee(p); fee.Source != exchange.SourceAPI || math.Abs(fee.Taker-0.0024) > 1e-12 || math.Abs(fee.Maker-0.0014) > 1e-12 || fee.Timestamp == 0 {
+		t.Fatalf("account fee %+v", fee)
+	}
+	if query, _ := url.ParseQuery(s.query("POST", "/0/private/TradeVolume")); query.Get("pair") != "ETHXBT" || query.Get("fee-info") != "true" {
+		t.Fatalf("trade volume query %v", query)
+	}
+}
+
+func Test_Kraken_RecentTrades(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+	since := time.Unix(1616663600, 0)
+
+	// less than 1000 trades, the "last" cursor is not requested
+	// the trades before 2022 have no trade id
+	trades, err := e.RecentTrades(p, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 2 {
+		t.Fatalf("trades %+v", trades)
+	}
+	sell, buy := trades[0], trades[1]
+	if sell.Side != market.Sell || sell.Rate != 0.03119 || sell.Quantity != 2.04 || sell.Timestamp != 1616663605445 || sell.TradeID != "" {
+		t.Fatalf("sell trade %+v", sell)
+	}
+	if buy.Side != market.Buy || buy.Rate != 0.031236 || buy.Quantity != 0.52 || buy.Pair != p || buy.Liquidity != market.LiquidityTaker {
+		t.Fatalf("buy trade %+v", buy)
+	}
+	if query, _ := url.ParseQuery(s.query("GET", "/0/public/Trades")); query.Get("pair") != "ETHXBT" || query.Get("since") != fmt.Sprint(since.UnixNano()) {
+		t.Fatalf("trades query %v", query)
+	}
+
+	// a full page of 1000 trades, the next page is requested since the "last" cursor
+	page := make([]string, 1000)
+	for i := range page {
+		page[i] = fmt.Sprintf(`["0.0312%02d","0.10000000",%d.25,"b","l","",%d]`, i%100, 1616663000+i, 30000+i)
+	}
+	s.setNext("GET", "/0/public/Trades",
+		`{"error":[],"result":{"XETHXXBT":[`+strings.Join(page, ",")+`],"last":"1616663999250000000"}}`,
+		`{"error":[],"result":{"XETHXXBT":[["0.031301","3.00000000",1616664002.0071,"s","l","",31000]],"last":"1616664002007100000"}}`)
+	trades, err = e.RecentTrades(p, time.Unix(1616663000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trades) != 1001 || trades[0].TradeID != "30000" || trades[999].TradeID != "30999" {
+		t.Fatalf("paged trades %d", len(trades))
+	}
+	if last := trades[1000]; last.TradeID != "31000" || last.Side != market.Sell || last.Rate != 0.031301 || last.Timestamp != 1616664002007 {
+		t.Fatalf("last trade %+v", last)
+	}
+	if query, _ := url.ParseQuery(s.query("GET", "/0/public/Trades")); query.Get("since") != "1616663999250000000" {
+		t.Fatalf("next page query %v", query)
+	}
+}
+
+func Test_Kraken_Candles(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	p := pair.GetPair(coin.GetCoin("BTC"), coin.GetCoin("ETH"))
+
+	// candle: [time, open, high, low, close, vwap, volume, count]
+	// the last candle is not committed yet, "last" is the id of the committed one
+	candles, err := e.Candles(p, time.Hour, time.Unix(1616661000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 2 || candles[0].Timestamp != 1616659200000 || candles[1].Timestamp != 1616662800000 {
+		t.Fatalf("candles %+v", candles)
+	}
+	candle := candles[0]
+	if candle.Pair != p || candle.Open != 0.03105 || candle.High != 0.03119 || candle.Low != 0.03098 || candle.Close != 0.03112 || candle.Volume != 412.732184 {
+		t.Fatalf("candle %+v", candle)
+	}
+	if math.Abs(candle.QuoteVolume-412.732184*0.031088) > 1e-9 {
+		t.Fatalf("candle quote volume %v", candle.QuoteVolume)
+	}
+	if candles[1].Close != 0.031236 || candles[1].Volume != 286.104521 {
+		t.Fatalf("current candle %+v", candles[1])
+	}
+	// since the start of the first candle, the OHLC since is exclusive
+	if query, _ := url.ParseQuery(s.query("GET", "/0/public/OHLC")); query.Get("pair") != "ETHXBT" || query.Get("interval") != "60" || query.Get("since") != "1616659199" {
+		t.Fatalf("candles query %v", query)
+	}
+
+	// 2 hours is not an interval of OHLC, the candle is built from the recent trades
+	candles, err = e.Candles(p, 2*time.Hour, time.Unix(1616663600, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candles) != 1 || candles[0].Timestamp != 1616659200000 || candles[0].Open != 0.03119 || candles[0].Close != 0.031236 || candles[0].Low != 0.03119 || candles[0].Volume != 2.56 {
+		t.Fatalf("candles from trades %+v", candles)
+	}
+	if query, _ := url.ParseQuery(s.query("GET", "/0/public/Trades")); query.Get("since") != fmt.Sprint(time.Unix(1616659200, 0).UnixNano()) {
+		t.Fatalf("trades query %v", query)
+	}
+}
+
+/********************General********************/
+func Test_Kraken_Capabilities(t *testing.T) {
+	e, _ := initKraken()
+
+	status := e.GetCapabilities()
+	if err := exchange.Require(e, exchange.FeatureLimitOrder); err != nil {
+		t.Error(err)
+	}
 	spew.Dump(status)
 }
 
 func Test_Kraken_Constrain(t *testing.T) {
-	e := initKraken()
+	e, _ := initKraken()
 
 	pair := pair.GetPairByKey("BTC|ETH")
 	coinName := coin.GetCoin(pair.Target.Code)
@@ -102,8 +919,113 @@ func Test_Kraken_Constrain(t *testing.T) {
 	log.Printf("Deposit: %v", e.CanDeposit(coinName))
 }
 
+func Test_Kraken_GetCode(t *testing.T) {
+	e := &kraken.Kraken{}
+	e.FixSymbol()
+
+	cases := map[string]string{
+		"XXBT":   "BTC",
+		"XBT":    "BTC",
+		"XBT.M":  "BTC",
+		"ZUSD":   "USD",
+		"USD.M":  "USD",
+		"XETH":   "ETH",
+		"ETH2.S": "ETH2",
+		"DOT.S":  "DOT",
+		"dot":    "DOT",
+		"XTZ":    "XTZ",
+		"ADA":    "ADA",
+	}
+	for symbol, code := range cases {
+		if got := e.GetCode(symbol); got != code {
+			t.Errorf("%s: expect %s, got %s", symbol, code, got)
+		}
+	}
+}
+
+func Test_Kraken_Signature(t *testing.T) {
+	//the example of the Kraken REST API document
+	secret := "kQH5HW/8p1uGOVjbgWA7FunAmGO8lsSUXNsu3eow76sz84Q18fWxnyRzBHCd3pd5nE9qa99HAZtuZuj6F1huXg=="
+	mapParams := map[string]string{
+		"nonce":     "1616492376594",
+		"ordertype": "limit",
+		"pair":      "XBTUSD",
+		"price":     "37500",
+		"type":      "buy",
+		"volume":    "1.25",
+	}
+
+	signature := kraken.ComputeHmac512("/0/private/AddOrder", mapParams, secret)
+	if signature != "4/dpxb3iT4tp/ZCVEwSnEsLxx0bqyhLpdfOpc6fn7OR8+UClSV5n9E6aSS8MPtnRfp32bAb0nmbRn6H8ndwLUQ==" {
+		t.Errorf("unexpected signature: %s", signature)
+	}
+}
+
+func Test_Kraken_TwoFactor(t *testing.T) {
+	e, s := initKraken()
+
+	password := e.ForUser(&user.User{API_KEY: krakenKey, API_SECRET: krakenSecret, Two_Factor: &user.TwoFactor{Mode: user.TwoFactorPassword, Password: "Password"}})
+	if err := password.UpdateAllBalances(); err != nil {
+		t.Fatal(err)
+	}
+	if form, _ := url.ParseQuery(s.query("POST", "/0/private/Balance")); form.Get("otp") != "Password" {
+		t.Fatalf("balance form %v", form)
+	}
+
+	// the TOTP code can't be computed, the request is not sent
+	broken := e.ForUser(&user.User{API_KEY: krakenKey, API_SECRET: krakenSecret, Two_Factor: &user.TwoFactor{Mode: user.TwoFactorTOTP, Secret: "not base32!"}})
+	if err := broken.UpdateAllBalances(); !exchange.IsKind(err, exchange.ErrAuth) {
+		t.Fatalf("UpdateAllBalances err %v", err)
+	}
+	if _, err := broken.ListOpenOrders(nil); !exchange.IsKind(err, exchange.ErrAuth) {
+		t.Fatalf("ListOpenOrders err %v", err)
+	}
+}
+
+func Test_Kraken_Balance(t *testing.T) {
+	e, s := initKraken()
+	defer s.reset()
+	btc, eth, usd := coin.GetCoin("BTC"), coin.GetCoin("ETH"), coin.GetCoin("USD")
+
+	if err := e.UpdateAllBalances(); err != nil {
+		t.Fatal(err)
+	}
+	balances := map[string]*market.Balance{}
+	for _, balance := range e.GetBalances() {
+		balances[balance.Coin.Code] = balance
+	}
+	// the open sell order holds the remaining 1.5 ETH
+	if b := balances[eth.Code]; b == nil || b.Total != 10 || b.Locked != 1.5 || b.Available != 8.5 {
+		t.Fatalf("ETH balance %+v", b)
+	}
+	if e.GetBalance(btc) != 1.5 || e.GetBalance(usd) != 5 {
+		t.Fatalf("balances BTC %v USD %v", e.GetBalance(btc), e.GetBalance(usd))
+	}
+
+	// the coin not returned anymore is removed
+	s.set("POST", "/0/private/Balance", `{"error":[],"result":{"XXBT":"1.5000000000","XETH":"10.0000000000"}}`)
+	if err := e.UpdateAllBalances(); err != nil {
+		t.Fatal(err)
+	}
+	for _, balance := range e.GetBalances() {
+		if balance.Coin == usd {
+			t.Fatalf("the USD balance is kept %+v", balance)
+		}
+	}
+
+	// the open orders are not available, the balances are kept
+	s.set("POST", "/0/private/Balance", `{"error":[],"result":{"XXBT":"0.1000000000","XETH":"10.0000000000"}}`)
+	s.set("POST", "/0/private/OpenOrders", `{"error":["EService:Unavailable"]}`)
+	if err := e.UpdateAllBalances(); !exchange.IsKind(err, exchange.ErrNetwork) {
+		t.Fatalf("UpdateAllBalances err %v", err)
+	}
+	if e.GetBalance(btc) != 1.5 || e.GetBalance(eth) != 8.5 {
+		t.Fatalf("the balances are changed BTC %v ETH %v", e.GetBalance(btc), e.GetBalance(eth))
+	}
+}
+
 func Test_Kraken_GetMaker(t *testing.T) {
-	e := initKraken()
+	e, _ := initKraken()
 
 	pair := pair.GetPairByKey("BTC|ETH")
 	maker, _ := e.GetMaker(pair)
@@ -111,20 +1033,21 @@ func Test_Kraken_GetMaker(t *testing.T) {
 	log.Printf("Maker: %v", maker)
 }
 
-/* Modify Config
-Redis Server: xx.xx.xx.xx:xxxx
-Redis DB: DB Number
-API Key: Exchange API Key
-API Secret: Exchange API Secret Key */
-func initKraken() exchange.Exchange {
-	pair.Init()
-	config := &exchange.Config{}
-	config.RedisServer = "RedisAddr:Port"
-	config.RedisDB = 0
-	config.API_KEY = "EUWBC321YTZGtSIaGah+6WHSM1uubz9vvvrUWWVIUniM9uiRaxoSiSM7"
-	config.API_SECRET = "N0eRNIGJ92kNxaa1xxb95YPvli/4Oi5un0AWDsfWWVqXH0yMNaZr5CdqRzHVSKtz/CgrAfJSCm/RFhxVE1mazQ=="
-	ex := kraken.CreateKraken(config)
-	log.Printf("Initial [ %v ]", ex.GetName())
-	config = nil
-	return ex
+var krakenOnce sync.Once
+var krakenInstance *kraken.Kraken
+var krakenServer *krakenStandIn
+
+// one stand-in and instance for all the tests
+func initKraken() (*kraken.Kraken, *krakenStandIn) {
+	krakenOnce.Do(func() {
+		pair.Init()
+		krakenServer = newKrakenStandIn()
+		config := &exchange.Config{}
+		config.API_KEY = krakenKey
+		config.API_SECRET = krakenSecret
+		config.API_URL = krakenServer.server.URL + "/0"
+		krakenInstance = kraken.CreateKraken(config)
+		log.Printf("Initial [ %v ]", krakenInstance.GetName())
+	})
+	return krakenInstance, krakenServer
 }
@@ -0,0 +1,160 @@
+package test
+
+import (
+	"log"
+	"testing"
+
+	"../exchange"
+	"../exchange/binance"
+	"../exchange/bitrue"
+	"../exchange/blank"
+	"../exchange/cryptopia"
+	"../exchange/fcoin"
+	"../exchange/huobi"
+	"../exchange/kraken"
+	"../market"
+	"../user"
+)
+
+// exchange stand-in, only the functions used in the tests are implemented
+type capabilityStandIn struct {
+	exchange.Exchange
+	name         exchange.ExchangeName
+	capabilities *exchange.Capabilities
+}
+
+func (e *capabilityStandIn) GetName() exchange.ExchangeName {
+	return e.name
+}
+
+func (e *capabilityStandIn) GetCapabilities() *exchange.Capabilities {
+	return e.capabilities
+}
+
+/********************General********************/
+func Test_Manager_SupportExchanges(t *testing.T) {
+	exMan := exchange.CreateExchangeManager()
+
+	support := exMan.GetSupportExchanges()
+	log.Printf("Support Exchanges: %v", support)
+
+	for _, name := range []exchange.ExchangeName{binance.NAME, bitrue.NAME, blank.NAME, cryptopia.NAME, fcoin.NAME, huobi.NAME, kraken.NAME} {
+		if _, ok := exchange.GetFactory(name); !ok {
+			t.Errorf("%s is not registered", name)
+		}
+	}
+	for i := 1; i < len(support); i++ {
+		if support[i-1] >= support[i] {
+			t.Errorf("Support Exchanges are not sorted: %v", support)
+		}
+	}
+}
+
+func Test_Manager_Unregistered(t *testing.T) {
+	exMan := exchange.CreateExchangeManager()
+
+	if _, err := exMan.Build(exchange.ExchangeName("UNKNOWN"), &exchange.Config{}); err == nil {
+		t.Errorf("Build an unregistered exchange should fail")
+	}
+	if ex := exMan.GetStr("UNKNOWN"); ex != nil {
+		t.Errorf("GetStr an unregistered exchange should be nil: %v", ex)
+	}
+}
+
+func Test_Manager_Capabilities(t *testing.T) {
+	exMan := exchange.CreateExchangeManager()
+
+	full := &capabilityStandIn{name: "FULL_STANDIN", capabilities: &exchange.Capabilities{
+		OrderTypes: []market.OrderType{market.LimitOrder, market.MarketOrder},
+		CancelAll:  true,
+		ListOrders: true,
+		Withdraw:   true,
+		FeeSource:  exchange.SourceAPI,
+	}}
+	limit := &capabilityStandIn{name: "LIMIT_STANDIN", capabilities: &exchange.Capabilities{
+		OrderTypes: []market.OrderType{market.LimitOrder},
+		FeeSource:  exchange.SourceStatic,
+	}}
+	exMan.Add(full)
+	exMan.Add(limit)
+
+	if err := exchange.Require(full, exchange.FeatureMarketOrder, exchange.FeatureCancelAll, exchange.FeatureFeeFromAPI); err != nil {
+		t.Errorf("%s should support the features: %v", full.name, err)
+	}
+	err := exchange.Require(limit, exchange.FeatureLimitOrder, exchange.FeatureCancelAll, exchange.FeatureWithdraw)
+	if !exchange.IsKind(err, exchange.ErrUnsupported) {
+		t.Errorf("%s should not support the features: %v", limit.name, err)
+	}
+	log.Printf("Require: %v", err)
+
+	for _, ex := range exMan.FilterByCapability(exchange.FeatureListOrders) {
+		if ex.GetName() == limit.name {
+			t.Errorf("%s does not support ListOrders", limit.name)
+		}
+	}
+	found := false
+	for _, ex := range exMan.FilterByCapability(exchange.FeatureLimitOrder) {
+		if ex.GetName() == limit.name {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("%s supports LimitOrder", limit.name)
+	}
+}
+
+func Test_Manager_Accounts(t *testing.T) {
+	exMan := exchange.CreateExchangeManager()
+
+	first := &capabilityStandIn{name: "ACCOUNT_STANDIN"}
+	second := &capabilityStandIn{name: "ACCOUNT_STANDIN"}
+	exMan.AddAccount("first", first)
+	exMan.AddAccount("second", second)
+
+	if ex := exMan.GetAccount("ACCOUNT_STANDIN", "first"); ex != first {
+		t.Errorf("the first account is replaced: %v", ex)
+	}
+	if ex := exMan.GetAccount("ACCOUNT_STANDIN", "second"); ex != second {
+		t.Errorf("the second account is not added: %v", ex)
+	}
+	if ex := exMan.Get("ACCOUNT_STANDIN"); ex != first {
+		t.Errorf("Get should return the first added account without a default one: %v", ex)
+	}
+
+	// the wrapper keeps the balance cache, it is not created again by every call
+	v2 := exMan.GetV2("ACCOUNT_STANDIN")
+	if v2 == nil || exchange.Legacy(v2) != first || exMan.GetV2("ACCOUNT_STANDIN") != v2 {
+		t.Errorf("GetV2 should return the same wrapper of the first account: %v", v2)
+	}
+	if v2 := exMan.GetAccountV2("ACCOUNT_STANDIN", "second"); v2 == nil || exchange.Legacy(v2) != second {
+		t.Errorf("GetAccountV2 should return the wrapper of the second account: %v", v2)
+	}
+
+	again := &capabilityStandIn{name: "ACCOUNT_STANDIN"}
+	exMan.AddAccount("first", again)
+	count := 0
+	for _, ex := range exMan.GetExchanges() {
+		if ex.GetName() == "ACCOUNT_STANDIN" {
+			count++
+		}
+	}
+	if count != 2 || exMan.GetAccount("ACCOUNT_STANDIN", "first") != again {
+		t.Errorf("adding the same account should replace it, %d exchanges", count)
+	}
+	if v2 := exMan.GetAccountV2("ACCOUNT_STANDIN", "first"); exchange.Legacy(v2) != again {
+		t.Errorf("the wrapper of the replaced account is kept: %v", v2)
+	}
+}
+
+func Test_Manager_CredentialKey(t *testing.T) {
+	u := &user.User{API_KEY: "Key", API_SECRET: "Secret"}
+	rotated := &user.User{API_KEY: "Key", API_SECRET: "Rotated"}
+	twoFactor := &user.User{API_KEY: "Key", API_SECRET: "Secret", Two_Factor: &user.TwoFactor{Mode: user.TwoFactorPassword, Password: "Password"}}
+
+	if u.CredentialKey() == rotated.CredentialKey() || u.CredentialKey() == twoFactor.CredentialKey() {
+		t.Errorf("the users with different credentials share the key")
+	}
+	if u.CredentialKey() != (&user.User{API_KEY: "Key", API_SECRET: "Secret"}).CredentialKey() {
+		t.Errorf("the key of the same credentials should not change")
+	}
+}
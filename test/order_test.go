@@ -0,0 +1,253 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"testing"
+
+	"../coin"
+	"../exchange"
+	"../exchange/blank"
+	"../market"
+	"../pair"
+)
+
+// v1 exchange stand-in which loses the response of the first placed order
+type lostResponseStandIn struct {
+	exchange.Exchange
+	placed  map[string]*market.Order
+	attempt int
+}
+
+func (e *lostResponseStandIn) GetName() exchange.ExchangeName {
+	return blank.NAME
+}
+
+func (e *lostResponseStandIn) PlaceOrder(request *market.OrderRequest) (*market.Order, error) {
+	e.attempt++
+	order := &market.Order{OrderID: "1", ClientOrderID: request.ClientOrderID, Status: market.New}
+	e.placed[request.ClientOrderID] = order
+	if e.attempt == 1 {
+		return nil, errors.New("Blank PlaceOrder Unmarshal Err: read tcp: i/o timeout")
+	}
+	return order, nil
+}
+
+func (e *lostResponseStandIn) OrderByClientID(p *pair.Pair, clientOrderID string) (*market.Order, error) {
+	if order, ok := e.placed[clientOrderID]; ok {
+		return order, nil
+	}
+	return nil, exchange.Errorf(blank.NAME, "OrderByClientID", exchange.ErrNotFound, "client order id %s is not found", clientOrderID)
+}
+
+// v1 exchange stand-in keeping the open orders in memory, order "bad" can't be canceled
+type openOrdersStandIn struct {
+	exchange.Exchange
+	lock     sync.Mutex
+	open     map[string]*market.Order
+	canceled int
+}
+
+func (e *openOrdersStandIn) ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	orders := []*market.Order{}
+	for _, order := range e.open {
+		orders = append(orders, order)
+	}
+	return exchange.PageOrders(orders, query), nil
+}
+
+func (e *openOrdersStandIn) CancelOrder(order *market.Order) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if _, ok := e.open[order.OrderID]; !ok {
+		return errors.New("order is already canceled")
+	}
+	if order.OrderID == "bad" {
+		return errors.New("order is locked")
+	}
+	delete(e.open, order.OrderID)
+	e.canceled++
+	return nil
+}
+
+// v1 exchange stand-in answering OrderStatus from the orders in memory
+type orderStatusStandIn struct {
+	exchange.Exchange
+	orders map[string]*market.Order
+}
+
+func (e *orderStatusStandIn) OrderStatus(order *market.Order) error {
+	o, ok := e.orders[order.OrderID]
+	if !ok {
+		return exchange.Errorf(blank.NAME, "OrderStatus", exchange.ErrNotFound, "order %s is not found", order.OrderID)
+	}
+	order.Status = o.Status
+	order.DealQuantity = o.DealQuantity
+	return nil
+}
+
+/********************General********************/
+func Test_Order_CheckRequest(t *testing.T) {
+	capabilities := &exchange.Capabilities{
+		OrderTypes:  []market.OrderType{market.LimitOrder, market.MarketOrder},
+		TimeInForce: []market.TimeInForce{market.GTC, market.IOC},
+	}
+	p := &pair.Pair{Name: "BTC|ETH", Base: &coin.Coin{Code: "BTC"}, Target: &coin.Coin{Code: "ETH"}}
+
+	request := &market.OrderRequest{Pair: p, Side: market.Buy, Quantity: 1, Rate: 0.03}
+	if err := exchange.CheckOrderRequest(blank.NAME, capabilities, request); err != nil {
+		t.Errorf("limit order should pass: %v", err)
+	}
+	if request.Type != market.LimitOrder || request.TimeInForce != market.GTC {
+		t.Errorf("default type and time in force are not set: %+v", request)
+	}
+
+	cases := []struct {
+		request *market.OrderRequest
+		kind    exchange.ErrorKind
+	}{
+		{&market.OrderRequest{Pair: p, Side: market.Sell, Type: market.LimitOrder, TimeInForce: market.IOC, Quantity: 1, Rate: 0.03}, ""},
+		{&market.OrderRequest{Pair: p, Side: market.Sell, Type: market.MarketOrder, TimeInForce: market.FOK, Quantity: 1}, ""},
+		{&market.OrderRequest{Pair: p, Side: market.Sell, Type: market.LimitOrder, TimeInForce: market.FOK, Quantity: 1, Rate: 0.03}, exchange.ErrUnsupported},
+		{&market.OrderRequest{Pair: p, Side: market.Sell, Type: market.StopLimitOrder, Quantity: 1, Rate: 0.03, StopRate: 0.031}, exchange.ErrUnsupported},
+		{&market.OrderRequest{Pair: p, Side: market.Sell, Type: market.LimitOrder, PostOnly: true, Quantity: 1, Rate: 0.03}, exchange.ErrUnsupported},
+		{&market.OrderRequest{Pair: p, Side: market.Sell, Type: market.LimitOrder, Quantity: 1}, exchange.ErrRejected},
+		{&market.OrderRequest{Pair: p, Side: "Hold", Quantity: 1, Rate: 0.03}, exchange.ErrRejected},
+		{&market.OrderRequest{Side: market.Buy, Quantity: 1, Rate: 0.03}, exchange.ErrRejected},
+	}
+	for i, c := range cases {
+		err := exchange.CheckOrderRequest(blank.NAME, capabilities, c.request)
+		if exchange.KindOf(err) != c.kind {
+			t.Errorf("case %d: expect %q got %v", i, c.kind, err)
+		}
+		log.Printf("case %d: %v", i, err)
+	}
+}
+
+func Test_Order_SubmitOrder(t *testing.T) {
+	standIn := &lostResponseStandIn{placed: make(map[string]*market.Order)}
+	ex := exchange.Upgrade(standIn)
+
+	request := &market.OrderRequest{Side: market.Buy, Quantity: 1, Rate: 0.03}
+	order, err := exchange.SubmitOrder(context.Background(), ex, request, 3)
+	if err != nil {
+		t.Fatalf("SubmitOrder Err: %v", err)
+	}
+	if request.ClientOrderID == "" || order.ClientOrderID != request.ClientOrderID {
+		t.Errorf("client order id is not kept: %q %q", request.ClientOrderID, order.ClientOrderID)
+	}
+	if standIn.attempt != 1 || len(standIn.placed) != 1 {
+		t.Errorf("order is placed again after the lost response: %d attempts", standIn.attempt)
+	}
+
+	if exchange.NewClientOrderID() == exchange.NewClientOrderID() {
+		t.Errorf("client order id is not unique")
+	}
+}
+
+func Test_Order_PageOrders(t *testing.T) {
+	btcEth := &pair.Pair{Name: "BTC|ETH"}
+	btcLtc := &pair.Pair{Name: "BTC|LTC"}
+	orders := []*market.Order{
+		{OrderID: "1", Pair: btcEth},
+		{OrderID: "2", Pair: btcLtc},
+		{OrderID: "3", Pair: btcEth},
+		{OrderID: "4", Pair: btcEth},
+	}
+
+	if page := exchange.PageOrders(orders, nil); len(page) != 4 {
+		t.Errorf("nil query should list all the orders: %d", len(page))
+	}
+	page := exchange.PageOrders(orders, &market.OrderQuery{Pair: btcEth, Offset: 1, Limit: 1})
+	if len(page) != 1 || page[0].OrderID != "3" {
+		t.Errorf("expect order 3, got %+v", page)
+	}
+	if page := exchange.PageOrders(orders, &market.OrderQuery{Offset: 10}); len(page) != 0 {
+		t.Errorf("offset out of range should be empty: %+v", page)
+	}
+}
+
+func Test_Order_CancelOpenOrders(t *testing.T) {
+	standIn := &openOrdersStandIn{open: make(map[string]*market.Order)}
+	for _, id := range []string{"1", "2", "3", "4", "5", "bad"} {
+		standIn.open[id] = &market.Order{OrderID: id}
+	}
+
+	reports := make([]*exchange.CancelReport, 3)
+	wg := sync.WaitGroup{}
+	for i := range reports {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reports[i], _ = exchange.CancelOpenOrders(standIn, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	if standIn.canceled != 5 || len(standIn.open) != 1 {
+		t.Errorf("expect 5 canceled orders, got %d", standIn.canceled)
+	}
+	canceled := 0
+	for _, report := range reports {
+		canceled += len(report.Canceled)
+		if len(report.Failed) != 1 || report.Err() == nil {
+			t.Errorf("order bad should fail in every report: %+v", report.Failed)
+		}
+		for _, failure := range report.Failed {
+			if failure.Order.OrderID != "bad" {
+				t.Errorf("order %s is canceled twice: %v", failure.Order.OrderID, failure.Err)
+			}
+		}
+	}
+	if canceled != 5 {
+		t.Errorf("expect 5 canceled orders in the reports, got %d", canceled)
+	}
+}
+
+func Test_Order_ApplyFills(t *testing.T) {
+	order := &market.Order{OrderID: "1", Quantity: 3}
+	trades := []*market.Trade{
+		{TradeID: "a", OrderID: "1", Rate: 0.03, Quantity: 1},
+		{TradeID: "b", OrderID: "2", Rate: 0.05, Quantity: 5},
+		{TradeID: "c", OrderID: "1", Rate: 0.06, Quantity: 2},
+	}
+
+	exchange.ApplyFills(order, trades)
+	if order.DealQuantity != 3 {
+		t.Errorf("expect deal quantity 3, got %v", order.DealQuantity)
+	}
+	if math.Abs(order.DealRate-0.05) > 1e-9 {
+		t.Errorf("expect deal rate 0.05, got %v", order.DealRate)
+	}
+}
+
+func Test_Order_OrdersStatusEach(t *testing.T) {
+	standIn := &orderStatusStandIn{orders: map[string]*market.Order{
+		"1": {OrderID: "1", Status: market.Filled, DealQuantity: 2},
+		"3": {OrderID: "3", Status: market.Partial, DealQuantity: 1},
+	}}
+	orders := []*market.Order{
+		{OrderID: "1", Status: market.New},
+		{OrderID: "2", Status: market.New},
+		{OrderID: "3", Status: market.New},
+	}
+
+	err := exchange.OrdersStatusEach(standIn, orders)
+	if !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Errorf("expect ErrNotFound of order 2, got %v", err)
+	}
+	if orders[0].Status != market.Filled || orders[0].DealQuantity != 2 {
+		t.Errorf("order 1 is not updated: %+v", orders[0])
+	}
+	if orders[1].Status != market.New {
+		t.Errorf("order 2 should be unchanged: %+v", orders[1])
+	}
+	if orders[2].Status != market.Partial {
+		t.Errorf("the orders after the failed one are not updated: %+v", orders[2])
+	}
+}
@@ -0,0 +1,213 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"../coin"
+	"../exchange"
+	"../exchange/blank"
+	"../market"
+	"../pair"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket stand-in server, handle is called for each connection
+type wsStandIn struct {
+	server      *httptest.Server
+	lock        sync.Mutex
+	connections int
+	received    []string
+}
+
+func newWsStandIn(handle func(s *wsStandIn, conn *websocket.Conn, connection int)) *wsStandIn {
+	s := &wsStandIn{}
+	upgrader := websocket.Upgrader{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s.lock.Lock()
+		s.connections++
+		connection := s.connections
+		s.lock.Unlock()
+		handle(s, conn, connection)
+	}))
+	return s
+}
+
+func (s *wsStandIn) url() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *wsStandIn) read(conn *websocket.Conn) error {
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	s.received = append(s.received, string(message))
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *wsStandIn) count(message string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	n := 0
+	for _, m := range s.received {
+		if m == message {
+			n++
+		}
+	}
+	return n
+}
+
+// v1 exchange stand-in with the order book of each pair, pair "bad" fails
+type orderBookStandIn struct {
+	exchange.Exchange
+}
+
+func (e *orderBookStandIn) GetName() exchange.ExchangeName {
+	return blank.NAME
+}
+
+func (e *orderBookStandIn) OrderBook(p *pair.Pair) (*market.Maker, error) {
+	if p.Name == "bad" {
+		return nil, errors.New("Blank OrderBook Unmarshal Err")
+	}
+	return &market.Maker{Bids: []market.Order{{Rate: 1, Quantity: 2}}}, nil
+}
+
+/********************General********************/
+func Test_Stream_Reconnect(t *testing.T) {
+	standIn := newWsStandIn(func(s *wsStandIn, conn *websocket.Conn, connection int) {
+		if err := s.read(conn); err != nil {
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte("book"))
+		if connection == 1 {
+			return // drop the first connection
+		}
+		for s.read(conn) == nil {
+		}
+	})
+	defer standIn.server.Close()
+
+	messages := make(chan string, 10)
+	client := exchange.NewWsClient(blank.NAME, standIn.url(), func(c *exchange.WsClient, message []byte) {
+		messages <- string(message)
+	})
+	client.MinBackoff = 10 * time.Millisecond
+	client.Subscribe(map[string]string{"event": "subscribe"})
+	client.Start()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case message := <-messages:
+			if message != "book" {
+				t.Errorf("unexpected message %s", message)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %d is not received", i+1)
+		}
+	}
+	client.Close()
+
+	if n := standIn.count(`{"event":"subscribe"}`); n != 2 {
+		t.Errorf("expect the subscription on both connections, got %d", n)
+	}
+	select {
+	case message := <-messages:
+		t.Errorf("message after Close: %s", message)
+	default:
+	}
+}
+
+func Test_Stream_Heartbeat(t *testing.T) {
+	standIn := newWsStandIn(func(s *wsStandIn, conn *websocket.Conn, connection int) {
+		for s.read(conn) == nil { // never answer
+		}
+	})
+	defer standIn.server.Close()
+
+	client := exchange.NewWsClient(blank.NAME, standIn.url(), func(c *exchange.WsClient, message []byte) {})
+	client.Heartbeat = 20 * time.Millisecond
+	client.MinBackoff = 10 * time.Millisecond
+	client.Ping = func() interface{} {
+		return map[string]string{"event": "ping"}
+	}
+	client.Start()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		standIn.lock.Lock()
+		connections := standIn.connections
+		standIn.lock.Unlock()
+		if connections >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the silent connection is not dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	client.Close()
+
+	if standIn.count(`{"event":"ping"}`) == 0 {
+		t.Errorf("no ping is sent")
+	}
+	if err := client.Send("late"); !exchange.IsKind(err, exchange.ErrNetwork) {
+		t.Errorf("expect ErrNetwork after Close, got %v", err)
+	}
+}
+
+func Test_Stream_CloseNotStarted(t *testing.T) {
+	client := exchange.NewWsClient(blank.NAME, "ws://127.0.0.1:1", func(c *exchange.WsClient, message []byte) {})
+
+	closed := make(chan struct{})
+	go func() {
+		client.Close()
+		client.Close()
+		client.Start() // nothing after Close
+		client.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Close blocks without Start")
+	}
+}
+
+func Test_Stream_PollOrderBook(t *testing.T) {
+	base := &coin.Coin{Code: "BTC"}
+	good := &pair.Pair{Name: "BTC|ETH", Base: base, Target: &coin.Coin{Code: "ETH"}}
+	bad := &pair.Pair{Name: "bad", Base: base, Target: base}
+
+	stop := make(chan struct{})
+	makers := exchange.PollOrderBook(&orderBookStandIn{}, []*pair.Pair{bad, good}, 10*time.Millisecond, stop)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case maker := <-makers:
+			if maker.Pair != good || len(maker.Bids) != 1 {
+				t.Errorf("unexpected maker: %+v", maker)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("maker %d is not polled", i+1)
+		}
+	}
+
+	close(stop)
+	for range makers { // closed after stop
+	}
+}
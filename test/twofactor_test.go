@@ -0,0 +1,61 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"../user"
+)
+
+/********************General********************/
+func Test_TwoFactor_TOTP(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" //"12345678901234567890", the key of RFC 6238 Appendix B
+
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, c := range cases {
+		code, err := user.TOTP(secret, time.Unix(c.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTP err: %v", err)
+		}
+		if code != c.code {
+			t.Errorf("time %d: expect %s, got %s", c.unix, c.code, code)
+		}
+	}
+
+	if _, err := user.TOTP("not base32!", time.Now()); err == nil {
+		t.Errorf("expect an error for the invalid secret")
+	}
+}
+
+func Test_TwoFactor_Code(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	var none *user.TwoFactor
+	if code, err := none.Code(now); code != "" || err != nil {
+		t.Errorf("nil two-factor: expect no code, got %q %v", code, err)
+	}
+
+	password := &user.TwoFactor{Mode: user.TwoFactorPassword, Password: "static"}
+	if code, _ := password.Code(now); code != "static" {
+		t.Errorf("expect the static password, got %q", code)
+	}
+
+	totp := &user.TwoFactor{Mode: user.TwoFactorTOTP, Secret: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq"}
+	if code, _ := totp.Code(now); code != "287082" {
+		t.Errorf("expect the TOTP code 287082, got %q", code)
+	}
+
+	unknown := &user.TwoFactor{Mode: "SMS"}
+	if _, err := unknown.Code(now); err == nil {
+		t.Errorf("expect an error for the unknown mode")
+	}
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"../coin"
+	"../exchange"
+	"../exchange/blank"
+	"../market"
+	"../pair"
+)
+
+// v1 exchange stand-in with the account, changed by the test between the polls
+type userDataStandIn struct {
+	exchange.Exchange
+	lock     sync.Mutex
+	open     []*market.Order
+	closed   map[string]market.OrderStatus
+	fills    []*market.Trade
+	balances []*market.Balance
+}
+
+func (e *userDataStandIn) GetName() exchange.ExchangeName {
+	return blank.NAME
+}
+
+func (e *userDataStandIn) ListOpenOrders(query *market.OrderQuery) ([]*market.Order, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	orders := []*market.Order{}
+	for _, o := range e.open {
+		order := *o
+		orders = append(orders, &order)
+	}
+	return orders, nil
+}
+
+func (e *userDataStandIn) OrderStatus(order *market.Order) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if status, ok := e.closed[order.OrderID]; ok {
+		order.Status = status
+		order.DealQuantity = order.Quantity
+		return nil
+	}
+	return exchange.Errorf(blank.NAME, "OrderStatus", exchange.ErrNotFound, "order %s", order.OrderID)
+}
+
+func (e *userDataStandIn) GetFills(p *pair.Pair, since time.Time) ([]*market.Trade, error) {
+	return nil, exchange.Errorf(blank.NAME, "GetFills", exchange.ErrUnsupported, "fills are not supported")
+}
+
+func (e *userDataStandIn) UpdateAllBalances() error { return nil }
+
+func (e *userDataStandIn) GetBalances() []*market.Balance {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	balances := []*market.Balance{}
+	for _, b := range e.balances {
+		balance := *b
+		balances = append(balances, &balance)
+	}
+	return balances
+}
+
+func nextUserEvent(t *testing.T, events <-chan *exchange.UserEvent) *exchange.UserEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no user event")
+	}
+	return nil
+}
+
+/********************General********************/
+func Test_UserData_Poll(t *testing.T) {
+	btc := &coin.Coin{Code: "BTC"}
+	standIn := &userDataStandIn{
+		open:     []*market.Order{{OrderID: "1", Quantity: 2, Status: market.New}},
+		closed:   map[string]market.OrderStatus{},
+		balances: []*market.Balance{{Coin: btc, Available: 1, Total: 1}},
+	}
+
+	stop := make(chan struct{})
+	events := exchange.PollUserData(standIn, 10*time.Millisecond, stop)
+
+	// the first poll: the open orders and the balances as they are
+	if event := nextUserEvent(t, events); event.Type != exchange.UserOrder || event.Order.OrderID != "1" || event.Order.Status != market.New {
+		t.Errorf("expect the open order, got %+v", event)
+	}
+	if event := nextUserEvent(t, events); event.Type != exchange.UserBalance || event.Balance.Available != 1 {
+		t.Errorf("expect the balance, got %+v", event)
+	}
+
+	// partial fill, then the order leaves the open orders filled
+	standIn.lock.Lock()
+	standIn.open[0].Status = market.Partial
+	standIn.open[0].DealQuantity = 1
+	standIn.lock.Unlock()
+	if event := nextUserEvent(t, events); event.Type != exchange.UserOrder || event.Order.Status != market.Partial || event.Order.DealQuantity != 1 {
+		t.Errorf("expect the partial order, got %+v", event)
+	}
+
+	standIn.lock.Lock()
+	standIn.open = nil
+	standIn.closed["1"] = market.Filled
+	standIn.balances[0].Available = 3
+	standIn.balances[0].Total = 3
+	standIn.lock.Unlock()
+	if event := nextUserEvent(t, events); event.Type != exchange.UserOrder || event.Order.Status != market.Filled || event.Order.DealQuantity != 2 {
+		t.Errorf("expect the filled order, got %+v", event)
+	}
+	if event := nextUserEvent(t, events); event.Type != exchange.UserBalance || event.Balance.Coin != btc || event.Balance.Total != 3 {
+		t.Errorf("expect the changed balance, got %+v", event)
+	}
+
+	// nothing changed
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(stop)
+	for range events { // closed after stop
+	}
+}
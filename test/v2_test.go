@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"../coin"
+	"../exchange"
+	"../exchange/blank"
+	"../market"
+	"../pair"
+)
+
+// v1 exchange stand-in, only the functions used in the tests are implemented
+type legacyStandIn struct {
+	exchange.Exchange
+}
+
+func (e *legacyStandIn) GetName() exchange.ExchangeName {
+	return blank.NAME
+}
+
+func (e *legacyStandIn) OrderBook(p *pair.Pair) (*market.Maker, error) {
+	time.Sleep(time.Second)
+	return &market.Maker{}, nil
+}
+
+func (e *legacyStandIn) GetLotSize(p *pair.Pair) float64 {
+	return -0.1
+}
+
+func (e *legacyStandIn) Withdraw(c *coin.Coin, quantity float64, addr, tag string) (*exchange.WithdrawalResult, error) {
+	return nil, exchange.Errorf(blank.NAME, "Withdraw", exchange.ErrRejected, "insufficient balance of %s", c.Code)
+}
+
+func (e *legacyStandIn) CancelOrder(order *market.Order) error {
+	return exchange.Errorf(blank.NAME, "CancelOrder", exchange.ErrRejected, "order %s is closed", order.OrderID)
+}
+
+func (e *legacyStandIn) PlaceOrder(request *market.OrderRequest) (*market.Order, error) {
+	time.Sleep(time.Second)
+	return &market.Order{OrderID: "1", ClientOrderID: request.ClientOrderID}, nil
+}
+
+func (e *legacyStandIn) UpdateAllBalances() error {
+	return exchange.Errorf(blank.NAME, "UpdateAllBalances", exchange.ErrAuth, "Key are nil")
+}
+
+func (e *legacyStandIn) GetBalance(c *coin.Coin) float64 {
+	return 0
+}
+
+/********************General********************/
+func Test_V2_ContextDeadline(t *testing.T) {
+	e := exchange.Upgrade(&legacyStandIn{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := e.OrderBook(ctx, &pair.Pair{Name: "BTC|ETH"})
+	if !exchange.IsKind(err, exchange.ErrCanceled) {
+		t.Errorf("OrderBook err should be %s: %v", exchange.ErrCanceled, err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("OrderBook did not return at the deadline")
+	}
+
+	// the order may be placed after the deadline, it is not reported as canceled
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = e.PlaceOrder(ctx, &market.OrderRequest{Pair: &pair.Pair{Name: "BTC|ETH"}, ClientOrderID: "c1"})
+	if !exchange.IsKind(err, exchange.ErrNetwork) {
+		t.Errorf("PlaceOrder err should be %s: %v", exchange.ErrNetwork, err)
+	}
+}
+
+func Test_V2_LegacyErrors(t *testing.T) {
+	e := exchange.Upgrade(&legacyStandIn{})
+	ctx := context.Background()
+
+	if _, err := e.GetLotSize(ctx, &pair.Pair{Name: "BTC|ETH"}); !exchange.IsKind(err, exchange.ErrNotFound) {
+		t.Errorf("GetLotSize err should be %s: %v", exchange.ErrNotFound, err)
+	}
+	if _, err := e.Withdraw(ctx, &coin.Coin{Code: "BTC"}, 1, "Address", ""); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Errorf("Withdraw err should be %s: %v", exchange.ErrRejected, err)
+	}
+	if err := e.CancelOrder(ctx, &market.Order{OrderID: "1"}); !exchange.IsKind(err, exchange.ErrRejected) {
+		t.Errorf("CancelOrder err should be %s: %v", exchange.ErrRejected, err)
+	}
+	if _, err := e.GetBalance(ctx, &coin.Coin{Code: "BTC"}); !exchange.IsKind(err, exchange.ErrAuth) {
+		t.Errorf("GetBalance err should be %s: %v", exchange.ErrAuth, err)
+	}
+	if err := e.UpdateAllBalances(ctx); !exchange.IsKind(err, exchange.ErrAuth) {
+		t.Errorf("UpdateAllBalances err should be %s: %v", exchange.ErrAuth, err)
+	}
+	wrapped := fmt.Errorf("cancel all: %w", exchange.Errorf(blank.NAME, "CancelOrder", exchange.ErrRejected, "order %s is closed", "1"))
+	if !exchange.IsKind(wrapped, exchange.ErrRejected) {
+		t.Errorf("the wrapped err should keep %s: %v", exchange.ErrRejected, wrapped)
+	}
+	if err := fmt.Errorf("book: %w", context.DeadlineExceeded); !exchange.IsKind(err, exchange.ErrCanceled) {
+		t.Errorf("the wrapped context err should be %s: %v", exchange.ErrCanceled, err)
+	}
+	if exchange.Legacy(e) == nil {
+		t.Errorf("Legacy should return the v1 exchange")
+	}
+}
@@ -1,8 +1,25 @@
 package user
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
 type User struct {
-	Name       string `json:"name"`
-	Account_ID string `json:"accountid"`
-	API_KEY    string `json:"apikey"`
-	API_SECRET string `json:"apisecret"`
+	Name       string     `json:"name"`
+	Account_ID string     `json:"accountid"`
+	API_KEY    string     `json:"apikey"`
+	API_SECRET string     `json:"apisecret"`
+	Two_Factor *TwoFactor `json:"twofactor"` //nil if the API Key has no two-factor password
+}
+
+/*The Key of the Credentials
+Different when the API Key, the secret or the two-factor setting is different, the secrets are hashed*/
+func (u *User) CredentialKey() string {
+	h := sha256.New()
+	h.Write([]byte(u.API_KEY + "\x00" + u.API_SECRET))
+	if u.Two_Factor != nil {
+		h.Write([]byte("\x00" + string(u.Two_Factor.Mode) + "\x00" + u.Two_Factor.Password + "\x00" + u.Two_Factor.Secret))
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
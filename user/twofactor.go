@@ -0,0 +1,65 @@
+package user
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TwoFactorMode string
+
+const (
+	TwoFactorNone     TwoFactorMode = ""         // the API Key has no two-factor password
+	TwoFactorPassword TwoFactorMode = "Password" // a static password set on the API Key
+	TwoFactorTOTP     TwoFactorMode = "TOTP"     // a code generated from the secret for each request
+)
+
+// the two-factor setting of an API Key, nil means TwoFactorNone
+type TwoFactor struct {
+	Mode     TwoFactorMode `json:"mode"`
+	Password string        `json:"password"` // for TwoFactorPassword
+	Secret   string        `json:"secret"`   // base32 secret of the authenticator app, for TwoFactorTOTP
+}
+
+/*Get the Two-Factor Password at the Time
+Empty if there is no two-factor, the password of TwoFactorPassword, the TOTP code of TwoFactorTOTP*/
+func (t *TwoFactor) Code(now time.Time) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+
+	switch t.Mode {
+	case TwoFactorNone:
+		return "", nil
+	case TwoFactorPassword:
+		return t.Password, nil
+	case TwoFactorTOTP:
+		return TOTP(t.Secret, now)
+	}
+	return "", fmt.Errorf("unknown two-factor mode: %s", t.Mode)
+}
+
+/*The 6 Digits TOTP Code of RFC 6238
+HMAC-SHA1 with 30 seconds step, the same as Google Authenticator*/
+func TOTP(secret string, now time.Time) (string, error) {
+	secret = strings.ToUpper(strings.Replace(secret, " ", "", -1))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", fmt.Errorf("TOTP secret is not base32: %v", err)
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(now.Unix()/30))
+
+	h := hmac.New(sha1.New, key)
+	h.Write(counter)
+	sum := h.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000), nil
+}